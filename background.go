@@ -0,0 +1,50 @@
+package macoma
+
+import (
+	"image"
+
+	"github.com/maax3v3/macoma/v2/internal/color"
+	"github.com/maax3v3/macoma/v2/internal/zone"
+)
+
+// backgroundDistance is the largest CIELAB distance (ΔE76) from white of
+// the mean color of a zone Options.SkipBackground takes for the paper.
+const backgroundDistance = 12
+
+// skipBackground drops the zones of the w×h image that touch its edge and
+// whose mean color in img is near-white or mostly transparent: the paper
+// around the drawing. Their pixels are left unlabelled and render blank.
+func skipBackground(img image.Image, zones []zone.Zone, labels []int, w, h int) ([]zone.Zone, []int) {
+	edge := make([]bool, len(zones))
+	mark := func(x, y int) {
+		if l := labels[y*w+x]; l >= 0 {
+			edge[l] = true
+		}
+	}
+	for x := 0; x < w; x++ {
+		mark(x, 0)
+		mark(x, h-1)
+	}
+	for y := 0; y < h; y++ {
+		mark(0, y)
+		mark(w-1, y)
+	}
+
+	var candidates []zone.Zone
+	for i, on := range edge {
+		if on {
+			candidates = append(candidates, zones[i])
+		}
+	}
+	if len(candidates) == 0 {
+		return zones, labels
+	}
+	colors := zone.ComputeZoneColors(candidates, img).Colors
+
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	drop := make([]bool, len(zones))
+	for i, c := range colors {
+		drop[candidates[i].ID] = c.A < 128 || color.DistanceLAB(c, white) <= backgroundDistance
+	}
+	return zone.Remove(zones, labels, drop)
+}
@@ -0,0 +1,57 @@
+package macoma
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"os"
+
+	"github.com/maax3v3/macoma/v2/internal/check"
+	"github.com/maax3v3/macoma/v2/internal/export"
+	"github.com/maax3v3/macoma/v2/internal/imaging"
+)
+
+// CheckReport is the result of CheckColoring: a ZoneCheck per zone of the
+// key, in key order, the number of zones of each status, and Score, the
+// fraction filled correctly.
+type CheckReport = check.Report
+
+// ZoneCheck is the verdict for one zone: the expected palette number, the
+// number whose color was found there and the color measured, and a status,
+// one of the ZoneCorrect, ZoneWrong and ZoneUnfilled constants.
+type ZoneCheck = check.Zone
+
+// Zone statuses of a CheckReport.
+const (
+	ZoneCorrect  = check.Correct
+	ZoneWrong    = check.Wrong
+	ZoneUnfilled = check.Unfilled
+)
+
+// CheckColoring compares a colored-in sheet with key, the game data of its
+// conversion (see Result.GameData and LoadGameData), and reports for every
+// zone whether it was filled with its color, another palette color, or left
+// blank. colored is a scan or photo of the page, straight and cropped to
+// it; the legend below the drawing may be included.
+func CheckColoring(key *GameData, colored image.Image) (*CheckReport, error) {
+	if key == nil || colored == nil {
+		return nil, fmt.Errorf("check: key and colored image are required")
+	}
+	return check.Check(key, colored)
+}
+
+// DecodeGameData reads game data written by SaveGameData or
+// GameData.Encode, such as the answer key for CheckColoring.
+func DecodeGameData(r io.Reader) (*GameData, error) {
+	return export.DecodeGameData(r)
+}
+
+// LoadGameData reads a game data file written by SaveGameData.
+func LoadGameData(path string) (*GameData, error) {
+	f, err := os.Open(imaging.ExpandPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("opening game data: %w", err)
+	}
+	defer f.Close()
+	return DecodeGameData(f)
+}
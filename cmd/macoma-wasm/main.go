@@ -0,0 +1,102 @@
+//go:build js && wasm
+
+// Command macoma-wasm exposes macoma to JavaScript for client-side
+// conversion in the browser:
+//
+//	GOOS=js GOARCH=wasm go build -o macoma.wasm ./cmd/macoma-wasm
+//
+// Load macoma.wasm with Go's wasm_exec.js; it registers a global
+// macomaConvert(bytes, options) that takes the encoded image as a Uint8Array
+// and returns a Promise of {png: Uint8Array, legend: [{number, color,
+// coverage}]}. options is optional and may set strategy, borderColor,
+// borderTolerance, colorTolerance and maxColors.
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/maax3v3/macoma/v2"
+)
+
+func main() {
+	js.Global().Set("macomaConvert", js.FuncOf(convert))
+	select {}
+}
+
+// convert implements macomaConvert. The conversion runs on its own goroutine
+// so the JavaScript event loop is not blocked while it works.
+func convert(_ js.Value, args []js.Value) any {
+	promise := js.Global().Get("Promise")
+	return promise.New(js.FuncOf(func(_ js.Value, p []js.Value) any {
+		resolve, reject := p[0], p[1]
+		go func() {
+			res, err := run(args)
+			if err != nil {
+				reject.Invoke(js.Global().Get("Error").New(err.Error()))
+				return
+			}
+			resolve.Invoke(res)
+		}()
+		return nil
+	}))
+}
+
+func run(args []js.Value) (js.Value, error) {
+	if len(args) == 0 || args[0].Type() != js.TypeObject {
+		return js.Undefined(), fmt.Errorf("macomaConvert: expected a Uint8Array")
+	}
+	input := make([]byte, args[0].Get("length").Int())
+	js.CopyBytesToGo(input, args[0])
+
+	var opts []macoma.Option
+	if len(args) > 1 && args[1].Type() == js.TypeObject {
+		o, err := options(args[1])
+		if err != nil {
+			return js.Undefined(), err
+		}
+		opts = o
+	}
+
+	png, legend, err := macoma.ConvertBytes(input, opts...)
+	if err != nil {
+		return js.Undefined(), err
+	}
+
+	out := js.Global().Get("Uint8Array").New(len(png))
+	js.CopyBytesToJS(out, png)
+	entries := make([]any, len(legend))
+	for i, e := range legend {
+		entries[i] = map[string]any{
+			"number":   e.Number,
+			"color":    fmt.Sprintf("#%02x%02x%02x", e.Color.R, e.Color.G, e.Color.B),
+			"coverage": e.Coverage,
+		}
+	}
+	return js.ValueOf(map[string]any{"png": out, "legend": entries}), nil
+}
+
+// options converts the JavaScript options object to macoma options.
+func options(v js.Value) ([]macoma.Option, error) {
+	var opts []macoma.Option
+	if s := v.Get("strategy"); s.Type() == js.TypeString {
+		opts = append(opts, macoma.WithStrategy(s.String()))
+	}
+	if s := v.Get("borderColor"); s.Type() == js.TypeString {
+		c, err := macoma.ParseHexColor(s.String())
+		if err != nil {
+			return nil, fmt.Errorf("borderColor: %w", err)
+		}
+		opts = append(opts, macoma.WithBorderColor(c))
+	}
+	if n := v.Get("borderTolerance"); n.Type() == js.TypeNumber {
+		opts = append(opts, macoma.WithBorderTolerance(n.Float()))
+	}
+	if n := v.Get("colorTolerance"); n.Type() == js.TypeNumber {
+		opts = append(opts, macoma.WithColorTolerance(n.Float()))
+	}
+	if n := v.Get("maxColors"); n.Type() == js.TypeNumber {
+		opts = append(opts, macoma.WithMaxColors(n.Int()))
+	}
+	return opts, nil
+}
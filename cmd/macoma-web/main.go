@@ -19,12 +19,18 @@ func main() {
 	maxBodyMB := flag.Int64("max-body-mb", 10, "Maximum request body size in MB")
 	timeoutSec := flag.Int("timeout-sec", 30, "Request timeout in seconds")
 	previewMaxDim := flag.Int("preview-max-dim", web.PreviewMaxDimension, "Maximum preview width/height in pixels")
+	workers := flag.Int("workers", web.DefaultConfig().Workers, "Number of conversions run concurrently")
+	maxQueue := flag.Int("max-queue", web.DefaultConfig().MaxQueue, "Number of requests allowed to wait for a worker before returning 503")
+	metrics := flag.Bool("metrics", false, "Serve conversion metrics (stage durations, zone and color counts, output bytes) as expvar JSON at /debug/vars")
 	flag.Parse()
 
 	cfg := web.DefaultConfig()
 	cfg.MaxBodyBytes = *maxBodyMB << 20
 	cfg.RequestTimeout = time.Duration(*timeoutSec) * time.Second
 	cfg.PreviewMaxDimension = *previewMaxDim
+	cfg.Workers = *workers
+	cfg.MaxQueue = *maxQueue
+	cfg.Metrics = *metrics
 
 	handler, err := web.Handler(cfg)
 	if err != nil {
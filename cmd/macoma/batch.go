@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/maax3v3/macoma/v2"
+	"github.com/maax3v3/macoma/v2/internal/cli"
+	"github.com/maax3v3/macoma/v2/internal/imaging"
+)
+
+// runBatch converts every image named by a directory or glob --in into the
+// --out directory, cfg.Jobs files at a time, printing one line per file as
+// it finishes and a summary. It returns the exit status: 1 if any file
+// failed, exitLowConfidence if any was rejected by --min-confidence, and 0
+// otherwise.
+func runBatch(stdout, stderr io.Writer, cfg cli.Config, opts macoma.Options) int {
+	inputs, err := cli.ExpandInputs(cfg.InPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	format := cfg.OutputFormat()
+	outputs, err := cli.BatchOutPaths(cfg.OutPath, inputs, format)
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	if err := os.MkdirAll(imaging.ExpandPath(cfg.OutPath), 0o755); err != nil {
+		fmt.Fprintf(stderr, "Error: creating output directory: %v\n", err)
+		return 1
+	}
+	jobs := cfg.Jobs
+	if jobs == 0 {
+		jobs = runtime.NumCPU()
+	}
+	jobs = min(jobs, len(inputs))
+	fmt.Fprintf(stdout, "Converting %d images (strategy=%s, %d at a time)...\n", len(inputs), cfg.DelimiterStrategy, jobs)
+
+	var (
+		mu                    sync.Mutex
+		done, failed, lowConf int
+		wg                    sync.WaitGroup
+	)
+	queue := make(chan int)
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				in, out := inputs[i], outputs[i]
+				err := convertFile(io.Discard, cfg, opts, in, out)
+
+				mu.Lock()
+				done++
+				switch {
+				case errors.Is(err, macoma.ErrLowConfidence):
+					lowConf++
+					fmt.Fprintf(stderr, "[%d/%d] %s: %v\n", done, len(inputs), in, err)
+				case err != nil:
+					failed++
+					fmt.Fprintf(stderr, "[%d/%d] %s: Error: %v\n", done, len(inputs), in, err)
+				default:
+					fmt.Fprintf(stdout, "[%d/%d] %s -> %s\n", done, len(inputs), in, out)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range inputs {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	fmt.Fprintf(stdout, "Done! %d converted, %d failed, %d below --min-confidence\n", len(inputs)-failed-lowConf, failed, lowConf)
+	switch {
+	case failed > 0:
+		return 1
+	case lowConf > 0:
+		return exitLowConfidence
+	}
+	return 0
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"syscall"
+
+	"github.com/maax3v3/macoma/v2"
+	"github.com/maax3v3/macoma/v2/internal/cli"
+	"github.com/maax3v3/macoma/v2/internal/imaging"
+	"github.com/maax3v3/macoma/v2/internal/ui"
+)
+
+// runAnalyze implements "macoma analyze": it runs the detection on --in and
+// prints its confidence and the delimiter and zone statistics.
+func runAnalyze(w io.Writer, cfg cli.Config, opts macoma.Options) error {
+	img, err := loadInput(cfg.InPath)
+	if err != nil {
+		return err
+	}
+	result, err := macoma.ConvertDetailed(img, opts)
+	if err != nil {
+		return err
+	}
+
+	zones := result.Zones()
+	areas := make([]int, len(zones))
+	for i, z := range zones {
+		areas[i] = z.Area
+	}
+	sort.Ints(areas)
+	ds := result.Delimiters()
+
+	fmt.Fprintf(w, "Image:        %dx%d\n", img.Bounds().Dx(), img.Bounds().Dy())
+	fmt.Fprintf(w, "Strategy:     %s\n", cfg.DelimiterStrategy)
+	fmt.Fprintf(w, "Confidence:   %s\n", formatConfidence(result.Confidence))
+	fmt.Fprintf(w, "Delimiters:   %d px (%.1f%%), %d strokes, thickness %.1f px mean, %.1f px median\n",
+		ds.Pixels, ds.Fraction*100, ds.Strokes, ds.MeanThickness, ds.MedianThickness)
+	if len(areas) > 0 {
+		fmt.Fprintf(w, "Zones:        %d (area %d px smallest, %d px median, %d px largest)\n",
+			len(areas), areas[0], areas[len(areas)/2], areas[len(areas)-1])
+	} else {
+		fmt.Fprintf(w, "Zones:        0\n")
+	}
+	colors := result.Stats().Colors
+	fmt.Fprintf(w, "Colors:       %d\n", len(colors))
+	for _, c := range colors {
+		fmt.Fprintf(w, "  %3d %s  %d zones, %d px (%.1f%%)\n", c.Number, c.Color, c.Zones, c.Area, c.Coverage*100)
+	}
+	return nil
+}
+
+// runPalette implements "macoma palette": it prints the numbered palette of
+// --in as "#rrggbb number coverage" lines, which --palette reads back, and
+// saves it to --out when given.
+func runPalette(w io.Writer, cfg cli.Config, opts macoma.Options) error {
+	img, err := loadInput(cfg.InPath)
+	if err != nil {
+		return err
+	}
+	result, err := macoma.ConvertDetailed(img, opts)
+	if err != nil {
+		return err
+	}
+
+	legend := result.Legend()
+	palette := make([]macoma.PaletteEntry, len(legend))
+	for i, e := range legend {
+		fmt.Fprintf(w, "#%02x%02x%02x %d %.1f%%\n", e.Color.R, e.Color.G, e.Color.B, e.Number, e.Coverage*100)
+		palette[i] = macoma.PaletteEntry{Number: e.Number, Color: e.Color}
+	}
+	if cfg.OutPath != "" {
+		if err := macoma.SavePalette(cfg.OutPath, palette); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runPreview implements "macoma preview": it saves --in with the delimiters
+// the detection flags find painted over it.
+func runPreview(w io.Writer, cfg cli.Config, opts macoma.Options) error {
+	img, err := loadInput(cfg.InPath)
+	if err != nil {
+		return err
+	}
+	overlay, err := macoma.VisualizeDetection(img, opts)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Saving preview: %s\n", cfg.OutPath)
+	return macoma.SavePNG(cfg.OutPath, overlay)
+}
+
+// runCheck implements "macoma check": it grades the --colored sheet against
+// the --key game data, prints a summary and every zone not filled with its
+// color, and saves the full report to --out when given.
+func runCheck(w io.Writer, cfg cli.Config) error {
+	key, err := macoma.LoadGameData(cfg.KeyPath)
+	if err != nil {
+		return err
+	}
+	colored, err := loadInput(cfg.ColoredPath)
+	if err != nil {
+		return err
+	}
+	report, err := macoma.CheckColoring(key, colored)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Zones:     %d\n", len(report.Zones))
+	fmt.Fprintf(w, "Correct:   %d (%.1f%%)\n", report.Correct, report.Score()*100)
+	fmt.Fprintf(w, "Wrong:     %d\n", report.Wrong)
+	fmt.Fprintf(w, "Unfilled:  %d\n", report.Unfilled)
+	for _, z := range report.Zones {
+		switch z.Status {
+		case macoma.ZoneWrong:
+			fmt.Fprintf(w, "Zone %d at (%d, %d): expected %d, found %d (%s)\n", z.ID, z.Label[0], z.Label[1], z.Expected, z.Found, z.Color)
+		case macoma.ZoneUnfilled:
+			fmt.Fprintf(w, "Zone %d at (%d, %d): expected %d, left blank\n", z.ID, z.Label[0], z.Label[1], z.Expected)
+		}
+	}
+
+	if cfg.OutPath != "" {
+		f, err := os.Create(imaging.ExpandPath(cfg.OutPath))
+		if err != nil {
+			return fmt.Errorf("creating report: %w", err)
+		}
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("saving report: %w", err)
+		}
+	}
+	return nil
+}
+
+// runStitch implements "macoma stitch": it charts --in as a cross-stitch
+// pattern, prints the threads to buy with their stitch counts and saves
+// the chart to --out.
+func runStitch(w io.Writer, cfg cli.Config) error {
+	img, err := loadInput(cfg.InPath)
+	if err != nil {
+		return err
+	}
+	opts := macoma.DefaultStitchOptions()
+	opts.Stitches = cfg.Stitches
+	opts.MaxThreads = cfg.StitchThreads
+	opts.CellSize = cfg.StitchCellSize
+	chart, err := macoma.ConvertStitch(img, opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Chart: %dx%d stitches, %d threads\n", chart.Cols, chart.Rows, len(chart.Threads))
+	for _, t := range chart.Threads {
+		fmt.Fprintf(w, "%s DMC %-5s %-28s %d\n", t.Symbol, t.Code, t.Name, t.Stitches)
+	}
+	fmt.Fprintf(w, "Saving chart: %s\n", cfg.OutPath)
+	return macoma.SavePNG(cfg.OutPath, chart.Image)
+}
+
+// runUI implements "macoma ui": it serves the tuning page on --addr, with
+// --in open if given, until interrupted.
+func runUI(w io.Writer, cfg cli.Config, opts macoma.Options) error {
+	uiCfg := ui.Config{Options: opts}
+	if cfg.InPath != "" {
+		img, err := loadInput(cfg.InPath)
+		if err != nil {
+			return err
+		}
+		uiCfg.Image, uiCfg.Name = img, filepath.Base(cfg.InPath)
+	}
+	handler, err := ui.Handler(uiCfg)
+	if err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("tcp", cfg.UIAddr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: handler}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Serve(ln)
+	}()
+	fmt.Fprintf(w, "Serving on http://%s (Ctrl+C to stop)\n", ln.Addr())
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		if err := server.Shutdown(context.Background()); err != nil {
+			return err
+		}
+		if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
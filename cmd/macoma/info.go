@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/maax3v3/macoma/v2"
+	"github.com/maax3v3/macoma/v2/internal/imaging"
+	"github.com/maax3v3/macoma/v2/internal/inspect"
+)
+
+// runInfo implements "macoma info <image>": it prints what macoma sees in an
+// input and the delimiter settings it would suggest for it.
+func runInfo(w io.Writer, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: macoma info <image>")
+	}
+	path := args[0]
+
+	format, err := imaging.DetectFormat(path)
+	if err != nil {
+		return err
+	}
+	img, err := macoma.LoadImage(path)
+	if err != nil {
+		return err
+	}
+	r := inspect.Inspect(img)
+
+	// Score the detection the recommended settings would produce.
+	opts := macoma.DefaultOptions()
+	opts.DelimiterStrategy = r.Strategy
+	opts.BorderDelimiterTolerance = r.Tolerance
+	opts.ColorDelimiterTolerance = r.Tolerance
+	conf, err := macoma.DetectionConfidence(img, opts)
+	if err != nil {
+		return err
+	}
+
+	colors := fmt.Sprintf("%d", r.Colors)
+	if r.Sampled {
+		colors = fmt.Sprintf("at least %d (sampled)", r.Colors)
+	}
+	flags := fmt.Sprintf("--delimiter-strategy=%s --%s-delimiter-tolerance=%g", r.Strategy, r.Strategy, r.Tolerance)
+
+	fmt.Fprintf(w, "File:        %s\n", path)
+	fmt.Fprintf(w, "Format:      %s\n", format)
+	fmt.Fprintf(w, "Dimensions:  %dx%d\n", r.Width, r.Height)
+	fmt.Fprintf(w, "Colors:      %s\n", colors)
+	fmt.Fprintf(w, "Alpha:       %s\n", yesNo(r.HasAlpha))
+	fmt.Fprintf(w, "Dark pixels: %.1f%%\n", r.DarkFraction*100)
+	fmt.Fprintf(w, "Recommended: %s\n", flags)
+	fmt.Fprintf(w, "             (%s)\n", r.Reason)
+	fmt.Fprintf(w, "Confidence:  %s\n", formatConfidence(conf))
+	return nil
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -1,22 +1,93 @@
 package main
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"image"
+	"image/png"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/maax3v3/macoma/v2"
 	"github.com/maax3v3/macoma/v2/internal/cli"
+	"github.com/maax3v3/macoma/v2/internal/imaging"
 )
 
+// exitLowConfidence is the exit status when --min-confidence rejects an
+// input, so scripts can tell dubious inputs apart from failures.
+const exitLowConfidence = 3
+
 func main() {
-	cfg, err := cli.Parse()
+	cmd, args := cli.SplitCommand(os.Args[1:])
+	if cmd == cli.CommandInfo {
+		if err := runInfo(os.Stdout, args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	cfg, err := cli.Parse(cmd, args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if cfg.PrintConfig {
+		if err := cli.EncodeSettings(os.Stdout, cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	opts, err := buildOptions(cfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
+	switch cmd {
+	case cli.CommandAnalyze:
+		err = runAnalyze(os.Stdout, cfg, opts)
+	case cli.CommandPalette:
+		err = runPalette(os.Stdout, cfg, opts)
+	case cli.CommandPreview:
+		err = runPreview(os.Stdout, cfg, opts)
+	case cli.CommandCheck:
+		err = runCheck(os.Stdout, cfg)
+	case cli.CommandStitch:
+		err = runStitch(os.Stdout, cfg)
+	case cli.CommandUI:
+		err = runUI(os.Stdout, cfg, opts)
+	default:
+		if cfg.Batch() {
+			os.Exit(runBatch(os.Stdout, os.Stderr, cfg, opts))
+		}
+		// Keep standard output for the image when it is piped out.
+		log := io.Writer(os.Stdout)
+		if cfg.OutPath == cli.StdioPath {
+			log = os.Stderr
+		}
+		err = convertFile(log, cfg, opts, cfg.InPath, cfg.OutPath)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if errors.Is(err, macoma.ErrLowConfidence) {
+			os.Exit(exitLowConfidence)
+		}
+		os.Exit(1)
+	}
+}
+
+// buildOptions maps the validated configuration to conversion options,
+// loading the palette, reference and watermark images it names.
+func buildOptions(cfg cli.Config) (macoma.Options, error) {
 	opts := macoma.Options{
-		DelimiterStrategy: cfg.DelimiterStrategy,
 		BorderDelimiterColor: macoma.Color{
 			R: cfg.BorderDelimiterColor.R,
 			G: cfg.BorderDelimiterColor.G,
@@ -24,30 +95,318 @@ func main() {
 			A: cfg.BorderDelimiterColor.A,
 		},
 		BorderDelimiterTolerance: cfg.BorderDelimiterTolerance,
+		BorderDelimiterLAB:       cfg.BorderDelimiterLAB,
 		ColorDelimiterTolerance:  cfg.ColorDelimiterTolerance,
+		ColorDelimiterRadius:     cfg.ColorDelimiterRadius,
+		GridCells:                cfg.GridCells,
+		ColorMetric:              cfg.ColorMetric,
 		MaxColors:                cfg.MaxColors,
+		Quantizer:                cfg.Quantizer,
+		AutoCrop:                 cfg.AutoCrop,
+		OutputMargin:             cfg.OutputMargin,
+		MaxDimension:             cfg.MaxDimension,
+		RestoreSize:              cfg.RestoreSize,
+		Denoise:                  cfg.Denoise,
+		BlurSigma:                cfg.BlurSigma,
+		PosterizeLevels:          cfg.PosterizeLevels,
+		MaxZoneArea:              cfg.MaxZoneArea,
+		Connectivity:             cfg.Connectivity,
+		TileHeight:               cfg.TileHeight,
+		SkipBackground:           cfg.SkipBackground,
+		LegendCoverage:           cfg.LegendCoverage,
+		LegendHex:                cfg.LegendHex,
+		LegendZoneCounts:         cfg.LegendZoneCounts,
+		LegendQR:                 cfg.LegendQR,
+		SeparateLegend:           cfg.LegendOutPath != "",
+		BackgroundColor:          macoma.Color{R: cfg.BackgroundColor.R, G: cfg.BackgroundColor.G, B: cfg.BackgroundColor.B, A: cfg.BackgroundColor.A},
+		TransparentBackground:    cfg.TransparentBackground,
+		LineColor:                macoma.Color{R: cfg.LineColor.R, G: cfg.LineColor.G, B: cfg.LineColor.B, A: cfg.LineColor.A},
+		PatternFill:              cfg.PatternFill,
+		HintOpacity:              cfg.HintOpacity,
+		LineWidth:                cfg.LineWidth,
+		LargePrint:               cfg.LargePrint,
+		RotateLabels:             cfg.RotateLabels,
+		MultiLabelFraction:       cfg.MultiLabelFraction,
+		EnsureLegible:            cfg.EnsureLegible,
+		OutputScale:              cfg.OutputScale,
+		Solution:                 cfg.Solution,
+		MinConfidence:            cfg.MinConfidence,
+	}
+	// Already validated by cli.Parse
+	opts.MinZoneSize.Pixels, opts.MinZoneSize.Percent, _ = cli.ParseZoneSize(cfg.MinZoneSize)
+	opts.LabelSet, _ = cli.ParseLabels(cfg.Labels)
+	strategies, _ := cli.ParseStrategies(cfg.DelimiterStrategy)
+	opts.DelimiterStrategy = strategies[0]
+	if len(strategies) > 1 {
+		for _, s := range strategies {
+			dc := macoma.DelimiterConfig{Strategy: s, BorderColor: opts.BorderDelimiterColor, Tolerance: cfg.ColorDelimiterTolerance, Radius: cfg.ColorDelimiterRadius}
+			if s == cli.StrategyBorder {
+				dc.Tolerance = cfg.BorderDelimiterTolerance
+			}
+			opts.Delimiters = append(opts.Delimiters, dc)
+		}
+		opts.DelimiterCombine = cfg.DelimiterCombine
+	}
+	borderColors, _ := cli.ParseBorderColors(cfg.ExtraBorderColors, cfg.BorderDelimiterTolerance)
+	for _, bc := range borderColors {
+		opts.ExtraBorderColors = append(opts.ExtraBorderColors, macoma.BorderColor{
+			Color:     macoma.Color{R: bc.Color.R, G: bc.Color.G, B: bc.Color.B, A: bc.Color.A},
+			Tolerance: bc.Tolerance,
+		})
 	}
 
-	fmt.Printf("Loading image: %s\n", cfg.InPath)
-	img, err := macoma.LoadImage(cfg.InPath)
+	if cfg.PaletteIn != "" {
+		palette, err := macoma.LoadPalette(cfg.PaletteIn)
+		if err != nil {
+			return macoma.Options{}, fmt.Errorf("loading palette: %w", err)
+		}
+		opts.ImportedPalette = palette
+	}
+
+	if cfg.Palette != "" {
+		palette, err := macoma.LoadPaletteColors(cfg.Palette)
+		if err != nil {
+			return macoma.Options{}, fmt.Errorf("loading palette: %w", err)
+		}
+		opts.Palette = palette
+	}
+
+	if cfg.PaletteFrom != "" {
+		ref, err := macoma.LoadImage(cfg.PaletteFrom)
+		if err != nil {
+			return macoma.Options{}, fmt.Errorf("loading palette image: %w", err)
+		}
+		opts.PaletteFromImage = ref
+	}
+
+	if cfg.Title != "" {
+		opts.Title = &macoma.Title{
+			Text:  cfg.Title,
+			Size:  cfg.TitleSize,
+			Align: cfg.TitleAlign,
+		}
+	}
+	if cfg.WatermarkText != "" || cfg.WatermarkImage != "" {
+		wm := &macoma.Watermark{
+			Text:     cfg.WatermarkText,
+			Position: cfg.WatermarkPosition,
+			Opacity:  cfg.WatermarkOpacity,
+		}
+		if cfg.WatermarkImage != "" {
+			stamp, err := macoma.LoadImage(cfg.WatermarkImage)
+			if err != nil {
+				return macoma.Options{}, fmt.Errorf("loading watermark image: %w", err)
+			}
+			wm.Image = stamp
+		}
+		opts.Watermark = wm
+	}
+	return opts, nil
+}
+
+// convertFile converts the image at in and saves it to out with its
+// sidecar files, reporting each step to log.
+func convertFile(log io.Writer, cfg cli.Config, opts macoma.Options, in, out string) error {
+	fmt.Fprintf(log, "Loading image: %s\n", in)
+	img, err := loadInput(in)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		return err
 	}
-	fmt.Printf("Image loaded: %dx%d\n", img.Bounds().Dx(), img.Bounds().Dy())
+	fmt.Fprintf(log, "Image loaded: %dx%d\n", img.Bounds().Dx(), img.Bounds().Dy())
 
-	fmt.Printf("Converting (strategy=%s)...\n", opts.DelimiterStrategy)
-	result, err := macoma.Convert(img, opts)
+	fmt.Fprintf(log, "Converting (strategy=%s)...\n", cfg.DelimiterStrategy)
+	opts.LegendQR = cli.LegendQRURL(cfg.LegendQR, out)
+	result, err := macoma.ConvertDetailed(img, opts)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		return err
+	}
+	fmt.Fprintf(log, "Detection confidence: %s\n", formatConfidence(result.Confidence))
+	if result.Scale > max(cfg.OutputScale, 1) {
+		fmt.Fprintf(log, "Output upscaled %dx to keep numbers legible\n", result.Scale)
 	}
 
-	fmt.Printf("Saving output: %s\n", cfg.OutPath)
-	if err := macoma.SavePNG(cfg.OutPath, result); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+	fmt.Fprintf(log, "Saving output: %s\n", out)
+	page := result.Image
+	if cfg.Reference != "" {
+		page = result.SideBySide(cfg.Reference)
+	}
+	if cfg.PaperLayout {
+		page = result.LayoutPage(page, pdfOptions(cfg))
+	}
+	write := func(w io.Writer) error {
+		return macoma.EncodePNGWithOptions(w, page, macoma.PNGOptions{
+			Compression: pngCompression(cfg.PNGCompression),
+			Interlaced:  cfg.PNGInterlace,
+		})
 	}
+	switch cfg.OutputFormat() {
+	case "jpeg":
+		write = func(w io.Writer) error { return macoma.EncodeJPEG(w, page, cfg.JPEGQuality) }
+	case "webp":
+		write = func(w io.Writer) error { return macoma.EncodeWebP(w, page) }
+	case "svg":
+		write = result.WriteSVG
+	case "svgz":
+		write = result.WriteSVGZ
+	case "eps":
+		write = result.WriteEPS
+	case "dxf":
+		write = result.WriteDXF
+	case "tiff":
+		write = result.WriteTIFF
+	case "pdf":
+		write = func(w io.Writer) error { return result.WritePDF(w, pdfOptions(cfg)) }
+	}
+	if err := writeOutput(out, write); err != nil {
+		return err
+	}
+
+	if path := cfg.LegendOutPath; path != "" {
+		fmt.Fprintf(log, "Saving legend: %s\n", path)
+		write := func(w io.Writer) error { return macoma.EncodePNG(w, result.LegendImage()) }
+		switch cli.LegendFormat(path) {
+		case "jpeg":
+			write = func(w io.Writer) error { return macoma.EncodeJPEG(w, result.LegendImage(), cfg.JPEGQuality) }
+		case "webp":
+			write = func(w io.Writer) error { return macoma.EncodeWebP(w, result.LegendImage()) }
+		case "pdf":
+			write = func(w io.Writer) error { return result.WriteLegendPDF(w, pdfOptions(cfg)) }
+		}
+		if err := writeOutput(path, write); err != nil {
+			return err
+		}
+	}
+
+	if cfg.DebugDump != "" {
+		dir := cfg.DebugDump
+		if cfg.Batch() {
+			// One subdirectory per input, named after its output so
+			// that inputs of the same name do not share one
+			base := filepath.Base(out)
+			dir = filepath.Join(dir, strings.TrimSuffix(base, filepath.Ext(base)))
+		}
+		fmt.Fprintf(log, "Saving debug images (%d zones, %d colors): %s\n", len(result.Zones()), len(result.Legend()), dir)
+		if err := macoma.SaveDebugImages(dir, result); err != nil {
+			return err
+		}
+	}
+
+	if cfg.RevealPath != "" {
+		fmt.Fprintf(log, "Saving reveal animation: %s\n", cfg.RevealPath)
+		if err := macoma.SaveRevealGIF(cfg.RevealPath, result, time.Duration(cfg.RevealDelay)*time.Millisecond); err != nil {
+			return err
+		}
+	}
+
+	if cfg.GameDataPath != "" {
+		fmt.Fprintf(log, "Saving game data: %s\n", cfg.GameDataPath)
+		if err := macoma.SaveGameData(cfg.GameDataPath, result.GameData()); err != nil {
+			return err
+		}
+	}
+
+	if cfg.GeoJSONPath != "" {
+		fmt.Fprintf(log, "Saving GeoJSON: %s\n", cfg.GeoJSONPath)
+		if err := macoma.SaveGeoJSON(cfg.GeoJSONPath, result.GeoJSON()); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Metadata {
+		path := cli.MetadataPath(out)
+		fmt.Fprintf(log, "Saving metadata: %s\n", path)
+		if err := macoma.SaveMetadata(path, result.Metadata()); err != nil {
+			return err
+		}
+	}
+
+	if result.Solution != nil {
+		path := cli.SolutionPath(out)
+		fmt.Fprintf(log, "Saving solution: %s\n", path)
+		if err := macoma.SavePNG(path, result.Solution); err != nil {
+			return err
+		}
+	}
+
+	if cfg.PalettePreview {
+		path := cli.PalettePreviewPath(out)
+		fmt.Fprintf(log, "Saving palette preview: %s\n", path)
+		if err := macoma.SavePNG(path, result.PalettePreview()); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Worksheets {
+		legend := result.Legend()
+		for i, page := range result.Worksheets() {
+			path := cli.WorksheetPath(out, legend[i].Number)
+			fmt.Fprintf(log, "Saving worksheet: %s\n", path)
+			if err := macoma.SavePNG(path, page); err != nil {
+				return err
+			}
+		}
+	}
+
+	if cfg.WriteSettings {
+		settingsPath := cli.SettingsPath(out)
+		fmt.Fprintf(log, "Saving settings: %s\n", settingsPath)
+		if err := cli.WriteSettings(settingsPath, cfg); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintln(log, "Done!")
+	return nil
+}
+
+// writeOutput creates path and fills it with write, or writes to standard
+// output when path is cli.StdioPath.
+func writeOutput(path string, write func(io.Writer) error) error {
+	if path == cli.StdioPath {
+		bw := bufio.NewWriter(os.Stdout)
+		if err := write(bw); err != nil {
+			return err
+		}
+		return bw.Flush()
+	}
+	f, err := os.Create(imaging.ExpandPath(path))
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+	defer f.Close()
+	return write(f)
+}
+
+// loadInput loads the image at path, or decodes standard input when path is
+// cli.StdioPath.
+func loadInput(path string) (image.Image, error) {
+	if path == cli.StdioPath {
+		return macoma.Decode(os.Stdin)
+	}
+	return macoma.LoadImage(path)
+}
+
+// pdfOptions maps the validated --paper, --dpi, --margin and --fit-paper
+// flags.
+func pdfOptions(cfg cli.Config) macoma.PDFOptions {
+	paper, _ := macoma.PaperByName(cfg.Paper)
+	return macoma.PDFOptions{Paper: paper, DPI: cfg.DPI, Margin: macoma.MillimetersToPoints(cfg.MarginMM), Fit: cfg.FitPaper}
+}
+
+// pngCompression maps a validated --png-compression name to its level.
+func pngCompression(name string) png.CompressionLevel {
+	switch name {
+	case "none":
+		return png.NoCompression
+	case "fast":
+		return png.BestSpeed
+	case "best":
+		return png.BestCompression
+	default:
+		return png.DefaultCompression
+	}
+}
 
-	fmt.Println("Done!")
+func formatConfidence(c macoma.Confidence) string {
+	return fmt.Sprintf("%.2f (density %.2f, continuity %.2f, closure %.2f)", c.Score, c.Density, c.Continuity, c.Closure)
 }
@@ -0,0 +1,90 @@
+package macoma
+
+import (
+	"context"
+	"image"
+
+	"github.com/maax3v3/macoma/v2/internal/pool"
+)
+
+// Converter runs conversions with a fixed set of options and recycles
+// their image-sized buffers: detection planes, delimiter maps and label
+// slices, and the output images and results handed back with Release. A
+// server that converts many images keeps one Converter, so each conversion
+// reuses the memory of the previous ones instead of leaving it to the
+// garbage collector.
+//
+// A Converter is safe for concurrent use, and its conversions give the
+// same images as Convert. The maps seen by Options.OnDetected and by a
+// custom strategy's Delimiter are recycled with the result, so they must
+// not be kept.
+type Converter struct {
+	opts []Option
+	pool *pool.Pool
+}
+
+// NewConverter returns a Converter that applies opts to every conversion,
+// before the options of each call.
+func NewConverter(opts ...Option) *Converter {
+	return &Converter{opts: opts, pool: &pool.Pool{}}
+}
+
+// Convert is like the package-level Convert, with the Converter's options
+// followed by opts.
+func (c *Converter) Convert(img image.Image, opts ...Option) (*image.RGBA, error) {
+	return c.ConvertContext(context.Background(), img, opts...)
+}
+
+// ConvertContext is like Convert but can be cancelled through ctx, as with
+// the package-level ConvertContext.
+func (c *Converter) ConvertContext(ctx context.Context, img image.Image, opts ...Option) (*image.RGBA, error) {
+	res, err := c.ConvertDetailedContext(ctx, img, opts...)
+	if err != nil {
+		return nil, err
+	}
+	out := res.Image
+	res.Image = nil
+	res.Release()
+	return out, nil
+}
+
+// ConvertDetailed is like the package-level ConvertDetailed, with the
+// Converter's options followed by opts. Call Release on the result once
+// done with it and its image.
+func (c *Converter) ConvertDetailed(img image.Image, opts ...Option) (*Result, error) {
+	return c.ConvertDetailedContext(context.Background(), img, opts...)
+}
+
+// ConvertDetailedContext is like ConvertDetailed but can be cancelled
+// through ctx.
+func (c *Converter) ConvertDetailedContext(ctx context.Context, img image.Image, opts ...Option) (*Result, error) {
+	ctx = pool.WithPool(ctx, c.pool)
+	return ConvertDetailedContext(ctx, img, append(c.opts[:len(c.opts):len(c.opts)], opts...)...)
+}
+
+// Release hands back an image returned by Convert once the caller is done
+// with it, typically after encoding it, so a later conversion can draw on
+// its pixels. img must not be used afterwards.
+func (c *Converter) Release(img *image.RGBA) {
+	if img != nil {
+		c.pool.PutBytes(img.Pix)
+	}
+}
+
+// Release hands the delimiter map, label map and Image of a result made
+// by a Converter back to it, for its next conversions. Neither r nor its
+// Image may be used afterwards. It does nothing for other results, and
+// when called again.
+func (r *Result) Release() {
+	p := r.pool
+	if p == nil {
+		return
+	}
+	r.pool = nil
+	p.PutBools(r.a.dm.IsDelimiter)
+	p.PutInts(r.a.labels)
+	if r.Image != nil {
+		p.PutBytes(r.Image.Pix)
+	}
+	r.a, r.Image = nil, nil
+}
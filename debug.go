@@ -0,0 +1,65 @@
+package macoma
+
+import (
+	"fmt"
+	"image"
+	stdcolor "image/color"
+	"os"
+	"path/filepath"
+
+	"github.com/maax3v3/macoma/v2/internal/imaging"
+	"github.com/maax3v3/macoma/v2/internal/renderer"
+	"github.com/maax3v3/macoma/v2/internal/zone"
+)
+
+// DebugImage is an intermediate stage of a conversion, rendered to find out
+// why a drawing produced unexpected zones.
+type DebugImage struct {
+	Name  string // short file-name-safe name, e.g. "zones"
+	Image *image.RGBA
+}
+
+// DebugImages renders the intermediate stages of the conversion, in
+// pipeline order:
+//
+//   - "detection": the delimiter pixels in black on white;
+//   - "zones": each zone in a color of its own, delimiters in black;
+//   - "zone-colors": each zone in its averaged source color, before the
+//     palette was reduced;
+//   - "coloring": the coloring page before the legend was added.
+//
+// All are at the size of the drawing (after any upscaling).
+func (r *Result) DebugImages() []DebugImage {
+	a := r.a
+	w, h := a.dm.Width, a.dm.Height
+	zc := zone.ComputeZoneColors(a.zones, a.img)
+	colors := make([]stdcolor.RGBA, len(zc.Colors))
+	for i, c := range zc.Colors {
+		colors[i] = c.ToStdColor()
+	}
+	coloring := image.NewRGBA(image.Rect(0, 0, w, h))
+	top := r.Image.PixOffset(0, r.titleH)
+	copy(coloring.Pix, r.Image.Pix[top:top+len(coloring.Pix)])
+	return []DebugImage{
+		{Name: "detection", Image: renderer.DelimiterMask(a.dm)},
+		{Name: "zones", Image: renderer.ZoneIDMap(a.labels, w, h)},
+		{Name: "zone-colors", Image: renderer.ZoneColorMap(a.labels, w, h, colors)},
+		{Name: "coloring", Image: coloring},
+	}
+}
+
+// SaveDebugImages writes the DebugImages of r into dir as numbered PNG
+// files, such as "2-zones.png", creating dir if needed.
+func SaveDebugImages(dir string, r *Result) error {
+	dir = imaging.ExpandPath(dir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating debug directory: %w", err)
+	}
+	for i, d := range r.DebugImages() {
+		path := filepath.Join(dir, fmt.Sprintf("%d-%s.png", i+1, d.Name))
+		if err := imaging.SavePNG(path, d.Image); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -6,3 +6,5 @@ require (
 	github.com/go-chi/chi/v5 v5.1.0
 	golang.org/x/image v0.15.0
 )
+
+require golang.org/x/text v0.14.0 // indirect
@@ -0,0 +1,131 @@
+package macoma
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/maax3v3/macoma/v2/internal/aggregation"
+	"github.com/maax3v3/macoma/v2/internal/color"
+	"github.com/maax3v3/macoma/v2/internal/detection"
+	"github.com/maax3v3/macoma/v2/internal/zone"
+)
+
+// DetectionMap marks the delimiter pixels of a drawing: IsDelimiter is
+// row-major, Width×Height long.
+type DetectionMap = detection.Map
+
+// Zone is a connected region of non-delimiter pixels, stored as ZoneRuns.
+// Points lists its pixels and NewZone builds one from pixels.
+type Zone = zone.Zone
+
+// ZoneRun is a horizontal span of zone pixels: columns X0 to X1-1 of row
+// Y.
+type ZoneRun = zone.Run
+
+// NewZone returns the zone of the given pixels, in any order, for
+// Options.OnZonesFound. Its ID is renumbered after the hook.
+func NewZone(pixels []image.Point) Zone {
+	return zone.FromPixels(0, pixels)
+}
+
+// Palette is the numbered palette of a conversion and the entry each zone
+// is painted with: zone i uses Entries[ZoneMap[i]].
+type Palette struct {
+	Entries []PaletteEntry
+	ZoneMap []int
+}
+
+// runDetectedHook lets Options.OnDetected edit dm in place.
+func runDetectedHook(opts Options, dm *detection.Map) error {
+	if opts.OnDetected == nil {
+		return nil
+	}
+	w, h := dm.Width, dm.Height
+	opts.OnDetected(dm)
+	if dm.Width != w || dm.Height != h || len(dm.IsDelimiter) != w*h {
+		return fmt.Errorf("OnDetected: the map must stay %dx%d", w, h)
+	}
+	return nil
+}
+
+// runZonesHook passes the zones through Options.OnZonesFound and rebuilds
+// the label map from the zones it returns, renumbering their IDs. Pixels of
+// dropped zones are left unlabelled and render blank; those outside the
+// drawing (see Options.SkipBackground) stay outside.
+func runZonesHook(opts Options, dm *detection.Map, zones []zone.Zone, labels []int) ([]zone.Zone, []int, error) {
+	if opts.OnZonesFound == nil {
+		return zones, labels, nil
+	}
+	zones = opts.OnZonesFound(zones)
+
+	w, h := dm.Width, dm.Height
+	old := labels
+	labels = make([]int, w*h)
+	for i := range labels {
+		labels[i] = -1
+		if old[i] == zone.Outside {
+			labels[i] = zone.Outside
+		}
+	}
+	for i := range zones {
+		zones[i].ID = i
+		if zones[i].Area() <= 0 {
+			return nil, nil, fmt.Errorf("OnZonesFound: zone %d has no pixels", i)
+		}
+		for _, r := range zones[i].Runs {
+			if r.X0 >= r.X1 || r.X0 < 0 || int(r.X1) > w || r.Y < 0 || int(r.Y) >= h {
+				return nil, nil, fmt.Errorf("OnZonesFound: zone %d has run %v outside the %dx%d image", i, r, w, h)
+			}
+			for x := int(r.X0); x < int(r.X1); x++ {
+				idx := int(r.Y)*w + x
+				if labels[idx] != -1 {
+					return nil, nil, fmt.Errorf("OnZonesFound: pixel (%d,%d) is in zones %d and %d", x, r.Y, labels[idx], i)
+				}
+				labels[idx] = i
+			}
+		}
+	}
+	return zones, labels, nil
+}
+
+// runPaletteHook lets Options.OnPaletteReduced edit the palette and zone
+// assignment of cm, then checks the result is usable.
+func runPaletteHook(opts Options, cm *aggregation.ColorMap) (*aggregation.ColorMap, error) {
+	if opts.OnPaletteReduced == nil {
+		return cm, nil
+	}
+	p := &Palette{Entries: make([]PaletteEntry, len(cm.Entries)), ZoneMap: cm.ZoneMap}
+	for i, e := range cm.Entries {
+		p.Entries[i] = PaletteEntry{Number: e.Number, Color: Color{R: e.Color.R, G: e.Color.G, B: e.Color.B, A: e.Color.A}}
+	}
+	n := len(cm.ZoneMap)
+	opts.OnPaletteReduced(p)
+	return paletteToColorMap("OnPaletteReduced", p, n)
+}
+
+// paletteToColorMap checks that p assigns each of n zones an entry and that
+// its numbers are positive and unique, and converts it. Errors are
+// prefixed with who built p.
+func paletteToColorMap(who string, p *Palette, n int) (*aggregation.ColorMap, error) {
+	if len(p.ZoneMap) != n {
+		return nil, fmt.Errorf("%s: ZoneMap must keep one entry per zone (%d), got %d", who, n, len(p.ZoneMap))
+	}
+	out := &aggregation.ColorMap{Entries: make([]aggregation.ColorEntry, len(p.Entries)), ZoneMap: p.ZoneMap}
+	seen := make(map[int]bool, len(p.Entries))
+	for i, e := range p.Entries {
+		if e.Number <= 0 || seen[e.Number] {
+			return nil, fmt.Errorf("%s: numbers must be positive and unique, got %d", who, e.Number)
+		}
+		seen[e.Number] = true
+		out.Entries[i] = aggregation.ColorEntry{
+			Number: e.Number,
+			Color:  color.RGBA{R: e.Color.R, G: e.Color.G, B: e.Color.B, A: e.Color.A},
+		}
+	}
+	for z, e := range p.ZoneMap {
+		if e < 0 || e >= len(p.Entries) {
+			return nil, fmt.Errorf("%s: zone %d maps to entry %d of %d", who, z, e, len(p.Entries))
+		}
+	}
+	return out, nil
+}
@@ -1,9 +1,11 @@
 package aggregation
 
 import (
+	"context"
 	"math"
 
 	"github.com/maax3v3/macoma/v2/internal/color"
+	"github.com/maax3v3/macoma/v2/internal/progress"
 )
 
 // ColorEntry represents a resulting color with its assigned number.
@@ -23,9 +25,16 @@ type ColorMap struct {
 // If maxColors is 0, no reduction is performed.
 // Returns a ColorMap that maps each zone to a numbered color entry.
 func ReduceColors(zoneColors []color.RGBA, maxColors int) *ColorMap {
+	cm, _ := ReduceColorsContext(context.Background(), zoneColors, maxColors, color.MetricEuclidean)
+	return cm
+}
+
+// ReduceColorsContext is like ReduceColors but measures color differences
+// with metric, and stops early and returns ctx.Err() once ctx is cancelled.
+func ReduceColorsContext(ctx context.Context, zoneColors []color.RGBA, maxColors int, metric color.Metric) (*ColorMap, error) {
 	n := len(zoneColors)
 	if n == 0 {
-		return &ColorMap{}
+		return &ColorMap{}, nil
 	}
 
 	// Build initial groups: group zones that already have the exact same color
@@ -53,13 +62,19 @@ func ReduceColors(zoneColors []color.RGBA, maxColors int) *ColorMap {
 	}
 
 	// Iteratively merge closest pair until we are within maxColors
+	merges := max(len(groups)-maxColors, 0)
 	for maxColors > 0 && len(groups) > maxColors {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		progress.Report(ctx, progress.Reduction, merges-(len(groups)-maxColors), merges)
+
 		// Find the two closest groups
 		bestDist := math.MaxFloat64
 		bestI, bestJ := 0, 1
 		for i := 0; i < len(groups); i++ {
 			for j := i + 1; j < len(groups); j++ {
-				d := color.DistanceLAB(groups[i].color, groups[j].color)
+				d := metric.Distance(groups[i].color, groups[j].color)
 				if d < bestDist {
 					bestDist = d
 					bestI = i
@@ -93,6 +108,8 @@ func ReduceColors(zoneColors []color.RGBA, maxColors int) *ColorMap {
 		groups = append(groups[:bestJ], groups[bestJ+1:]...)
 	}
 
+	progress.Report(ctx, progress.Reduction, 1, 1)
+
 	// Build the result
 	cm := &ColorMap{
 		Entries: make([]ColorEntry, len(groups)),
@@ -108,5 +125,24 @@ func ReduceColors(zoneColors []color.RGBA, maxColors int) *ColorMap {
 		}
 	}
 
-	return cm
+	return cm, nil
+}
+
+// Coverage returns, for each palette entry, the fraction (0–1) of the total
+// zone area painted with that entry. zoneAreas[i] is the pixel count of
+// zone i.
+func (cm *ColorMap) Coverage(zoneAreas []int) []float64 {
+	cov := make([]float64, len(cm.Entries))
+	total := 0
+	for zID, entryIdx := range cm.ZoneMap {
+		cov[entryIdx] += float64(zoneAreas[zID])
+		total += zoneAreas[zID]
+	}
+	if total == 0 {
+		return cov
+	}
+	for i := range cov {
+		cov[i] /= float64(total)
+	}
+	return cov
 }
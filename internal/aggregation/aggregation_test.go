@@ -1,6 +1,9 @@
 package aggregation
 
 import (
+	"context"
+	"errors"
+	"math"
 	"testing"
 
 	"github.com/maax3v3/macoma/v2/internal/color"
@@ -165,3 +168,43 @@ func TestReduceColors_NumbersAreOneBased(t *testing.T) {
 		}
 	}
 }
+
+func TestColorMap_Coverage(t *testing.T) {
+	cm := &ColorMap{
+		Entries: []ColorEntry{
+			{Number: 1, Color: color.RGBA{R: 255, A: 255}},
+			{Number: 2, Color: color.RGBA{B: 255, A: 255}},
+		},
+		ZoneMap: []int{0, 1, 0},
+	}
+	cov := cm.Coverage([]int{30, 50, 20})
+	want := []float64{0.5, 0.5}
+	for i := range want {
+		if math.Abs(cov[i]-want[i]) > 1e-9 {
+			t.Errorf("entry %d: got %f, want %f", i, cov[i], want[i])
+		}
+	}
+}
+
+func TestColorMap_CoverageNoArea(t *testing.T) {
+	cm := &ColorMap{
+		Entries: []ColorEntry{{Number: 1}},
+		ZoneMap: []int{0},
+	}
+	if cov := cm.Coverage([]int{0}); cov[0] != 0 {
+		t.Errorf("expected zero coverage, got %f", cov[0])
+	}
+}
+
+func TestReduceColorsContext_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	colors := []color.RGBA{{R: 255, A: 255}, {G: 255, A: 255}, {B: 255, A: 255}}
+	if _, err := ReduceColorsContext(ctx, colors, 2, color.MetricEuclidean); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+	// Nothing to merge: the result is complete regardless
+	if cm, err := ReduceColorsContext(ctx, colors, 0, color.MetricEuclidean); err != nil || len(cm.Entries) != 3 {
+		t.Errorf("no reduction: got %v", err)
+	}
+}
@@ -0,0 +1,179 @@
+package aggregation
+
+import (
+	"image"
+	"math"
+	"sort"
+
+	"github.com/maax3v3/macoma/v2/internal/color"
+)
+
+// paletteSampleLimit bounds the pixels read from a reference image; a photo
+// of a pencil set doesn't need every pixel to find its colors.
+const paletteSampleLimit = 1 << 18
+
+// paletteMaxBins is how many of the most populated color bins are clustered.
+// Bins beyond it hold too few pixels to be a real supply color.
+const paletteMaxBins = 256
+
+// ExtractPalette finds up to n dominant colors in img, most common first.
+// Pixels are binned at 4 bits per channel, the most populated bins are kept,
+// and bins are merged pairwise by CIELAB distance under metric (weighted by
+// pixel count) until n remain. Transparent pixels are ignored. n <= 0
+// returns every kept bin.
+func ExtractPalette(img image.Image, n int, metric color.Metric) []color.RGBA {
+	b := img.Bounds()
+	total := b.Dx() * b.Dy()
+	if total == 0 {
+		return nil
+	}
+	stride := (total + paletteSampleLimit - 1) / paletteSampleLimit
+
+	type bin struct {
+		r, g, b, count int
+	}
+	bins := make(map[int]*bin)
+	for i := 0; i < total; i += stride {
+		c := color.FromStdColor(img.At(b.Min.X+i%b.Dx(), b.Min.Y+i/b.Dx()))
+		if c.A < 128 {
+			continue
+		}
+		key := int(c.R>>4)<<8 | int(c.G>>4)<<4 | int(c.B>>4)
+		bn := bins[key]
+		if bn == nil {
+			bn = &bin{}
+			bins[key] = bn
+		}
+		bn.r += int(c.R)
+		bn.g += int(c.G)
+		bn.b += int(c.B)
+		bn.count++
+	}
+
+	type cluster struct {
+		c      color.RGBA
+		lab    color.LAB
+		weight int
+	}
+	clusters := make([]cluster, 0, len(bins))
+	for _, bn := range bins {
+		c := color.RGBA{
+			R: uint8(bn.r / bn.count),
+			G: uint8(bn.g / bn.count),
+			B: uint8(bn.b / bn.count),
+			A: 255,
+		}
+		clusters = append(clusters, cluster{c: c, lab: c.ToLAB(), weight: bn.count})
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].weight != clusters[j].weight {
+			return clusters[i].weight > clusters[j].weight
+		}
+		return clusters[i].c.Hex() < clusters[j].c.Hex()
+	})
+	if len(clusters) > paletteMaxBins {
+		clusters = clusters[:paletteMaxBins]
+	}
+
+	for n > 0 && len(clusters) > n {
+		bestDist := math.MaxFloat64
+		bestI, bestJ := 0, 1
+		for i := 0; i < len(clusters); i++ {
+			for j := i + 1; j < len(clusters); j++ {
+				if d := metric.LABDistance(clusters[i].lab, clusters[j].lab); d < bestDist {
+					bestDist, bestI, bestJ = d, i, j
+				}
+			}
+		}
+		a, z := clusters[bestI], clusters[bestJ]
+		merged := color.WeightedMean([]color.RGBA{a.c, z.c}, []int{a.weight, z.weight})
+		clusters[bestI] = cluster{c: merged, lab: merged.ToLAB(), weight: a.weight + z.weight}
+		clusters = append(clusters[:bestJ], clusters[bestJ+1:]...)
+	}
+
+	sort.SliceStable(clusters, func(i, j int) bool { return clusters[i].weight > clusters[j].weight })
+	palette := make([]color.RGBA, len(clusters))
+	for i, cl := range clusters {
+		palette[i] = cl.c
+	}
+	return palette
+}
+
+// MapToPalette assigns every zone to the palette color closest to its own
+// color (in CIELAB space, under metric). Only palette colors that end up
+// used get a legend entry; entries keep the palette's order and are
+// numbered from 1.
+func MapToPalette(zoneColors []color.RGBA, palette []color.RGBA, metric color.Metric) *ColorMap {
+	cm := &ColorMap{ZoneMap: make([]int, len(zoneColors))}
+	if len(palette) == 0 {
+		return cm
+	}
+
+	nearest, used := nearestColors(zoneColors, palette, metric)
+	entryOf := make([]int, len(palette))
+	for i, u := range used {
+		if u {
+			entryOf[i] = len(cm.Entries)
+			cm.Entries = append(cm.Entries, ColorEntry{Number: len(cm.Entries) + 1, Color: palette[i]})
+		}
+	}
+	for z, p := range nearest {
+		cm.ZoneMap[z] = entryOf[p]
+	}
+	return cm
+}
+
+// MapToEntries is like MapToPalette for an already numbered palette, such
+// as the legend of an earlier conversion: zones are assigned to the closest
+// entry and the used entries keep their numbers, so a redrawn page matches
+// the original legend. Entries are ordered by number.
+func MapToEntries(zoneColors []color.RGBA, entries []ColorEntry, metric color.Metric) *ColorMap {
+	cm := &ColorMap{ZoneMap: make([]int, len(zoneColors))}
+	if len(entries) == 0 {
+		return cm
+	}
+
+	sorted := append([]ColorEntry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Number < sorted[j].Number })
+	palette := make([]color.RGBA, len(sorted))
+	for i, e := range sorted {
+		palette[i] = e.Color
+	}
+
+	nearest, used := nearestColors(zoneColors, palette, metric)
+	entryOf := make([]int, len(sorted))
+	for i, u := range used {
+		if u {
+			entryOf[i] = len(cm.Entries)
+			cm.Entries = append(cm.Entries, sorted[i])
+		}
+	}
+	for z, p := range nearest {
+		cm.ZoneMap[z] = entryOf[p]
+	}
+	return cm
+}
+
+// nearestColors returns the index of the closest palette color (in CIELAB
+// space, under metric) for each zone color, and which palette colors were
+// chosen at all.
+func nearestColors(zoneColors, palette []color.RGBA, metric color.Metric) (nearest []int, used []bool) {
+	labs := make([]color.LAB, len(palette))
+	for i, p := range palette {
+		labs[i] = p.ToLAB()
+	}
+	nearest = make([]int, len(zoneColors))
+	used = make([]bool, len(palette))
+	for z, c := range zoneColors {
+		lab := c.ToLAB()
+		best, bestDist := 0, math.MaxFloat64
+		for i, pl := range labs {
+			if d := metric.LABDistance(lab, pl); d < bestDist {
+				best, bestDist = i, d
+			}
+		}
+		nearest[z] = best
+		used[best] = true
+	}
+	return nearest, used
+}
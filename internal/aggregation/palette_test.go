@@ -0,0 +1,138 @@
+package aggregation
+
+import (
+	"image"
+	stdcolor "image/color"
+	"testing"
+
+	"github.com/maax3v3/macoma/v2/internal/color"
+)
+
+// stripes returns an image of vertical stripes with the given widths.
+func stripes(widths []int, cols []stdcolor.RGBA) image.Image {
+	total := 0
+	for _, w := range widths {
+		total += w
+	}
+	img := image.NewRGBA(image.Rect(0, 0, total, 4))
+	x := 0
+	for i, w := range widths {
+		for ; w > 0; w-- {
+			for y := 0; y < 4; y++ {
+				img.SetRGBA(x, y, cols[i])
+			}
+			x++
+		}
+	}
+	return img
+}
+
+func TestExtractPalette(t *testing.T) {
+	img := stripes([]int{30, 20, 10}, []stdcolor.RGBA{
+		{R: 250, A: 255},
+		{G: 200, A: 255},
+		{B: 255, A: 255},
+	})
+
+	got := ExtractPalette(img, 0, color.MetricEuclidean)
+	want := []color.RGBA{{R: 250, A: 255}, {G: 200, A: 255}, {B: 255, A: 255}}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d: got %+v, want %+v (most common first)", i, got[i], want[i])
+		}
+	}
+}
+
+func TestExtractPalette_MergesToN(t *testing.T) {
+	img := stripes([]int{10, 10, 10}, []stdcolor.RGBA{
+		{R: 250, A: 255},
+		{R: 240, G: 10, A: 255},
+		{B: 255, A: 255},
+	})
+	got := ExtractPalette(img, 2, color.MetricEuclidean)
+	if len(got) != 2 {
+		t.Fatalf("got %d colors, want 2", len(got))
+	}
+	if got[0].R < 200 || got[0].B != 0 {
+		t.Errorf("the two reds should merge first, got %+v", got)
+	}
+}
+
+func TestExtractPalette_IgnoresTransparent(t *testing.T) {
+	img := stripes([]int{10, 30}, []stdcolor.RGBA{{R: 255, A: 255}, {}})
+	got := ExtractPalette(img, 0, color.MetricEuclidean)
+	if len(got) != 1 {
+		t.Errorf("got %v, want only the opaque red", got)
+	}
+}
+
+func TestMapToPalette(t *testing.T) {
+	palette := []color.RGBA{
+		{R: 255, A: 255},
+		{G: 255, A: 255},
+		{B: 255, A: 255},
+	}
+	zones := []color.RGBA{
+		{R: 20, G: 20, B: 230, A: 255}, // blue
+		{R: 230, G: 30, B: 20, A: 255}, // red
+		{R: 10, G: 10, B: 200, A: 255}, // blue
+	}
+	cm := MapToPalette(zones, palette, color.MetricEuclidean)
+
+	// Green is unused and gets no entry; numbering follows palette order.
+	if len(cm.Entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(cm.Entries))
+	}
+	if cm.Entries[0].Color != palette[0] || cm.Entries[0].Number != 1 {
+		t.Errorf("entry 0: got %+v", cm.Entries[0])
+	}
+	if cm.Entries[1].Color != palette[2] || cm.Entries[1].Number != 2 {
+		t.Errorf("entry 1: got %+v", cm.Entries[1])
+	}
+	wantMap := []int{1, 0, 1}
+	for i, w := range wantMap {
+		if cm.ZoneMap[i] != w {
+			t.Errorf("zone %d: got entry %d, want %d", i, cm.ZoneMap[i], w)
+		}
+	}
+}
+
+func TestMapToPalette_Metric(t *testing.T) {
+	// A slate blue is closer to teal by CIE76 but, perceptually, to blue.
+	palette := []color.RGBA{
+		{R: 0x40, G: 0xa0, B: 0xa0, A: 255},
+		{R: 0x00, G: 0x40, B: 0xe0, A: 255},
+	}
+	zones := []color.RGBA{{R: 0x40, G: 0x60, B: 0x80, A: 255}}
+
+	if cm := MapToPalette(zones, palette, color.MetricEuclidean); cm.Entries[0].Color != palette[0] {
+		t.Errorf("Euclidean: got %s, want teal", cm.Entries[0].Color.Hex())
+	}
+	if cm := MapToPalette(zones, palette, color.MetricCIEDE2000); cm.Entries[0].Color != palette[1] {
+		t.Errorf("CIEDE2000: got %s, want blue", cm.Entries[0].Color.Hex())
+	}
+}
+
+func TestMapToEntries_KeepsNumbers(t *testing.T) {
+	entries := []ColorEntry{
+		{Number: 7, Color: color.RGBA{B: 255, A: 255}},
+		{Number: 2, Color: color.RGBA{R: 255, A: 255}},
+		{Number: 4, Color: color.RGBA{G: 255, A: 255}},
+	}
+	zones := []color.RGBA{
+		{R: 20, G: 20, B: 230, A: 255}, // blue
+		{R: 230, G: 30, B: 20, A: 255}, // red
+	}
+	cm := MapToEntries(zones, entries, color.MetricEuclidean)
+
+	// Green is unused; the others keep their numbers, ordered by number.
+	if len(cm.Entries) != 2 || cm.Entries[0].Number != 2 || cm.Entries[1].Number != 7 {
+		t.Fatalf("entries: got %+v, want numbers 2 and 7", cm.Entries)
+	}
+	if cm.Entries[cm.ZoneMap[0]].Number != 7 || cm.Entries[cm.ZoneMap[1]].Number != 2 {
+		t.Errorf("zone map: got %v", cm.ZoneMap)
+	}
+}
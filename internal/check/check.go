@@ -0,0 +1,200 @@
+// Package check compares a colored-in sheet against the answer key of its
+// coloring: the game data of the conversion, which holds every zone's
+// outline and expected palette number.
+package check
+
+import (
+	"fmt"
+	"image"
+	"sort"
+
+	"github.com/maax3v3/macoma/v2/internal/color"
+	"github.com/maax3v3/macoma/v2/internal/export"
+)
+
+// Zone statuses.
+const (
+	Correct  = "correct"  // filled with the expected color
+	Wrong    = "wrong"    // filled with another palette color
+	Unfilled = "unfilled" // left blank
+)
+
+// paper is the color of an unfilled zone.
+var paper = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+
+// whiteDistance is the largest CIELAB distance (ΔE76) from the paper of a
+// palette color that may be left blank: a white zone needs no coloring.
+const whiteDistance = 12
+
+// Zone is the verdict for one zone of the key.
+type Zone struct {
+	ID       int          `json:"id"`
+	Label    export.Point `json:"label"`    // where the number is printed, in key pixels
+	Expected int          `json:"expected"` // palette number of the key
+	Found    int          `json:"found"`    // closest palette number, 0 when unfilled
+	Color    string       `json:"color"`    // "#rrggbb" measured on the sheet
+	Status   string       `json:"status"`   // Correct, Wrong or Unfilled
+}
+
+// Report is the result of checking a sheet: one Zone per key zone, in key
+// order, and the number of zones of each status.
+type Report struct {
+	Zones    []Zone `json:"zones"`
+	Correct  int    `json:"correct"`
+	Wrong    int    `json:"wrong"`
+	Unfilled int    `json:"unfilled"`
+}
+
+// Score returns the fraction of zones filled correctly, 0–1.
+func (r *Report) Score() float64 {
+	if len(r.Zones) == 0 {
+		return 0
+	}
+	return float64(r.Correct) / float64(len(r.Zones))
+}
+
+// Check measures the color of every zone of key on sheet and compares it
+// with the expected palette entry. sheet is the colored page, scanned or
+// photographed straight and cropped to the page: it is scaled to the width
+// of the key, and a legend below the drawing is ignored.
+//
+// A zone's color is the median of its pixels, after dropping the darkest
+// ones in the proportion of the zone's outline, so the printed lines and
+// number do not count. It is matched to the closest palette color, or to
+// the white of the paper for an unfilled zone, by CIELAB distance. Zones
+// whose expected color is near-white may be left blank.
+func Check(key *export.GameData, sheet image.Image) (*Report, error) {
+	b := sheet.Bounds()
+	if b.Empty() {
+		return nil, fmt.Errorf("the colored image is empty")
+	}
+	scale := float64(b.Dx()) / float64(key.Width)
+	if float64(b.Dy()) < float64(key.Height)*scale*0.98 {
+		return nil, fmt.Errorf("the colored image (%dx%d) is too short for the %dx%d drawing of the key; scan the whole page",
+			b.Dx(), b.Dy(), key.Width, key.Height)
+	}
+
+	palette := make(map[int]color.RGBA, len(key.Palette))
+	numbers := make([]int, 0, len(key.Palette))
+	for _, p := range key.Palette {
+		c, err := color.ParseHex(p.Color)
+		if err != nil {
+			return nil, fmt.Errorf("key palette number %d: %w", p.Number, err)
+		}
+		palette[p.Number] = c
+		numbers = append(numbers, p.Number)
+	}
+	sort.Ints(numbers)
+
+	// at samples the sheet at the center of key pixel (x, y).
+	at := func(x, y int) color.RGBA {
+		sx := b.Min.X + min(int((float64(x)+0.5)*scale), b.Dx()-1)
+		sy := b.Min.Y + min(int((float64(y)+0.5)*scale), b.Dy()-1)
+		return color.FromStdColor(sheet.At(sx, sy))
+	}
+
+	r := &Report{Zones: make([]Zone, len(key.Zones))}
+	for i, gz := range key.Zones {
+		var pixels []color.RGBA
+		fill(gz, func(x, y int) {
+			if x >= 0 && x < key.Width && y >= 0 && y < key.Height {
+				pixels = append(pixels, at(x, y))
+			}
+		})
+		c := zoneColor(pixels, gz.Area)
+
+		found, best := 0, color.DistanceLAB(c, paper)
+		for _, n := range numbers {
+			if d := color.DistanceLAB(c, palette[n]); d <= best {
+				found, best = n, d
+			}
+		}
+		if want, ok := palette[gz.Number]; found == 0 && ok && color.DistanceLAB(want, paper) <= whiteDistance {
+			found = gz.Number
+		}
+
+		z := Zone{ID: gz.ID, Label: gz.Label, Expected: gz.Number, Found: found, Color: c.Hex()}
+		switch {
+		case found == 0:
+			z.Status = Unfilled
+			r.Unfilled++
+		case found == gz.Number:
+			z.Status = Correct
+			r.Correct++
+		default:
+			z.Status = Wrong
+			r.Wrong++
+		}
+		r.Zones[i] = z
+	}
+	return r, nil
+}
+
+// zoneColor returns the color of a zone from the sheet pixels inside its
+// outline. area is the zone's area without its share of the delimiter
+// lines: that many of the lightest pixels are kept, and their per-channel
+// median returned.
+func zoneColor(pixels []color.RGBA, area int) color.RGBA {
+	if len(pixels) == 0 {
+		return paper
+	}
+	sort.Slice(pixels, func(i, j int) bool { return luma(pixels[i]) > luma(pixels[j]) })
+	if area > 0 && area < len(pixels) {
+		pixels = pixels[:area]
+	}
+	channel := make([]uint8, len(pixels))
+	median := func(get func(color.RGBA) uint8) uint8 {
+		for i, p := range pixels {
+			channel[i] = get(p)
+		}
+		sort.Slice(channel, func(i, j int) bool { return channel[i] < channel[j] })
+		return channel[len(channel)/2]
+	}
+	return color.RGBA{
+		R: median(func(c color.RGBA) uint8 { return c.R }),
+		G: median(func(c color.RGBA) uint8 { return c.G }),
+		B: median(func(c color.RGBA) uint8 { return c.B }),
+		A: 255,
+	}
+}
+
+func luma(c color.RGBA) int {
+	return 299*int(c.R) + 587*int(c.G) + 114*int(c.B)
+}
+
+// fill calls fn for every pixel whose center lies inside the outline of gz
+// and outside its holes. Outline vertices lie on pixel corners, so these
+// are exactly the zone's pixels and its share of the delimiter lines.
+func fill(gz export.GameZone, fn func(x, y int)) {
+	if len(gz.Outline) == 0 {
+		return
+	}
+	rings := append([][]export.Point{gz.Outline}, gz.Holes...)
+	minY, maxY := gz.Outline[0][1], gz.Outline[0][1]
+	for _, p := range gz.Outline {
+		minY, maxY = min(minY, p[1]), max(maxY, p[1])
+	}
+
+	var xs []float64
+	for y := minY; y < maxY; y++ {
+		cy := float64(y) + 0.5
+		xs = xs[:0]
+		for _, ring := range rings {
+			for i := range ring {
+				a, c := ring[i], ring[(i+1)%len(ring)]
+				if (float64(a[1]) > cy) == (float64(c[1]) > cy) {
+					continue
+				}
+				t := (cy - float64(a[1])) / float64(c[1]-a[1])
+				xs = append(xs, float64(a[0])+t*float64(c[0]-a[0]))
+			}
+		}
+		sort.Float64s(xs)
+		// Even-odd: pixel centers between each pair of crossings are inside.
+		for i := 0; i+1 < len(xs); i += 2 {
+			for x := int(xs[i] + 0.5); float64(x)+0.5 < xs[i+1]; x++ {
+				fn(x, y)
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package check
+
+import (
+	"image"
+	stdcolor "image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/maax3v3/macoma/v2/internal/aggregation"
+	"github.com/maax3v3/macoma/v2/internal/color"
+	"github.com/maax3v3/macoma/v2/internal/detection"
+	"github.com/maax3v3/macoma/v2/internal/export"
+	"github.com/maax3v3/macoma/v2/internal/zone"
+)
+
+var (
+	red  = color.RGBA{R: 220, G: 20, B: 20, A: 255}
+	blue = color.RGBA{R: 20, G: 40, B: 220, A: 255}
+)
+
+// threeZones returns the key of a 30x10 drawing split into three zones by
+// 2-pixel delimiter columns, painted red, blue and red.
+func threeZones() *export.GameData {
+	const w, h = 30, 10
+	dm := detection.NewMap(w, h)
+	for y := 0; y < h; y++ {
+		for _, x := range []int{9, 10, 19, 20} {
+			dm.IsDelimiter[y*w+x] = true
+		}
+	}
+	zones, labels := zone.FindZones(dm)
+	cm := &aggregation.ColorMap{
+		Entries: []aggregation.ColorEntry{{Number: 1, Color: red}, {Number: 2, Color: blue}},
+		ZoneMap: []int{0, 1, 0},
+	}
+	return export.BuildGameData(zones, labels, w, h, cm)
+}
+
+// sheet returns the colored page of threeZones at scale k: the x ranges of
+// fills painted, black delimiter lines, and a legend below the drawing.
+func sheet(k int, fills map[[2]int]color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 30*k, 16*k))
+	draw.Draw(img, img.Rect, image.White, image.Point{}, draw.Src)
+	for r, c := range fills {
+		draw.Draw(img, image.Rect(r[0]*k, 0, r[1]*k, 10*k), image.NewUniform(c.ToStdColor()), image.Point{}, draw.Src)
+	}
+	for _, x := range []int{9, 19} {
+		draw.Draw(img, image.Rect(x*k, 0, (x+2)*k, 10*k), image.Black, image.Point{}, draw.Src)
+	}
+	draw.Draw(img, image.Rect(0, 12*k, 4*k, 14*k), image.NewUniform(stdcolor.Black), image.Point{}, draw.Src)
+	return img
+}
+
+func TestCheck(t *testing.T) {
+	key := threeZones()
+	colored := sheet(3, map[[2]int]color.RGBA{{0, 9}: red, {21, 30}: blue})
+	// A printed number inside the first zone.
+	draw.Draw(colored, image.Rect(12, 12, 15, 18), image.Black, image.Point{}, draw.Src)
+
+	r, err := Check(key, colored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{Correct, Unfilled, Wrong}
+	for i, z := range r.Zones {
+		if z.Status != want[i] {
+			t.Errorf("zone %d: got %s (found %d, color %s), want %s", i, z.Status, z.Found, z.Color, want[i])
+		}
+	}
+	if r.Correct != 1 || r.Unfilled != 1 || r.Wrong != 1 || r.Zones[2].Found != 2 {
+		t.Errorf("got %+v", r)
+	}
+
+	if s := r.Score(); s < 0.33 || s > 0.34 {
+		t.Errorf("score: got %g, want 1/3", s)
+	}
+}
+
+func TestCheck_WhiteZoneLeftBlank(t *testing.T) {
+	key := threeZones()
+	key.Palette[1].Color = "#fbfbf8"
+	r, err := Check(key, sheet(2, map[[2]int]color.RGBA{{0, 9}: red}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := r.Zones[1].Status; s != Correct {
+		t.Errorf("blank zone of a near-white color: got %s, want %s", s, Correct)
+	}
+}
+
+func TestCheck_TooShort(t *testing.T) {
+	if _, err := Check(threeZones(), image.NewRGBA(image.Rect(0, 0, 60, 10))); err == nil {
+		t.Error("expected an error for a sheet shorter than the drawing")
+	}
+}
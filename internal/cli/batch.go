@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Batch reports whether --in names several images: a directory, or a glob
+// pattern such as "scans/*.jpg". --out is then a directory.
+func (c Config) Batch() bool {
+	if c.InPath == StdioPath {
+		return false
+	}
+	if strings.ContainsAny(c.InPath, "*?[") {
+		return true
+	}
+	fi, err := os.Stat(c.InPath)
+	return err == nil && fi.IsDir()
+}
+
+// isImagePath reports whether path has the extension of a supported input
+// image.
+func isImagePath(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png", ".jpg", ".jpeg", ".webp":
+		return true
+	}
+	return false
+}
+
+// ExpandInputs returns the images named by a batch --in, sorted: the
+// supported images (PNG, JPEG, WEBP) directly inside a directory, or the
+// files matching a glob pattern. It fails when there are none.
+func ExpandInputs(in string) ([]string, error) {
+	var paths []string
+	if fi, err := os.Stat(in); err == nil && fi.IsDir() {
+		entries, err := os.ReadDir(in)
+		if err != nil {
+			return nil, fmt.Errorf("reading input directory: %w", err)
+		}
+		for _, e := range entries {
+			if !e.IsDir() && isImagePath(e.Name()) {
+				paths = append(paths, filepath.Join(in, e.Name()))
+			}
+		}
+	} else {
+		matches, err := filepath.Glob(in)
+		if err != nil {
+			return nil, fmt.Errorf("bad input pattern %q: %w", in, err)
+		}
+		for _, m := range matches {
+			if fi, err := os.Stat(m); err == nil && !fi.IsDir() {
+				paths = append(paths, m)
+			}
+		}
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no images found in %q", in)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// BatchOutPath returns the output path in outDir for input inPath and an
+// output format, e.g. "out", "scans/cat.jpg", "png" → "out/cat.png".
+func BatchOutPath(outDir, inPath, format string) string {
+	base := filepath.Base(inPath)
+	return filepath.Join(outDir, strings.TrimSuffix(base, filepath.Ext(base))+"."+format)
+}
+
+// BatchOutPaths returns the output path in outDir of each of inputs, as
+// BatchOutPath does, except that inputs which would share one keep their
+// extension: "scans/cat.jpg" and "scans/cat.png" → "out/cat.jpg.png" and
+// "out/cat.png.png". It fails when outputs still collide, as for images
+// of the same name in different directories.
+func BatchOutPaths(outDir string, inputs []string, format string) ([]string, error) {
+	count := make(map[string]int, len(inputs))
+	for _, in := range inputs {
+		count[BatchOutPath(outDir, in, format)]++
+	}
+	outs := make([]string, len(inputs))
+	owner := make(map[string]string, len(inputs))
+	for i, in := range inputs {
+		out := BatchOutPath(outDir, in, format)
+		if count[out] > 1 {
+			out = filepath.Join(outDir, filepath.Base(in)+"."+format)
+		}
+		if prev, ok := owner[out]; ok {
+			return nil, fmt.Errorf("%s and %s would both be written to %s", prev, in, out)
+		}
+		owner[out] = in
+		outs[i] = out
+	}
+	return outs, nil
+}
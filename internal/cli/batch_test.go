@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// touch creates empty files under dir.
+func touch(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, n := range names {
+		if err := os.WriteFile(filepath.Join(dir, n), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestExpandInputs(t *testing.T) {
+	dir := t.TempDir()
+	touch(t, dir, "b.JPG", "a.png", "notes.txt", "c.webp")
+	if err := os.Mkdir(filepath.Join(dir, "sub.png"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ExpandInputs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "a.png"), filepath.Join(dir, "b.JPG"), filepath.Join(dir, "c.webp")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("directory: got %v, want %v", got, want)
+	}
+
+	got, err = ExpandInputs(filepath.Join(dir, "*.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{filepath.Join(dir, "a.png")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("glob: got %v, want %v", got, want)
+	}
+
+	if _, err := ExpandInputs(filepath.Join(dir, "*.tiff")); err == nil {
+		t.Error("expected an error when nothing matches")
+	}
+}
+
+func TestBatchOutPath(t *testing.T) {
+	if got := BatchOutPath("out", "scans/cat.photo.jpg", "svgz"); got != filepath.Join("out", "cat.photo.svgz") {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestBatchOutPaths(t *testing.T) {
+	got, err := BatchOutPaths("out", []string{"scans/cat.jpg", "scans/cat.png", "scans/dog.jpg"}, "png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join("out", "cat.jpg.png"), filepath.Join("out", "cat.png.png"), filepath.Join("out", "dog.png")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if _, err := BatchOutPaths("out", []string{"a/cat.png", "b/cat.png"}, "png"); err == nil {
+		t.Error("expected an error for images of the same name in different directories")
+	}
+}
+
+func TestParseArgs_Batch(t *testing.T) {
+	dir := t.TempDir()
+	touch(t, dir, "a.png", "file.png")
+
+	cfg, err := ParseArgs([]string{"--in=" + dir, "--out=" + filepath.Join(dir, "out")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Batch() || cfg.OutputFormat() != "png" {
+		t.Errorf("batch %v, format %q; want a batch writing png", cfg.Batch(), cfg.OutputFormat())
+	}
+	if cfg, _ := ParseArgs([]string{"--in=a.png", "--out=b.png"}); cfg.Batch() {
+		t.Error("a single image is not a batch")
+	}
+
+	for name, args := range map[string][]string{
+		"out is a file": {"--in=" + filepath.Join(dir, "*.png"), "--out=" + filepath.Join(dir, "file.png")},
+		"game data":     {"--in=" + dir, "--out=out", "--game-data=zones.json"},
+		"geojson":       {"--in=" + dir, "--out=out", "--geojson=zones.geojson"},
+		"negative jobs": {"--in=" + dir, "--out=out", "--jobs=-1"},
+	} {
+		if _, err := ParseArgs(args); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
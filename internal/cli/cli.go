@@ -1,84 +1,806 @@
 package cli
 
 import (
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
 	"strings"
 
+	"github.com/maax3v3/macoma/v2"
 	"github.com/maax3v3/macoma/v2/internal/color"
+	"github.com/maax3v3/macoma/v2/internal/qr"
 )
 
 // Strategy constants for delimiter detection.
 const (
 	StrategyBorder = "border"
 	StrategyColor  = "color"
+	StrategyGrid   = "grid"
 )
 
-// Config holds the parsed CLI arguments.
+// Combine mode constants for several delimiter strategies.
+const (
+	CombineUnion        = "union"
+	CombineIntersection = "intersection"
+)
+
+// Color metric constants.
+const (
+	MetricEuclidean = "euclidean"
+	MetricCIEDE2000 = "ciede2000"
+)
+
+// StdioPath as --in reads the input image from standard input, and as --out
+// writes the output to standard output, so macoma can sit in a pipe.
+const StdioPath = "-"
+
+// Config holds the parsed CLI arguments. Its JSON form (excluding the
+// input/output paths and one-shot actions) is the settings file written by
+// --write-settings and replayed by --settings.
 type Config struct {
-	InPath                   string
-	OutPath                  string
-	DelimiterStrategy        string
-	BorderDelimiterColor     color.RGBA
-	BorderDelimiterTolerance float64
-	ColorDelimiterTolerance  float64
-	MaxColors                int
+	InPath                   string     `json:"-"`
+	OutPath                  string     `json:"-"`
+	Format                   string     `json:"-"`                  // output format; empty = from the --out extension
+	Preset                   string     `json:"-"`                  // built-in preset the other flags start from
+	DelimiterStrategy        string     `json:"delimiter_strategy"` // one strategy, or several comma-separated
+	DelimiterCombine         string     `json:"delimiter_combine"`
+	BorderDelimiterColor     color.RGBA `json:"border_delimiter_color"`
+	BorderDelimiterTolerance float64    `json:"border_delimiter_tolerance"`
+	BorderDelimiterLAB       bool       `json:"border_delimiter_lab"`
+	ExtraBorderColors        string     `json:"extra_border_colors"` // comma-separated "#hex" or "#hex:tolerance"
+	ColorDelimiterTolerance  float64    `json:"color_delimiter_tolerance"`
+	ColorDelimiterRadius     int        `json:"color_delimiter_radius"`
+	GridCells                int        `json:"grid_cells"`   // cells across with the grid strategy
+	ColorMetric              string     `json:"color_metric"` // euclidean or ciede2000
+	AutoCrop                 bool       `json:"auto_crop"`
+	OutputMargin             int        `json:"output_margin"` // white margin around the drawing, in pixels
+	MaxDimension             int        `json:"max_dimension"` // shrink inputs larger than this; 0 = never
+	RestoreSize              bool       `json:"restore_size"`
+	Denoise                  int        `json:"denoise"`          // median filter radius; 0 = off
+	BlurSigma                float64    `json:"blur_sigma"`       // Gaussian pre-blur; 0 = off
+	PosterizeLevels          int        `json:"posterize_levels"` // levels per channel; 0 = off
+	MaxColors                int        `json:"max_colors"`
+	Quantizer                string     `json:"quantizer"` // color reduction: merge or a registered quantizer
+	MaxZoneArea              int        `json:"max_zone_area"`
+	Connectivity             int        `json:"connectivity"`  // flood fill: 4 or 8
+	TileHeight               int        `json:"tile_height"`   // rows per band for detection and flood fill; 0 = whole image
+	MinZoneSize              string     `json:"min_zone_size"` // pixels, or a percentage like "0.5%"
+	SkipBackground           bool       `json:"skip_background"`
+	PaletteFrom              string     `json:"palette_from"` // path to a reference image for the palette
+	PaletteIn                string     `json:"palette_in"`   // path to the JSON palette of an earlier run
+	Palette                  string     `json:"palette"`      // path to a fixed palette of colors
+	LegendCoverage           bool       `json:"legend_coverage"`
+	LegendHex                bool       `json:"legend_hex"`
+	LegendZoneCounts         bool       `json:"legend_zone_counts"`
+	LegendQR                 string     `json:"legend_qr"` // URL for a QR code in the legend; see SolutionPlaceholder
+	BackgroundColor          color.RGBA `json:"background_color"`
+	TransparentBackground    bool       `json:"transparent_background"`
+	LineColor                color.RGBA `json:"line_color"`
+	MinConfidence            float64    `json:"min_confidence"`
+	PatternFill              bool       `json:"pattern_fill"`
+	HintOpacity              float64    `json:"hint_opacity"`
+	LineWidth                int        `json:"line_width"`
+	LargePrint               bool       `json:"large_print"`
+	EnsureLegible            bool       `json:"ensure_legible"`
+	OutputScale              int        `json:"output_scale"`
+	RotateLabels             bool       `json:"rotate_labels"`
+	MultiLabelFraction       float64    `json:"multi_label_fraction"`
+	Labels                   string     `json:"labels"` // letters, symbols or comma-separated labels; "" = numbers
+	Worksheets               bool       `json:"worksheets"`
+	Solution                 bool       `json:"solution"`
+	PalettePreview           bool       `json:"palette_preview"`
+	Metadata                 bool       `json:"metadata"`
+	WatermarkText            string     `json:"watermark_text"`
+	WatermarkImage           string     `json:"watermark_image"` // path to a small image stamp
+	WatermarkPosition        string     `json:"watermark_position"`
+	WatermarkOpacity         float64    `json:"watermark_opacity"`
+	Title                    string     `json:"title"`
+	TitleSize                int        `json:"title_size"`      // text height in pixels; 0 = from the output width
+	TitleAlign               string     `json:"title_align"`     // left, center or right
+	PNGCompression           string     `json:"png_compression"` // default, none, fast or best
+	PNGInterlace             bool       `json:"png_interlace"`
+	JPEGQuality              int        `json:"jpeg_quality"` // 1-100
+	Paper                    string     `json:"paper"`        // PDF page size: a4, a5 or letter
+	DPI                      float64    `json:"dpi"`
+	MarginMM                 float64    `json:"margin_mm"`
+	FitPaper                 bool       `json:"fit_paper"`
+	PaperLayout              bool       `json:"paper_layout"`
+	Reference                string     `json:"reference"`    // original, preview or "" for none
+	GameDataPath             string     `json:"-"`            // optional tap-to-fill JSON export
+	GeoJSONPath              string     `json:"-"`            // optional zone polygons as GeoJSON
+	RevealPath               string     `json:"-"`            // optional animated GIF revealing the solution
+	RevealDelay              int        `json:"reveal_delay"` // milliseconds per reveal frame
+	LegendOutPath            string     `json:"-"`            // optional legend image or PDF, left off the drawing
+	KeyPath                  string     `json:"-"`            // check: game data of the coloring
+	ColoredPath              string     `json:"-"`            // check: the colored sheet
+	Stitches                 int        `json:"-"`            // stitch: chart width in stitches
+	StitchThreads            int        `json:"-"`            // stitch: at most this many floss colors; 0 = all
+	StitchCellSize           int        `json:"-"`            // stitch: chart pixels per stitch
+	UIAddr                   string     `json:"-"`            // ui: address the page is served on
+	DebugDump                string     `json:"-"`            // directory for intermediate images
+	Jobs                     int        `json:"-"`            // files converted in parallel in batch mode; 0 = one per CPU
+	WriteSettings            bool       `json:"-"`
+	PrintConfig              bool       `json:"-"`
+}
+
+// DefaultConfig returns the configuration used when no flags are given.
+func DefaultConfig() Config {
+	return Config{
+		DelimiterStrategy:        StrategyColor,
+		DelimiterCombine:         CombineUnion,
+		BorderDelimiterColor:     color.RGBA{A: 255},
+		BackgroundColor:          color.RGBA{R: 255, G: 255, B: 255, A: 255},
+		LineColor:                color.RGBA{A: 255},
+		BorderDelimiterTolerance: 10,
+		ColorDelimiterTolerance:  10,
+		ColorDelimiterRadius:     2,
+		GridCells:                macoma.DefaultGridCells,
+		ColorMetric:              MetricEuclidean,
+		MaxColors:                10,
+		Quantizer:                macoma.QuantizerMerge,
+		Connectivity:             4,
+		WatermarkPosition:        "bottom-right",
+		WatermarkOpacity:         0.5,
+		TitleAlign:               "center",
+		PNGCompression:           "default",
+		JPEGQuality:              90,
+		Paper:                    "a4",
+		DPI:                      300,
+		MarginMM:                 10,
+		RevealDelay:              1000,
+		Stitches:                 80,
+		StitchThreads:            20,
+		StitchCellSize:           16,
+		UIAddr:                   "localhost:8080",
+	}
+}
+
+// Parse parses the arguments of cmd and returns a validated Config, exiting
+// when only help was asked for.
+func Parse(cmd Command, args []string) (Config, error) {
+	cfg, err := ParseCommand(cmd, args)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	return cfg, err
+}
+
+// ParseArgs parses the arguments of the convert command (without the
+// program name) and returns a validated Config.
+func ParseArgs(args []string) (Config, error) {
+	return ParseCommand(CommandConvert, args)
+}
+
+// applySettings loads the --settings file at path and re-applies every
+// flag explicitly passed to fs on top of it.
+func applySettings(fs *flag.FlagSet, path string) (Config, error) {
+	loaded, err := LoadSettings(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("--settings: %w", err)
+	}
+	if err := replayFlags(fs, &loaded); err != nil {
+		return Config{}, err
+	}
+	return loaded, nil
+}
+
+// applyPreset sets the fields of cfg that its --preset decides, then
+// re-applies every flag explicitly passed to fs on top of them. The preset
+// is applied by macoma.Options.ApplyPreset on a copy of cfg, so the CLI
+// follows the library's list of preset fields.
+func applyPreset(fs *flag.FlagSet, cfg Config) (Config, error) {
+	var opts macoma.Options
+	copyFields(&opts, &cfg)
+	opts.MinZoneSize.Pixels, opts.MinZoneSize.Percent, _ = ParseZoneSize(cfg.MinZoneSize)
+	minZone := opts.MinZoneSize
+	if err := opts.ApplyPreset(macoma.Preset(cfg.Preset)); err != nil {
+		return Config{}, fmt.Errorf("--preset: %w (presets: %s)", err, presetNames())
+	}
+	copyFields(&cfg, &opts)
+	if opts.MinZoneSize != minZone {
+		cfg.MinZoneSize = formatZoneSize(opts.MinZoneSize)
+	}
+	if err := replayFlags(fs, &cfg); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// copyFields sets every field of the struct dst points to from the field
+// of src with the same name and type, if any.
+func copyFields(dst, src any) {
+	d, s := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem()
+	for i := 0; i < d.NumField(); i++ {
+		f := d.Type().Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if v := s.FieldByName(f.Name); v.IsValid() && v.Type() == f.Type {
+			d.Field(i).Set(v)
+		}
+	}
+}
+
+func presetNames() string {
+	names := make([]string, 0, len(macoma.Presets()))
+	for _, p := range macoma.Presets() {
+		names = append(names, string(p))
+	}
+	return strings.Join(names, ", ")
+}
+
+// formatZoneSize is the inverse of ParseZoneSize.
+func formatZoneSize(z macoma.ZoneSize) string {
+	switch {
+	case z.Percent > 0:
+		return strconv.FormatFloat(z.Percent, 'f', -1, 64) + "%"
+	case z.Pixels > 0:
+		return strconv.Itoa(z.Pixels)
+	}
+	return ""
+}
+
+// replayFlags sets every flag explicitly passed to fs again on cfg, so
+// they override values loaded after parsing.
+func replayFlags(fs *flag.FlagSet, cfg *Config) error {
+	replay := flag.NewFlagSet("replay", flag.ContinueOnError)
+	bindFlags(replay, cfg)
+	var replayErr error
+	fs.Visit(func(f *flag.Flag) {
+		name := f.Name
+		if alias, ok := flagAliases[name]; ok {
+			name = alias
+		}
+		if replayErr == nil && replay.Lookup(name) != nil {
+			replayErr = replay.Set(name, f.Value.String())
+		}
+	})
+	return replayErr
+}
+
+// bindFlags registers every Config flag on fs, bound to the fields of cfg and
+// using their current values as defaults. These are the flags of the
+// convert command.
+func bindFlags(fs *flag.FlagSet, cfg *Config) {
+	fs.StringVar(&cfg.InPath, "in", cfg.InPath, "Path to input image (required, supports PNG, JPEG, WEBP; - for standard input), or a directory or glob pattern (quoted, e.g. \"scans/*.jpg\") to convert many")
+	fs.StringVar(&cfg.OutPath, "out", cfg.OutPath, "Path to generated output image (required; .png, .jpg/.jpeg, .webp (lossless), .svg/.svgz for vector output, .eps/.dxf for plotter and cutter line art, .tif/.tiff for a multi-page TIFF, or .pdf; - for standard output, see --format), or the output directory when --in names many images")
+	fs.IntVar(&cfg.Jobs, "jobs", cfg.Jobs, "Images converted in parallel when --in names many (0 = one per CPU)")
+	fs.StringVar(&cfg.Format, "format", cfg.Format, "Output format: png, jpeg, webp, svg, svgz, eps, dxf, tiff or pdf (default: from the --out extension)")
+	bindPresetFlag(fs, cfg)
+	bindPreprocessFlags(fs, cfg)
+	fs.BoolVar(&cfg.RestoreSize, "restore-size", cfg.RestoreSize, "Render an input shrunk by --max-dimension back at its original size")
+	fs.IntVar(&cfg.OutputMargin, "output-margin", cfg.OutputMargin, "Surround the drawing with a white margin this many pixels wide on output, e.g. after --auto-crop (0 = none)")
+	bindDetectionFlags(fs, cfg)
+	bindPaletteFlags(fs, cfg)
+	fs.BoolVar(&cfg.LegendCoverage, "legend-coverage", cfg.LegendCoverage, "Annotate each legend entry with the percentage of the area it covers")
+	fs.BoolVar(&cfg.LegendHex, "legend-hex", cfg.LegendHex, "Print each legend entry's hex code (e.g. #C84B3A) beside its swatch")
+	fs.BoolVar(&cfg.LegendZoneCounts, "legend-zone-counts", cfg.LegendZoneCounts, "Print how many zones use each legend color (e.g. × 3) as a checklist")
+	fs.StringVar(&cfg.LegendQR, "legend-qr", cfg.LegendQR, "URL printed as a QR code in the legend corner, e.g. the solution online; "+SolutionPlaceholder+" stands for the answer key file name (e.g. https://example.com/keys/"+SolutionPlaceholder+")")
+	fs.TextVar(&cfg.BackgroundColor, "background-color", cfg.BackgroundColor, "Hex color of the paper: zones, margins and legend area (e.g. #FFF8E7 for cream)")
+	fs.BoolVar(&cfg.TransparentBackground, "transparent-background", cfg.TransparentBackground, "Leave the paper transparent instead of --background-color, to composite the coloring onto a worksheet (PNG, WebP and SVG output)")
+	fs.TextVar(&cfg.LineColor, "line-color", cfg.LineColor, "Hex color of the zone borders (e.g. #C0C0C0 for light gray)")
+	fs.Float64Var(&cfg.MinConfidence, "min-confidence", cfg.MinConfidence, "Fail (exit code 3) when the detection confidence score is below this value, 0-1 (0 = never)")
+	fs.BoolVar(&cfg.PatternFill, "pattern-fill", cfg.PatternFill, "Hatch each zone with a black-and-white pattern per color (with a pattern legend) for monochrome printing")
+	fs.Float64Var(&cfg.HintOpacity, "hint-opacity", cfg.HintOpacity, "Tint every zone with a faint version of its color, as a hint for young children: its opacity, 0-1, e.g. 0.15 (0 = plain paper)")
+	fs.IntVar(&cfg.LineWidth, "line-width", cfg.LineWidth, "Redraw every zone border exactly this many pixels wide, for even outlines from uneven scans (0 = copy the source strokes)")
+	fs.BoolVar(&cfg.LargePrint, "large-print", cfg.LargePrint, "Accessibility mode: large high-contrast numbers, thicker outlines and a bigger legend (upscales small drawings)")
+	fs.BoolVar(&cfg.EnsureLegible, "ensure-legible", cfg.EnsureLegible, "Upscale the output (up to 4x) when zones are too thin for their numbers, and report the factor")
+	fs.IntVar(&cfg.OutputScale, "output-scale", cfg.OutputScale, "Render at this many times the source resolution, with sharp zone edges and full-size numbers, lines and legend, for printing small images (0 = source size)")
+	fs.BoolVar(&cfg.RotateLabels, "rotate-labels", cfg.RotateLabels, "Turn the numbers of long, thin zones along the zone when they don't fit upright")
+	fs.StringVar(&cfg.Labels, "labels", cfg.Labels, "Label zones and legend entries with \"letters\" (A, B, C...), \"symbols\" (shapes for cross-stitch charts) or a comma-separated list (e.g. \"A,B,♥\") instead of numbers")
+	fs.Float64Var(&cfg.MultiLabelFraction, "multi-label-fraction", cfg.MultiLabelFraction, "Repeat the number of zones covering more than this fraction of the image, once per fraction (up to 9), 0-1 (0 = never)")
+	fs.BoolVar(&cfg.Solution, "solution", cfg.Solution, "Also write the numbered answer key, zones filled with their colors, next to the output (<out>-solution.png)")
+	fs.BoolVar(&cfg.PalettePreview, "palette-preview", cfg.PalettePreview, "Also write the drawing recolored with the reduced palette, without numbers, next to the output (<out>-palette.png), to check --max-colors")
+	fs.BoolVar(&cfg.Worksheets, "worksheets", cfg.Worksheets, "Also write one \"find all the Ns\" worksheet PNG per color next to the output (<out>-worksheet-<n>.png)")
+	fs.StringVar(&cfg.WatermarkText, "watermark-text", cfg.WatermarkText, "Text stamped onto the output (letters, digits and common punctuation with the built-in font)")
+	fs.StringVar(&cfg.WatermarkImage, "watermark-image", cfg.WatermarkImage, "Path to a small image stamped onto the output (overrides --watermark-text)")
+	fs.StringVar(&cfg.WatermarkPosition, "watermark-position", cfg.WatermarkPosition, "Watermark position: top-left, top-right, bottom-left, bottom-right, center")
+	fs.Float64Var(&cfg.WatermarkOpacity, "watermark-opacity", cfg.WatermarkOpacity, "Watermark opacity, 0-1")
+	fs.StringVar(&cfg.Title, "title", cfg.Title, "Caption printed above the drawing (above the legend with --legend-out), e.g. \"Unit 3 - The Farm\"")
+	fs.IntVar(&cfg.TitleSize, "title-size", cfg.TitleSize, "Title text height in pixels (0 = from the output width)")
+	fs.StringVar(&cfg.TitleAlign, "title-align", cfg.TitleAlign, "Title alignment: left, center or right")
+	fs.StringVar(&cfg.PNGCompression, "png-compression", cfg.PNGCompression, "PNG compression level: default, none, fast or best (fast suits batch runs)")
+	fs.BoolVar(&cfg.PNGInterlace, "png-interlace", cfg.PNGInterlace, "Write an interlaced PNG that displays progressively while loading")
+	fs.IntVar(&cfg.JPEGQuality, "jpeg-quality", cfg.JPEGQuality, "JPEG output quality, 1-100")
+	fs.StringVar(&cfg.Paper, "paper", cfg.Paper, "PDF page size: a4, a5 or letter")
+	fs.Float64Var(&cfg.DPI, "dpi", cfg.DPI, "Print resolution of PDF and --paper-layout output; drawings too large for the page are shrunk to fit")
+	fs.Float64Var(&cfg.MarginMM, "margin", cfg.MarginMM, "PDF page margins in millimeters")
+	fs.BoolVar(&cfg.FitPaper, "fit-paper", cfg.FitPaper, "Enlarge drawings smaller than the page to fill it inside the margins")
+	fs.StringVar(&cfg.Reference, "reference", cfg.Reference, "Put a colored reference left of the coloring on one PNG, JPEG or WebP canvas: original (the drawing) or preview (recolored with the reduced palette)")
+	fs.BoolVar(&cfg.PaperLayout, "paper-layout", cfg.PaperLayout, "Place PNG, JPEG and WebP output on a --paper page at --dpi, with --margin, as in the PDF output")
+	fs.BoolVar(&cfg.Metadata, "metadata", cfg.Metadata, "Also write the palette, zone counts per color, image size and options as JSON next to the output (<out>.metadata.json)")
+	fs.StringVar(&cfg.RevealPath, "reveal", cfg.RevealPath, "Also write an animated .gif revealing the solution one color at a time, for classroom projection")
+	fs.IntVar(&cfg.RevealDelay, "reveal-delay", cfg.RevealDelay, "Milliseconds each --reveal frame shows; the finished picture shows three times as long")
+	fs.StringVar(&cfg.GameDataPath, "game-data", cfg.GameDataPath, "Also write zone outlines, numbers, label points and adjacency as JSON for tap-to-fill apps")
+	fs.StringVar(&cfg.GeoJSONPath, "geojson", cfg.GeoJSONPath, "Also write the zone outlines and holes as GeoJSON polygons with their palette numbers (.geojson or .json), for apps that draw colorings natively")
+	fs.StringVar(&cfg.LegendOutPath, "legend-out", cfg.LegendOutPath, "Write the legend to this .png, .jpg, .webp or .pdf file instead of below the drawing, which keeps its aspect ratio")
+	fs.StringVar(&cfg.DebugDump, "debug-dump", cfg.DebugDump, "Also write the intermediate images (detection map, zones by ID, zone colors before reduction, coloring before the legend) into this directory, to diagnose unexpected zones")
+	fs.BoolVar(&cfg.PrintConfig, "print-config", cfg.PrintConfig, "Print the resolved settings (defaults, --settings file and flags merged) as JSON and exit")
+	fs.BoolVar(&cfg.WriteSettings, "write-settings", cfg.WriteSettings, "Save the resolved settings next to the output (<out>.settings.json) for replay with --settings")
+}
+
+// bindPreprocessFlags registers the flags that prepare the input image
+// before detection.
+func bindPreprocessFlags(fs *flag.FlagSet, cfg *Config) {
+	fs.BoolVar(&cfg.AutoCrop, "auto-crop", cfg.AutoCrop, "Trim the blank paper margins of the input before converting, so the drawing stays large once the legend is appended")
+	fs.IntVar(&cfg.MaxDimension, "max-dimension", cfg.MaxDimension, "Shrink inputs whose long edge exceeds this many pixels before converting, for fast conversion of huge scans (0 = never)")
+	fs.IntVar(&cfg.Denoise, "denoise", cfg.Denoise, "Median filter radius run over the input before detection, to remove JPEG artifacts and scanner noise (e.g. 1 or 2; 0 = off)")
+	fs.Float64Var(&cfg.BlurSigma, "blur", cfg.BlurSigma, "Standard deviation in pixels of a Gaussian blur run over the input before detection, to suppress paper grain and texture (e.g. 1.5; 0 = off)")
+	fs.IntVar(&cfg.PosterizeLevels, "posterize", cfg.PosterizeLevels, "Quantize each color channel of the input to this many levels before detection, 2-255, to flatten gradients (0 = off)")
+}
+
+// bindDetectionFlags registers the flags that shape delimiter detection and
+// the zones found between the delimiters.
+func bindDetectionFlags(fs *flag.FlagSet, cfg *Config) {
+	fs.StringVar(&cfg.DelimiterStrategy, "delimiter-strategy", cfg.DelimiterStrategy, "Delimitation strategy: \"border\" (explicit border color), \"color\" (neighbor color difference), \"grid\" (square cells, for pixel art) or a registered one, or several as \"border,color\" (see --delimiter-combine)")
+	fs.StringVar(&cfg.DelimiterCombine, "delimiter-combine", cfg.DelimiterCombine, "How several delimiter strategies combine: \"union\" (found by any) or \"intersection\" (found by all)")
+	fs.TextVar(&cfg.BorderDelimiterColor, "border-delimiter-color", cfg.BorderDelimiterColor, "Hex color of the drawing delimiter lines (border strategy only, e.g. #000, #FF00FF)")
+	fs.Float64Var(&cfg.BorderDelimiterTolerance, "border-delimiter-tolerance", cfg.BorderDelimiterTolerance, "Tolerance % for matching the border color, 0-100 (border strategy only; 0 = automatic, per image)")
+	fs.BoolVar(&cfg.BorderDelimiterLAB, "border-delimiter-lab", cfg.BorderDelimiterLAB, "Match the border color by CIELAB distance instead of RGB; the tolerance is then a ΔE76 value (border strategy only)")
+	fs.StringVar(&cfg.ExtraBorderColors, "extra-border-colors", cfg.ExtraBorderColors, "Further outline colors for the border strategy, comma-separated, each optionally with its own tolerance (e.g. \"#5a3214:15,#303080\"; default: --border-delimiter-tolerance)")
+	fs.Float64Var(&cfg.ColorDelimiterTolerance, "color-delimiter-tolerance", cfg.ColorDelimiterTolerance, "Color difference threshold % from which neighbors are considered different sections, 0-100 (color strategy only)")
+	fs.IntVar(&cfg.ColorDelimiterRadius, "color-delimiter-radius", cfg.ColorDelimiterRadius, "Neighborhood radius of the color strategy: a (2r+1)x(2r+1) window; larger for high-resolution scans, 1 for small icons")
+	fs.IntVar(&cfg.GridCells, "grid-cells", cfg.GridCells, "Number of square cells across the image, up to "+strconv.Itoa(macoma.MaxGridCells)+" (grid strategy only); every cell is numbered")
+	fs.StringVar(&cfg.ColorMetric, "color-metric", cfg.ColorMetric, "Color difference used for palette reduction and border matching: \"euclidean\" or \"ciede2000\" (perceptual; --border-delimiter-tolerance is then a ΔE00 value)")
+	fs.IntVar(&cfg.Connectivity, "connectivity", cfg.Connectivity, "Zone flood-fill connectivity: 4 (pixels sharing an edge) or 8 (also diagonal neighbors; thin diagonal lines then leak)")
+	fs.IntVar(&cfg.TileHeight, "tile-height", cfg.TileHeight, "Detect delimiters and flood-fill zones in bands of this many rows to bound memory on huge scans, e.g. 1024 (0 = whole image)")
+	fs.StringVar(&cfg.MinZoneSize, "min-zone-size", cfg.MinZoneSize, "Merge zones smaller than this into their largest neighbor, in pixels or as a percentage of the image (e.g. 20 or 0.05%)")
+	fs.BoolVar(&cfg.SkipBackground, "skip-background", cfg.SkipBackground, "Leave near-white zones touching the image edge (the paper around the drawing) blank, without a number or legend entry")
+}
+
+// bindPresetFlag registers --preset.
+func bindPresetFlag(fs *flag.FlagSet, cfg *Config) {
+	fs.StringVar(&cfg.Preset, "preset", cfg.Preset, "Start from a difficulty level instead of tuning each flag: "+presetNames()+"; explicit flags override it")
+}
+
+// bindPaletteFlags registers the flags that choose the palette the zones are
+// numbered with.
+func bindPaletteFlags(fs *flag.FlagSet, cfg *Config) {
+	fs.IntVar(&cfg.MaxColors, "max-colors", cfg.MaxColors, "Maximum number of colors in the magic drawing (0 = unlimited)")
+	fs.StringVar(&cfg.Quantizer, "quantizer", cfg.Quantizer, "Color reduction: \"merge\" (repeatedly merge the two closest colors) or a registered quantizer")
+	fs.IntVar(&cfg.MaxZoneArea, "max-zone-area", cfg.MaxZoneArea, "Split zones larger than this many pixels into sub-zones with faint divider lines (0 = never)")
+	fs.StringVar(&cfg.PaletteFrom, "palette-from", cfg.PaletteFrom, "Path to a reference image (e.g. a photo of your pencils) whose dominant colors become the palette")
+	fs.StringVar(&cfg.PaletteIn, "palette-in", cfg.PaletteIn, "Path to the JSON palette of an earlier run (e.g. its --game-data file); zones are mapped onto it and keep its numbers")
+	fs.StringVar(&cfg.Palette, "palette", cfg.Palette, "Path to a fixed palette (one hex color per line, optionally followed by a name, a JSON palette, or a .gpl GIMP palette); zones are mapped onto those exact colors")
+}
+
+// Validate checks that the configuration is complete and within range.
+func (c Config) Validate() error {
+	if c.InPath == "" {
+		return fmt.Errorf("--in is required")
+	}
+	if c.OutPath == "" {
+		return fmt.Errorf("--out is required")
+	}
+	if c.Jobs < 0 {
+		return fmt.Errorf("--jobs must be >= 0, got %d", c.Jobs)
+	}
+	if c.OutPath == StdioPath {
+		// Sidecar files are named after --out.
+		sidecars := []struct {
+			flag string
+			set  bool
+		}{{"--metadata", c.Metadata}, {"--solution", c.Solution}, {"--palette-preview", c.PalettePreview}, {"--worksheets", c.Worksheets}, {"--write-settings", c.WriteSettings}, {"--legend-qr with " + SolutionPlaceholder, strings.Contains(c.LegendQR, SolutionPlaceholder)}}
+		for _, sc := range sidecars {
+			if sc.set {
+				return fmt.Errorf("%s needs an --out file, not %q", sc.flag, StdioPath)
+			}
+		}
+	}
+	if c.Batch() {
+		if c.OutPath == StdioPath {
+			return fmt.Errorf("--out must be a directory when --in names many images, got %q", StdioPath)
+		}
+		if c.GameDataPath != "" {
+			return fmt.Errorf("--game-data takes a single --in image")
+		}
+		if c.GeoJSONPath != "" {
+			return fmt.Errorf("--geojson takes a single --in image")
+		}
+		if c.RevealPath != "" {
+			return fmt.Errorf("--reveal takes a single --in image")
+		}
+		if c.LegendOutPath != "" {
+			return fmt.Errorf("--legend-out takes a single --in image")
+		}
+		if fi, err := os.Stat(c.OutPath); err == nil && !fi.IsDir() {
+			return fmt.Errorf("--out must be a directory when --in names many images, got file %q", c.OutPath)
+		}
+	}
+	switch c.Format {
+	case "":
+		if !c.Batch() && c.OutputFormat() == "" {
+			return fmt.Errorf("--out must be a .png, .jpg, .jpeg, .webp, .svg, .svgz, .eps, .dxf, .tif, .tiff or .pdf file (or pass --format), got %q", filepath.Ext(c.OutPath))
+		}
+	case "png", "jpeg", "webp", "svg", "svgz", "eps", "dxf", "tiff", "pdf":
+	default:
+		return fmt.Errorf("--format must be one of png, jpeg, webp, svg, svgz, eps, dxf, tiff, pdf, got %q", c.Format)
+	}
+	if c.RevealPath != "" && strings.ToLower(filepath.Ext(c.RevealPath)) != ".gif" {
+		return fmt.Errorf("--reveal must be a .gif file, got %q", c.RevealPath)
+	}
+	if c.GameDataPath != "" && strings.ToLower(filepath.Ext(c.GameDataPath)) != ".json" {
+		return fmt.Errorf("--game-data must be a .json file, got %q", c.GameDataPath)
+	}
+	if ext := strings.ToLower(filepath.Ext(c.GeoJSONPath)); c.GeoJSONPath != "" && ext != ".geojson" && ext != ".json" {
+		return fmt.Errorf("--geojson must be a .geojson or .json file, got %q", c.GeoJSONPath)
+	}
+	if c.TransparentBackground {
+		switch c.OutputFormat() {
+		case "jpeg", "tiff", "pdf":
+			return fmt.Errorf("--transparent-background needs a png, webp, svg or svgz output: %s has no transparency", c.OutputFormat())
+		}
+	}
+	switch c.Reference {
+	case "", macoma.ReferenceOriginal, macoma.ReferencePreview:
+	default:
+		return fmt.Errorf("--reference must be original or preview, got %q", c.Reference)
+	}
+	if c.Reference != "" {
+		switch c.OutputFormat() {
+		case "svg", "svgz", "eps", "dxf", "tiff", "pdf":
+			return fmt.Errorf("--reference needs a png, jpeg or webp output, got %s", c.OutputFormat())
+		}
+	}
+	if c.PaperLayout {
+		switch c.OutputFormat() {
+		case "svg", "svgz", "eps", "dxf", "tiff":
+			return fmt.Errorf("--paper-layout needs a png, jpeg, webp or pdf output, got %s", c.OutputFormat())
+		}
+	}
+	if len(c.LegendQR) > qr.MaxBytes {
+		return fmt.Errorf("--legend-qr must be at most %d bytes to fit a QR code, got %d", qr.MaxBytes, len(c.LegendQR))
+	}
+	if c.LegendOutPath != "" && LegendFormat(c.LegendOutPath) == "" {
+		return fmt.Errorf("--legend-out must be a .png, .jpg, .jpeg, .webp or .pdf file, got %q", filepath.Ext(c.LegendOutPath))
+	}
+	return c.validateSettings()
+}
+
+// validateSettings checks the settings portion of the configuration.
+func (c Config) validateSettings() error {
+	if _, err := ParseStrategies(c.DelimiterStrategy); err != nil {
+		return fmt.Errorf("--delimiter-strategy: %w", err)
+	}
+	if c.DelimiterCombine != CombineUnion && c.DelimiterCombine != CombineIntersection {
+		return fmt.Errorf("--delimiter-combine must be %q or %q, got %q", CombineUnion, CombineIntersection, c.DelimiterCombine)
+	}
+	if c.OutputMargin < 0 {
+		return fmt.Errorf("--output-margin must be >= 0, got %d", c.OutputMargin)
+	}
+	if c.MaxDimension < 0 {
+		return fmt.Errorf("--max-dimension must be >= 0, got %d", c.MaxDimension)
+	}
+	if c.Denoise < 0 {
+		return fmt.Errorf("--denoise must be >= 0, got %d", c.Denoise)
+	}
+	if c.BlurSigma < 0 || c.BlurSigma > 50 {
+		return fmt.Errorf("--blur must be between 0 and 50, got %f", c.BlurSigma)
+	}
+	if c.PosterizeLevels != 0 && (c.PosterizeLevels < 2 || c.PosterizeLevels > 255) {
+		return fmt.Errorf("--posterize must be 0 or between 2 and 255, got %d", c.PosterizeLevels)
+	}
+	if err := macoma.CheckQuantizer(c.Quantizer); err != nil {
+		return fmt.Errorf("--quantizer: %w", err)
+	}
+	if c.BorderDelimiterTolerance < 0 || c.BorderDelimiterTolerance > 100 {
+		return fmt.Errorf("--border-delimiter-tolerance must be between 0 and 100, got %f", c.BorderDelimiterTolerance)
+	}
+	if c.ColorDelimiterTolerance < 0 || c.ColorDelimiterTolerance > 100 {
+		return fmt.Errorf("--color-delimiter-tolerance must be between 0 and 100, got %f", c.ColorDelimiterTolerance)
+	}
+	if c.ColorDelimiterRadius < 1 {
+		return fmt.Errorf("--color-delimiter-radius must be >= 1, got %d", c.ColorDelimiterRadius)
+	}
+	if c.GridCells < 1 || c.GridCells > macoma.MaxGridCells {
+		return fmt.Errorf("--grid-cells must be between 1 and %d, got %d", macoma.MaxGridCells, c.GridCells)
+	}
+	if c.ColorMetric != MetricEuclidean && c.ColorMetric != MetricCIEDE2000 {
+		return fmt.Errorf("--color-metric must be %q or %q, got %q", MetricEuclidean, MetricCIEDE2000, c.ColorMetric)
+	}
+	if c.MaxColors < 0 {
+		return fmt.Errorf("--max-colors must be >= 0, got %d", c.MaxColors)
+	}
+	if c.MinConfidence < 0 || c.MinConfidence > 1 {
+		return fmt.Errorf("--min-confidence must be between 0 and 1, got %f", c.MinConfidence)
+	}
+	if c.PaletteIn != "" && strings.ToLower(filepath.Ext(c.PaletteIn)) != ".json" {
+		return fmt.Errorf("--palette-in must be a .json file, got %q", c.PaletteIn)
+	}
+	if _, err := ParseLabels(c.Labels); err != nil {
+		return fmt.Errorf("--labels: %w", err)
+	}
+	if c.HintOpacity < 0 || c.HintOpacity > 1 {
+		return fmt.Errorf("--hint-opacity must be between 0 and 1, got %f", c.HintOpacity)
+	}
+	if c.MultiLabelFraction < 0 || c.MultiLabelFraction > 1 {
+		return fmt.Errorf("--multi-label-fraction must be between 0 and 1, got %f", c.MultiLabelFraction)
+	}
+	if c.LineWidth < 0 {
+		return fmt.Errorf("--line-width must be >= 0, got %d", c.LineWidth)
+	}
+	if c.OutputScale < 0 {
+		return fmt.Errorf("--output-scale must be >= 0, got %d", c.OutputScale)
+	}
+	if c.MaxZoneArea < 0 {
+		return fmt.Errorf("--max-zone-area must be >= 0, got %d", c.MaxZoneArea)
+	}
+	if c.Connectivity != 4 && c.Connectivity != 8 {
+		return fmt.Errorf("--connectivity must be 4 or 8, got %d", c.Connectivity)
+	}
+	if c.TileHeight < 0 {
+		return fmt.Errorf("--tile-height must be >= 0, got %d", c.TileHeight)
+	}
+	if _, err := ParseBorderColors(c.ExtraBorderColors, c.BorderDelimiterTolerance); err != nil {
+		return fmt.Errorf("--extra-border-colors: %w", err)
+	}
+	if _, _, err := ParseZoneSize(c.MinZoneSize); err != nil {
+		return fmt.Errorf("--min-zone-size: %w", err)
+	}
+	switch c.WatermarkPosition {
+	case "top-left", "top-right", "bottom-left", "bottom-right", "center":
+	default:
+		return fmt.Errorf("--watermark-position must be one of top-left, top-right, bottom-left, bottom-right, center, got %q", c.WatermarkPosition)
+	}
+	if c.WatermarkOpacity <= 0 || c.WatermarkOpacity > 1 {
+		return fmt.Errorf("--watermark-opacity must be in (0, 1], got %f", c.WatermarkOpacity)
+	}
+	switch c.TitleAlign {
+	case "left", "center", "right":
+	default:
+		return fmt.Errorf("--title-align must be one of left, center, right, got %q", c.TitleAlign)
+	}
+	if c.TitleSize < 0 {
+		return fmt.Errorf("--title-size must be >= 0, got %d", c.TitleSize)
+	}
+	switch c.PNGCompression {
+	case "default", "none", "fast", "best":
+	default:
+		return fmt.Errorf("--png-compression must be one of default, none, fast, best, got %q", c.PNGCompression)
+	}
+	if c.JPEGQuality < 1 || c.JPEGQuality > 100 {
+		return fmt.Errorf("--jpeg-quality must be between 1 and 100, got %d", c.JPEGQuality)
+	}
+	paper, ok := macoma.PaperByName(c.Paper)
+	if !ok {
+		return fmt.Errorf("--paper must be a4, a5 or letter, got %q", c.Paper)
+	}
+	if c.DPI <= 0 {
+		return fmt.Errorf("--dpi must be > 0, got %f", c.DPI)
+	}
+	if c.MarginMM < 0 {
+		return fmt.Errorf("--margin must be >= 0, got %f", c.MarginMM)
+	}
+	if 2*macoma.MillimetersToPoints(c.MarginMM) >= min(paper.Width, paper.Height) {
+		return fmt.Errorf("--margin of %g mm leaves no room on %s paper", c.MarginMM, c.Paper)
+	}
+	if c.RevealDelay <= 0 {
+		return fmt.Errorf("--reveal-delay must be > 0, got %d", c.RevealDelay)
+	}
+	return nil
 }
 
-// Parse parses CLI arguments and returns a validated Config.
-func Parse() (Config, error) {
-	inPath := flag.String("in", "", "Path to input image (required, supports PNG, JPEG, WEBP)")
-	outPath := flag.String("out", "", "Path to generated output image (required, must be .png)")
-	strategy := flag.String("delimiter-strategy", StrategyColor, "Delimitation strategy: \"border\" (explicit border color) or \"color\" (neighbor color difference)")
-	borderColor := flag.String("border-delimiter-color", "#000", "Hex color of the drawing delimiter lines (border strategy only, e.g. #000, #FF00FF)")
-	borderTolerance := flag.Float64("border-delimiter-tolerance", 10, "Tolerance % for matching the border color, 0-100 (border strategy only)")
-	colorTolerance := flag.Float64("color-delimiter-tolerance", 10, "Color difference threshold % from which neighbors are considered different sections, 0-100 (color strategy only)")
-	maxColors := flag.Int("max-colors", 10, "Maximum number of colors in the magic drawing (0 = unlimited)")
+// ParseStrategies parses a --delimiter-strategy value: "border", "color",
+// "grid", a strategy added with macoma.RegisterStrategy, or several of
+// them comma-separated, in the order given.
+func ParseStrategies(s string) ([]string, error) {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if err := macoma.CheckStrategy(item); err != nil {
+			return nil, err
+		}
+		for _, prev := range out {
+			if prev == item {
+				return nil, fmt.Errorf("strategy %q given twice", item)
+			}
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// BorderColor is an outline color of --extra-border-colors with its
+// tolerance.
+type BorderColor struct {
+	Color     color.RGBA
+	Tolerance float64
+}
 
-	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: macoma [options]\n\nOptions:\n")
-		flag.PrintDefaults()
-		fmt.Fprintf(os.Stderr, "\nExample:\n  macoma --in=drawing.png --out=coloring.png --delimiter-strategy=color --color-delimiter-tolerance=10 --max-colors=15\n")
+// ParseBorderColors parses an --extra-border-colors value: comma-separated
+// hex colors, each optionally followed by ":" and a tolerance of 0–100, such
+// as "#5a3214:15,#303080". Colors without one get defTolerance. Empty means
+// no colors.
+func ParseBorderColors(s string, defTolerance float64) ([]BorderColor, error) {
+	var out []BorderColor
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		hex, tol, hasTol := strings.Cut(item, ":")
+		c, err := color.ParseHex(strings.TrimSpace(hex))
+		if err != nil {
+			return nil, err
+		}
+		bc := BorderColor{Color: c, Tolerance: defTolerance}
+		if hasTol {
+			bc.Tolerance, err = strconv.ParseFloat(strings.TrimSpace(tol), 64)
+			if err != nil || bc.Tolerance < 0 || bc.Tolerance > 100 {
+				return nil, fmt.Errorf("tolerance must be between 0 and 100, got %q", tol)
+			}
+		}
+		out = append(out, bc)
 	}
+	return out, nil
+}
 
-	flag.Parse()
+// ParseLabels parses a --labels value: "letters", "symbols", or labels
+// separated by commas, such as "A,B,♥". Empty means numbers (nil).
+func ParseLabels(s string) ([]string, error) {
+	switch strings.TrimSpace(s) {
+	case "":
+		return nil, nil
+	case "letters":
+		return macoma.LetterLabels, nil
+	case "symbols":
+		return macoma.SymbolLabels, nil
+	}
+	labels := strings.Split(s, ",")
+	seen := make(map[string]bool, len(labels))
+	for i, l := range labels {
+		l = strings.TrimSpace(l)
+		if l == "" {
+			return nil, fmt.Errorf("label %d is empty", i+1)
+		}
+		if seen[l] {
+			return nil, fmt.Errorf("label %q appears twice", l)
+		}
+		seen[l] = true
+		labels[i] = l
+	}
+	return labels, nil
+}
 
-	if *inPath == "" {
-		return Config{}, fmt.Errorf("--in is required")
+// ParseZoneSize parses a --min-zone-size value: a pixel count such as "20",
+// or a percentage of the image area such as "0.5%". Empty means 0 pixels.
+func ParseZoneSize(s string) (pixels int, percent float64, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, 0, nil
 	}
-	if *outPath == "" {
-		return Config{}, fmt.Errorf("--out is required")
+	if p, ok := strings.CutSuffix(s, "%"); ok {
+		percent, err = strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return 0, 0, fmt.Errorf("percentage must be between 0 and 100, got %q", s)
+		}
+		return 0, percent, nil
 	}
-	if ext := strings.ToLower(filepath.Ext(*outPath)); ext != ".png" {
-		return Config{}, fmt.Errorf("--out must be a .png file, got %q", ext)
+	pixels, err = strconv.Atoi(s)
+	if err != nil || pixels < 0 {
+		return 0, 0, fmt.Errorf("must be a pixel count >= 0 or a percentage, got %q", s)
 	}
-	if *strategy != StrategyBorder && *strategy != StrategyColor {
-		return Config{}, fmt.Errorf("--delimiter-strategy must be %q or %q, got %q", StrategyBorder, StrategyColor, *strategy)
+	return pixels, 0, nil
+}
+
+// OutputFormat returns the output format: --format if given, otherwise the
+// one named by the --out extension, or "" when the extension is unknown.
+// In batch mode, where --out is a directory, and when writing to standard
+// output it defaults to "png".
+func (c Config) OutputFormat() string {
+	if c.Format != "" {
+		return c.Format
 	}
-	if *borderTolerance < 0 || *borderTolerance > 100 {
-		return Config{}, fmt.Errorf("--border-delimiter-tolerance must be between 0 and 100, got %f", *borderTolerance)
+	if c.Batch() || c.OutPath == StdioPath {
+		return "png"
 	}
-	if *colorTolerance < 0 || *colorTolerance > 100 {
-		return Config{}, fmt.Errorf("--color-delimiter-tolerance must be between 0 and 100, got %f", *colorTolerance)
+	switch ext := strings.ToLower(filepath.Ext(c.OutPath)); ext {
+	case ".png", ".webp", ".svg", ".svgz", ".eps", ".dxf", ".pdf":
+		return ext[1:]
+	case ".jpg", ".jpeg":
+		return "jpeg"
+	case ".tif", ".tiff":
+		return "tiff"
+	}
+	return ""
+}
+
+// LegendFormat returns the format of a --legend-out path from its
+// extension: "png", "jpeg", "webp" or "pdf", or "" if it is none of them.
+func LegendFormat(path string) string {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".png", ".webp", ".pdf":
+		return ext[1:]
+	case ".jpg", ".jpeg":
+		return "jpeg"
+	}
+	return ""
+}
+
+// SettingsPath returns the settings sidecar path for an output file, e.g.
+// "coloring.png" → "coloring.settings.json".
+func SettingsPath(outPath string) string {
+	return strings.TrimSuffix(outPath, filepath.Ext(outPath)) + ".settings.json"
+}
+
+// MetadataPath returns the metadata sidecar path for an output file, e.g.
+// "coloring.png" → "coloring.metadata.json".
+func MetadataPath(outPath string) string {
+	return strings.TrimSuffix(outPath, filepath.Ext(outPath)) + ".metadata.json"
+}
+
+// SolutionPlaceholder in --legend-qr is replaced by the file name of the
+// answer key of each output, so one template links every sheet of a batch
+// to its own solution.
+const SolutionPlaceholder = "{solution}"
+
+// LegendQRURL returns the --legend-qr URL for an output file, with
+// SolutionPlaceholder replaced by the escaped name of its answer key, e.g.
+// "https://example.com/{solution}", "out/cat.png" →
+// "https://example.com/cat-solution.png".
+func LegendQRURL(template, outPath string) string {
+	if !strings.Contains(template, SolutionPlaceholder) {
+		return template
 	}
-	if *maxColors < 0 {
-		return Config{}, fmt.Errorf("--max-colors must be >= 0, got %d", *maxColors)
+	return strings.ReplaceAll(template, SolutionPlaceholder, url.PathEscape(filepath.Base(SolutionPath(outPath))))
+}
+
+// SolutionPath returns the path of the answer key next to an output file,
+// e.g. "coloring.png" → "coloring-solution.png".
+func SolutionPath(outPath string) string {
+	return strings.TrimSuffix(outPath, filepath.Ext(outPath)) + "-solution.png"
+}
+
+// PalettePreviewPath returns the path of the palette preview next to an
+// output file, e.g. "coloring.png" → "coloring-palette.png".
+func PalettePreviewPath(outPath string) string {
+	return strings.TrimSuffix(outPath, filepath.Ext(outPath)) + "-palette.png"
+}
+
+// WorksheetPath returns the path of the worksheet for legend number n next
+// to an output file, e.g. "coloring.png", 3 → "coloring-worksheet-3.png".
+func WorksheetPath(outPath string, n int) string {
+	return fmt.Sprintf("%s-worksheet-%d.png", strings.TrimSuffix(outPath, filepath.Ext(outPath)), n)
+}
+
+// EncodeSettings writes the settings portion of cfg as indented JSON, in the
+// format read back by LoadSettings.
+func EncodeSettings(w io.Writer, cfg Config) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(cfg); err != nil {
+		return fmt.Errorf("encoding settings: %w", err)
 	}
+	return nil
+}
 
-	dc, err := color.ParseHex(*borderColor)
+// WriteSettings saves the settings portion of cfg to path.
+func WriteSettings(path string, cfg Config) error {
+	f, err := os.Create(path)
 	if err != nil {
-		return Config{}, fmt.Errorf("--border-delimiter-color: %w", err)
+		return fmt.Errorf("writing settings: %w", err)
 	}
+	defer f.Close()
+	return EncodeSettings(f, cfg)
+}
 
-	return Config{
-		InPath:                   *inPath,
-		OutPath:                  *outPath,
-		DelimiterStrategy:        *strategy,
-		BorderDelimiterColor:     dc,
-		BorderDelimiterTolerance: *borderTolerance,
-		ColorDelimiterTolerance:  *colorTolerance,
-		MaxColors:                *maxColors,
-	}, nil
+// LoadSettings reads a settings file on top of DefaultConfig, so files
+// written by older versions pick up defaults for newer settings.
+func LoadSettings(path string) (Config, error) {
+	cfg := DefaultConfig()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("reading settings: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("parsing settings: %w", err)
+	}
+	return cfg, nil
 }
@@ -0,0 +1,430 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/maax3v3/macoma/v2"
+	"github.com/maax3v3/macoma/v2/internal/color"
+)
+
+func TestParseArgs_Defaults(t *testing.T) {
+	cfg, err := ParseArgs([]string{"--in=a.png", "--out=b.png"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := DefaultConfig()
+	want.InPath = "a.png"
+	want.OutPath = "b.png"
+	if cfg != want {
+		t.Errorf("got %+v, want %+v", cfg, want)
+	}
+}
+
+func TestParseArgs_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"missing in", []string{"--out=b.png"}},
+		{"missing out", []string{"--in=a.png"}},
+		{"unsupported out", []string{"--in=a.png", "--out=b.bmp"}},
+		{"bad jpeg quality", []string{"--in=a.png", "--out=b.jpg", "--jpeg-quality=0"}},
+		{"bad strategy", []string{"--in=a.png", "--out=b.png", "--delimiter-strategy=magic"}},
+		{"zero grid cells", []string{"--in=a.png", "--out=b.png", "--delimiter-strategy=grid", "--grid-cells=0"}},
+		{"too many grid cells", []string{"--in=a.png", "--out=b.png", "--delimiter-strategy=grid", "--grid-cells=1000"}},
+		{"bad color", []string{"--in=a.png", "--out=b.png", "--border-delimiter-color=#12"}},
+		{"negative max colors", []string{"--in=a.png", "--out=b.png", "--max-colors=-1"}},
+		{"bad quantizer", []string{"--in=a.png", "--out=b.png", "--quantizer=kmeans"}},
+		{"bad format", []string{"--in=a.png", "--out=b.png", "--format=bmp"}},
+		{"bad paper", []string{"--in=a.png", "--out=b.pdf", "--paper=a3"}},
+		{"zero dpi", []string{"--in=a.png", "--out=b.pdf", "--dpi=0"}},
+		{"negative margin", []string{"--in=a.png", "--out=b.pdf", "--margin=-1"}},
+		{"margin wider than paper", []string{"--in=a.png", "--out=b.pdf", "--margin=400"}},
+		{"margin fills a5", []string{"--in=a.png", "--out=b.pdf", "--paper=a5", "--margin=75"}},
+		{"transparent jpeg", []string{"--in=a.png", "--out=b.jpg", "--transparent-background"}},
+		{"bad reference", []string{"--in=a.png", "--out=b.png", "--reference=photo"}},
+		{"reference in pdf", []string{"--in=a.png", "--out=b.pdf", "--reference=original"}},
+		{"paper layout of svg", []string{"--in=a.png", "--out=b.svg", "--paper-layout"}},
+		{"legend QR too long", []string{"--in=a.png", "--out=b.png", "--legend-qr=https://example.com/" + strings.Repeat("x", 200)}},
+		{"legend QR solution on stdout", []string{"--in=a.png", "--out=-", "--legend-qr=https://example.com/{solution}"}},
+		{"paper layout of dxf", []string{"--in=a.png", "--out=b.dxf", "--paper-layout"}},
+		{"reference in eps", []string{"--in=a.png", "--out=b.eps", "--reference=preview"}},
+		{"bad connectivity", []string{"--in=a.png", "--out=b.png", "--connectivity=6"}},
+		{"bad min zone size", []string{"--in=a.png", "--out=b.png", "--min-zone-size=tiny"}},
+		{"min zone size over 100%", []string{"--in=a.png", "--out=b.png", "--min-zone-size=150%"}},
+		{"bad png compression", []string{"--in=a.png", "--out=b.png", "--png-compression=max"}},
+		{"non-json palette in", []string{"--in=a.png", "--out=b.png", "--palette-in=palette.txt"}},
+		{"non-gif reveal", []string{"--in=a.png", "--out=b.png", "--reveal=reveal.png"}},
+		{"zero reveal delay", []string{"--in=a.png", "--out=b.png", "--reveal=reveal.gif", "--reveal-delay=0"}},
+		{"non-json game data", []string{"--in=a.png", "--out=b.png", "--game-data=zones.txt"}},
+		{"non-geojson geojson", []string{"--in=a.png", "--out=b.png", "--geojson=zones.txt"}},
+		{"extensionless geojson", []string{"--in=a.png", "--out=b.png", "--geojson=zones"}},
+		{"bad title align", []string{"--in=a.png", "--out=b.png", "--title=Farm", "--title-align=justify"}},
+		{"negative title size", []string{"--in=a.png", "--out=b.png", "--title=Farm", "--title-size=-1"}},
+		{"unsupported legend out", []string{"--in=a.png", "--out=b.png", "--legend-out=legend.svg"}},
+		{"metadata to stdout", []string{"--in=-", "--out=-", "--metadata"}},
+		{"solution to stdout", []string{"--in=a.png", "--out=-", "--solution"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseArgs(tt.args); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestSettings_RoundTripWithOverrides(t *testing.T) {
+	dir := t.TempDir()
+	settingsPath := filepath.Join(dir, "run.settings.json")
+
+	saved, err := ParseArgs([]string{
+		"--in=a.png", "--out=b.png",
+		"--delimiter-strategy=border",
+		"--border-delimiter-color=#112233",
+		"--max-colors=7",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteSettings(settingsPath, saved); err != nil {
+		t.Fatal(err)
+	}
+
+	replayed, err := ParseArgs([]string{
+		"--settings=" + settingsPath,
+		"--in=c.png", "--out=d.png",
+		"--max-colors=3",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if replayed.DelimiterStrategy != StrategyBorder {
+		t.Errorf("strategy not replayed: %q", replayed.DelimiterStrategy)
+	}
+	if replayed.BorderDelimiterColor != (color.RGBA{R: 0x11, G: 0x22, B: 0x33, A: 255}) {
+		t.Errorf("border color not replayed: %+v", replayed.BorderDelimiterColor)
+	}
+	if replayed.MaxColors != 3 {
+		t.Errorf("explicit flag should override settings file: max colors %d", replayed.MaxColors)
+	}
+	if replayed.InPath != "c.png" || replayed.OutPath != "d.png" {
+		t.Errorf("paths: got %q -> %q", replayed.InPath, replayed.OutPath)
+	}
+}
+
+func TestParseArgs_Preset(t *testing.T) {
+	cfg, err := ParseArgs([]string{"--in=a.png", "--out=b.png", "--preset=kids", "--max-colors=8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MaxColors != 8 {
+		t.Errorf("explicit flag should override the preset: max colors %d", cfg.MaxColors)
+	}
+	if cfg.ColorDelimiterTolerance != 20 || cfg.MinZoneSize != "0.2%" || !cfg.LargePrint || !cfg.SkipBackground {
+		t.Errorf("kids preset not applied: %+v", cfg)
+	}
+
+	dir := t.TempDir()
+	settingsPath := filepath.Join(dir, "run.settings.json")
+	saved := DefaultConfig()
+	saved.LegendHex = true
+	saved.MaxColors = 3
+	if err := WriteSettings(settingsPath, saved); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = ParseArgs([]string{"--in=a.png", "--out=b.png", "--settings=" + settingsPath, "--preset=poster"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MaxColors != 16 || cfg.OutputScale != 2 || !cfg.LegendHex {
+		t.Errorf("preset should override the settings file but keep what it does not decide: %+v", cfg)
+	}
+
+	if _, err := ParseArgs([]string{"--in=a.png", "--out=b.png", "--preset=expert"}); err == nil || !strings.Contains(err.Error(), "standard") {
+		t.Errorf("unknown preset: got %v, want an error listing the presets", err)
+	}
+}
+
+// TestParseArgs_PresetFields checks that --preset sets every field any
+// preset changes as Preset.Options does.
+func TestParseArgs_PresetFields(t *testing.T) {
+	def := reflect.ValueOf(macoma.DefaultOptions())
+	changed := map[string]bool{}
+	for _, p := range macoma.Presets() {
+		po, err := p.Options()
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := reflect.ValueOf(po)
+		for i := 0; i < v.NumField(); i++ {
+			if !reflect.DeepEqual(v.Field(i).Interface(), def.Field(i).Interface()) {
+				changed[v.Type().Field(i).Name] = true
+			}
+		}
+	}
+
+	for _, p := range macoma.Presets() {
+		cfg, err := ParseArgs([]string{"--in=a.png", "--out=b.png", "--preset=" + string(p)})
+		if err != nil {
+			t.Fatalf("%s: %v", p, err)
+		}
+		po, _ := p.Options()
+		want, got := reflect.ValueOf(po), reflect.ValueOf(cfg)
+		for name := range changed {
+			w, g := want.FieldByName(name).Interface(), got.FieldByName(name)
+			if z, ok := w.(macoma.ZoneSize); ok {
+				w = formatZoneSize(z)
+			}
+			if !g.IsValid() {
+				t.Errorf("%s: preset field %s has no Config field", p, name)
+			} else if !reflect.DeepEqual(g.Interface(), w) {
+				t.Errorf("%s: %s = %v, want %v", p, name, g.Interface(), w)
+			}
+		}
+	}
+}
+
+func TestParseArgs_PrintConfigWithoutPaths(t *testing.T) {
+	cfg, err := ParseArgs([]string{"--print-config", "--max-colors=4"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.PrintConfig || cfg.MaxColors != 4 {
+		t.Errorf("got %+v", cfg)
+	}
+
+	if _, err := ParseArgs([]string{"--print-config", "--max-colors=-2"}); err == nil {
+		t.Error("expected invalid settings to be rejected with --print-config")
+	}
+}
+
+func TestEncodeSettings_OmitsPathsAndActions(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.InPath = "secret/in.png"
+	cfg.PrintConfig = true
+
+	var buf bytes.Buffer
+	if err := EncodeSettings(&buf, cfg); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, unwanted := range []string{"secret", "PrintConfig", "print_config", "write_settings"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("settings JSON should not contain %q:\n%s", unwanted, out)
+		}
+	}
+	if !strings.Contains(out, `"max_colors": 10`) {
+		t.Errorf("settings JSON missing max_colors:\n%s", out)
+	}
+}
+
+func TestOutputFormat(t *testing.T) {
+	tests := []struct {
+		out, format, want string
+	}{
+		{"b.png", "", "png"},
+		{"b.SVGZ", "", "svgz"},
+		{"b.eps", "", "eps"},
+		{"b.out", "dxf", "dxf"},
+		{"b.tif", "", "tiff"},
+		{"b.pdf", "", "pdf"},
+		{"b.out", "pdf", "pdf"},
+		{"b.jpg", "", "jpeg"},
+		{"b.JPEG", "", "jpeg"},
+		{"b.webp", "", "webp"},
+		{"b.out", "webp", "webp"},
+		{"b.bmp", "", ""},
+		{"-", "", "png"},
+		{"-", "svg", "svg"},
+	}
+	for _, tt := range tests {
+		c := Config{OutPath: tt.out, Format: tt.format}
+		if got := c.OutputFormat(); got != tt.want {
+			t.Errorf("OutputFormat(%q, %q) = %q, want %q", tt.out, tt.format, got, tt.want)
+		}
+	}
+}
+
+func TestParseZoneSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		pixels  int
+		percent float64
+	}{
+		{"", 0, 0},
+		{"20", 20, 0},
+		{"0.5%", 0, 0.5},
+		{" 2 % ", 0, 2},
+	}
+	for _, tt := range tests {
+		pixels, percent, err := ParseZoneSize(tt.in)
+		if err != nil || pixels != tt.pixels || percent != tt.percent {
+			t.Errorf("ParseZoneSize(%q) = %d, %g, %v; want %d, %g", tt.in, pixels, percent, err, tt.pixels, tt.percent)
+		}
+	}
+	for _, in := range []string{"-1", "1.5", "abc", "-2%", "101%"} {
+		if _, _, err := ParseZoneSize(in); err == nil {
+			t.Errorf("ParseZoneSize(%q): expected error", in)
+		}
+	}
+}
+
+func TestParseLabels(t *testing.T) {
+	if got, err := ParseLabels(""); got != nil || err != nil {
+		t.Errorf(`ParseLabels("") = %v, %v; want nil`, got, err)
+	}
+	if got, _ := ParseLabels("letters"); len(got) != 26 || got[0] != "A" {
+		t.Errorf("letters: got %v", got)
+	}
+	got, err := ParseLabels("A, B,♥")
+	if err != nil || len(got) != 3 || got[1] != "B" || got[2] != "♥" {
+		t.Errorf("got %q, %v; want [A B ♥]", got, err)
+	}
+	for _, in := range []string{"A,,B", "A,B,A", ","} {
+		if _, err := ParseLabels(in); err == nil {
+			t.Errorf("ParseLabels(%q): expected error", in)
+		}
+	}
+}
+
+func TestParseStrategies(t *testing.T) {
+	got, err := ParseStrategies("border, color")
+	if err != nil || len(got) != 2 || got[0] != StrategyBorder || got[1] != StrategyColor {
+		t.Errorf("got %v, %v; want [border color]", got, err)
+	}
+	if got, err := ParseStrategies("grid"); err != nil || len(got) != 1 || got[0] != StrategyGrid {
+		t.Errorf("got %v, %v; want [grid]", got, err)
+	}
+	for _, in := range []string{"", "edges", "color,color", "border,"} {
+		if _, err := ParseStrategies(in); err == nil {
+			t.Errorf("ParseStrategies(%q): expected error", in)
+		}
+	}
+}
+
+func TestParseBorderColors(t *testing.T) {
+	got, err := ParseBorderColors(" #5a3214:15, #303080 ,", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []BorderColor{
+		{Color: color.RGBA{R: 0x5a, G: 0x32, B: 0x14, A: 255}, Tolerance: 15},
+		{Color: color.RGBA{R: 0x30, G: 0x30, B: 0x80, A: 255}, Tolerance: 10},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("color %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	for _, in := range []string{"#zzz", "#000:x", "#000:101", "#000:-1"} {
+		if _, err := ParseBorderColors(in, 10); err == nil {
+			t.Errorf("ParseBorderColors(%q): expected error", in)
+		}
+	}
+}
+
+func TestLegendFormat(t *testing.T) {
+	for path, want := range map[string]string{
+		"legend.png":  "png",
+		"legend.JPG":  "jpeg",
+		"legend.webp": "webp",
+		"out/key.pdf": "pdf",
+		"legend.svg":  "",
+		"legend":      "",
+	} {
+		if got := LegendFormat(path); got != want {
+			t.Errorf("LegendFormat(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestMetadataPath(t *testing.T) {
+	if got := MetadataPath("out/coloring.png"); got != "out/coloring.metadata.json" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSolutionPath(t *testing.T) {
+	if got := SolutionPath("out/coloring.pdf"); got != "out/coloring-solution.png" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestLegendQRURL(t *testing.T) {
+	if got := LegendQRURL("https://example.com/keys/{solution}", "out/my cat.png"); got != "https://example.com/keys/my%20cat-solution.png" {
+		t.Errorf("got %q", got)
+	}
+	if got := LegendQRURL("https://example.com", "out/cat.png"); got != "https://example.com" {
+		t.Errorf("a URL without the placeholder should be unchanged, got %q", got)
+	}
+}
+
+func TestPalettePreviewPath(t *testing.T) {
+	if got := PalettePreviewPath("out/coloring.svg"); got != "out/coloring-palette.png" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestWorksheetPath(t *testing.T) {
+	if got := WorksheetPath("out/coloring.png", 3); got != "out/coloring-worksheet-3.png" {
+		t.Errorf("WorksheetPath: got %q", got)
+	}
+}
+
+func TestSettingsPath(t *testing.T) {
+	if got := SettingsPath("out/coloring.png"); got != "out/coloring.settings.json" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestParseArgs_Stdio(t *testing.T) {
+	cfg, err := ParseArgs([]string{"--in=-", "--out=-", "--format=pdf"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Batch() || cfg.OutputFormat() != "pdf" {
+		t.Errorf("batch %v, format %q; want a single pdf", cfg.Batch(), cfg.OutputFormat())
+	}
+}
+
+func TestParseStrategies_Registered(t *testing.T) {
+	macoma.RegisterStrategy("cli-test-edges", func(opts macoma.Options) macoma.Delimiter { return nil })
+	got, err := ParseStrategies("border,cli-test-edges")
+	if err != nil || len(got) != 2 || got[1] != "cli-test-edges" {
+		t.Errorf("got %v, %v; want [border cli-test-edges]", got, err)
+	}
+	if _, err := ParseArgs([]string{"--in=a.png", "--out=b.png", "--delimiter-strategy=cli-test-edges"}); err != nil {
+		t.Errorf("ParseArgs: %v", err)
+	}
+}
+
+// firstColor is a Quantizer painting every zone with the first zone color.
+type firstColor struct{}
+
+func (firstColor) Quantize(_ context.Context, zones []macoma.ZoneColor, _ int) (*macoma.Palette, error) {
+	return &macoma.Palette{
+		Entries: []macoma.PaletteEntry{{Number: 1, Color: zones[0].Color}},
+		ZoneMap: make([]int, len(zones)),
+	}, nil
+}
+
+func TestParseArgs_RegisteredQuantizer(t *testing.T) {
+	macoma.RegisterQuantizer("cli-test-first", firstColor{})
+	cfg, err := ParseArgs([]string{"--in=a.png", "--out=b.png", "--quantizer=cli-test-first"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Quantizer != "cli-test-first" {
+		t.Errorf("got quantizer %q, want cli-test-first", cfg.Quantizer)
+	}
+}
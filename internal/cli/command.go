@@ -0,0 +1,246 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Command is a macoma subcommand.
+type Command string
+
+// Subcommands of the macoma CLI.
+const (
+	CommandConvert Command = "convert" // render the coloring page
+	CommandAnalyze Command = "analyze" // print detection and zone statistics
+	CommandPalette Command = "palette" // print or save the extracted palette
+	CommandPreview Command = "preview" // save the detected delimiters as an overlay
+	CommandInfo    Command = "info"    // inspect an input and suggest settings
+	CommandCheck   Command = "check"   // grade a colored sheet against its answer key
+	CommandStitch  Command = "stitch"  // chart a cross-stitch pattern in DMC threads
+	CommandUI      Command = "ui"      // serve a local page to tune the settings live
+)
+
+// SplitCommand returns the subcommand named by the first argument and the
+// arguments after it. Without one the command is CommandConvert, so the
+// flag-only invocations of earlier versions keep working.
+func SplitCommand(args []string) (Command, []string) {
+	if len(args) > 0 {
+		switch c := Command(args[0]); c {
+		case CommandConvert, CommandAnalyze, CommandPalette, CommandPreview, CommandInfo, CommandCheck, CommandStitch, CommandUI:
+			return c, args[1:]
+		}
+	}
+	return CommandConvert, args
+}
+
+// commandSpec describes the flags and checks of a subcommand that takes a
+// Config.
+type commandSpec struct {
+	summary  string
+	example  string
+	bind     func(*flag.FlagSet, *Config)
+	validate func(Config) error
+}
+
+// flagAliases maps the shorthand flags some commands accept to the flag
+// they stand for.
+var flagAliases = map[string]string{
+	"colors": "max-colors",
+}
+
+const inHelp = "Path to input image (required, supports PNG, JPEG, WEBP; - for standard input)"
+
+var commands = map[Command]commandSpec{
+	CommandConvert: {
+		summary: "Convert a drawing into a numbered coloring page.",
+		example: "macoma convert --in=drawing.png --out=coloring.png --delimiter-strategy=color --color-delimiter-tolerance=10 --max-colors=15",
+		bind:    bindFlags,
+		validate: func(c Config) error {
+			// --print-config only needs valid settings, not input/output paths.
+			if c.PrintConfig {
+				return c.validateSettings()
+			}
+			return c.Validate()
+		},
+	},
+	CommandAnalyze: {
+		summary: "Print the detection confidence, delimiter and zone statistics of a drawing without rendering it.",
+		example: "macoma analyze --in=drawing.png --delimiter-strategy=border",
+		bind: func(fs *flag.FlagSet, cfg *Config) {
+			fs.StringVar(&cfg.InPath, "in", cfg.InPath, inHelp)
+			bindPresetFlag(fs, cfg)
+			bindPreprocessFlags(fs, cfg)
+			bindDetectionFlags(fs, cfg)
+			bindPaletteFlags(fs, cfg)
+		},
+		validate: func(c Config) error { return c.validateSingle(CommandAnalyze) },
+	},
+	CommandPalette: {
+		summary: "Print the numbered palette of a drawing, one \"#rrggbb number coverage\" line per color.",
+		example: "macoma palette --in=drawing.png --colors=12 --out=series.gpl",
+		bind: func(fs *flag.FlagSet, cfg *Config) {
+			fs.StringVar(&cfg.InPath, "in", cfg.InPath, inHelp)
+			fs.StringVar(&cfg.OutPath, "out", cfg.OutPath, "Also save the palette: .json for --palette-in, .gpl for a GIMP palette, any other extension for a hex list; all three work with --palette")
+			bindPresetFlag(fs, cfg)
+			bindPreprocessFlags(fs, cfg)
+			bindDetectionFlags(fs, cfg)
+			bindPaletteFlags(fs, cfg)
+			fs.IntVar(&cfg.MaxColors, "colors", cfg.MaxColors, "Same as --max-colors")
+		},
+		validate: func(c Config) error { return c.validateSingle(CommandPalette) },
+	},
+	CommandPreview: {
+		summary: "Save the input with its detected delimiters painted magenta, to tune the detection flags.",
+		example: "macoma preview --in=drawing.png --out=preview.png --border-delimiter-tolerance=20",
+		bind: func(fs *flag.FlagSet, cfg *Config) {
+			fs.StringVar(&cfg.InPath, "in", cfg.InPath, inHelp)
+			fs.StringVar(&cfg.OutPath, "out", cfg.OutPath, "Path to the overlay image (required, .png)")
+			bindPreprocessFlags(fs, cfg)
+			bindDetectionFlags(fs, cfg)
+		},
+		validate: func(c Config) error {
+			if c.OutPath == "" {
+				return fmt.Errorf("--out is required")
+			}
+			if strings.ToLower(filepath.Ext(c.OutPath)) != ".png" {
+				return fmt.Errorf("--out must be a .png file, got %q", c.OutPath)
+			}
+			return c.validateSingle(CommandPreview)
+		},
+	},
+	CommandCheck: {
+		summary: "Compare a colored-in sheet with the answer key of its coloring and report which zones are filled correctly.",
+		example: "macoma check --key=coloring.json --colored=scan.jpg",
+		bind: func(fs *flag.FlagSet, cfg *Config) {
+			fs.StringVar(&cfg.KeyPath, "key", cfg.KeyPath, "Path to the answer key: the --game-data file written with the coloring (required)")
+			fs.StringVar(&cfg.ColoredPath, "colored", cfg.ColoredPath, "Path to a scan or photo of the colored sheet, straight and cropped to the page (required)")
+			fs.StringVar(&cfg.OutPath, "out", cfg.OutPath, "Also save the per-zone report as JSON")
+		},
+		validate: func(c Config) error {
+			if c.KeyPath == "" {
+				return fmt.Errorf("--key is required")
+			}
+			if c.ColoredPath == "" {
+				return fmt.Errorf("--colored is required")
+			}
+			return nil
+		},
+	},
+	CommandStitch: {
+		summary: "Chart a cross-stitch pattern: the image snapped to a grid of stitches, each marked with the symbol of its DMC thread, with a thread key below.",
+		example: "macoma stitch --in=photo.jpg --out=chart.png --stitches=100 --threads=15",
+		bind: func(fs *flag.FlagSet, cfg *Config) {
+			fs.StringVar(&cfg.InPath, "in", cfg.InPath, inHelp)
+			fs.StringVar(&cfg.OutPath, "out", cfg.OutPath, "Path to the chart image (required, .png)")
+			fs.IntVar(&cfg.Stitches, "stitches", cfg.Stitches, "Chart width in stitches; the rows follow from the aspect ratio")
+			fs.IntVar(&cfg.StitchThreads, "threads", cfg.StitchThreads, "Maximum number of thread colors (0 = no limit)")
+			fs.IntVar(&cfg.StitchCellSize, "cell-size", cfg.StitchCellSize, "Chart pixels per stitch (at least 8)")
+		},
+		validate: func(c Config) error {
+			if c.OutPath == "" {
+				return fmt.Errorf("--out is required")
+			}
+			if strings.ToLower(filepath.Ext(c.OutPath)) != ".png" {
+				return fmt.Errorf("--out must be a .png file, got %q", c.OutPath)
+			}
+			if c.Stitches < 1 {
+				return fmt.Errorf("--stitches must be positive, got %d", c.Stitches)
+			}
+			if c.StitchThreads < 0 {
+				return fmt.Errorf("--threads must be non-negative, got %d", c.StitchThreads)
+			}
+			if c.StitchCellSize < 8 {
+				return fmt.Errorf("--cell-size must be at least 8, got %d", c.StitchCellSize)
+			}
+			return c.validateSingle(CommandStitch)
+		},
+	},
+	CommandUI: {
+		summary: "Serve a local page with sliders for the strategy, tolerance, max colors and min zone size, re-rendering the coloring as they move.",
+		example: "macoma ui --in=drawing.png --addr=localhost:8080",
+		bind: func(fs *flag.FlagSet, cfg *Config) {
+			fs.StringVar(&cfg.InPath, "in", cfg.InPath, "Path to the image to open on start (optional; the page can also load one)")
+			fs.StringVar(&cfg.UIAddr, "addr", cfg.UIAddr, "Address to serve the page on")
+			bindPresetFlag(fs, cfg)
+			bindPreprocessFlags(fs, cfg)
+			bindDetectionFlags(fs, cfg)
+			bindPaletteFlags(fs, cfg)
+		},
+		validate: func(c Config) error {
+			if c.UIAddr == "" {
+				return fmt.Errorf("--addr is required")
+			}
+			if c.Batch() {
+				return fmt.Errorf("macoma ui takes a single --in image")
+			}
+			return c.validateSettings()
+		},
+	},
+}
+
+// ParseCommand parses the arguments of cmd, which must not be CommandInfo,
+// and returns a validated Config. Each command only accepts the flags it
+// uses. Values are resolved in order: defaults, then the --settings file if
+// given, then the --preset if given, then explicitly passed flags.
+func ParseCommand(cmd Command, args []string) (Config, error) {
+	spec, ok := commands[cmd]
+	if !ok {
+		return Config{}, fmt.Errorf("unknown command %q", cmd)
+	}
+	cfg := DefaultConfig()
+	fs := flag.NewFlagSet("macoma "+string(cmd), flag.ContinueOnError)
+	spec.bind(fs, &cfg)
+	settingsPath := fs.String("settings", "", "Path to a settings file (from --write-settings) to replay; explicit flags override it")
+
+	fs.Usage = func() {
+		if cmd == CommandConvert {
+			fmt.Fprintf(fs.Output(), "Usage: macoma [convert] [options]\n       macoma analyze|palette|preview|check|stitch|ui [options]\n       macoma info <image>\n\n")
+		} else {
+			fmt.Fprintf(fs.Output(), "Usage: macoma %s [options]\n\n", cmd)
+		}
+		fmt.Fprintf(fs.Output(), "%s\n\nOptions:\n", spec.summary)
+		fs.PrintDefaults()
+		fmt.Fprintf(fs.Output(), "\nExample:\n  %s\n", spec.example)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+	if fs.NArg() > 0 {
+		return Config{}, fmt.Errorf("unexpected argument %q (commands: convert, analyze, palette, preview, info, check, stitch, ui)", fs.Arg(0))
+	}
+
+	if *settingsPath != "" {
+		loaded, err := applySettings(fs, *settingsPath)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg = loaded
+	}
+	if cfg.Preset != "" {
+		preset, err := applyPreset(fs, cfg)
+		if err != nil {
+			return Config{}, err
+		}
+		cfg = preset
+	}
+
+	if err := spec.validate(cfg); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// validateSingle checks the configuration of a command that reads one
+// --in image.
+func (c Config) validateSingle(cmd Command) error {
+	if c.InPath == "" {
+		return fmt.Errorf("--in is required")
+	}
+	if c.Batch() {
+		return fmt.Errorf("macoma %s takes a single --in image", cmd)
+	}
+	return c.validateSettings()
+}
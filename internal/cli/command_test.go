@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		args     []string
+		wantCmd  Command
+		wantRest []string
+	}{
+		{nil, CommandConvert, nil},
+		{[]string{"--in=a.png", "--out=b.png"}, CommandConvert, []string{"--in=a.png", "--out=b.png"}},
+		{[]string{"convert", "--in=a.png"}, CommandConvert, []string{"--in=a.png"}},
+		{[]string{"analyze", "--in=a.png"}, CommandAnalyze, []string{"--in=a.png"}},
+		{[]string{"palette"}, CommandPalette, []string{}},
+		{[]string{"preview", "--in=a.png"}, CommandPreview, []string{"--in=a.png"}},
+		{[]string{"info", "a.png"}, CommandInfo, []string{"a.png"}},
+		{[]string{"check", "--key=k.json"}, CommandCheck, []string{"--key=k.json"}},
+		{[]string{"stitch", "--in=a.png"}, CommandStitch, []string{"--in=a.png"}},
+		{[]string{"ui", "--addr=:9000"}, CommandUI, []string{"--addr=:9000"}},
+	}
+	for _, tt := range tests {
+		cmd, rest := SplitCommand(tt.args)
+		if cmd != tt.wantCmd || !reflect.DeepEqual(rest, tt.wantRest) {
+			t.Errorf("SplitCommand(%q) = %q, %q; want %q, %q", tt.args, cmd, rest, tt.wantCmd, tt.wantRest)
+		}
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	cfg, err := ParseCommand(CommandAnalyze, []string{"--in=a.png", "--delimiter-strategy=border", "--max-colors=4"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := DefaultConfig()
+	want.InPath = "a.png"
+	want.DelimiterStrategy = StrategyBorder
+	want.MaxColors = 4
+	if cfg != want {
+		t.Errorf("got %+v, want %+v", cfg, want)
+	}
+
+	if _, err := ParseCommand(CommandPalette, []string{"--in=a.png"}); err != nil {
+		t.Errorf("palette without --out: %v", err)
+	}
+	if cfg, err := ParseCommand(CommandPalette, []string{"--in=a.png", "--colors=12", "--out=series.gpl"}); err != nil || cfg.MaxColors != 12 {
+		t.Errorf("palette --colors: got %d, %v", cfg.MaxColors, err)
+	}
+	if _, err := ParseCommand(CommandPreview, []string{"--in=a.png", "--out=p.png"}); err != nil {
+		t.Errorf("preview: %v", err)
+	}
+	if cfg, err := ParseCommand(CommandCheck, []string{"--key=k.json", "--colored=scan.jpg"}); err != nil || cfg.KeyPath != "k.json" || cfg.ColoredPath != "scan.jpg" {
+		t.Errorf("check: got %q, %q, %v", cfg.KeyPath, cfg.ColoredPath, err)
+	}
+	if cfg, err := ParseCommand(CommandStitch, []string{"--in=a.png", "--out=c.png", "--stitches=120", "--threads=0"}); err != nil || cfg.Stitches != 120 || cfg.StitchThreads != 0 || cfg.StitchCellSize != 16 {
+		t.Errorf("stitch: got %d, %d, %d, %v", cfg.Stitches, cfg.StitchThreads, cfg.StitchCellSize, err)
+	}
+	if cfg, err := ParseCommand(CommandUI, nil); err != nil || cfg.UIAddr != "localhost:8080" || cfg.InPath != "" {
+		t.Errorf("ui: got %q, %q, %v", cfg.UIAddr, cfg.InPath, err)
+	}
+}
+
+func TestParseCommand_AliasOverridesSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.settings.json")
+	saved := DefaultConfig()
+	saved.MaxColors = 7
+	if err := WriteSettings(path, saved); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := ParseCommand(CommandPalette, []string{"--settings=" + path, "--in=a.png", "--colors=12"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MaxColors != 12 {
+		t.Errorf("--colors should override the settings file: max colors %d", cfg.MaxColors)
+	}
+}
+
+func TestParseCommand_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  Command
+		args []string
+	}{
+		{"analyze missing in", CommandAnalyze, nil},
+		{"analyze render flag", CommandAnalyze, []string{"--in=a.png", "--line-width=2"}},
+		{"analyze bad strategy", CommandAnalyze, []string{"--in=a.png", "--delimiter-strategy=magic"}},
+		{"analyze colors alias", CommandAnalyze, []string{"--in=a.png", "--colors=3"}},
+		{"palette output flag", CommandPalette, []string{"--in=a.png", "--paper=letter"}},
+		{"preview missing out", CommandPreview, []string{"--in=a.png"}},
+		{"preview non-png out", CommandPreview, []string{"--in=a.png", "--out=p.pdf"}},
+		{"preview palette flag", CommandPreview, []string{"--in=a.png", "--out=p.png", "--max-colors=3"}},
+		{"stray argument", CommandConvert, []string{"--in=a.png", "--out=b.png", "extra"}},
+		{"info", CommandInfo, []string{"a.png"}},
+		{"check missing key", CommandCheck, []string{"--colored=scan.jpg"}},
+		{"check missing colored", CommandCheck, []string{"--key=k.json"}},
+		{"stitch missing out", CommandStitch, []string{"--in=a.png"}},
+		{"stitch non-png out", CommandStitch, []string{"--in=a.png", "--out=c.pdf"}},
+		{"stitch zero stitches", CommandStitch, []string{"--in=a.png", "--out=c.png", "--stitches=0"}},
+		{"stitch negative threads", CommandStitch, []string{"--in=a.png", "--out=c.png", "--threads=-1"}},
+		{"stitch small cells", CommandStitch, []string{"--in=a.png", "--out=c.png", "--cell-size=4"}},
+		{"stitch convert flag", CommandStitch, []string{"--in=a.png", "--out=c.png", "--max-colors=3"}},
+		{"ui empty addr", CommandUI, []string{"--addr="}},
+		{"ui render flag", CommandUI, []string{"--line-width=2"}},
+		{"ui bad strategy", CommandUI, []string{"--delimiter-strategy=magic"}},
+		{"check detection flag", CommandCheck, []string{"--key=k.json", "--colored=scan.jpg", "--max-colors=3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseCommand(tt.cmd, tt.args); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
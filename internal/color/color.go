@@ -28,7 +28,8 @@ func (c RGBA) ToStdColor() color.RGBA {
 	return color.RGBA{R: c.R, G: c.G, B: c.B, A: c.A}
 }
 
-// ParseHex parses a hex color string like "#000", "#000000", "#FF00FF".
+// ParseHex parses a hex color string like "#000", "#000000", "#FF00FF", or
+// "#FF00FF80" (with alpha).
 func ParseHex(s string) (RGBA, error) {
 	s = strings.TrimPrefix(s, "#")
 	var r, g, b uint8
@@ -46,12 +47,42 @@ func ParseHex(s string) (RGBA, error) {
 		if err != nil {
 			return RGBA{}, fmt.Errorf("invalid hex color %q: %w", s, err)
 		}
+	case 8:
+		var a uint8
+		_, err := fmt.Sscanf(s, "%02x%02x%02x%02x", &r, &g, &b, &a)
+		if err != nil {
+			return RGBA{}, fmt.Errorf("invalid hex color %q: %w", s, err)
+		}
+		return RGBA{R: r, G: g, B: b, A: a}, nil
 	default:
-		return RGBA{}, fmt.Errorf("invalid hex color %q: must be 3 or 6 hex digits", s)
+		return RGBA{}, fmt.Errorf("invalid hex color %q: must be 3, 6 or 8 hex digits", s)
 	}
 	return RGBA{R: r, G: g, B: b, A: 255}, nil
 }
 
+// Hex formats the color as "#rrggbb", or "#rrggbbaa" if it is not opaque.
+func (c RGBA) Hex() string {
+	if c.A == 255 {
+		return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	}
+	return fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A)
+}
+
+// MarshalText implements encoding.TextMarshaler using the Hex format.
+func (c RGBA) MarshalText() ([]byte, error) {
+	return []byte(c.Hex()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using ParseHex.
+func (c *RGBA) UnmarshalText(text []byte) error {
+	parsed, err := ParseHex(string(text))
+	if err != nil {
+		return err
+	}
+	*c = parsed
+	return nil
+}
+
 // LAB represents a color in the CIELAB color space.
 type LAB struct {
 	L, A, B float64
@@ -109,6 +140,106 @@ func DistanceLAB(a, b RGBA) float64 {
 	return math.Sqrt(dl*dl + da*da + db*db)
 }
 
+// Metric selects how color differences are measured.
+type Metric int
+
+const (
+	// MetricEuclidean is the straight-line distance: in CIELAB (CIE76) when
+	// comparing palette colors, in RGB when matching a border color.
+	MetricEuclidean Metric = iota
+	// MetricCIEDE2000 is the CIEDE2000 color difference, which corrects
+	// CIELAB's uneven perceptual spacing, notably in blues and
+	// desaturated colors. Its scale is roughly that of CIELAB, where 1
+	// is a just noticeable difference and 100 is black against white.
+	MetricCIEDE2000
+)
+
+// LABDistance returns the difference between two CIELAB colors under m.
+func (m Metric) LABDistance(a, b LAB) float64 {
+	if m == MetricCIEDE2000 {
+		return DeltaE2000(a, b)
+	}
+	dl, da, db := a.L-b.L, a.A-b.A, a.B-b.B
+	return math.Sqrt(dl*dl + da*da + db*db)
+}
+
+// Distance returns the difference between two colors under m, measured in
+// CIELAB.
+func (m Metric) Distance(a, b RGBA) float64 {
+	return m.LABDistance(a.ToLAB(), b.ToLAB())
+}
+
+// DeltaE2000 computes the CIEDE2000 color difference ΔE00 between two
+// CIELAB colors, with the parametric weights kL = kC = kH = 1. It follows
+// Sharma, Wu and Dalal, "The CIEDE2000 Color-Difference Formula:
+// Implementation Notes, Supplementary Test Data, and Mathematical
+// Observations" (2005).
+func DeltaE2000(x, y LAB) float64 {
+	const pow25to7 = 6103515625 // 25^7
+	deg := math.Pi / 180
+
+	c1, c2 := math.Hypot(x.A, x.B), math.Hypot(y.A, y.B)
+	cBar7 := math.Pow((c1+c2)/2, 7)
+	g := 0.5 * (1 - math.Sqrt(cBar7/(cBar7+pow25to7)))
+	a1, a2 := (1+g)*x.A, (1+g)*y.A
+	c1p, c2p := math.Hypot(a1, x.B), math.Hypot(a2, y.B)
+	h1p, h2p := hueDegrees(x.B, a1), hueDegrees(y.B, a2)
+
+	dL := y.L - x.L
+	dC := c2p - c1p
+	var dh float64
+	if c1p*c2p != 0 {
+		dh = h2p - h1p
+		if dh > 180 {
+			dh -= 360
+		} else if dh < -180 {
+			dh += 360
+		}
+	}
+	dH := 2 * math.Sqrt(c1p*c2p) * math.Sin(dh/2*deg)
+
+	lBar := (x.L + y.L) / 2
+	cBar := (c1p + c2p) / 2
+	hBar := h1p + h2p
+	if c1p*c2p != 0 {
+		switch {
+		case math.Abs(h1p-h2p) <= 180:
+			hBar /= 2
+		case hBar < 360:
+			hBar = (hBar + 360) / 2
+		default:
+			hBar = (hBar - 360) / 2
+		}
+	}
+
+	t := 1 - 0.17*math.Cos((hBar-30)*deg) + 0.24*math.Cos(2*hBar*deg) +
+		0.32*math.Cos((3*hBar+6)*deg) - 0.20*math.Cos((4*hBar-63)*deg)
+	dTheta := 30 * math.Exp(-((hBar-275)/25)*((hBar-275)/25))
+	cBar7p := math.Pow(cBar, 7)
+	rC := 2 * math.Sqrt(cBar7p/(cBar7p+pow25to7))
+	l50 := (lBar - 50) * (lBar - 50)
+	sL := 1 + 0.015*l50/math.Sqrt(20+l50)
+	sC := 1 + 0.045*cBar
+	sH := 1 + 0.015*cBar*t
+	rT := -math.Sin(2*dTheta*deg) * rC
+
+	l, c, h := dL/sL, dC/sC, dH/sH
+	return math.Sqrt(l*l + c*c + h*h + rT*c*h)
+}
+
+// hueDegrees returns the hue angle atan2(b, a) in [0, 360) degrees; the
+// hue of a neutral color is 0.
+func hueDegrees(b, a float64) float64 {
+	if a == 0 && b == 0 {
+		return 0
+	}
+	h := math.Atan2(b, a) * 180 / math.Pi
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
+
 // DistanceRGB computes the Euclidean distance in RGB space between two colors.
 func DistanceRGB(a, b RGBA) float64 {
 	dr := float64(a.R) - float64(b.R)
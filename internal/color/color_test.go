@@ -53,6 +53,11 @@ func TestParseHex(t *testing.T) {
 			input: "abc",
 			want:  RGBA{0xAA, 0xBB, 0xCC, 255},
 		},
+		{
+			name:  "8-digit with alpha",
+			input: "#FF000080",
+			want:  RGBA{255, 0, 0, 0x80},
+		},
 		{
 			name:    "invalid length 1",
 			input:   "#F",
@@ -99,6 +104,35 @@ func TestParseHex(t *testing.T) {
 	}
 }
 
+func TestHexRoundTrip(t *testing.T) {
+	tests := []struct {
+		c    RGBA
+		want string
+	}{
+		{RGBA{0, 0, 0, 255}, "#000000"},
+		{RGBA{0xC8, 0x4B, 0x3A, 255}, "#c84b3a"},
+		{RGBA{255, 0, 0, 0x80}, "#ff000080"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			text, err := tt.c.MarshalText()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(text) != tt.want {
+				t.Errorf("MarshalText: got %q, want %q", text, tt.want)
+			}
+			var back RGBA
+			if err := back.UnmarshalText(text); err != nil {
+				t.Fatal(err)
+			}
+			if back != tt.c {
+				t.Errorf("round trip: got %+v, want %+v", back, tt.c)
+			}
+		})
+	}
+}
+
 func TestFromStdColor(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -213,6 +247,47 @@ func TestDistanceLAB(t *testing.T) {
 	})
 }
 
+func TestDeltaE2000(t *testing.T) {
+	// Test pairs from Sharma, Wu and Dalal (2005), table 1.
+	tests := []struct {
+		a, b LAB
+		want float64
+	}{
+		{LAB{50, 2.6772, -79.7751}, LAB{50, 0, -82.7485}, 2.0425},
+		{LAB{50, 3.1571, -77.2803}, LAB{50, 0, -82.7485}, 2.8615},
+		{LAB{50, 0, 0}, LAB{50, -1, 2}, 2.3669},
+		{LAB{50, -1, 2}, LAB{50, 0, 0}, 2.3669},
+		{LAB{50, 2.49, -0.001}, LAB{50, -2.49, 0.0009}, 7.1792},
+		{LAB{50, 2.5, 0}, LAB{73, 25, -18}, 27.1492},
+		{LAB{50, 2.5, 0}, LAB{50, 3.1736, 0.5854}, 1.0000},
+		{LAB{60.2574, -34.0099, 36.2677}, LAB{60.4626, -34.1751, 39.4387}, 1.2644},
+		{LAB{22.7233, 20.0904, -46.694}, LAB{23.0331, 14.973, -42.5619}, 2.0373},
+		{LAB{90.9257, -0.5406, -0.9208}, LAB{88.6381, -0.8985, -0.7239}, 1.5381},
+		{LAB{2.0776, 0.0795, -1.135}, LAB{0.9033, -0.0636, -0.5514}, 0.9082},
+	}
+	for _, tt := range tests {
+		if got := DeltaE2000(tt.a, tt.b); math.Abs(got-tt.want) > 1e-4 {
+			t.Errorf("DeltaE2000(%v, %v) = %.4f, want %.4f", tt.a, tt.b, got, tt.want)
+		}
+		if got, rev := DeltaE2000(tt.a, tt.b), DeltaE2000(tt.b, tt.a); math.Abs(got-rev) > 1e-9 {
+			t.Errorf("DeltaE2000 is not symmetric for %v, %v: %f vs %f", tt.a, tt.b, got, rev)
+		}
+	}
+	if d := DeltaE2000(LAB{40, 10, -20}, LAB{40, 10, -20}); d != 0 {
+		t.Errorf("identical colors: got %f", d)
+	}
+}
+
+func TestMetric_Distance(t *testing.T) {
+	a, b := RGBA{20, 40, 200, 255}, RGBA{30, 60, 180, 255}
+	if got, want := MetricEuclidean.Distance(a, b), DistanceLAB(a, b); math.Abs(got-want) > 1e-9 {
+		t.Errorf("Euclidean: got %f, want %f", got, want)
+	}
+	if got, want := MetricCIEDE2000.Distance(a, b), DeltaE2000(a.ToLAB(), b.ToLAB()); got != want {
+		t.Errorf("CIEDE2000: got %f, want %f", got, want)
+	}
+}
+
 func TestDistanceRGB(t *testing.T) {
 	t.Run("identical colors have zero distance", func(t *testing.T) {
 		c := RGBA{50, 50, 50, 255}
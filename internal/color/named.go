@@ -0,0 +1,187 @@
+package color
+
+import (
+	"math"
+	"sync"
+)
+
+// namedColor pairs a CSS/X11 color keyword with its sRGB value.
+type namedColor struct {
+	name string
+	rgba RGBA
+}
+
+// namedColors is the CSS Color Module Level 4 keyword table (which is the
+// X11 set plus rebeccapurple). Aliases that share a value (aqua/cyan,
+// fuchsia/magenta, the "grey" spellings) are listed once.
+var namedColors = []namedColor{
+	{"aliceblue", RGBA{240, 248, 255, 255}},
+	{"antiquewhite", RGBA{250, 235, 215, 255}},
+	{"aqua", RGBA{0, 255, 255, 255}},
+	{"aquamarine", RGBA{127, 255, 212, 255}},
+	{"azure", RGBA{240, 255, 255, 255}},
+	{"beige", RGBA{245, 245, 220, 255}},
+	{"bisque", RGBA{255, 228, 196, 255}},
+	{"black", RGBA{0, 0, 0, 255}},
+	{"blanchedalmond", RGBA{255, 235, 205, 255}},
+	{"blue", RGBA{0, 0, 255, 255}},
+	{"blueviolet", RGBA{138, 43, 226, 255}},
+	{"brown", RGBA{165, 42, 42, 255}},
+	{"burlywood", RGBA{222, 184, 135, 255}},
+	{"cadetblue", RGBA{95, 158, 160, 255}},
+	{"chartreuse", RGBA{127, 255, 0, 255}},
+	{"chocolate", RGBA{210, 105, 30, 255}},
+	{"coral", RGBA{255, 127, 80, 255}},
+	{"cornflowerblue", RGBA{100, 149, 237, 255}},
+	{"cornsilk", RGBA{255, 248, 220, 255}},
+	{"crimson", RGBA{220, 20, 60, 255}},
+	{"darkblue", RGBA{0, 0, 139, 255}},
+	{"darkcyan", RGBA{0, 139, 139, 255}},
+	{"darkgoldenrod", RGBA{184, 134, 11, 255}},
+	{"darkgray", RGBA{169, 169, 169, 255}},
+	{"darkgreen", RGBA{0, 100, 0, 255}},
+	{"darkkhaki", RGBA{189, 183, 107, 255}},
+	{"darkmagenta", RGBA{139, 0, 139, 255}},
+	{"darkolivegreen", RGBA{85, 107, 47, 255}},
+	{"darkorange", RGBA{255, 140, 0, 255}},
+	{"darkorchid", RGBA{153, 50, 204, 255}},
+	{"darkred", RGBA{139, 0, 0, 255}},
+	{"darksalmon", RGBA{233, 150, 122, 255}},
+	{"darkseagreen", RGBA{143, 188, 143, 255}},
+	{"darkslateblue", RGBA{72, 61, 139, 255}},
+	{"darkslategray", RGBA{47, 79, 79, 255}},
+	{"darkturquoise", RGBA{0, 206, 209, 255}},
+	{"darkviolet", RGBA{148, 0, 211, 255}},
+	{"deeppink", RGBA{255, 20, 147, 255}},
+	{"deepskyblue", RGBA{0, 191, 255, 255}},
+	{"dimgray", RGBA{105, 105, 105, 255}},
+	{"dodgerblue", RGBA{30, 144, 255, 255}},
+	{"firebrick", RGBA{178, 34, 34, 255}},
+	{"floralwhite", RGBA{255, 250, 240, 255}},
+	{"forestgreen", RGBA{34, 139, 34, 255}},
+	{"fuchsia", RGBA{255, 0, 255, 255}},
+	{"gainsboro", RGBA{220, 220, 220, 255}},
+	{"ghostwhite", RGBA{248, 248, 255, 255}},
+	{"gold", RGBA{255, 215, 0, 255}},
+	{"goldenrod", RGBA{218, 165, 32, 255}},
+	{"gray", RGBA{128, 128, 128, 255}},
+	{"green", RGBA{0, 128, 0, 255}},
+	{"greenyellow", RGBA{173, 255, 47, 255}},
+	{"honeydew", RGBA{240, 255, 240, 255}},
+	{"hotpink", RGBA{255, 105, 180, 255}},
+	{"indianred", RGBA{205, 92, 92, 255}},
+	{"indigo", RGBA{75, 0, 130, 255}},
+	{"ivory", RGBA{255, 255, 240, 255}},
+	{"khaki", RGBA{240, 230, 140, 255}},
+	{"lavender", RGBA{230, 230, 250, 255}},
+	{"lavenderblush", RGBA{255, 240, 245, 255}},
+	{"lawngreen", RGBA{124, 252, 0, 255}},
+	{"lemonchiffon", RGBA{255, 250, 205, 255}},
+	{"lightblue", RGBA{173, 216, 230, 255}},
+	{"lightcoral", RGBA{240, 128, 128, 255}},
+	{"lightcyan", RGBA{224, 255, 255, 255}},
+	{"lightgoldenrodyellow", RGBA{250, 250, 210, 255}},
+	{"lightgray", RGBA{211, 211, 211, 255}},
+	{"lightgreen", RGBA{144, 238, 144, 255}},
+	{"lightpink", RGBA{255, 182, 193, 255}},
+	{"lightsalmon", RGBA{255, 160, 122, 255}},
+	{"lightseagreen", RGBA{32, 178, 170, 255}},
+	{"lightskyblue", RGBA{135, 206, 250, 255}},
+	{"lightslategray", RGBA{119, 136, 153, 255}},
+	{"lightsteelblue", RGBA{176, 196, 222, 255}},
+	{"lightyellow", RGBA{255, 255, 224, 255}},
+	{"lime", RGBA{0, 255, 0, 255}},
+	{"limegreen", RGBA{50, 205, 50, 255}},
+	{"linen", RGBA{250, 240, 230, 255}},
+	{"maroon", RGBA{128, 0, 0, 255}},
+	{"mediumaquamarine", RGBA{102, 205, 170, 255}},
+	{"mediumblue", RGBA{0, 0, 205, 255}},
+	{"mediumorchid", RGBA{186, 85, 211, 255}},
+	{"mediumpurple", RGBA{147, 112, 219, 255}},
+	{"mediumseagreen", RGBA{60, 179, 113, 255}},
+	{"mediumslateblue", RGBA{123, 104, 238, 255}},
+	{"mediumspringgreen", RGBA{0, 250, 154, 255}},
+	{"mediumturquoise", RGBA{72, 209, 204, 255}},
+	{"mediumvioletred", RGBA{199, 21, 133, 255}},
+	{"midnightblue", RGBA{25, 25, 112, 255}},
+	{"mintcream", RGBA{245, 255, 250, 255}},
+	{"mistyrose", RGBA{255, 228, 225, 255}},
+	{"moccasin", RGBA{255, 228, 181, 255}},
+	{"navajowhite", RGBA{255, 222, 173, 255}},
+	{"navy", RGBA{0, 0, 128, 255}},
+	{"oldlace", RGBA{253, 245, 230, 255}},
+	{"olive", RGBA{128, 128, 0, 255}},
+	{"olivedrab", RGBA{107, 142, 35, 255}},
+	{"orange", RGBA{255, 165, 0, 255}},
+	{"orangered", RGBA{255, 69, 0, 255}},
+	{"orchid", RGBA{218, 112, 214, 255}},
+	{"palegoldenrod", RGBA{238, 232, 170, 255}},
+	{"palegreen", RGBA{152, 251, 152, 255}},
+	{"paleturquoise", RGBA{175, 238, 238, 255}},
+	{"palevioletred", RGBA{219, 112, 147, 255}},
+	{"papayawhip", RGBA{255, 239, 213, 255}},
+	{"peachpuff", RGBA{255, 218, 185, 255}},
+	{"peru", RGBA{205, 133, 63, 255}},
+	{"pink", RGBA{255, 192, 203, 255}},
+	{"plum", RGBA{221, 160, 221, 255}},
+	{"powderblue", RGBA{176, 224, 230, 255}},
+	{"purple", RGBA{128, 0, 128, 255}},
+	{"rebeccapurple", RGBA{102, 51, 153, 255}},
+	{"red", RGBA{255, 0, 0, 255}},
+	{"rosybrown", RGBA{188, 143, 143, 255}},
+	{"royalblue", RGBA{65, 105, 225, 255}},
+	{"saddlebrown", RGBA{139, 69, 19, 255}},
+	{"salmon", RGBA{250, 128, 114, 255}},
+	{"sandybrown", RGBA{244, 164, 96, 255}},
+	{"seagreen", RGBA{46, 139, 87, 255}},
+	{"seashell", RGBA{255, 245, 238, 255}},
+	{"sienna", RGBA{160, 82, 45, 255}},
+	{"silver", RGBA{192, 192, 192, 255}},
+	{"skyblue", RGBA{135, 206, 235, 255}},
+	{"slateblue", RGBA{106, 90, 205, 255}},
+	{"slategray", RGBA{112, 128, 144, 255}},
+	{"snow", RGBA{255, 250, 250, 255}},
+	{"springgreen", RGBA{0, 255, 127, 255}},
+	{"steelblue", RGBA{70, 130, 180, 255}},
+	{"tan", RGBA{210, 180, 140, 255}},
+	{"teal", RGBA{0, 128, 128, 255}},
+	{"thistle", RGBA{216, 191, 216, 255}},
+	{"tomato", RGBA{255, 99, 71, 255}},
+	{"turquoise", RGBA{64, 224, 208, 255}},
+	{"violet", RGBA{238, 130, 238, 255}},
+	{"wheat", RGBA{245, 222, 179, 255}},
+	{"white", RGBA{255, 255, 255, 255}},
+	{"whitesmoke", RGBA{245, 245, 245, 255}},
+	{"yellow", RGBA{255, 255, 0, 255}},
+	{"yellowgreen", RGBA{154, 205, 50, 255}},
+}
+
+var (
+	namedLABOnce sync.Once
+	namedLAB     []LAB
+)
+
+// ClosestNamed returns the name of the CSS/X11 color closest to c in CIELAB
+// space, along with the distance to it. Alpha is ignored.
+func ClosestNamed(c RGBA) (name string, dist float64) {
+	namedLABOnce.Do(func() {
+		namedLAB = make([]LAB, len(namedColors))
+		for i, nc := range namedColors {
+			namedLAB[i] = nc.rgba.ToLAB()
+		}
+	})
+
+	lab := c.ToLAB()
+	best := math.MaxFloat64
+	for i, nl := range namedLAB {
+		dl := lab.L - nl.L
+		da := lab.A - nl.A
+		db := lab.B - nl.B
+		d := dl*dl + da*da + db*db
+		if d < best {
+			best = d
+			name = namedColors[i].name
+		}
+	}
+	return name, math.Sqrt(best)
+}
@@ -0,0 +1,43 @@
+package color
+
+import "testing"
+
+func TestClosestNamed(t *testing.T) {
+	tests := []struct {
+		name     string
+		c        RGBA
+		wantName string
+		exact    bool
+	}{
+		{name: "exact black", c: RGBA{0, 0, 0, 255}, wantName: "black", exact: true},
+		{name: "exact white", c: RGBA{255, 255, 255, 255}, wantName: "white", exact: true},
+		{name: "exact rebeccapurple", c: RGBA{102, 51, 153, 255}, wantName: "rebeccapurple", exact: true},
+		{name: "near red", c: RGBA{250, 5, 3, 255}, wantName: "red"},
+		{name: "near navy", c: RGBA{2, 1, 125, 255}, wantName: "navy"},
+		{name: "alpha is ignored", c: RGBA{0, 128, 0, 0}, wantName: "green", exact: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, dist := ClosestNamed(tt.c)
+			if got != tt.wantName {
+				t.Errorf("got %q, want %q", got, tt.wantName)
+			}
+			if tt.exact && dist != 0 {
+				t.Errorf("expected zero distance for exact match, got %f", dist)
+			}
+			if !tt.exact && dist <= 0 {
+				t.Errorf("expected positive distance, got %f", dist)
+			}
+		})
+	}
+}
+
+func TestNamedColors_UniqueNames(t *testing.T) {
+	seen := make(map[string]bool, len(namedColors))
+	for _, nc := range namedColors {
+		if seen[nc.name] {
+			t.Errorf("duplicate color name %q", nc.name)
+		}
+		seen[nc.name] = true
+	}
+}
@@ -0,0 +1,149 @@
+// Package contour traces labelled pixel regions into polygons.
+//
+// Outlines follow pixel edges ("crack following"), so vertices lie on pixel
+// corners: a single pixel at (x, y) is the square (x,y)-(x+1,y+1). Rings are
+// closed implicitly (the first vertex is not repeated) and contain only the
+// corners where the outline changes direction.
+package contour
+
+import "image"
+
+// Polygon is the outline of one labelled region.
+type Polygon struct {
+	// Outer is the exterior ring, clockwise on screen (y pointing down).
+	Outer []image.Point
+	// Holes are interior rings, counter-clockwise on screen.
+	Holes [][]image.Point
+}
+
+// edge is a directed unit pixel edge with the region on its right-hand side
+// (on screen, y down).
+type edge struct {
+	from, to image.Point
+}
+
+// Trace returns one Polygon per label in 0..n-1 for a w×h label map (as
+// produced by zone.FindZones). Negative labels are background. Regions are
+// treated as 4-connected: at corners where two pixels of a label touch only
+// diagonally, the outline turns towards the region so they are not joined.
+// A region that is not 4-connected yields its first ring as Outer and any
+// further exterior rings are dropped.
+func Trace(labels []int, w, h, n int) []Polygon {
+	at := func(x, y int) int {
+		if x < 0 || x >= w || y < 0 || y >= h {
+			return -1
+		}
+		return labels[y*w+x]
+	}
+
+	edges := make([][]edge, n)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			l := labels[y*w+x]
+			if l < 0 || l >= n {
+				continue
+			}
+			if at(x, y-1) != l {
+				edges[l] = append(edges[l], edge{image.Pt(x, y), image.Pt(x+1, y)})
+			}
+			if at(x+1, y) != l {
+				edges[l] = append(edges[l], edge{image.Pt(x+1, y), image.Pt(x+1, y+1)})
+			}
+			if at(x, y+1) != l {
+				edges[l] = append(edges[l], edge{image.Pt(x+1, y+1), image.Pt(x, y+1)})
+			}
+			if at(x-1, y) != l {
+				edges[l] = append(edges[l], edge{image.Pt(x, y+1), image.Pt(x, y)})
+			}
+		}
+	}
+
+	polys := make([]Polygon, n)
+	for l, es := range edges {
+		for _, ring := range link(es) {
+			if signedArea(ring) > 0 {
+				if polys[l].Outer == nil {
+					polys[l].Outer = ring
+				}
+			} else {
+				polys[l].Holes = append(polys[l].Holes, ring)
+			}
+		}
+	}
+	return polys
+}
+
+// link chains directed edges into closed rings and drops collinear vertices.
+func link(es []edge) [][]image.Point {
+	out := make(map[image.Point][]int, len(es))
+	for i, e := range es {
+		out[e.from] = append(out[e.from], i)
+	}
+	used := make([]bool, len(es))
+
+	var rings [][]image.Point
+	for start := range es {
+		if used[start] {
+			continue
+		}
+		var ring []image.Point
+		cur := start
+		for !used[cur] {
+			used[cur] = true
+			e := es[cur]
+			ring = append(ring, e.from)
+			next := -1
+			for _, c := range out[e.to] {
+				if used[c] && c != start {
+					continue
+				}
+				// At a saddle corner two edges leave e.to; prefer the right
+				// turn, which keeps diagonal neighbors apart.
+				if next == -1 || turn(e, es[c]) > turn(e, es[next]) {
+					next = c
+				}
+			}
+			if next == -1 {
+				break
+			}
+			cur = next
+		}
+		rings = append(rings, simplify(ring))
+	}
+	return rings
+}
+
+// turn ranks the direction change from a to b: right turn 1, straight 0,
+// left turn -1 (on screen, y down).
+func turn(a, b edge) int {
+	ax, ay := a.to.X-a.from.X, a.to.Y-a.from.Y
+	bx, by := b.to.X-b.from.X, b.to.Y-b.from.Y
+	return ax*by - ay*bx
+}
+
+// simplify removes vertices lying on a straight run between their neighbors.
+func simplify(ring []image.Point) []image.Point {
+	n := len(ring)
+	if n < 3 {
+		return ring
+	}
+	var out []image.Point
+	for i, p := range ring {
+		prev, next := ring[(i+n-1)%n], ring[(i+1)%n]
+		if (p.X-prev.X)*(next.Y-p.Y)-(p.Y-prev.Y)*(next.X-p.X) != 0 {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// signedArea returns twice the signed area of ring, positive for clockwise
+// rings on screen.
+func signedArea(ring []image.Point) int {
+	a := 0
+	for i, p := range ring {
+		q := ring[(i+1)%len(ring)]
+		a += p.X*q.Y - q.X*p.Y
+	}
+	return a
+}
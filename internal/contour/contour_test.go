@@ -0,0 +1,105 @@
+package contour
+
+import (
+	"image"
+	"reflect"
+	"testing"
+)
+
+// labelsFromRows builds a label map from rows of digits; '.' is background.
+func labelsFromRows(rows ...string) ([]int, int, int) {
+	h, w := len(rows), len(rows[0])
+	labels := make([]int, w*h)
+	for y, row := range rows {
+		for x, c := range row {
+			labels[y*w+x] = -1
+			if c != '.' {
+				labels[y*w+x] = int(c - '0')
+			}
+		}
+	}
+	return labels, w, h
+}
+
+func TestTrace_Rectangle(t *testing.T) {
+	labels, w, h := labelsFromRows(
+		"....",
+		".00.",
+		".00.",
+		".00.",
+	)
+	polys := Trace(labels, w, h, 1)
+	want := []image.Point{{1, 1}, {3, 1}, {3, 4}, {1, 4}}
+	if !sameRing(polys[0].Outer, want) {
+		t.Errorf("outer: got %v, want %v", polys[0].Outer, want)
+	}
+	if len(polys[0].Holes) != 0 {
+		t.Errorf("unexpected holes: %v", polys[0].Holes)
+	}
+}
+
+func TestTrace_Hole(t *testing.T) {
+	labels, w, h := labelsFromRows(
+		"000",
+		"010",
+		"000",
+	)
+	polys := Trace(labels, w, h, 2)
+	if !sameRing(polys[0].Outer, []image.Point{{0, 0}, {3, 0}, {3, 3}, {0, 3}}) {
+		t.Errorf("outer: got %v", polys[0].Outer)
+	}
+	if len(polys[0].Holes) != 1 || !sameRing(polys[0].Holes[0], []image.Point{{1, 1}, {1, 2}, {2, 2}, {2, 1}}) {
+		t.Errorf("holes: got %v", polys[0].Holes)
+	}
+	if !sameRing(polys[1].Outer, []image.Point{{1, 1}, {2, 1}, {2, 2}, {1, 2}}) {
+		t.Errorf("inner zone: got %v", polys[1].Outer)
+	}
+}
+
+func TestTrace_LShape(t *testing.T) {
+	labels, w, h := labelsFromRows(
+		"0.",
+		"00",
+	)
+	polys := Trace(labels, w, h, 1)
+	want := []image.Point{{0, 0}, {1, 0}, {1, 1}, {2, 1}, {2, 2}, {0, 2}}
+	if !sameRing(polys[0].Outer, want) {
+		t.Errorf("outer: got %v, want %v", polys[0].Outer, want)
+	}
+}
+
+func TestTrace_DiagonalTouchIsNotJoined(t *testing.T) {
+	// Label 0's pixels meet label 1's pixels only at the center corner; each
+	// label is traced as if its two pixels were separate.
+	labels, w, h := labelsFromRows(
+		"01",
+		"10",
+	)
+	polys := Trace(labels, w, h, 2)
+	if !sameRing(polys[0].Outer, []image.Point{{0, 0}, {1, 0}, {1, 1}, {0, 1}}) &&
+		!sameRing(polys[0].Outer, []image.Point{{1, 1}, {2, 1}, {2, 2}, {1, 2}}) {
+		t.Errorf("expected a single-pixel ring, got %v", polys[0].Outer)
+	}
+}
+
+func TestTrace_EmptyLabel(t *testing.T) {
+	labels, w, h := labelsFromRows("00")
+	polys := Trace(labels, w, h, 2)
+	if polys[1].Outer != nil {
+		t.Errorf("expected no outline for an unused label, got %v", polys[1].Outer)
+	}
+}
+
+// sameRing reports whether a and b are the same cyclic vertex sequence.
+func sameRing(a, b []image.Point) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for shift := range a {
+		rotated := append(append([]image.Point{}, a[shift:]...), a[:shift]...)
+		if reflect.DeepEqual(rotated, b) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package detection
+
+import (
+	"context"
+	"image"
+)
+
+// CombineMode selects how a CompositeDelimiter merges its maps.
+type CombineMode int
+
+const (
+	// CombineUnion marks a pixel found by any delimiter.
+	CombineUnion CombineMode = iota
+	// CombineIntersection marks a pixel found by every delimiter.
+	CombineIntersection
+)
+
+// CompositeDelimiter runs several delimiters over the same image and
+// combines their maps, so that e.g. explicit black outlines and strong
+// color edges both become delimiters. With no delimiters nothing is marked.
+type CompositeDelimiter struct {
+	Delimiters []Delimiter
+	Mode       CombineMode
+}
+
+// Detect combines the maps of all delimiters per d.Mode.
+func (d *CompositeDelimiter) Detect(img image.Image) *Map {
+	dm, _ := d.DetectContext(context.Background(), img)
+	return dm
+}
+
+// DetectContext is like Detect but stops early and returns ctx.Err() once
+// ctx is cancelled.
+func (d *CompositeDelimiter) DetectContext(ctx context.Context, img image.Image) (*Map, error) {
+	b := img.Bounds()
+	var out *Map
+	for _, del := range d.Delimiters {
+		dm, err := del.DetectContext(ctx, img)
+		if err != nil {
+			return nil, err
+		}
+		if out == nil {
+			out = dm
+			continue
+		}
+		for i, is := range dm.IsDelimiter {
+			if d.Mode == CombineIntersection {
+				out.IsDelimiter[i] = out.IsDelimiter[i] && is
+			} else {
+				out.IsDelimiter[i] = out.IsDelimiter[i] || is
+			}
+		}
+	}
+	if out == nil {
+		out = NewMap(b.Dx(), b.Dy())
+	}
+	return out, nil
+}
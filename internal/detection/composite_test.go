@@ -0,0 +1,54 @@
+package detection
+
+import (
+	"context"
+	"errors"
+	"image/color"
+	"testing"
+
+	mcol "github.com/maax3v3/macoma/v2/internal/color"
+)
+
+func TestCompositeDelimiter(t *testing.T) {
+	// A black stroke on white, then a red/blue color edge with no stroke.
+	w := 30
+	img := newSolidImage(w, 1, color.RGBA{255, 255, 255, 255})
+	img.data[5] = color.RGBA{0, 0, 0, 255}
+	for x := 15; x < w; x++ {
+		img.data[x] = color.RGBA{220, 0, 0, 255}
+	}
+	for x := 23; x < w; x++ {
+		img.data[x] = color.RGBA{0, 0, 220, 255}
+	}
+	border := &BorderDelimiter{Color: mcol.RGBA{A: 255}, TolerancePct: 10}
+	colors := &ColorDelimiter{TolerancePct: 20, Radius: 1}
+
+	union := (&CompositeDelimiter{Delimiters: []Delimiter{border, colors}}).Detect(img)
+	if !union.At(5, 0) || !union.At(22, 0) || !union.At(23, 0) {
+		t.Error("union should mark the stroke and the color edge")
+	}
+	if union.At(0, 0) || union.At(10, 0) || union.At(29, 0) {
+		t.Error("union marked plain paper or fill")
+	}
+
+	inter := (&CompositeDelimiter{Delimiters: []Delimiter{border, colors}, Mode: CombineIntersection}).Detect(img)
+	if !inter.At(5, 0) {
+		t.Error("intersection should keep the stroke, found by both")
+	}
+	if inter.At(4, 0) || inter.At(22, 0) {
+		t.Error("intersection should drop pixels found by only one delimiter")
+	}
+
+	if n := (&CompositeDelimiter{}).Detect(img).Count(); n != 0 {
+		t.Errorf("no delimiters: got %d delimiter pixels", n)
+	}
+}
+
+func TestCompositeDelimiter_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d := &CompositeDelimiter{Delimiters: []Delimiter{&ColorDelimiter{TolerancePct: 10}}}
+	if _, err := d.DetectContext(ctx, newSolidImage(10, 10, color.RGBA{A: 255})); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+}
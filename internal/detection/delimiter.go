@@ -1,10 +1,14 @@
 package detection
 
 import (
+	"context"
 	"image"
 	"sync"
+	"sync/atomic"
 
 	"github.com/maax3v3/macoma/v2/internal/color"
+	"github.com/maax3v3/macoma/v2/internal/pool"
+	"github.com/maax3v3/macoma/v2/internal/progress"
 )
 
 // Map holds a boolean grid where true means the pixel is a delimiter pixel.
@@ -21,42 +25,148 @@ func (m *Map) At(x, y int) bool {
 // Delimiter detects which pixels in an image are delimiters (zone boundaries).
 type Delimiter interface {
 	Detect(img image.Image) *Map
+
+	// DetectContext is like Detect but stops early and returns ctx.Err()
+	// once ctx is cancelled.
+	DetectContext(ctx context.Context, img image.Image) (*Map, error)
 }
 
 // BorderDelimiter classifies pixels as delimiters if their color matches a
-// specific border color within a tolerance.
+// specific border color within a tolerance, or one of the Extra colors
+// within its own.
 type BorderDelimiter struct {
 	Color        color.RGBA
 	TolerancePct float64
+
+	// Auto ignores TolerancePct and picks the tolerance per image with
+	// Otsu's method on the histogram of distances to Color, which splits
+	// the dark stroke pixels from the paper and fills.
+	Auto bool
+
+	// Extra lists further outline colors, such as dark brown strokes in a
+	// drawing outlined mostly in black. A pixel matching any color is a
+	// delimiter.
+	Extra []BorderColor
+
+	// Metric is how the distance to Color is measured. With
+	// MetricEuclidean (the default) TolerancePct is a share of the largest
+	// RGB distance; with MetricCIEDE2000 it is a ΔE00 value, where 100 is
+	// roughly black against white.
+	Metric color.Metric
+
+	// LAB measures the MetricEuclidean distance in CIELAB (CIE76) rather
+	// than RGB, so TolerancePct is a ΔE76 value, where 100 is black
+	// against white. RGB sees dark navy as close to black, CIELAB does
+	// not.
+	LAB bool
+}
+
+// BorderColor is one outline color of a BorderDelimiter, with the same
+// meaning of TolerancePct and Auto as the BorderDelimiter fields.
+type BorderColor struct {
+	Color        color.RGBA
+	TolerancePct float64
+	Auto         bool
 }
 
+// borderCacheSize caps the colors whose distances BorderDelimiter remembers
+// per band of rows.
+const borderCacheSize = 1 << 12
+
 // Detect classifies every pixel as delimiter or filler based on color distance
-// to the configured border color.
+// to the configured border colors.
 func (d *BorderDelimiter) Detect(img image.Image) *Map {
+	dm, _ := d.DetectContext(context.Background(), img)
+	return dm
+}
+
+// DetectContext is like Detect but stops early and returns ctx.Err() once
+// ctx is cancelled.
+func (d *BorderDelimiter) DetectContext(ctx context.Context, img image.Image) (*Map, error) {
 	bounds := img.Bounds()
 	w := bounds.Dx()
 	h := bounds.Dy()
-	threshold := (d.TolerancePct / 100.0) * color.MaxRGBDistance
-
-	dm := &Map{
-		Width:       w,
-		Height:      h,
-		IsDelimiter: make([]bool, w*h),
+	colors := append([]BorderColor{{Color: d.Color, TolerancePct: d.TolerancePct, Auto: d.Auto}}, d.Extra...)
+	dist := make([]func(color.RGBA) float64, len(colors))
+	var auto []int // indices of the colors with an automatic tolerance
+	for k, c := range colors {
+		dist[k] = d.distance(c.Color)
+		if c.Auto {
+			auto = append(auto, k)
+		}
 	}
 
-	parallelRows(h, func(sy, ey int) {
+	// Match the fixed tolerances directly, and bin the distances to the
+	// automatic colors to split their histograms afterwards.
+	dm := newMapContext(ctx, w, h)
+	bufs := pool.From(ctx)
+	bins := make([][]uint8, len(auto))
+	for a := range bins {
+		bins[a] = bufs.Bytes(w * h)
+		defer bufs.PutBytes(bins[a])
+	}
+	err := parallelRowsContext(ctx, h, progress.Detection, func(sy, ey int) {
+		// Drawings use few distinct colors; remember the distances of each
+		// so the CIELAB conversion runs once per color, not per pixel.
+		seen := make(map[color.RGBA][]float64)
 		for y := sy; y < ey; y++ {
 			for x := 0; x < w; x++ {
 				px := color.FromStdColor(img.At(bounds.Min.X+x, bounds.Min.Y+y))
-				dist := color.DistanceRGB(px, d.Color)
-				if dist <= threshold {
-					dm.IsDelimiter[y*w+x] = true
+				v, ok := seen[px]
+				if !ok {
+					v = make([]float64, len(colors))
+					for k := range colors {
+						v[k] = dist[k](px)
+					}
+					if len(seen) < borderCacheSize {
+						seen[px] = v
+					}
+				}
+				i := y*w + x
+				for k, c := range colors {
+					if !c.Auto && v[k] <= c.TolerancePct {
+						dm.IsDelimiter[i] = true
+						break
+					}
+				}
+				for a, k := range auto {
+					bins[a][i] = distanceBin(v[k])
 				}
 			}
 		}
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	return dm
+	for a := range auto {
+		hist := make([]int, distanceBins)
+		for _, b := range bins[a] {
+			hist[b]++
+		}
+		t, ok := OtsuThreshold(hist)
+		if !ok {
+			t = int(distanceBin(autoFallbackTolerance))
+		}
+		for i, b := range bins[a] {
+			if int(b) <= t {
+				dm.IsDelimiter[i] = true
+			}
+		}
+	}
+	return dm, nil
+}
+
+// distance returns the distance to target in TolerancePct units for
+// d.Metric.
+func (d *BorderDelimiter) distance(target color.RGBA) func(color.RGBA) float64 {
+	if d.Metric == color.MetricCIEDE2000 || d.LAB {
+		lab := target.ToLAB()
+		return func(px color.RGBA) float64 { return d.Metric.LABDistance(px.ToLAB(), lab) }
+	}
+	return func(px color.RGBA) float64 {
+		return color.DistanceRGB(px, target) / color.MaxRGBDistance * 100
+	}
 }
 
 // ColorDelimiter classifies pixels as delimiters using a local range filter.
@@ -66,113 +176,229 @@ func (d *BorderDelimiter) Detect(img image.Image) *Map {
 // spans both sides of the boundary.
 type ColorDelimiter struct {
 	TolerancePct float64
+
+	// Radius is the neighborhood radius: the window is (2r+1)×(2r+1).
+	// Larger windows bridge wider anti-aliasing on high-resolution scans;
+	// radius 1 keeps the boundary bands thin on small icons. 0 means
+	// DefaultColorRadius.
+	Radius int
 }
 
-// Detect marks every pixel whose 5×5 neighborhood contains colors that
-// differ by more than the tolerance.
+// DefaultColorRadius is the neighborhood radius of a ColorDelimiter with no
+// Radius set: a 5×5 window.
+const DefaultColorRadius = 2
+
+// Detect marks every pixel whose neighborhood (5×5 by default) contains
+// colors that differ by more than the tolerance.
 //
 // Performance notes:
-//   - Precomputes a flat RGB buffer to avoid repeated interface dispatch.
-//   - Uses squared integer RGB distance (no sqrt, no float per pixel).
-//   - Parallelized across row bands — each worker only writes its own rows.
+//   - The window min/max is separable and computed with running minima, so
+//     the cost per pixel does not grow with Radius.
+//   - Uses integer per-channel ranges (no float per pixel).
+//   - Parallelized across row bands, then column bands.
 func (d *ColorDelimiter) Detect(img image.Image) *Map {
+	dm, _ := d.DetectContext(context.Background(), img)
+	return dm
+}
+
+// DetectContext is like Detect but stops early and returns ctx.Err() once
+// ctx is cancelled.
+func (d *ColorDelimiter) DetectContext(ctx context.Context, img image.Image) (*Map, error) {
 	bounds := img.Bounds()
 	w := bounds.Dx()
 	h := bounds.Dy()
 
-	// Precompute flat RGB buffer to avoid repeated img.At interface dispatch.
-	buf := make([]color.RGBA, w*h)
-	parallelRows(h, func(sy, ey int) {
-		for y := sy; y < ey; y++ {
-			for x := 0; x < w; x++ {
-				buf[y*w+x] = color.FromStdColor(img.At(bounds.Min.X+x, bounds.Min.Y+y))
-			}
-		}
-	})
-
 	// Chebyshev threshold: max per-channel difference.
 	// More sensitive than Euclidean to single-channel differences (e.g.
 	// dark green vs black where only the green channel diverges).
 	threshold := int(d.TolerancePct / 100.0 * 255.0)
 
-	dm := &Map{
-		Width:       w,
-		Height:      h,
-		IsDelimiter: make([]bool, w*h),
-	}
+	dm := newMapContext(ctx, w, h)
 
 	// Local range filter: for each pixel, compute the min/max of each
-	// channel in its 5×5 neighborhood (radius 2). If the largest
-	// per-channel range exceeds the threshold the pixel sits at a
-	// color boundary.
-	const radius = 2
-	parallelRows(h, func(sy, ey int) {
+	// channel in its neighborhood (5×5 at the default radius 2). If the
+	// largest per-channel range exceeds the threshold the pixel sits at a
+	// color boundary. The window is separable: rows first, then columns,
+	// with running minima and maxima that cost the same whatever the
+	// radius. Maxima are kept as complements (255 − v), so the column pass
+	// only takes minima.
+	radius := d.Radius
+	if radius <= 0 {
+		radius = DefaultColorRadius
+	}
+
+	// Row pass: per channel, the min and complemented max of each pixel's
+	// row window.
+	bufs := pool.From(ctx)
+	var lo, hi [3][]uint8
+	for c := range lo {
+		lo[c] = bufs.Bytes(w * h)
+		hi[c] = bufs.Bytes(w * h)
+		defer bufs.PutBytes(lo[c])
+		defer bufs.PutBytes(hi[c])
+	}
+	err := parallelRowsContext(ctx, h, "", func(sy, ey int) {
+		var row [3][]uint8
+		for c := range row {
+			row[c] = make([]uint8, w)
+		}
+		scratch := newRowScratch(w, radius)
 		for y := sy; y < ey; y++ {
 			for x := 0; x < w; x++ {
-				var minR, minG, minB int = 255, 255, 255
-				var maxR, maxG, maxB int
-
-				y0 := y - radius
-				if y0 < 0 {
-					y0 = 0
-				}
-				y1 := y + radius
-				if y1 >= h {
-					y1 = h - 1
-				}
-				x0 := x - radius
-				if x0 < 0 {
-					x0 = 0
-				}
-				x1 := x + radius
-				if x1 >= w {
-					x1 = w - 1
-				}
-
-				for ny := y0; ny <= y1; ny++ {
-					off := ny * w
-					for nx := x0; nx <= x1; nx++ {
-						c := buf[off+nx]
-						r, g, b := int(c.R), int(c.G), int(c.B)
-						if r < minR {
-							minR = r
-						}
-						if r > maxR {
-							maxR = r
-						}
-						if g < minG {
-							minG = g
-						}
-						if g > maxG {
-							maxG = g
-						}
-						if b < minB {
-							minB = b
-						}
-						if b > maxB {
-							maxB = b
-						}
-					}
-				}
+				px := color.FromStdColor(img.At(bounds.Min.X+x, bounds.Min.Y+y))
+				row[0][x], row[1][x], row[2][x] = px.R, px.G, px.B
+			}
+			off := y * w
+			for c := range row {
+				scratch.runningMinMax(lo[c][off:off+w], hi[c][off:off+w], row[c], radius)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
 
-				dr := maxR - minR
-				dg := maxG - minG
-				db := maxB - minB
-				maxDiff := dr
-				if dg > maxDiff {
-					maxDiff = dg
-				}
-				if db > maxDiff {
-					maxDiff = db
-				}
-				if maxDiff > threshold {
-					dm.IsDelimiter[y*w+x] = true
+	// Column pass: the same over the row results gives the window's min and
+	// max. It runs on bands of columns, a row segment at a time, so memory
+	// is read in order.
+	err = parallelRowsContext(ctx, w, progress.Detection, func(sx, ex int) {
+		n := ex - sx
+		vlo := bufs.Bytes(h * n)
+		vhi := bufs.Bytes(h * n)
+		diff := bufs.Bytes(h * n)
+		defer bufs.PutBytes(vlo)
+		defer bufs.PutBytes(vhi)
+		defer bufs.PutBytes(diff)
+		scratch := newBandScratch(h, n, radius)
+		for c := range lo {
+			scratch.columnMin(vlo, lo[c], w, sx, ex, radius)
+			scratch.columnMin(vhi, hi[c], w, sx, ex, radius)
+			for j := range diff {
+				diff[j] = max(diff[j], 255-vhi[j]-vlo[j])
+			}
+		}
+		for y := 0; y < h; y++ {
+			for i, v := range diff[y*n : (y+1)*n] {
+				if int(v) > threshold {
+					dm.IsDelimiter[y*w+sx+i] = true
 				}
 			}
 		}
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	return dm
+	return dm, nil
+}
+
+// rowScratch holds the buffers of runningMinMax for rows of one length.
+type rowScratch struct {
+	padLo, padHi               []uint8
+	preLo, sufLo, preHi, sufHi []uint8
+}
+
+func newRowScratch(n, r int) *rowScratch {
+	m := n + 2*r
+	s := &rowScratch{
+		padLo: make([]uint8, m), padHi: make([]uint8, m),
+		preLo: make([]uint8, m), sufLo: make([]uint8, m),
+		preHi: make([]uint8, m), sufHi: make([]uint8, m),
+	}
+	// Neutral padding: nothing is below 255 or above 0.
+	for i := 0; i < r; i++ {
+		s.padLo[i], s.padLo[m-1-i] = 255, 255
+	}
+	return s
+}
+
+// runningMinMax sets lo[i] to the minimum and hi[i] to the complemented
+// maximum (255 − max) of src[i-r:i+r+1], clipped to src, in constant time
+// per element whatever r (van Herk/Gil-Werman). src is cut into blocks of
+// 2r+1, after r neutral values on each side; every window then spans a
+// suffix of one block and a prefix of the next, whose extremes are
+// precomputed.
+func (s *rowScratch) runningMinMax(lo, hi, src []uint8, r int) {
+	n, k := len(src), 2*r+1
+	m := n + 2*r
+	copy(s.padLo[r:], src)
+	copy(s.padHi[r:], src)
+
+	padLo, padHi := s.padLo[:m], s.padHi[:m]
+	preLo, sufLo := s.preLo[:m], s.sufLo[:m]
+	preHi, sufHi := s.preHi[:m], s.sufHi[:m]
+	for start := 0; start < m; start += k {
+		end := min(start+k, m)
+		mn, mx := padLo[start], padHi[start]
+		for j := start; j < end; j++ {
+			mn, mx = min(mn, padLo[j]), max(mx, padHi[j])
+			preLo[j], preHi[j] = mn, mx
+		}
+		mn, mx = padLo[end-1], padHi[end-1]
+		for j := end - 1; j >= start; j-- {
+			mn, mx = min(mn, padLo[j]), max(mx, padHi[j])
+			sufLo[j], sufHi[j] = mn, mx
+		}
+	}
+	lo, hi = lo[:n], hi[:n]
+	preLo, preHi = preLo[k-1:k-1+n], preHi[k-1:k-1+n]
+	sufLo, sufHi = sufLo[:n], sufHi[:n]
+	for i := range lo {
+		lo[i] = min(sufLo[i], preLo[i])
+		hi[i] = 255 - max(sufHi[i], preHi[i])
+	}
+}
+
+// bandScratch holds the buffers of columnMin for bands of one size.
+type bandScratch struct {
+	blank    []uint8 // a row segment of maximal values
+	pre, suf []uint8
+}
+
+func newBandScratch(h, n, r int) *bandScratch {
+	m := (h + 2*r) * n
+	blank := make([]uint8, n)
+	for i := range blank {
+		blank[i] = 255
+	}
+	return &bandScratch{blank: blank, pre: make([]uint8, m), suf: make([]uint8, m)}
+}
+
+// columnMin is runningMin down every column sx..ex-1 of src, a plane w
+// pixels wide: dst, with ex-sx values per row, receives the minimum of
+// each pixel's column window. Whole row segments are processed at once.
+func (s *bandScratch) columnMin(dst, src []uint8, w, sx, ex, r int) {
+	n, k := ex-sx, 2*r+1
+	h := len(src) / w
+	m := h + 2*r
+	row := func(j int) []uint8 {
+		if j < r || j >= r+h {
+			return s.blank
+		}
+		o := (j-r)*w + sx
+		return src[o : o+n]
+	}
+	minInto := func(d, a, b []uint8) {
+		d, a = d[:len(b)], a[:len(b)]
+		for i, v := range b {
+			d[i] = min(a[i], v)
+		}
+	}
+
+	for start := 0; start < m; start += k {
+		end := min(start+k, m)
+		copy(s.pre[start*n:], row(start))
+		for j := start + 1; j < end; j++ {
+			minInto(s.pre[j*n:], s.pre[(j-1)*n:], row(j))
+		}
+		copy(s.suf[(end-1)*n:], row(end-1))
+		for j := end - 2; j >= start; j-- {
+			minInto(s.suf[j*n:], s.suf[(j+1)*n:], row(j))
+		}
+	}
+	for y := 0; y < h; y++ {
+		minInto(dst[y*n:], s.suf[y*n:], s.pre[(y+k-1)*n:(y+k)*n])
+	}
 }
 
 // Detect is a convenience wrapper that creates a BorderDelimiter.
@@ -204,3 +430,36 @@ func parallelRows(h int, fn func(startY, endY int)) {
 	}
 	wg.Wait()
 }
+
+// rowChunk is the number of rows a worker processes between checks for
+// cancellation.
+const rowChunk = 64
+
+// parallelRowsContext runs fn over chunks of rows on multiple goroutines,
+// reporting the rows done as stage progress unless stage is empty. Once ctx
+// is cancelled no further chunks are started, and ctx.Err() is returned
+// after the running ones finish.
+func parallelRowsContext(ctx context.Context, h int, stage string, fn func(startY, endY int)) error {
+	const numWorkers = 8
+	var next, done atomic.Int64
+	var wg sync.WaitGroup
+	for worker := 0; worker < numWorkers; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				sy := int(next.Add(rowChunk)) - rowChunk
+				if sy >= h {
+					return
+				}
+				ey := min(sy+rowChunk, h)
+				fn(sy, ey)
+				if stage != "" {
+					progress.Report(ctx, stage, int(done.Add(int64(ey-sy))), h)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
@@ -1,11 +1,15 @@
 package detection
 
 import (
+	"context"
+	"errors"
 	"image"
 	"image/color"
+	"math/rand"
 	"testing"
 
 	mcol "github.com/maax3v3/macoma/v2/internal/color"
+	"github.com/maax3v3/macoma/v2/internal/pool"
 )
 
 // solidImage is a minimal image.Image for testing.
@@ -108,6 +112,83 @@ func TestDetect_ToleranceExpands(t *testing.T) {
 	})
 }
 
+func TestBorderDelimiter_CIEDE2000(t *testing.T) {
+	// Black border color; white, black, dark gray and dark green pixels.
+	// Dark gray is perceptually close to black (ΔE00 about 10) but 16% of
+	// the RGB range away; dark green is the other way round (22 vs 9%).
+	img := newSolidImage(4, 1, color.RGBA{255, 255, 255, 255})
+	img.data[1] = color.RGBA{0, 0, 0, 255}
+	img.data[2] = color.RGBA{40, 40, 40, 255}
+	img.data[3] = color.RGBA{0, 40, 0, 255}
+
+	tests := []struct {
+		metric mcol.Metric
+		want   []bool
+	}{
+		{mcol.MetricCIEDE2000, []bool{false, true, true, false}},
+		{mcol.MetricEuclidean, []bool{false, true, false, true}},
+	}
+	for _, tt := range tests {
+		d := &BorderDelimiter{Color: mcol.RGBA{A: 255}, TolerancePct: 12, Metric: tt.metric}
+		dm := d.Detect(img)
+		for x, w := range tt.want {
+			if dm.At(x, 0) != w {
+				t.Errorf("metric %d: pixel %d: got %v, want %v", tt.metric, x, dm.At(x, 0), w)
+			}
+		}
+	}
+}
+
+func TestBorderDelimiter_LAB(t *testing.T) {
+	// Black and dark navy strokes: 18% of the RGB range apart, but about
+	// 50 apart in CIELAB.
+	img := newSolidImage(3, 1, color.RGBA{255, 255, 255, 255})
+	img.data[1] = color.RGBA{0, 0, 0, 255}
+	img.data[2] = color.RGBA{0, 0, 80, 255}
+
+	tests := []struct {
+		lab  bool
+		want []bool
+	}{
+		{false, []bool{false, true, true}},
+		{true, []bool{false, true, false}},
+	}
+	for _, tt := range tests {
+		d := &BorderDelimiter{Color: mcol.RGBA{A: 255}, TolerancePct: 20, LAB: tt.lab}
+		dm := d.Detect(img)
+		for x, w := range tt.want {
+			if dm.At(x, 0) != w {
+				t.Errorf("LAB %v: pixel %d: got %v, want %v", tt.lab, x, dm.At(x, 0), w)
+			}
+		}
+	}
+}
+
+func TestBorderDelimiter_Extra(t *testing.T) {
+	// White paper, a black stroke, a dark brown stroke and a red fill.
+	img := newSolidImage(4, 1, color.RGBA{255, 255, 255, 255})
+	img.data[1] = color.RGBA{0, 0, 0, 255}
+	img.data[2] = color.RGBA{90, 50, 20, 255}
+	img.data[3] = color.RGBA{220, 30, 30, 255}
+
+	black := &BorderDelimiter{Color: mcol.RGBA{A: 255}, TolerancePct: 10}
+	if dm := black.Detect(img); dm.At(2, 0) {
+		t.Fatal("black alone should miss the brown stroke")
+	}
+
+	both := &BorderDelimiter{
+		Color:        mcol.RGBA{A: 255},
+		TolerancePct: 10,
+		Extra:        []BorderColor{{Color: mcol.RGBA{R: 100, G: 55, B: 25, A: 255}, TolerancePct: 5}},
+	}
+	dm := both.Detect(img)
+	for x, want := range []bool{false, true, true, false} {
+		if dm.At(x, 0) != want {
+			t.Errorf("pixel %d: got %v, want %v", x, dm.At(x, 0), want)
+		}
+	}
+}
+
 func TestBorderDelimiter_ImplementsInterface(t *testing.T) {
 	var _ Delimiter = (*BorderDelimiter)(nil)
 }
@@ -165,6 +246,66 @@ func TestColorDelimiter_TwoHalves(t *testing.T) {
 	}
 }
 
+func TestColorDelimiter_Radius(t *testing.T) {
+	// The boundary band is 2r pixels wide: columns [20-r, 20+r).
+	w, h := 40, 3
+	img := newSolidImage(w, h, color.RGBA{255, 0, 0, 255})
+	for y := 0; y < h; y++ {
+		for x := 20; x < w; x++ {
+			img.data[y*w+x] = color.RGBA{0, 0, 255, 255}
+		}
+	}
+	for _, tt := range []struct{ radius, band int }{{0, 4}, {1, 2}, {2, 4}, {5, 10}} {
+		dm := (&ColorDelimiter{TolerancePct: 5, Radius: tt.radius}).Detect(img)
+		n := 0
+		for x := 0; x < w; x++ {
+			if dm.At(x, 1) {
+				n++
+			}
+		}
+		if n != tt.band {
+			t.Errorf("radius %d: band of %d pixels, want %d", tt.radius, n, tt.band)
+		}
+	}
+}
+
+func TestColorDelimiter_MatchesBruteForce(t *testing.T) {
+	// Random blocks of color, with windows wider than the image at the
+	// largest radius.
+	w, h := 23, 17
+	img := newSolidImage(w, h, color.RGBA{A: 255})
+	rng := rand.New(rand.NewSource(1))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8(rng.Intn(4) * 60)
+			img.data[y*w+x] = color.RGBA{v, uint8(x / 5 * 40), uint8(y / 4 * 50), 255}
+		}
+	}
+	for _, radius := range []int{1, 2, 3, 7, 30} {
+		for _, tol := range []float64{10, 40} {
+			dm := (&ColorDelimiter{TolerancePct: tol, Radius: radius}).Detect(img)
+			threshold := int(tol / 100 * 255)
+			for y := 0; y < h; y++ {
+				for x := 0; x < w; x++ {
+					lo, hi := [3]int{255, 255, 255}, [3]int{}
+					for ny := max(0, y-radius); ny <= min(h-1, y+radius); ny++ {
+						for nx := max(0, x-radius); nx <= min(w-1, x+radius); nx++ {
+							c := img.data[ny*w+nx]
+							for i, v := range []uint8{c.R, c.G, c.B} {
+								lo[i], hi[i] = min(lo[i], int(v)), max(hi[i], int(v))
+							}
+						}
+					}
+					want := hi[0]-lo[0] > threshold || hi[1]-lo[1] > threshold || hi[2]-lo[2] > threshold
+					if dm.At(x, y) != want {
+						t.Fatalf("radius %d, tolerance %v: pixel (%d,%d) is %v, want %v", radius, tol, x, y, dm.At(x, y), want)
+					}
+				}
+			}
+		}
+	}
+}
+
 func TestColorDelimiter_HighTolerance(t *testing.T) {
 	// With very high tolerance (100%), even very different neighbors won't be delimiters
 	w, h := 10, 1
@@ -226,3 +367,93 @@ func TestMap_At(t *testing.T) {
 		}
 	}
 }
+
+func TestDetectContext_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	img := newSolidImage(50, 300, color.RGBA{255, 255, 255, 255})
+	for _, d := range []Delimiter{&BorderDelimiter{TolerancePct: 10}, &ColorDelimiter{TolerancePct: 10}} {
+		if dm, err := d.DetectContext(ctx, img); !errors.Is(err, context.Canceled) || dm != nil {
+			t.Errorf("%T: got %v, %v; want context.Canceled", d, dm, err)
+		}
+	}
+}
+
+func TestParallelRowsContext_CoversAllRows(t *testing.T) {
+	seen := make([]int, 1000)
+	err := parallelRowsContext(context.Background(), len(seen), "", func(sy, ey int) {
+		for y := sy; y < ey; y++ {
+			seen[y]++
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for y, n := range seen {
+		if n != 1 {
+			t.Fatalf("row %d processed %d times", y, n)
+		}
+	}
+}
+
+func TestDetectTiled_MatchesWholeImage(t *testing.T) {
+	w, h := 19, 31
+	img := newSolidImage(w, h, color.RGBA{A: 255})
+	rng := rand.New(rand.NewSource(2))
+	for i := range img.data {
+		v := uint8(rng.Intn(3) * 100)
+		img.data[i] = color.RGBA{v, v, 255 - v, 255}
+	}
+	delims := []Delimiter{
+		&ColorDelimiter{TolerancePct: 20, Radius: 3},
+		&BorderDelimiter{Color: mcol.RGBA{A: 255}, TolerancePct: 10},
+		&CompositeDelimiter{Delimiters: []Delimiter{&ColorDelimiter{TolerancePct: 20}, &BorderDelimiter{Color: mcol.RGBA{A: 255}, TolerancePct: 10}}},
+	}
+	for _, d := range delims {
+		want := d.Detect(img)
+		for _, tile := range []int{1, 4, 10} {
+			got, err := DetectTiled(context.Background(), d, img, tile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range want.IsDelimiter {
+				if got.IsDelimiter[i] != want.IsDelimiter[i] {
+					t.Fatalf("%T, tile height %d: pixel (%d,%d) differs", d, tile, i%w, i/w)
+				}
+			}
+		}
+	}
+}
+
+func TestDetect_PooledBuffers(t *testing.T) {
+	w, h := 19, 31
+	img := newSolidImage(w, h, color.RGBA{A: 255})
+	rng := rand.New(rand.NewSource(3))
+	for i := range img.data {
+		v := uint8(rng.Intn(3) * 100)
+		img.data[i] = color.RGBA{v, v, 255 - v, 255}
+	}
+	d := &ColorDelimiter{TolerancePct: 20}
+	want := d.Detect(img)
+
+	// Buffers left dirty by earlier conversions must not leak into the map
+	p := &pool.Pool{}
+	ctx := pool.WithPool(context.Background(), p)
+	for _, tile := range []int{0, 0, 7, 7} {
+		dirty := make([]bool, w*h)
+		for i := range dirty {
+			dirty[i] = true
+		}
+		p.PutBools(dirty)
+		got, err := DetectTiled(ctx, d, img, tile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range want.IsDelimiter {
+			if got.IsDelimiter[i] != want.IsDelimiter[i] {
+				t.Fatalf("tile height %d: pixel (%d,%d) differs", tile, i%w, i/w)
+			}
+		}
+		p.PutBools(got.IsDelimiter)
+	}
+}
@@ -0,0 +1,49 @@
+package detection
+
+import (
+	"context"
+	"image"
+
+	"github.com/maax3v3/macoma/v2/internal/grid"
+	"github.com/maax3v3/macoma/v2/internal/pool"
+)
+
+// GridDelimiter ignores the image content and marks the lines of a grid of
+// square cells, Cols across, as delimiters, so that every cell becomes a
+// zone. Cell edges fall where grid.Sample puts them, and the image edges
+// frame the grid.
+type GridDelimiter struct {
+	Cols int
+}
+
+// Detect returns the grid lines of an image the size of img.
+func (d *GridDelimiter) Detect(img image.Image) *Map {
+	dm, _ := d.DetectContext(context.Background(), img)
+	return dm
+}
+
+// DetectContext is like Detect; the map only depends on the image size, so
+// ctx is only used for its buffer pool.
+func (d *GridDelimiter) DetectContext(ctx context.Context, img image.Image) (*Map, error) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dm := &Map{Width: w, Height: h, IsDelimiter: pool.From(ctx).Bools(w * h)}
+	if w == 0 || h == 0 {
+		return dm, nil
+	}
+	cols := min(max(d.Cols, 1), w)
+	rows := min(grid.Rows(w, h, cols), h)
+	for i := 0; i <= cols; i++ {
+		x := min(i*w/cols, w-1)
+		for y := 0; y < h; y++ {
+			dm.IsDelimiter[y*w+x] = true
+		}
+	}
+	for j := 0; j <= rows; j++ {
+		row := dm.IsDelimiter[min(j*h/rows, h-1)*w:]
+		for x := 0; x < w; x++ {
+			row[x] = true
+		}
+	}
+	return dm, nil
+}
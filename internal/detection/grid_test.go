@@ -0,0 +1,24 @@
+package detection
+
+import (
+	"image"
+	"testing"
+)
+
+func TestGridDelimiter(t *testing.T) {
+	dm := (&GridDelimiter{Cols: 4}).Detect(image.NewRGBA(image.Rect(0, 0, 40, 20)))
+	// 4 columns of 10 pixels, so 2 rows of 10, framed by the edges
+	for x := 0; x < 40; x++ {
+		for y := 0; y < 20; y++ {
+			want := x%10 == 0 || x == 39 || y%10 == 0 || y == 19
+			if dm.At(x, y) != want {
+				t.Fatalf("(%d, %d): got %v, want %v", x, y, dm.At(x, y), want)
+			}
+		}
+	}
+
+	// More columns than pixels are clamped to the width.
+	if dm := (&GridDelimiter{Cols: 50}).Detect(image.NewRGBA(image.Rect(0, 0, 3, 1))); dm.Count() != 3 {
+		t.Errorf("got %d delimiters, want 3", dm.Count())
+	}
+}
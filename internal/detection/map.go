@@ -0,0 +1,139 @@
+package detection
+
+import (
+	"context"
+
+	"github.com/maax3v3/macoma/v2/internal/pool"
+)
+
+// NewMap allocates an empty (all filler) delimiter map.
+func NewMap(w, h int) *Map {
+	return &Map{
+		Width:       w,
+		Height:      h,
+		IsDelimiter: make([]bool, w*h),
+	}
+}
+
+// newMapContext is NewMap with the slice taken from the pool of ctx, if
+// any.
+func newMapContext(ctx context.Context, w, h int) *Map {
+	return &Map{Width: w, Height: h, IsDelimiter: pool.From(ctx).Bools(w * h)}
+}
+
+// Clone returns a deep copy of the map.
+func (m *Map) Clone() *Map {
+	out := &Map{
+		Width:       m.Width,
+		Height:      m.Height,
+		IsDelimiter: make([]bool, len(m.IsDelimiter)),
+	}
+	copy(out.IsDelimiter, m.IsDelimiter)
+	return out
+}
+
+// Count returns the number of delimiter pixels.
+func (m *Map) Count() int {
+	count := 0
+	for _, d := range m.IsDelimiter {
+		if d {
+			count++
+		}
+	}
+	return count
+}
+
+// Invert returns a new map where delimiter and filler pixels are swapped.
+func (m *Map) Invert() *Map {
+	out := NewMap(m.Width, m.Height)
+	for i, d := range m.IsDelimiter {
+		out.IsDelimiter[i] = !d
+	}
+	return out
+}
+
+// Dilate returns a new map where every pixel within Chebyshev distance k of a
+// delimiter pixel (a (2k+1)×(2k+1) square) is a delimiter. k <= 0 returns a
+// clone.
+func (m *Map) Dilate(k int) *Map {
+	return m.morph(k, false)
+}
+
+// Erode returns a new map where a pixel stays a delimiter only if every
+// in-bounds pixel within Chebyshev distance k is a delimiter. Pixels outside
+// the image do not erode the edges. k <= 0 returns a clone.
+func (m *Map) Erode(k int) *Map {
+	return m.morph(k, true)
+}
+
+// morph applies a square structuring element as two separable 1-D passes
+// (rows, then columns). Each pass uses a sliding window count, so the cost is
+// O(W×H) regardless of k.
+func (m *Map) morph(k int, erode bool) *Map {
+	if k <= 0 {
+		return m.Clone()
+	}
+	w, h := m.Width, m.Height
+	tmp := make([]bool, w*h)
+	out := NewMap(w, h)
+
+	// decide reports the output of a window given how many of its n
+	// in-bounds pixels are delimiters.
+	decide := func(count, n int) bool {
+		if erode {
+			return count == n
+		}
+		return count > 0
+	}
+
+	parallelRows(h, func(sy, ey int) {
+		for y := sy; y < ey; y++ {
+			row := m.IsDelimiter[y*w : (y+1)*w]
+			count := 0
+			for x := 0; x < k && x < w; x++ {
+				if row[x] {
+					count++
+				}
+			}
+			for x := 0; x < w; x++ {
+				if in := x + k; in < w && row[in] {
+					count++
+				}
+				if o := x - k - 1; o >= 0 && row[o] {
+					count--
+				}
+				lo, hi := max(x-k, 0), min(x+k, w-1)
+				tmp[y*w+x] = decide(count, hi-lo+1)
+			}
+		}
+	})
+
+	parallelCols(w, func(sx, ex int) {
+		for x := sx; x < ex; x++ {
+			count := 0
+			for y := 0; y < k && y < h; y++ {
+				if tmp[y*w+x] {
+					count++
+				}
+			}
+			for y := 0; y < h; y++ {
+				if in := y + k; in < h && tmp[in*w+x] {
+					count++
+				}
+				if o := y - k - 1; o >= 0 && tmp[o*w+x] {
+					count--
+				}
+				lo, hi := max(y-k, 0), min(y+k, h-1)
+				out.IsDelimiter[y*w+x] = decide(count, hi-lo+1)
+			}
+		}
+	})
+
+	return out
+}
+
+// parallelCols runs fn across column bands. It mirrors parallelRows for
+// passes that walk the image column by column.
+func parallelCols(w int, fn func(startX, endX int)) {
+	parallelRows(w, fn)
+}
@@ -0,0 +1,127 @@
+package detection
+
+import "testing"
+
+// mapFromRows builds a Map from strings where '#' marks a delimiter pixel.
+func mapFromRows(rows ...string) *Map {
+	h := len(rows)
+	w := len(rows[0])
+	m := NewMap(w, h)
+	for y, row := range rows {
+		for x, ch := range row {
+			m.IsDelimiter[y*w+x] = ch == '#'
+		}
+	}
+	return m
+}
+
+func assertMapRows(t *testing.T, got *Map, rows ...string) {
+	t.Helper()
+	want := mapFromRows(rows...)
+	if got.Width != want.Width || got.Height != want.Height {
+		t.Fatalf("size: got %dx%d, want %dx%d", got.Width, got.Height, want.Width, want.Height)
+	}
+	for i := range want.IsDelimiter {
+		if got.IsDelimiter[i] != want.IsDelimiter[i] {
+			t.Errorf("pixel (%d,%d): got %v, want %v",
+				i%want.Width, i/want.Width, got.IsDelimiter[i], want.IsDelimiter[i])
+		}
+	}
+}
+
+func TestMap_Count(t *testing.T) {
+	m := mapFromRows(
+		"#..",
+		".##",
+	)
+	if got := m.Count(); got != 3 {
+		t.Errorf("Count() = %d, want 3", got)
+	}
+}
+
+func TestMap_CloneIsIndependent(t *testing.T) {
+	m := mapFromRows("#.")
+	c := m.Clone()
+	c.IsDelimiter[1] = true
+	if m.IsDelimiter[1] {
+		t.Error("modifying clone changed the original")
+	}
+}
+
+func TestMap_Invert(t *testing.T) {
+	m := mapFromRows(
+		"#.",
+		".#",
+	)
+	assertMapRows(t, m.Invert(),
+		".#",
+		"#.",
+	)
+}
+
+func TestMap_Dilate(t *testing.T) {
+	m := mapFromRows(
+		".....",
+		".....",
+		"..#..",
+		".....",
+		".....",
+	)
+	assertMapRows(t, m.Dilate(1),
+		".....",
+		".###.",
+		".###.",
+		".###.",
+		".....",
+	)
+	assertMapRows(t, m.Dilate(0),
+		".....",
+		".....",
+		"..#..",
+		".....",
+		".....",
+	)
+}
+
+func TestMap_DilateClipsAtEdges(t *testing.T) {
+	m := mapFromRows(
+		"#...",
+		"....",
+	)
+	assertMapRows(t, m.Dilate(2),
+		"###.",
+		"###.",
+	)
+}
+
+func TestMap_Erode(t *testing.T) {
+	m := mapFromRows(
+		"#####",
+		"#####",
+		"####.",
+	)
+	assertMapRows(t, m.Erode(1),
+		"#####",
+		"###..",
+		"###..",
+	)
+}
+
+func TestMap_ErodeUndoesDilate(t *testing.T) {
+	m := mapFromRows(
+		"......",
+		"......",
+		"..##..",
+		"..##..",
+		"......",
+		"......",
+	)
+	assertMapRows(t, m.Dilate(1).Erode(1),
+		"......",
+		"......",
+		"..##..",
+		"..##..",
+		"......",
+		"......",
+	)
+}
@@ -0,0 +1,59 @@
+package detection
+
+// distanceBins is the number of histogram bins of an automatic border
+// tolerance, each distanceBinWidth tolerance units wide. Distances past the
+// last bin (only possible with ΔE00) share it.
+const (
+	distanceBins     = 256
+	distanceBinWidth = 0.5
+)
+
+// autoFallbackTolerance is the tolerance used when the distances cannot be
+// split, such as in a single-color image.
+const autoFallbackTolerance = 10
+
+// distanceBin returns the histogram bin of a distance to the border color.
+func distanceBin(v float64) uint8 {
+	b := int(v / distanceBinWidth)
+	if b >= distanceBins {
+		b = distanceBins - 1
+	}
+	return uint8(b)
+}
+
+// OtsuThreshold returns the bin t that best splits the histogram into the
+// bins up to t and those above it, by Otsu's method: the split maximizing
+// the between-class variance. Ties go to the middle of the tied bins, so a
+// gap between two peaks is cut in the middle. ok is false when no split
+// has both classes populated, such as when all counts fall in one bin.
+func OtsuThreshold(hist []int) (t int, ok bool) {
+	var total, sum float64
+	for i, n := range hist {
+		total += float64(n)
+		sum += float64(i) * float64(n)
+	}
+
+	var w0, sum0, best float64
+	first, last := -1, -1
+	for i := 0; i < len(hist)-1; i++ {
+		w0 += float64(hist[i])
+		sum0 += float64(i) * float64(hist[i])
+		w1 := total - w0
+		if w0 == 0 || w1 == 0 {
+			continue
+		}
+		mu0 := sum0 / w0
+		mu1 := (sum - sum0) / w1
+		v := w0 * w1 * (mu0 - mu1) * (mu0 - mu1)
+		switch {
+		case v > best:
+			best, first, last = v, i, i
+		case v == best:
+			last = i
+		}
+	}
+	if first == -1 {
+		return 0, false
+	}
+	return (first + last) / 2, true
+}
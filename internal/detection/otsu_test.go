@@ -0,0 +1,64 @@
+package detection
+
+import (
+	"image/color"
+	"testing"
+
+	mcol "github.com/maax3v3/macoma/v2/internal/color"
+)
+
+func TestOtsuThreshold(t *testing.T) {
+	hist := make([]int, 20)
+	hist[2], hist[3] = 10, 5
+	hist[15], hist[16] = 40, 60
+
+	got, ok := OtsuThreshold(hist)
+	if !ok {
+		t.Fatal("expected a split")
+	}
+	// Any cut from bin 3 to 14 separates the peaks; ties go to the middle.
+	if got != 8 {
+		t.Errorf("got bin %d, want 8", got)
+	}
+}
+
+func TestOtsuThreshold_NoSplit(t *testing.T) {
+	for _, hist := range [][]int{{}, {0, 0, 0}, {0, 7, 0}} {
+		if _, ok := OtsuThreshold(hist); ok {
+			t.Errorf("%v: expected no split", hist)
+		}
+	}
+}
+
+func TestBorderDelimiter_Auto(t *testing.T) {
+	// A faded gray pen (30% of the RGB range from black) with lighter
+	// anti-aliasing on cream paper: 10% misses the strokes entirely.
+	w := 20
+	img := newSolidImage(w, 1, color.RGBA{245, 240, 225, 255})
+	for x := 8; x < 12; x++ {
+		img.data[x] = color.RGBA{75, 75, 80, 255}
+	}
+	img.data[7] = color.RGBA{120, 120, 125, 255}
+	img.data[12] = color.RGBA{200, 195, 185, 255}
+
+	fixed := (&BorderDelimiter{Color: mcol.RGBA{A: 255}, TolerancePct: 10}).Detect(img)
+	if fixed.At(9, 0) {
+		t.Fatal("10% tolerance should miss the gray pen")
+	}
+
+	dm := (&BorderDelimiter{Color: mcol.RGBA{A: 255}, Auto: true}).Detect(img)
+	for x := 0; x < w; x++ {
+		want := x >= 7 && x < 12
+		if dm.At(x, 0) != want {
+			t.Errorf("pixel %d: got %v, want %v", x, dm.At(x, 0), want)
+		}
+	}
+}
+
+func TestBorderDelimiter_AutoSingleColor(t *testing.T) {
+	black := (&BorderDelimiter{Color: mcol.RGBA{A: 255}, Auto: true}).Detect(newSolidImage(3, 3, color.RGBA{A: 255}))
+	white := (&BorderDelimiter{Color: mcol.RGBA{A: 255}, Auto: true}).Detect(newSolidImage(3, 3, color.RGBA{255, 255, 255, 255}))
+	if !black.At(1, 1) || white.At(1, 1) {
+		t.Errorf("single color: black %v, white %v; want the fallback tolerance", black.At(1, 1), white.At(1, 1))
+	}
+}
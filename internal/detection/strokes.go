@@ -0,0 +1,134 @@
+package detection
+
+import (
+	"image"
+	"math"
+	"sort"
+)
+
+// maxThicknessBucket is the last bucket of StrokeStats.ThicknessHistogram;
+// it collects every stroke at least that thick.
+const maxThicknessBucket = 16
+
+// Stroke describes one 8-connected component of delimiter pixels.
+type Stroke struct {
+	Bounds    image.Rectangle
+	Area      int     // number of delimiter pixels
+	Perimeter int     // pixel sides facing filler or the image edge
+	Length    float64 // estimated centerline length in pixels
+	Thickness float64 // estimated mean width in pixels
+}
+
+// StrokeStats summarizes the delimiter strokes of a map.
+type StrokeStats struct {
+	Strokes         []Stroke
+	TotalLength     float64
+	MeanThickness   float64 // mean over strokes
+	MedianThickness float64 // median over strokes
+
+	// ThicknessHistogram[t] counts strokes whose thickness rounds to t
+	// pixels; the last bucket collects everything thicker.
+	ThicknessHistogram []int
+}
+
+// AnalyzeStrokes labels the connected components of delimiter pixels and
+// estimates the length and thickness of each one.
+//
+// Each component is approximated by the rectangle with the same area A and
+// perimeter P: its short side T solves T² − (P/2)·T + A = 0, and the length
+// is A / T. This is exact for straight strokes, degrades gracefully for
+// curves, and reports blobs as squares (length ≈ thickness).
+func AnalyzeStrokes(m *Map) StrokeStats {
+	w, h := m.Width, m.Height
+	visited := make([]bool, w*h)
+	stats := StrokeStats{ThicknessHistogram: make([]int, maxThicknessBucket+1)}
+
+	var queue []int
+	for start, isDelim := range m.IsDelimiter {
+		if !isDelim || visited[start] {
+			continue
+		}
+
+		s := Stroke{Bounds: image.Rect(start%w, start/w, start%w+1, start/w+1)}
+		visited[start] = true
+		queue = append(queue[:0], start)
+		for len(queue) > 0 {
+			idx := queue[len(queue)-1]
+			queue = queue[:len(queue)-1]
+			x, y := idx%w, idx/w
+			s.Area++
+			s.Bounds = s.Bounds.Union(image.Rect(x, y, x+1, y+1))
+
+			// Perimeter counts 4-neighbor sides leaving the stroke.
+			for _, d := range [4]image.Point{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+				nx, ny := x+d.X, y+d.Y
+				if nx < 0 || nx >= w || ny < 0 || ny >= h || !m.IsDelimiter[ny*w+nx] {
+					s.Perimeter++
+				}
+			}
+
+			// Connectivity is 8-way so diagonal line segments stay whole.
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					nx, ny := x+dx, y+dy
+					if nx < 0 || nx >= w || ny < 0 || ny >= h {
+						continue
+					}
+					ni := ny*w + nx
+					if m.IsDelimiter[ni] && !visited[ni] {
+						visited[ni] = true
+						queue = append(queue, ni)
+					}
+				}
+			}
+		}
+
+		s.Thickness = rectangleShortSide(float64(s.Area), float64(s.Perimeter))
+		s.Length = float64(s.Area) / s.Thickness
+		stats.Strokes = append(stats.Strokes, s)
+	}
+
+	if len(stats.Strokes) == 0 {
+		return stats
+	}
+
+	thicknesses := make([]float64, len(stats.Strokes))
+	var sum float64
+	for i, s := range stats.Strokes {
+		thicknesses[i] = s.Thickness
+		sum += s.Thickness
+		stats.TotalLength += s.Length
+		bucket := int(math.Round(s.Thickness))
+		if bucket > maxThicknessBucket {
+			bucket = maxThicknessBucket
+		}
+		stats.ThicknessHistogram[bucket]++
+	}
+	stats.MeanThickness = sum / float64(len(thicknesses))
+
+	sort.Float64s(thicknesses)
+	mid := len(thicknesses) / 2
+	if len(thicknesses)%2 == 1 {
+		stats.MedianThickness = thicknesses[mid]
+	} else {
+		stats.MedianThickness = (thicknesses[mid-1] + thicknesses[mid]) / 2
+	}
+
+	return stats
+}
+
+// rectangleShortSide returns the short side of the rectangle with the given
+// area and perimeter. Shapes more compact than a square (discriminant < 0)
+// are treated as squares.
+func rectangleShortSide(area, perimeter float64) float64 {
+	half := perimeter / 2
+	disc := half*half - 4*area
+	if disc < 0 {
+		disc = 0
+	}
+	t := (half - math.Sqrt(disc)) / 2
+	if t < 1 {
+		t = 1
+	}
+	return t
+}
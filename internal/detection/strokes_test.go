@@ -0,0 +1,75 @@
+package detection
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAnalyzeStrokes_Empty(t *testing.T) {
+	stats := AnalyzeStrokes(NewMap(10, 10))
+	if len(stats.Strokes) != 0 {
+		t.Errorf("expected no strokes, got %d", len(stats.Strokes))
+	}
+	if stats.MedianThickness != 0 {
+		t.Errorf("expected zero median thickness, got %f", stats.MedianThickness)
+	}
+}
+
+func TestAnalyzeStrokes_StraightLine(t *testing.T) {
+	// A 40×3 horizontal stroke in the middle of the map.
+	m := NewMap(50, 20)
+	for y := 8; y < 11; y++ {
+		for x := 5; x < 45; x++ {
+			m.IsDelimiter[y*m.Width+x] = true
+		}
+	}
+
+	stats := AnalyzeStrokes(m)
+	if len(stats.Strokes) != 1 {
+		t.Fatalf("expected 1 stroke, got %d", len(stats.Strokes))
+	}
+	s := stats.Strokes[0]
+	if s.Area != 120 {
+		t.Errorf("area: got %d, want 120", s.Area)
+	}
+	if math.Abs(s.Thickness-3) > 0.01 {
+		t.Errorf("thickness: got %f, want 3", s.Thickness)
+	}
+	if math.Abs(s.Length-40) > 0.1 {
+		t.Errorf("length: got %f, want 40", s.Length)
+	}
+	if stats.ThicknessHistogram[3] != 1 {
+		t.Errorf("histogram: expected one stroke in bucket 3, got %v", stats.ThicknessHistogram)
+	}
+}
+
+func TestAnalyzeStrokes_DiagonalIsOneStroke(t *testing.T) {
+	m := NewMap(10, 10)
+	for i := 0; i < 10; i++ {
+		m.IsDelimiter[i*m.Width+i] = true
+	}
+	stats := AnalyzeStrokes(m)
+	if len(stats.Strokes) != 1 {
+		t.Fatalf("expected diagonal to be a single stroke, got %d", len(stats.Strokes))
+	}
+}
+
+func TestAnalyzeStrokes_SeparateComponents(t *testing.T) {
+	m := mapFromRows(
+		"##......",
+		"##......",
+		"........",
+		".....###",
+	)
+	stats := AnalyzeStrokes(m)
+	if len(stats.Strokes) != 2 {
+		t.Fatalf("expected 2 strokes, got %d", len(stats.Strokes))
+	}
+	// A 2×2 blob reads as a square of side 2.
+	if got := stats.Strokes[0].Thickness; math.Abs(got-2) > 0.01 {
+		t.Errorf("blob thickness: got %f, want 2", got)
+	}
+	if got := stats.Strokes[1].Length; math.Abs(got-3) > 0.01 {
+		t.Errorf("segment length: got %f, want 3", got)
+	}
+}
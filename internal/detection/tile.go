@@ -0,0 +1,81 @@
+package detection
+
+import (
+	"context"
+	"image"
+
+	"github.com/maax3v3/macoma/v2/internal/pool"
+	"github.com/maax3v3/macoma/v2/internal/progress"
+)
+
+// Overlapper is implemented by delimiters whose decision for a pixel
+// depends on its neighbors. Overlap returns how many rows of context above
+// and below a band of rows they need to classify it as on the whole image.
+type Overlapper interface {
+	Overlap() int
+}
+
+// Overlap returns the neighborhood radius.
+func (d *ColorDelimiter) Overlap() int {
+	if d.Radius <= 0 {
+		return DefaultColorRadius
+	}
+	return d.Radius
+}
+
+// Overlap returns 0: pixels are classified on their own color. With an
+// automatic tolerance, each band picks its own.
+func (d *BorderDelimiter) Overlap() int { return 0 }
+
+// Overlap returns the largest overlap of the combined delimiters, or -1 if
+// one of them does not implement Overlapper.
+func (d *CompositeDelimiter) Overlap() int {
+	n := 0
+	for _, del := range d.Delimiters {
+		o, ok := del.(Overlapper)
+		if !ok || o.Overlap() < 0 {
+			return -1
+		}
+		n = max(n, o.Overlap())
+	}
+	return n
+}
+
+// DetectTiled runs d over horizontal bands of img, tileHeight rows each,
+// so that the scratch memory of the strategy is bounded by a band instead
+// of the whole image. Each band is extended by d's overlap above and below
+// and only its own rows are kept, so the map matches that of the whole
+// image. Delimiters that do not implement Overlapper, or report a negative
+// overlap, run on the whole image, as does a tileHeight <= 0.
+func DetectTiled(ctx context.Context, d Delimiter, img image.Image, tileHeight int) (*Map, error) {
+	o, ok := d.(Overlapper)
+	b := img.Bounds()
+	if tileHeight <= 0 || tileHeight >= b.Dy() || !ok || o.Overlap() < 0 {
+		return d.DetectContext(ctx, img)
+	}
+	overlap := o.Overlap()
+
+	w, h := b.Dx(), b.Dy()
+	dm := newMapContext(ctx, w, h)
+	for y0 := 0; y0 < h; y0 += tileHeight {
+		y1 := min(y0+tileHeight, h)
+		top, bottom := max(0, y0-overlap), min(h, y1+overlap)
+		band := image.Rect(b.Min.X, b.Min.Y+top, b.Max.X, b.Min.Y+bottom)
+		tile, err := d.DetectContext(progress.Mute(ctx), &window{img, band})
+		if err != nil {
+			return nil, err
+		}
+		copy(dm.IsDelimiter[y0*w:y1*w], tile.IsDelimiter[(y0-top)*w:])
+		pool.From(ctx).PutBools(tile.IsDelimiter)
+		progress.Report(ctx, progress.Detection, y1, h)
+	}
+	return dm, nil
+}
+
+// window is the part r of an image.
+type window struct {
+	image.Image
+	r image.Rectangle
+}
+
+func (w *window) Bounds() image.Rectangle { return w.r }
@@ -0,0 +1,200 @@
+// Package dmc holds the colors of common DMC six-strand embroidery floss,
+// the threads cross-stitch charts are usually written for.
+package dmc
+
+import (
+	"math"
+	"sync"
+
+	"github.com/maax3v3/macoma/v2/internal/color"
+)
+
+// Thread is a DMC floss color.
+type Thread struct {
+	Code  string // the number on the skein, e.g. "321"
+	Name  string
+	Color color.RGBA
+}
+
+// Threads is the floss table, grouped by hue. The sRGB values are the
+// approximations in common use by charting software; dye lots vary.
+var Threads = []Thread{
+	{"B5200", "Snow White", color.RGBA{R: 255, G: 255, B: 255, A: 255}},
+	{"White", "White", color.RGBA{R: 252, G: 251, B: 248, A: 255}},
+	{"3865", "Winter White", color.RGBA{R: 249, G: 247, B: 241, A: 255}},
+	{"Ecru", "Ecru", color.RGBA{R: 240, G: 234, B: 218, A: 255}},
+	{"762", "Pearl Gray Very Light", color.RGBA{R: 236, G: 236, B: 236, A: 255}},
+	{"415", "Pearl Gray", color.RGBA{R: 211, G: 211, B: 214, A: 255}},
+	{"318", "Steel Gray Light", color.RGBA{R: 171, G: 171, B: 171, A: 255}},
+	{"414", "Steel Gray Dark", color.RGBA{R: 140, G: 140, B: 140, A: 255}},
+	{"317", "Pewter Gray", color.RGBA{R: 108, G: 108, B: 108, A: 255}},
+	{"413", "Pewter Gray Dark", color.RGBA{R: 86, G: 86, B: 86, A: 255}},
+	{"3799", "Pewter Gray Very Dark", color.RGBA{R: 66, G: 66, B: 66, A: 255}},
+	{"310", "Black", color.RGBA{R: 0, G: 0, B: 0, A: 255}},
+	{"3072", "Beaver Gray Very Light", color.RGBA{R: 230, G: 232, B: 232, A: 255}},
+	{"648", "Beaver Gray Light", color.RGBA{R: 188, G: 180, B: 172, A: 255}},
+	{"647", "Beaver Gray Medium", color.RGBA{R: 176, G: 166, B: 156, A: 255}},
+	{"646", "Beaver Gray Dark", color.RGBA{R: 135, G: 125, B: 115, A: 255}},
+	{"645", "Beaver Gray Very Dark", color.RGBA{R: 110, G: 101, B: 92, A: 255}},
+	{"844", "Beaver Gray Ultra Dark", color.RGBA{R: 72, G: 72, B: 72, A: 255}},
+	{"822", "Beige Gray Light", color.RGBA{R: 231, G: 226, B: 211, A: 255}},
+	{"644", "Beige Gray Medium", color.RGBA{R: 221, G: 216, B: 203, A: 255}},
+	{"642", "Beige Gray Dark", color.RGBA{R: 164, G: 152, B: 120, A: 255}},
+
+	{"818", "Baby Pink", color.RGBA{R: 255, G: 223, B: 217, A: 255}},
+	{"776", "Pink Medium", color.RGBA{R: 252, G: 176, B: 185, A: 255}},
+	{"899", "Rose Medium", color.RGBA{R: 242, G: 118, B: 136, A: 255}},
+	{"335", "Rose", color.RGBA{R: 238, G: 84, B: 110, A: 255}},
+	{"326", "Rose Very Dark", color.RGBA{R: 179, G: 59, B: 75, A: 255}},
+	{"604", "Cranberry Light", color.RGBA{R: 255, G: 176, B: 190, A: 255}},
+	{"602", "Cranberry Medium", color.RGBA{R: 226, G: 72, B: 116, A: 255}},
+	{"600", "Cranberry Very Dark", color.RGBA{R: 205, G: 47, B: 99, A: 255}},
+	{"3806", "Cyclamen Pink Light", color.RGBA{R: 255, G: 140, B: 174, A: 255}},
+	{"3706", "Melon Medium", color.RGBA{R: 255, G: 173, B: 188, A: 255}},
+	{"3705", "Melon Dark", color.RGBA{R: 255, G: 121, B: 146, A: 255}},
+	{"3687", "Mauve", color.RGBA{R: 201, G: 107, B: 112, A: 255}},
+	{"3350", "Dusty Rose Ultra Dark", color.RGBA{R: 188, G: 67, B: 101, A: 255}},
+	{"353", "Peach", color.RGBA{R: 254, G: 215, B: 204, A: 255}},
+	{"352", "Coral Light", color.RGBA{R: 253, G: 156, B: 151, A: 255}},
+	{"351", "Coral", color.RGBA{R: 233, G: 106, B: 103, A: 255}},
+	{"349", "Coral Dark", color.RGBA{R: 210, G: 16, B: 53, A: 255}},
+	{"817", "Coral Red Very Dark", color.RGBA{R: 187, G: 5, B: 31, A: 255}},
+	{"666", "Bright Red", color.RGBA{R: 227, G: 29, B: 66, A: 255}},
+	{"321", "Red", color.RGBA{R: 199, G: 43, B: 59, A: 255}},
+	{"304", "Red Medium", color.RGBA{R: 183, G: 31, B: 51, A: 255}},
+	{"498", "Red Dark", color.RGBA{R: 167, G: 19, B: 43, A: 255}},
+	{"815", "Garnet Medium", color.RGBA{R: 135, G: 7, B: 31, A: 255}},
+
+	{"718", "Plum", color.RGBA{R: 156, G: 36, B: 98, A: 255}},
+	{"915", "Plum Dark", color.RGBA{R: 130, G: 0, B: 67, A: 255}},
+	{"554", "Violet Light", color.RGBA{R: 219, G: 179, B: 203, A: 255}},
+	{"553", "Violet", color.RGBA{R: 163, G: 99, B: 139, A: 255}},
+	{"552", "Violet Medium", color.RGBA{R: 128, G: 58, B: 107, A: 255}},
+	{"550", "Violet Very Dark", color.RGBA{R: 92, G: 24, B: 78, A: 255}},
+	{"211", "Lavender Light", color.RGBA{R: 227, G: 203, B: 227, A: 255}},
+	{"209", "Lavender Dark", color.RGBA{R: 195, G: 159, B: 195, A: 255}},
+	{"208", "Lavender Very Dark", color.RGBA{R: 131, G: 91, B: 139, A: 255}},
+	{"3747", "Blue Violet Very Light", color.RGBA{R: 211, G: 215, B: 237, A: 255}},
+	{"340", "Blue Violet Medium", color.RGBA{R: 173, G: 167, B: 199, A: 255}},
+	{"333", "Blue Violet Very Dark", color.RGBA{R: 92, G: 84, B: 120, A: 255}},
+
+	{"775", "Baby Blue Very Light", color.RGBA{R: 217, G: 235, B: 241, A: 255}},
+	{"3325", "Baby Blue Light", color.RGBA{R: 184, G: 210, B: 230, A: 255}},
+	{"334", "Baby Blue Medium", color.RGBA{R: 115, G: 159, B: 193, A: 255}},
+	{"322", "Baby Blue Dark", color.RGBA{R: 90, G: 143, B: 184, A: 255}},
+	{"312", "Baby Blue Very Dark", color.RGBA{R: 53, G: 102, B: 139, A: 255}},
+	{"800", "Delft Blue Pale", color.RGBA{R: 192, G: 204, B: 222, A: 255}},
+	{"799", "Delft Blue Medium", color.RGBA{R: 116, G: 142, B: 182, A: 255}},
+	{"798", "Delft Blue Dark", color.RGBA{R: 70, G: 106, B: 142, A: 255}},
+	{"797", "Royal Blue", color.RGBA{R: 19, G: 71, B: 125, A: 255}},
+	{"820", "Royal Blue Very Dark", color.RGBA{R: 14, G: 54, B: 92, A: 255}},
+	{"336", "Navy Blue", color.RGBA{R: 37, G: 59, B: 115, A: 255}},
+	{"823", "Navy Blue Dark", color.RGBA{R: 33, G: 48, B: 99, A: 255}},
+	{"939", "Navy Blue Very Dark", color.RGBA{R: 27, G: 40, B: 83, A: 255}},
+	{"996", "Electric Blue Medium", color.RGBA{R: 48, G: 194, B: 236, A: 255}},
+	{"3843", "Electric Blue", color.RGBA{R: 20, G: 170, B: 208, A: 255}},
+	{"995", "Electric Blue Dark", color.RGBA{R: 38, G: 150, B: 182, A: 255}},
+	{"3846", "Bright Turquoise Light", color.RGBA{R: 6, G: 227, B: 230, A: 255}},
+	{"807", "Peacock Blue", color.RGBA{R: 100, G: 171, B: 186, A: 255}},
+	{"806", "Peacock Blue Dark", color.RGBA{R: 61, G: 149, B: 165, A: 255}},
+	{"598", "Turquoise Light", color.RGBA{R: 144, G: 195, B: 204, A: 255}},
+	{"3810", "Turquoise Dark", color.RGBA{R: 72, G: 142, B: 154, A: 255}},
+
+	{"964", "Sea Green Light", color.RGBA{R: 169, G: 226, B: 216, A: 255}},
+	{"959", "Sea Green Medium", color.RGBA{R: 89, G: 199, B: 180, A: 255}},
+	{"3814", "Aquamarine", color.RGBA{R: 80, G: 139, B: 125, A: 255}},
+	{"911", "Emerald Green Medium", color.RGBA{R: 24, G: 144, B: 101, A: 255}},
+	{"910", "Emerald Green Dark", color.RGBA{R: 24, G: 126, B: 86, A: 255}},
+	{"909", "Emerald Green Very Dark", color.RGBA{R: 21, G: 111, B: 73, A: 255}},
+	{"702", "Kelly Green", color.RGBA{R: 71, G: 167, B: 47, A: 255}},
+	{"700", "Christmas Green Bright", color.RGBA{R: 7, G: 115, B: 27, A: 255}},
+	{"699", "Christmas Green", color.RGBA{R: 5, G: 101, B: 23, A: 255}},
+	{"704", "Chartreuse Bright", color.RGBA{R: 158, G: 207, B: 52, A: 255}},
+	{"703", "Chartreuse", color.RGBA{R: 123, G: 181, B: 71, A: 255}},
+	{"907", "Parrot Green Light", color.RGBA{R: 199, G: 230, B: 102, A: 255}},
+	{"906", "Parrot Green Medium", color.RGBA{R: 127, G: 179, B: 53, A: 255}},
+	{"905", "Parrot Green Dark", color.RGBA{R: 98, G: 138, B: 40, A: 255}},
+	{"904", "Parrot Green Very Dark", color.RGBA{R: 85, G: 120, B: 34, A: 255}},
+	{"3348", "Yellow Green Light", color.RGBA{R: 204, G: 217, B: 177, A: 255}},
+	{"471", "Avocado Green Very Light", color.RGBA{R: 174, G: 191, B: 121, A: 255}},
+	{"469", "Avocado Green", color.RGBA{R: 114, G: 132, B: 60, A: 255}},
+	{"937", "Avocado Green Medium", color.RGBA{R: 98, G: 113, B: 51, A: 255}},
+	{"368", "Pistachio Green Light", color.RGBA{R: 166, G: 194, B: 152, A: 255}},
+	{"367", "Pistachio Green Dark", color.RGBA{R: 97, G: 122, B: 82, A: 255}},
+	{"319", "Pistachio Green Very Dark", color.RGBA{R: 32, G: 95, B: 46, A: 255}},
+	{"890", "Pistachio Green Ultra Dark", color.RGBA{R: 23, G: 73, B: 35, A: 255}},
+	{"3346", "Hunter Green", color.RGBA{R: 64, G: 106, B: 57, A: 255}},
+
+	{"3078", "Golden Yellow Very Light", color.RGBA{R: 253, G: 249, B: 205, A: 255}},
+	{"445", "Lemon Light", color.RGBA{R: 255, G: 251, B: 139, A: 255}},
+	{"307", "Lemon", color.RGBA{R: 253, G: 237, B: 84, A: 255}},
+	{"973", "Canary Bright", color.RGBA{R: 255, G: 227, B: 0, A: 255}},
+	{"444", "Lemon Dark", color.RGBA{R: 255, G: 214, B: 0, A: 255}},
+	{"727", "Topaz Very Light", color.RGBA{R: 255, G: 241, B: 175, A: 255}},
+	{"743", "Yellow Medium", color.RGBA{R: 254, G: 211, B: 118, A: 255}},
+	{"725", "Topaz Medium Light", color.RGBA{R: 255, G: 200, B: 64, A: 255}},
+	{"972", "Canary Deep", color.RGBA{R: 255, G: 181, B: 21, A: 255}},
+	{"783", "Topaz Medium", color.RGBA{R: 206, G: 145, B: 36, A: 255}},
+	{"729", "Old Gold Medium", color.RGBA{R: 208, G: 165, B: 62, A: 255}},
+	{"680", "Old Gold Dark", color.RGBA{R: 188, G: 141, B: 14, A: 255}},
+	{"742", "Tangerine Light", color.RGBA{R: 255, G: 191, B: 87, A: 255}},
+	{"741", "Tangerine Medium", color.RGBA{R: 255, G: 163, B: 43, A: 255}},
+	{"740", "Tangerine", color.RGBA{R: 255, G: 139, B: 0, A: 255}},
+	{"970", "Pumpkin Light", color.RGBA{R: 247, G: 139, B: 19, A: 255}},
+	{"947", "Burnt Orange", color.RGBA{R: 255, G: 123, B: 77, A: 255}},
+	{"946", "Burnt Orange Medium", color.RGBA{R: 235, G: 99, B: 7, A: 255}},
+	{"900", "Burnt Orange Dark", color.RGBA{R: 209, G: 88, B: 7, A: 255}},
+	{"608", "Bright Orange", color.RGBA{R: 253, G: 93, B: 53, A: 255}},
+	{"721", "Orange Spice Medium", color.RGBA{R: 242, G: 120, B: 66, A: 255}},
+	{"3340", "Apricot Medium", color.RGBA{R: 255, G: 131, B: 111, A: 255}},
+	{"3824", "Apricot Light", color.RGBA{R: 254, G: 205, B: 194, A: 255}},
+
+	{"948", "Peach Very Light", color.RGBA{R: 254, G: 231, B: 218, A: 255}},
+	{"754", "Peach Light", color.RGBA{R: 247, G: 203, B: 191, A: 255}},
+	{"951", "Tawny Light", color.RGBA{R: 255, G: 226, B: 207, A: 255}},
+	{"945", "Tawny", color.RGBA{R: 251, G: 213, B: 187, A: 255}},
+	{"402", "Mahogany Very Light", color.RGBA{R: 247, G: 167, B: 119, A: 255}},
+	{"3064", "Desert Sand", color.RGBA{R: 196, G: 142, B: 112, A: 255}},
+	{"922", "Copper Light", color.RGBA{R: 226, G: 115, B: 35, A: 255}},
+	{"920", "Copper Medium", color.RGBA{R: 172, G: 84, B: 20, A: 255}},
+	{"301", "Mahogany Medium", color.RGBA{R: 179, G: 95, B: 43, A: 255}},
+	{"400", "Mahogany Dark", color.RGBA{R: 143, G: 67, B: 15, A: 255}},
+	{"300", "Mahogany Very Dark", color.RGBA{R: 111, G: 47, B: 0, A: 255}},
+	{"3046", "Yellow Beige Medium", color.RGBA{R: 216, G: 188, B: 154, A: 255}},
+	{"3045", "Yellow Beige Dark", color.RGBA{R: 188, G: 150, B: 106, A: 255}},
+	{"738", "Tan Very Light", color.RGBA{R: 236, G: 204, B: 158, A: 255}},
+	{"437", "Tan Light", color.RGBA{R: 228, G: 187, B: 142, A: 255}},
+	{"436", "Tan", color.RGBA{R: 203, G: 144, B: 81, A: 255}},
+	{"435", "Brown Very Light", color.RGBA{R: 184, G: 119, B: 72, A: 255}},
+	{"434", "Brown Light", color.RGBA{R: 152, G: 94, B: 51, A: 255}},
+	{"433", "Brown Medium", color.RGBA{R: 122, G: 69, B: 31, A: 255}},
+	{"801", "Coffee Brown Dark", color.RGBA{R: 101, G: 57, B: 25, A: 255}},
+	{"898", "Coffee Brown Very Dark", color.RGBA{R: 73, G: 42, B: 19, A: 255}},
+	{"938", "Coffee Brown Ultra Dark", color.RGBA{R: 54, G: 31, B: 14, A: 255}},
+	{"3371", "Black Brown", color.RGBA{R: 30, G: 17, B: 8, A: 255}},
+}
+
+var (
+	threadLABOnce sync.Once
+	threadLAB     []color.LAB
+)
+
+// Nearest returns the index in Threads of the floss closest to c in
+// CIELAB space. Alpha is ignored.
+func Nearest(c color.RGBA) int {
+	threadLABOnce.Do(func() {
+		threadLAB = make([]color.LAB, len(Threads))
+		for i, t := range Threads {
+			threadLAB[i] = t.Color.ToLAB()
+		}
+	})
+
+	lab := c.ToLAB()
+	best, bestDist := 0, math.MaxFloat64
+	for i, tl := range threadLAB {
+		if d := color.MetricEuclidean.LABDistance(lab, tl); d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
@@ -0,0 +1,37 @@
+package dmc
+
+import (
+	"testing"
+
+	"github.com/maax3v3/macoma/v2/internal/color"
+)
+
+func TestThreads_UniqueCodes(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, th := range Threads {
+		if seen[th.Code] {
+			t.Errorf("code %s is listed twice", th.Code)
+		}
+		seen[th.Code] = true
+		if th.Color.A != 255 {
+			t.Errorf("%s: floss should be opaque, got alpha %d", th.Code, th.Color.A)
+		}
+	}
+}
+
+func TestNearest(t *testing.T) {
+	tests := []struct {
+		c    color.RGBA
+		want string
+	}{
+		{color.RGBA{A: 255}, "310"},
+		{color.RGBA{R: 255, G: 255, B: 255, A: 255}, "B5200"},
+		{color.RGBA{R: 200, G: 40, B: 60, A: 255}, "321"},
+		{color.RGBA{R: 255, G: 140, B: 0, A: 255}, "740"},
+	}
+	for _, tt := range tests {
+		if got := Threads[Nearest(tt.c)].Code; got != tt.want {
+			t.Errorf("Nearest(%v) = %s, want %s", tt.c, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DXF layer names, so cutters and CAM tools can run or skip the numbers.
+const (
+	DXFOutlineLayer = "OUTLINES"
+	DXFNumberLayer  = "NUMBERS"
+)
+
+// WriteDXF writes gd as an AutoCAD R12 ASCII drawing, the DXF flavor every
+// plotter and laser cutter package reads: each zone border is one LINE on
+// the OUTLINES layer and each zone number a centered TEXT on NUMBERS. Units
+// are image pixels with the y axis flipped, so the drawing is upright.
+func WriteDXF(w io.Writer, gd *GameData, style OutlineStyle) error {
+	bw := bufio.NewWriter(w)
+	h := gd.Height
+	pair := func(code int, value string) {
+		fmt.Fprintf(bw, "%d\n%s\n", code, value)
+	}
+	point := func(code, x, y int) {
+		fmt.Fprintf(bw, "%d\n%d\n%d\n%d\n%d\n0\n", code, x, code+10, h-y, code+20)
+	}
+
+	pair(0, "SECTION")
+	pair(2, "HEADER")
+	pair(9, "$ACADVER")
+	pair(1, "AC1009")
+	pair(9, "$EXTMIN")
+	point(10, 0, h)
+	pair(9, "$EXTMAX")
+	point(10, gd.Width, 0)
+	pair(0, "ENDSEC")
+
+	pair(0, "SECTION")
+	pair(2, "ENTITIES")
+	for _, s := range Borders(gd) {
+		pair(0, "LINE")
+		pair(8, DXFOutlineLayer)
+		point(10, s.From[0], s.From[1])
+		point(11, s.To[0], s.To[1])
+	}
+	for _, z := range gd.Zones {
+		pair(0, "TEXT")
+		pair(8, DXFNumberLayer)
+		// The first point is required but ignored once the text is
+		// aligned: 72=1 centers it and 73=2 sets its middle on point 11.
+		point(10, z.Label[0], z.Label[1])
+		pair(40, fmt.Sprint(style.LabelSize))
+		pair(1, dxfString(style.label(z.Number)))
+		pair(72, "1")
+		point(11, z.Label[0], z.Label[1])
+		pair(73, "2")
+	}
+	pair(0, "ENDSEC")
+	pair(0, "EOF")
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("writing DXF: %w", err)
+	}
+	return nil
+}
+
+// dxfString writes non-ASCII characters as \U+XXXX escapes, which R12
+// files need and every DXF reader understands.
+func dxfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= 0x20 && r < 0x7f {
+			b.WriteRune(r)
+		} else {
+			fmt.Fprintf(&b, "\\U+%04X", r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,78 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/maax3v3/macoma/v2/internal/color"
+)
+
+// epsBatch is how many segments go into one path before it is stroked;
+// PostScript interpreters limit the length of the current path.
+const epsBatch = 500
+
+// WriteEPS writes gd as Encapsulated PostScript line art: the zone borders
+// and numbers, without paper or legend. One image pixel is one point and
+// the y axis is flipped, so the page matches the PNG output.
+func WriteEPS(w io.Writer, gd *GameData, style OutlineStyle) error {
+	line := style.LineColor
+	if line == "" {
+		line = "#000"
+	}
+	c, err := color.ParseHex(line)
+	if err != nil {
+		return fmt.Errorf("line color: %w", err)
+	}
+
+	bw := bufio.NewWriter(w)
+	h := gd.Height
+	fmt.Fprintf(bw, "%%!PS-Adobe-3.0 EPSF-3.0\n%%%%BoundingBox: 0 0 %d %d\n", gd.Width, h)
+	bw.WriteString("%%Creator: macoma\n%%EndComments\n")
+	bw.WriteString("/m {moveto} bind def /l {lineto} bind def\n")
+	// ct centers a string on a point: (s) x y ct
+	bw.WriteString("/ct {moveto dup stringwidth pop -2 div dy rmoveto show} bind def\n")
+	fmt.Fprintf(bw, "1 setlinejoin 1 setlinecap %s setlinewidth\n", formatFloat(style.StrokeWidth))
+	fmt.Fprintf(bw, "%.3f %.3f %.3f setrgbcolor\n", float64(c.R)/255, float64(c.G)/255, float64(c.B)/255)
+
+	for i, s := range Borders(gd) {
+		fmt.Fprintf(bw, "%d %d m %d %d l\n", s.From[0], h-s.From[1], s.To[0], h-s.To[1])
+		if (i+1)%epsBatch == 0 {
+			bw.WriteString("stroke\n")
+		}
+	}
+	bw.WriteString("stroke\n")
+
+	// Cap height is about 0.7 em in Helvetica, so dy centers digits.
+	fmt.Fprintf(bw, "/Helvetica findfont %d scalefont setfont /dy %.2f def\n",
+		style.LabelSize, -0.35*float64(style.LabelSize))
+	for _, z := range gd.Zones {
+		fmt.Fprintf(bw, "(%s) %d %d ct\n", psString(style.label(z.Number)), z.Label[0], h-z.Label[1])
+	}
+	bw.WriteString("showpage\n%%EOF\n")
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("writing EPS: %w", err)
+	}
+	return nil
+}
+
+// psString escapes s for a PostScript string literal. The standard fonts
+// are Latin-1, so other characters become '?'.
+func psString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
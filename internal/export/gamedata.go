@@ -0,0 +1,160 @@
+// Package export builds machine-readable descriptions of a finished
+// conversion for consumers other than the PNG renderer.
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"sync"
+
+	"github.com/maax3v3/macoma/v2/internal/aggregation"
+	"github.com/maax3v3/macoma/v2/internal/color"
+	"github.com/maax3v3/macoma/v2/internal/contour"
+	"github.com/maax3v3/macoma/v2/internal/zone"
+)
+
+// GameDataVersion is the schema version written to GameData.Version. It is
+// bumped on any incompatible change to the JSON layout.
+const GameDataVersion = 1
+
+// Point is an (x, y) pair, encoded as a two-element JSON array.
+type Point [2]int
+
+// GameData is the tap-to-fill description of a coloring: the palette, and
+// for every zone its outline, its number, where to draw the number, and which
+// zones touch it. All coordinates are in source image pixels with the origin
+// at the top-left corner; outline vertices lie on pixel corners.
+type GameData struct {
+	Version int            `json:"version"`
+	Width   int            `json:"width"`
+	Height  int            `json:"height"`
+	Palette []PaletteEntry `json:"palette"`
+	Zones   []GameZone     `json:"zones"`
+}
+
+// PaletteEntry is one legend color.
+type PaletteEntry struct {
+	Number int    `json:"number"` // the number printed in zones and legend
+	Color  string `json:"color"`  // "#rrggbb"
+	Name   string `json:"name"`   // closest CSS color keyword
+}
+
+// GameZone describes one fillable zone.
+type GameZone struct {
+	ID     int `json:"id"`
+	Number int `json:"number"` // palette number the zone must be filled with
+	Area   int `json:"area"`   // zone pixels, excluding delimiter lines
+
+	Centroid Point  `json:"centroid"`
+	Label    Point  `json:"label"`  // interior point where the number is drawn
+	Bounds   [4]int `json:"bounds"` // min x, min y, max x, max y (exclusive)
+
+	// Outline is the exterior ring, clockwise, and Holes the interior rings.
+	// Delimiter lines are shared out to the nearest zone, so outlines tile
+	// the image without gaps and neighbors share their edges exactly.
+	Outline []Point   `json:"outline"`
+	Holes   [][]Point `json:"holes,omitempty"`
+
+	// Neighbors lists the IDs of the zones sharing a border with this one.
+	Neighbors []int `json:"neighbors"`
+}
+
+// BuildGameData assembles GameData from the zones and label map of a w×h
+// image and the color map assigning each zone its number.
+func BuildGameData(zones []zone.Zone, labels []int, w, h int, cm *aggregation.ColorMap) *GameData {
+	gd := &GameData{
+		Version: GameDataVersion,
+		Width:   w,
+		Height:  h,
+		Palette: make([]PaletteEntry, len(cm.Entries)),
+		Zones:   make([]GameZone, len(zones)),
+	}
+	for i, e := range cm.Entries {
+		name, _ := color.ClosestNamed(e.Color)
+		gd.Palette[i] = PaletteEntry{Number: e.Number, Color: e.Color.Hex(), Name: name}
+	}
+
+	expanded := zone.ExpandLabels(labels, w, h)
+	polys := contour.Trace(expanded, w, h, len(zones))
+	adj := zone.Adjacency(labels, w, h, len(zones))
+
+	// Use a simple worker pool, as zone.ComputeZoneColors does
+	work := make(chan int, len(zones))
+	for i := range zones {
+		work <- i
+	}
+	close(work)
+
+	numWorkers := 8
+	if len(zones) < numWorkers {
+		numWorkers = len(zones)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(numWorkers)
+	for w := 0; w < numWorkers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				z := &zones[i]
+				b := z.BoundingBox()
+				gz := GameZone{
+					ID:        z.ID,
+					Number:    cm.Entries[cm.ZoneMap[i]].Number,
+					Area:      z.Area(),
+					Centroid:  toPoint(z.Centroid()),
+					Label:     toPoint(z.InteriorPoint()),
+					Bounds:    [4]int{b.Min.X, b.Min.Y, b.Max.X, b.Max.Y},
+					Outline:   toPoints(polys[i].Outer),
+					Neighbors: adj[i],
+				}
+				for _, hole := range polys[i].Holes {
+					gz.Holes = append(gz.Holes, toPoints(hole))
+				}
+				gd.Zones[i] = gz
+			}
+		}()
+	}
+	wg.Wait()
+
+	return gd
+}
+
+// Encode writes gd as compact JSON; outlines of detailed drawings hold many
+// thousands of points, so indentation would multiply the file size.
+func (gd *GameData) Encode(w io.Writer) error {
+	if err := json.NewEncoder(w).Encode(gd); err != nil {
+		return fmt.Errorf("encoding game data: %w", err)
+	}
+	return nil
+}
+
+// DecodeGameData reads game data written by Encode, such as an answer key
+// for checking a colored sheet. Other schema versions are rejected.
+func DecodeGameData(r io.Reader) (*GameData, error) {
+	var gd GameData
+	if err := json.NewDecoder(r).Decode(&gd); err != nil {
+		return nil, fmt.Errorf("decoding game data: %w", err)
+	}
+	if gd.Version != GameDataVersion {
+		return nil, fmt.Errorf("decoding game data: unsupported version %d, want %d", gd.Version, GameDataVersion)
+	}
+	if gd.Width <= 0 || gd.Height <= 0 {
+		return nil, fmt.Errorf("decoding game data: invalid size %dx%d", gd.Width, gd.Height)
+	}
+	return &gd, nil
+}
+
+func toPoint(p image.Point) Point {
+	return Point{p.X, p.Y}
+}
+
+func toPoints(ring []image.Point) []Point {
+	out := make([]Point, len(ring))
+	for i, p := range ring {
+		out[i] = toPoint(p)
+	}
+	return out
+}
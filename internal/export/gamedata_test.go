@@ -0,0 +1,114 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/maax3v3/macoma/v2/internal/aggregation"
+	"github.com/maax3v3/macoma/v2/internal/color"
+	"github.com/maax3v3/macoma/v2/internal/detection"
+	"github.com/maax3v3/macoma/v2/internal/zone"
+)
+
+// twoZones returns a 5x3 image split by a vertical delimiter at x=2.
+func twoZones() ([]zone.Zone, []int, *aggregation.ColorMap) {
+	dm := detection.NewMap(5, 3)
+	for y := 0; y < 3; y++ {
+		dm.IsDelimiter[y*5+2] = true
+	}
+	zones, labels := zone.FindZones(dm)
+	cm := aggregation.ReduceColors([]color.RGBA{{255, 0, 0, 255}, {0, 0, 255, 255}}, 0)
+	return zones, labels, cm
+}
+
+func TestBuildGameData(t *testing.T) {
+	zones, labels, cm := twoZones()
+	gd := BuildGameData(zones, labels, 5, 3, cm)
+
+	if gd.Version != GameDataVersion || gd.Width != 5 || gd.Height != 3 {
+		t.Errorf("header: got version %d, %dx%d", gd.Version, gd.Width, gd.Height)
+	}
+	if len(gd.Palette) != 2 || len(gd.Zones) != 2 {
+		t.Fatalf("got %d palette entries and %d zones, want 2 and 2", len(gd.Palette), len(gd.Zones))
+	}
+	if gd.Palette[0].Color[0] != '#' || gd.Palette[0].Name == "" {
+		t.Errorf("palette entry: %+v", gd.Palette[0])
+	}
+
+	left := gd.Zones[0]
+	if left.Area != 6 {
+		t.Errorf("area: got %d, want 6", left.Area)
+	}
+	if left.Bounds != [4]int{0, 0, 2, 3} {
+		t.Errorf("bounds: got %v", left.Bounds)
+	}
+	if len(left.Neighbors) != 1 || left.Neighbors[0] != 1 {
+		t.Errorf("neighbors: got %v, want [1]", left.Neighbors)
+	}
+	if len(left.Outline) != 4 {
+		t.Errorf("outline: got %v, want a rectangle", left.Outline)
+	}
+	if left.Number != cm.Entries[cm.ZoneMap[0]].Number {
+		t.Errorf("number: got %d", left.Number)
+	}
+}
+
+func TestBuildGameData_OutlinesTileImage(t *testing.T) {
+	zones, labels, cm := twoZones()
+	gd := BuildGameData(zones, labels, 5, 3, cm)
+
+	// The delimiter column is shared out, so the outline areas add up to the
+	// full image.
+	total := 0
+	for _, z := range gd.Zones {
+		a := 0
+		for i, p := range z.Outline {
+			q := z.Outline[(i+1)%len(z.Outline)]
+			a += p[0]*q[1] - q[0]*p[1]
+		}
+		total += a / 2
+	}
+	if total != 15 {
+		t.Errorf("outline areas sum to %d, want 15", total)
+	}
+}
+
+func TestGameData_Encode(t *testing.T) {
+	zones, labels, cm := twoZones()
+	var buf bytes.Buffer
+	if err := BuildGameData(zones, labels, 5, 3, cm).Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	for _, key := range []string{"version", "width", "height", "palette", "zones"} {
+		if _, ok := decoded[key]; !ok {
+			t.Errorf("missing key %q", key)
+		}
+	}
+}
+
+func TestDecodeGameData(t *testing.T) {
+	zones, labels, cm := twoZones()
+	var buf bytes.Buffer
+	if err := BuildGameData(zones, labels, 5, 3, cm).Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	gd, err := DecodeGameData(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gd.Width != 5 || len(gd.Zones) != 2 || len(gd.Zones[1].Outline) != 4 {
+		t.Errorf("got %+v", gd)
+	}
+
+	for _, in := range []string{`{"version": 2, "width": 5, "height": 3}`, `{"version": 1}`, `[]`} {
+		if _, err := DecodeGameData(strings.NewReader(in)); err == nil {
+			t.Errorf("DecodeGameData(%s): expected error", in)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// FeatureCollection is the zones of a coloring as a GeoJSON (RFC 7946)
+// document, for apps and tools that draw polygons natively. Coordinates
+// are the image pixels of GameData, so y points down.
+type FeatureCollection struct {
+	Type     string    `json:"type"` // always "FeatureCollection"
+	BBox     [4]int    `json:"bbox"` // 0, 0, width, height
+	Features []Feature `json:"features"`
+}
+
+// Feature is one zone: a polygon and its palette entry.
+type Feature struct {
+	Type       string            `json:"type"` // always "Feature"
+	ID         int               `json:"id"`
+	Geometry   Polygon           `json:"geometry"`
+	Properties FeatureProperties `json:"properties"`
+}
+
+// Polygon is a GeoJSON Polygon geometry: the exterior ring, then the
+// holes, each closed by repeating its first vertex.
+type Polygon struct {
+	Type        string    `json:"type"` // always "Polygon"
+	Coordinates [][]Point `json:"coordinates"`
+}
+
+// FeatureProperties carries the game data of a zone besides its outline.
+type FeatureProperties struct {
+	Number    int    `json:"number"`
+	Color     string `json:"color"` // "#rrggbb" of the palette entry
+	Area      int    `json:"area"`
+	Label     Point  `json:"label"`
+	Neighbors []int  `json:"neighbors"`
+}
+
+// GeoJSON returns the zones of gd as a feature collection. GameData rings
+// are clockwise on screen, which with y pointing down is counterclockwise
+// by the numbers, so they already follow the RFC 7946 winding: exteriors
+// counterclockwise, holes clockwise.
+func (gd *GameData) GeoJSON() *FeatureCollection {
+	colors := make(map[int]string, len(gd.Palette))
+	for _, p := range gd.Palette {
+		colors[p.Number] = p.Color
+	}
+	fc := &FeatureCollection{
+		Type:     "FeatureCollection",
+		BBox:     [4]int{0, 0, gd.Width, gd.Height},
+		Features: make([]Feature, len(gd.Zones)),
+	}
+	for i, z := range gd.Zones {
+		rings := make([][]Point, 0, 1+len(z.Holes))
+		rings = append(rings, closeRing(z.Outline))
+		for _, h := range z.Holes {
+			rings = append(rings, closeRing(h))
+		}
+		fc.Features[i] = Feature{
+			Type:     "Feature",
+			ID:       z.ID,
+			Geometry: Polygon{Type: "Polygon", Coordinates: rings},
+			Properties: FeatureProperties{
+				Number:    z.Number,
+				Color:     colors[z.Number],
+				Area:      z.Area,
+				Label:     z.Label,
+				Neighbors: z.Neighbors,
+			},
+		}
+	}
+	return fc
+}
+
+// Encode writes fc as compact JSON, like GameData.Encode.
+func (fc *FeatureCollection) Encode(w io.Writer) error {
+	if err := json.NewEncoder(w).Encode(fc); err != nil {
+		return fmt.Errorf("encoding GeoJSON: %w", err)
+	}
+	return nil
+}
+
+// closeRing returns ring with its first vertex repeated at the end, as
+// GeoJSON requires.
+func closeRing(ring []Point) []Point {
+	if len(ring) == 0 || ring[0] == ring[len(ring)-1] {
+		return ring
+	}
+	out := make([]Point, len(ring)+1)
+	copy(out, ring)
+	out[len(ring)] = ring[0]
+	return out
+}
@@ -0,0 +1,55 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestGameData_GeoJSON(t *testing.T) {
+	zones, labels, cm := twoZones()
+	fc := BuildGameData(zones, labels, 5, 3, cm).GeoJSON()
+
+	if fc.Type != "FeatureCollection" || fc.BBox != [4]int{0, 0, 5, 3} || len(fc.Features) != 2 {
+		t.Fatalf("got %s %v with %d features", fc.Type, fc.BBox, len(fc.Features))
+	}
+	for _, f := range fc.Features {
+		if f.Type != "Feature" || f.Geometry.Type != "Polygon" {
+			t.Errorf("feature %d: got %s of %s", f.ID, f.Type, f.Geometry.Type)
+		}
+		ring := f.Geometry.Coordinates[0]
+		if len(ring) < 4 || ring[0] != ring[len(ring)-1] {
+			t.Errorf("feature %d: exterior ring %v is not closed", f.ID, ring)
+		}
+		// Shoelace sum: positive is counterclockwise by the numbers.
+		area := 0
+		for i := 0; i+1 < len(ring); i++ {
+			area += ring[i][0]*ring[i+1][1] - ring[i+1][0]*ring[i][1]
+		}
+		if area <= 0 {
+			t.Errorf("feature %d: exterior ring %v should be counterclockwise", f.ID, ring)
+		}
+	}
+	if p := fc.Features[0].Properties; p.Number != 1 || p.Color != "#ff0000" || len(p.Neighbors) != 1 {
+		t.Errorf("properties: got %+v", p)
+	}
+
+	var buf bytes.Buffer
+	if err := fc.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil || doc["type"] != "FeatureCollection" {
+		t.Errorf("encoded document: %v, %v", doc["type"], err)
+	}
+}
+
+func TestCloseRing(t *testing.T) {
+	if got := closeRing([]Point{{0, 0}, {1, 0}, {1, 1}}); len(got) != 4 || got[3] != (Point{0, 0}) {
+		t.Errorf("got %v, want the first vertex repeated", got)
+	}
+	closed := []Point{{0, 0}, {1, 0}, {1, 1}, {0, 0}}
+	if got := closeRing(closed); len(got) != 4 {
+		t.Errorf("a closed ring should be unchanged, got %v", got)
+	}
+}
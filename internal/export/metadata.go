@@ -0,0 +1,84 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/maax3v3/macoma/v2/internal/aggregation"
+	"github.com/maax3v3/macoma/v2/internal/color"
+	"github.com/maax3v3/macoma/v2/internal/zone"
+)
+
+// MetadataVersion is the schema version written to Metadata.Version.
+const MetadataVersion = 1
+
+// Metadata describes a rendered coloring for apps that consume its legend
+// programmatically: the palette with per-color zone counts, the image
+// dimensions and the options it was rendered with. The palette has the same
+// shape as in GameData, so a metadata file can be replayed with --palette-in.
+type Metadata struct {
+	Version int `json:"version"`
+
+	// Width and Height are the dimensions of the rendered image; the legend
+	// occupies the rows from LegendTop down.
+	Width     int `json:"width"`
+	Height    int `json:"height"`
+	LegendTop int `json:"legend_top"`
+
+	Palette []MetadataColor `json:"palette"`
+	Options any             `json:"options"`
+}
+
+// MetadataColor is one legend color and how many zones use it.
+type MetadataColor struct {
+	Number   int      `json:"number"`
+	Label    string   `json:"label,omitempty"` // drawn instead of the number, with a label set
+	Color    string   `json:"color"`           // "#rrggbb"
+	RGB      [3]uint8 `json:"rgb"`
+	Name     string   `json:"name"` // closest CSS color keyword
+	Zones    int      `json:"zones"`
+	Coverage float64  `json:"coverage"` // fraction of the painted area
+}
+
+// BuildMetadata assembles Metadata for an image of w×h pixels whose drawing
+// ends at legendTop. options is encoded as given.
+func BuildMetadata(zones []zone.Zone, cm *aggregation.ColorMap, w, h, legendTop int, options any) *Metadata {
+	md := &Metadata{
+		Version:   MetadataVersion,
+		Width:     w,
+		Height:    h,
+		LegendTop: legendTop,
+		Palette:   make([]MetadataColor, len(cm.Entries)),
+		Options:   options,
+	}
+	areas := make([]int, len(zones))
+	for i := range zones {
+		areas[i] = zones[i].Area()
+	}
+	coverage := cm.Coverage(areas)
+	for i, e := range cm.Entries {
+		name, _ := color.ClosestNamed(e.Color)
+		md.Palette[i] = MetadataColor{
+			Number:   e.Number,
+			Color:    e.Color.Hex(),
+			RGB:      [3]uint8{e.Color.R, e.Color.G, e.Color.B},
+			Name:     name,
+			Coverage: coverage[i],
+		}
+	}
+	for i := range zones {
+		md.Palette[cm.ZoneMap[i]].Zones++
+	}
+	return md
+}
+
+// Encode writes md as indented JSON.
+func (md *Metadata) Encode(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(md); err != nil {
+		return fmt.Errorf("encoding metadata: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBuildMetadata(t *testing.T) {
+	zones, _, cm := twoZones()
+	md := BuildMetadata(zones, cm, 5, 20, 3, map[string]int{"max_colors": 2})
+
+	if md.Version != MetadataVersion || md.Width != 5 || md.Height != 20 || md.LegendTop != 3 {
+		t.Errorf("header: got %+v", md)
+	}
+	if len(md.Palette) != 2 {
+		t.Fatalf("got %d palette entries, want 2", len(md.Palette))
+	}
+	total := 0.0
+	for _, c := range md.Palette {
+		if c.Zones != 1 {
+			t.Errorf("color %d: got %d zones, want 1", c.Number, c.Zones)
+		}
+		if c.Color[0] != '#' || c.Name == "" {
+			t.Errorf("color %d: %+v", c.Number, c)
+		}
+		total += c.Coverage
+	}
+	if total < 0.999 || total > 1.001 {
+		t.Errorf("coverage sums to %f, want 1", total)
+	}
+}
+
+func TestMetadata_ReadableAsPalette(t *testing.T) {
+	zones, _, cm := twoZones()
+	var buf bytes.Buffer
+	if err := BuildMetadata(zones, cm, 5, 20, 3, nil).Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := DecodePalette(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Color != cm.Entries[0].Color {
+		t.Errorf("got %+v, want the palette of the color map", entries)
+	}
+}
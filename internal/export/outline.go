@@ -0,0 +1,87 @@
+package export
+
+// Segment is a horizontal or vertical stretch of zone border between two
+// pixel corners, From above or left of To.
+type Segment struct {
+	From, To Point
+}
+
+// OutlineStyle sizes the line-art outputs, EPS and DXF: zone borders and
+// numbers, without paper or legend.
+type OutlineStyle struct {
+	LabelSize   int     // text height of zone numbers, in pixels
+	StrokeWidth float64 // width of zone outlines, in pixels (EPS only)
+	LineColor   string  // "#rrggbb"; empty means black (EPS only)
+
+	// Labels replaces palette numbers by text as in SVGStyle.
+	Labels []string
+}
+
+// label returns the text drawn for palette number n.
+func (s OutlineStyle) label(n int) string {
+	return labelText(s.Labels, n)
+}
+
+// Borders returns the zone borders of gd as maximal horizontal and
+// vertical segments, each drawn once. Neighboring outlines share their
+// edges, so tracing every ring would make a plotter or cutter go over each
+// border twice. Segments are ordered row by row, then column by column.
+func Borders(gd *GameData) []Segment {
+	w, h := gd.Width, gd.Height
+	if w <= 0 || h <= 0 {
+		return nil
+	}
+	// horiz[y*w+x] is the unit edge from (x, y) to (x+1, y); vert[x*h+y]
+	// the one from (x, y) to (x, y+1).
+	horiz := make([]bool, w*(h+1))
+	vert := make([]bool, (w+1)*h)
+	mark := func(ring []Point) {
+		for i := range ring {
+			a, b := ring[i], ring[(i+1)%len(ring)]
+			if a[1] == b[1] {
+				for x := min(a[0], b[0]); x < max(a[0], b[0]); x++ {
+					horiz[a[1]*w+x] = true
+				}
+			} else if a[0] == b[0] {
+				for y := min(a[1], b[1]); y < max(a[1], b[1]); y++ {
+					vert[a[0]*h+y] = true
+				}
+			}
+		}
+	}
+	for _, z := range gd.Zones {
+		mark(z.Outline)
+		for _, hole := range z.Holes {
+			mark(hole)
+		}
+	}
+
+	var segs []Segment
+	for y := 0; y <= h; y++ {
+		row := horiz[y*w : (y+1)*w]
+		for x := 0; x < w; x++ {
+			if !row[x] {
+				continue
+			}
+			x0 := x
+			for x < w && row[x] {
+				x++
+			}
+			segs = append(segs, Segment{Point{x0, y}, Point{x, y}})
+		}
+	}
+	for x := 0; x <= w; x++ {
+		col := vert[x*h : (x+1)*h]
+		for y := 0; y < h; y++ {
+			if !col[y] {
+				continue
+			}
+			y0 := y
+			for y < h && col[y] {
+				y++
+			}
+			segs = append(segs, Segment{Point{x, y0}, Point{x, y}})
+		}
+	}
+	return segs
+}
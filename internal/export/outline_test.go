@@ -0,0 +1,83 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// twoSquares is a 2x1 image split into two one-pixel zones.
+func twoSquares() *GameData {
+	return &GameData{
+		Width: 2, Height: 1,
+		Zones: []GameZone{
+			{ID: 1, Number: 1, Label: Point{0, 0}, Outline: []Point{{0, 0}, {1, 0}, {1, 1}, {0, 1}}},
+			{ID: 2, Number: 2, Label: Point{1, 0}, Outline: []Point{{1, 0}, {2, 0}, {2, 1}, {1, 1}}},
+		},
+	}
+}
+
+func TestBorders(t *testing.T) {
+	got := Borders(twoSquares())
+	want := []Segment{
+		{Point{0, 0}, Point{2, 0}},
+		{Point{0, 1}, Point{2, 1}},
+		{Point{0, 0}, Point{0, 1}},
+		{Point{1, 0}, Point{1, 1}}, // shared, drawn once
+		{Point{2, 0}, Point{2, 1}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("segment %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestWriteEPS(t *testing.T) {
+	var buf bytes.Buffer
+	style := OutlineStyle{LabelSize: 10, StrokeWidth: 1, Labels: []string{"(a)"}}
+	if err := WriteEPS(&buf, twoSquares(), style); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"%!PS-Adobe-3.0 EPSF-3.0\n",
+		"%%BoundingBox: 0 0 2 1\n",
+		"0 1 m 2 1 l\n", // top border, y flipped
+		"(\\(a\\)) 0 1 ct\n",
+		"(2) 1 1 ct\n",
+		"%%EOF\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if err := WriteEPS(&buf, twoSquares(), OutlineStyle{LineColor: "nope"}); err == nil {
+		t.Error("expected an error for a bad line color")
+	}
+}
+
+func TestWriteDXF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteDXF(&buf, twoSquares(), OutlineStyle{LabelSize: 10, Labels: []string{"é"}}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if n := strings.Count(out, "\nLINE\n"); n != 5 {
+		t.Errorf("got %d LINE entities, want 5", n)
+	}
+	if n := strings.Count(out, "\nTEXT\n"); n != 2 {
+		t.Errorf("got %d TEXT entities, want 2", n)
+	}
+	for _, want := range []string{"AC1009", "\n8\n" + DXFOutlineLayer + "\n", "\n1\n\\U+00E9\n", "0\nEOF\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	if !strings.HasPrefix(out, "0\nSECTION\n") {
+		t.Errorf("unexpected start: %q", out[:20])
+	}
+}
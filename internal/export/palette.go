@@ -0,0 +1,156 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/maax3v3/macoma/v2/internal/aggregation"
+	"github.com/maax3v3/macoma/v2/internal/color"
+)
+
+// DecodePalette reads the numbered palette of an earlier conversion from
+// any JSON document with a top-level "palette" array of PaletteEntry, such
+// as game data. Numbers must be positive and unique.
+func DecodePalette(r io.Reader) ([]aggregation.ColorEntry, error) {
+	var doc struct {
+		Palette []PaletteEntry `json:"palette"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decoding palette: %w", err)
+	}
+	if len(doc.Palette) == 0 {
+		return nil, fmt.Errorf("decoding palette: no \"palette\" entries")
+	}
+
+	entries := make([]aggregation.ColorEntry, len(doc.Palette))
+	seen := make(map[int]bool, len(doc.Palette))
+	for i, p := range doc.Palette {
+		if p.Number <= 0 {
+			return nil, fmt.Errorf("decoding palette: entry %d: number must be positive, got %d", i, p.Number)
+		}
+		if seen[p.Number] {
+			return nil, fmt.Errorf("decoding palette: number %d appears twice", p.Number)
+		}
+		seen[p.Number] = true
+		c, err := color.ParseHex(p.Color)
+		if err != nil {
+			return nil, fmt.Errorf("decoding palette: number %d: %w", p.Number, err)
+		}
+		entries[i] = aggregation.ColorEntry{Number: p.Number, Color: c}
+	}
+	return entries, nil
+}
+
+// DecodeColors reads a plain palette: one hex color per line, optionally
+// followed by whitespace and a name ("#ff0000 Red"), in palette order.
+// Blank lines and lines starting with "//" are skipped.
+func DecodeColors(r io.Reader) ([]color.RGBA, error) {
+	var colors []color.RGBA
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "//") {
+			continue
+		}
+		c, err := color.ParseHex(strings.Fields(text)[0])
+		if err != nil {
+			return nil, fmt.Errorf("decoding palette: line %d: %w", line, err)
+		}
+		colors = append(colors, c)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("decoding palette: %w", err)
+	}
+	if len(colors) == 0 {
+		return nil, fmt.Errorf("decoding palette: no colors")
+	}
+	return colors, nil
+}
+
+// EncodePalette writes entries as a JSON document with a "palette" array,
+// in the format read back by DecodePalette.
+func EncodePalette(w io.Writer, entries []aggregation.ColorEntry) error {
+	doc := struct {
+		Palette []PaletteEntry `json:"palette"`
+	}{Palette: make([]PaletteEntry, len(entries))}
+	for i, e := range entries {
+		name, _ := color.ClosestNamed(e.Color)
+		doc.Palette[i] = PaletteEntry{Number: e.Number, Color: e.Color.Hex(), Name: name}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("encoding palette: %w", err)
+	}
+	return nil
+}
+
+// EncodeColors writes a plain palette in the format read back by
+// DecodeColors: one hex color per line, followed by its closest CSS color
+// name.
+func EncodeColors(w io.Writer, colors []color.RGBA) error {
+	bw := bufio.NewWriter(w)
+	for _, c := range colors {
+		name, _ := color.ClosestNamed(c)
+		fmt.Fprintf(bw, "%s %s\n", c.Hex(), name)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("encoding palette: %w", err)
+	}
+	return nil
+}
+
+// EncodeGPL writes entries as a GIMP palette named name, which GIMP,
+// Inkscape and Krita import: one "R G B" row per color, in order, named
+// with its number and closest CSS color name.
+func EncodeGPL(w io.Writer, name string, entries []aggregation.ColorEntry) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "GIMP Palette\nName: %s\n#\n", strings.ReplaceAll(name, "\n", " "))
+	for _, e := range entries {
+		cname, _ := color.ClosestNamed(e.Color)
+		fmt.Fprintf(bw, "%3d %3d %3d\t%d %s\n", e.Color.R, e.Color.G, e.Color.B, e.Number, cname)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("encoding palette: %w", err)
+	}
+	return nil
+}
+
+// DecodeGPL reads the colors of a GIMP palette in order. The header,
+// comments and the names after the channels are skipped.
+func DecodeGPL(r io.Reader) ([]color.RGBA, error) {
+	sc := bufio.NewScanner(r)
+	if !sc.Scan() || strings.TrimSpace(sc.Text()) != "GIMP Palette" {
+		if err := sc.Err(); err != nil {
+			return nil, fmt.Errorf("decoding palette: %w", err)
+		}
+		return nil, fmt.Errorf("decoding palette: missing \"GIMP Palette\" header")
+	}
+	var colors []color.RGBA
+	for line := 2; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, "Name:") || strings.HasPrefix(text, "Columns:") {
+			continue
+		}
+		var rgb [3]int
+		if n, _ := fmt.Sscan(text, &rgb[0], &rgb[1], &rgb[2]); n != 3 {
+			return nil, fmt.Errorf("decoding palette: line %d: want \"R G B\", got %q", line, text)
+		}
+		for _, v := range rgb {
+			if v < 0 || v > 255 {
+				return nil, fmt.Errorf("decoding palette: line %d: channel %d out of range 0-255", line, v)
+			}
+		}
+		colors = append(colors, color.RGBA{R: uint8(rgb[0]), G: uint8(rgb[1]), B: uint8(rgb[2]), A: 255})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("decoding palette: %w", err)
+	}
+	if len(colors) == 0 {
+		return nil, fmt.Errorf("decoding palette: no colors")
+	}
+	return colors, nil
+}
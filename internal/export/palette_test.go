@@ -0,0 +1,145 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/maax3v3/macoma/v2/internal/color"
+)
+
+func TestDecodePalette_GameDataRoundTrip(t *testing.T) {
+	zones, labels, cm := twoZones()
+	var buf bytes.Buffer
+	if err := BuildGameData(zones, labels, 5, 3, cm).Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := DecodePalette(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(cm.Entries) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(cm.Entries))
+	}
+	for i, e := range entries {
+		if e != cm.Entries[i] {
+			t.Errorf("entry %d: got %+v, want %+v", i, e, cm.Entries[i])
+		}
+	}
+}
+
+func TestDecodePalette_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"not json":         `palette`,
+		"empty":            `{"palette": []}`,
+		"duplicate number": `{"palette": [{"number": 1, "color": "#ff0000"}, {"number": 1, "color": "#00ff00"}]}`,
+		"zero number":      `{"palette": [{"number": 0, "color": "#ff0000"}]}`,
+		"bad color":        `{"palette": [{"number": 1, "color": "red"}]}`,
+	}
+	for name, doc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := DecodePalette(strings.NewReader(doc)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestDecodeColors(t *testing.T) {
+	doc := "// My crayons\n#ff0000 Red\n\n#00F\tBlue\n  #123456  \n"
+	colors, err := DecodeColors(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"#ff0000", "#0000ff", "#123456"}
+	if len(colors) != len(want) {
+		t.Fatalf("got %d colors, want %d", len(colors), len(want))
+	}
+	for i, c := range colors {
+		if c.Hex() != want[i] {
+			t.Errorf("color %d: got %s, want %s", i, c.Hex(), want[i])
+		}
+	}
+
+	for name, doc := range map[string]string{
+		"empty":     "// nothing\n\n",
+		"bad color": "#ff0000\nred\n",
+	} {
+		if _, err := DecodeColors(strings.NewReader(doc)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestEncodePalette_RoundTrip(t *testing.T) {
+	_, _, cm := twoZones()
+	var buf bytes.Buffer
+	if err := EncodePalette(&buf, cm.Entries); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := DecodePalette(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(cm.Entries) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(cm.Entries))
+	}
+	for i, e := range entries {
+		if e != cm.Entries[i] {
+			t.Errorf("entry %d: got %+v, want %+v", i, e, cm.Entries[i])
+		}
+	}
+}
+
+func TestEncodeColors_RoundTrip(t *testing.T) {
+	_, _, cm := twoZones()
+	colors := []color.RGBA{cm.Entries[0].Color, cm.Entries[1].Color}
+	var buf bytes.Buffer
+	if err := EncodeColors(&buf, colors); err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecodeColors(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(colors) || got[0] != colors[0] || got[1] != colors[1] {
+		t.Errorf("got %v, want %v", got, colors)
+	}
+}
+
+func TestEncodeGPL_RoundTrip(t *testing.T) {
+	_, _, cm := twoZones()
+	var buf bytes.Buffer
+	if err := EncodeGPL(&buf, "series", cm.Entries); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "GIMP Palette\nName: series\n") {
+		t.Errorf("missing header:\n%s", buf.String())
+	}
+	got, err := DecodeGPL(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(cm.Entries) {
+		t.Fatalf("got %d colors, want %d", len(got), len(cm.Entries))
+	}
+	for i, c := range got {
+		if want := cm.Entries[i].Color; c != want {
+			t.Errorf("color %d: got %v, want %v", i, c, want)
+		}
+	}
+}
+
+func TestDecodeGPL_Invalid(t *testing.T) {
+	for name, doc := range map[string]string{
+		"no header":    "255 0 0 Red\n",
+		"no colors":    "GIMP Palette\nName: empty\n#\n",
+		"short row":    "GIMP Palette\n255 0\n",
+		"out of range": "GIMP Palette\n256 0 0 Red\n",
+	} {
+		if _, err := DecodeGPL(strings.NewReader(doc)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package export
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/maax3v3/macoma/v2/internal/color"
+)
+
+// SVGStyle sizes the elements of a vector coloring. The legend fields have
+// the same meaning as in the PNG renderer's Config, so both outputs share
+// one layout.
+type SVGStyle struct {
+	LabelSize   int     // font size of zone numbers, in pixels
+	StrokeWidth float64 // width of zone outlines, in pixels
+
+	LegendPadding    int
+	LegendCircleSize int
+	LegendSpacing    int
+	LegendMargin     int
+	NoLegend         bool // leave the legend off, as the PNG does
+
+	// Background and LineColor are the paper and zone border colors, as
+	// "#rrggbb"; empty means white and black.
+	Background string
+	LineColor  string
+
+	// Labels replaces palette numbers by text as in the renderer's Config:
+	// number n is drawn as Labels[n-1] when that is set.
+	Labels []string
+}
+
+// writeLabel writes the text drawn for palette number n, escaped for XML.
+func (s SVGStyle) writeLabel(bw *bufio.Writer, n int) {
+	xml.EscapeText(bw, []byte(labelText(s.Labels, n)))
+}
+
+// labelText returns labels[n-1], or n itself when that is not set.
+func labelText(labels []string, n int) string {
+	if n >= 1 && n <= len(labels) && labels[n-1] != "" {
+		return labels[n-1]
+	}
+	return strconv.Itoa(n)
+}
+
+// WriteSVG writes gd as a standalone SVG document: one outlined path per
+// zone with its number, and the color legend below the drawing. Coordinates
+// are source image pixels, so the SVG overlays the PNG output exactly.
+func WriteSVG(w io.Writer, gd *GameData, style SVGStyle) error {
+	bw := bufio.NewWriter(w)
+
+	n := len(gd.Palette)
+	if style.NoLegend {
+		n = 0
+	}
+	legend := newSVGLegend(n, gd.Width, style)
+	totalH := gd.Height + legend.height
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		gd.Width, totalH, gd.Width, totalH)
+	paper, line := style.Background, style.LineColor
+	if paper == "" {
+		paper = "#fff"
+	}
+	if line == "" {
+		line = "#000"
+	}
+	fmt.Fprintf(bw, `<rect width="%d" height="%d" fill="%s"/>`+"\n", gd.Width, totalH, paper)
+
+	fmt.Fprintf(bw, `<g fill="%s" fill-rule="evenodd" stroke="%s" stroke-width="%s" stroke-linejoin="round">`+"\n",
+		paper, line, formatFloat(style.StrokeWidth))
+	for _, z := range gd.Zones {
+		fmt.Fprintf(bw, `<path id="zone-%d" data-number="%d" d="`, z.ID, z.Number)
+		writeRing(bw, z.Outline)
+		for _, hole := range z.Holes {
+			writeRing(bw, hole)
+		}
+		bw.WriteString("\"/>\n")
+	}
+	bw.WriteString("</g>\n")
+
+	fmt.Fprintf(bw, `<g font-family="sans-serif" font-size="%d" text-anchor="middle" dominant-baseline="central">`+"\n", style.LabelSize)
+	for _, z := range gd.Zones {
+		fmt.Fprintf(bw, `<text x="%d" y="%d">`, z.Label[0], z.Label[1])
+		style.writeLabel(bw, z.Number)
+		bw.WriteString("</text>\n")
+	}
+	bw.WriteString("</g>\n")
+
+	if err := legend.write(bw, gd); err != nil {
+		return err
+	}
+
+	bw.WriteString("</svg>\n")
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("writing SVG: %w", err)
+	}
+	return nil
+}
+
+// writeRing appends a closed subpath. Traced outlines only have horizontal
+// and vertical edges, so H and V commands keep the path short.
+func writeRing(bw *bufio.Writer, ring []Point) {
+	if len(ring) == 0 {
+		return
+	}
+	fmt.Fprintf(bw, "M%d %d", ring[0][0], ring[0][1])
+	for i := 1; i < len(ring); i++ {
+		prev, p := ring[i-1], ring[i]
+		switch {
+		case p[1] == prev[1]:
+			fmt.Fprintf(bw, "H%d", p[0])
+		case p[0] == prev[0]:
+			fmt.Fprintf(bw, "V%d", p[1])
+		default:
+			fmt.Fprintf(bw, "L%d %d", p[0], p[1])
+		}
+	}
+	bw.WriteByte('Z')
+}
+
+// svgLegend mirrors the PNG legend layout: items wrap into centered rows.
+type svgLegend struct {
+	style       SVGStyle
+	width       int
+	itemWidth   int
+	itemsPerRow int
+	height      int
+}
+
+func newSVGLegend(n, width int, style SVGStyle) svgLegend {
+	l := svgLegend{style: style, width: width, itemWidth: style.LegendCircleSize + style.LegendSpacing}
+	if n == 0 || l.itemWidth <= 0 {
+		return l
+	}
+	l.itemsPerRow = max((width-2*style.LegendMargin)/l.itemWidth, 1)
+	rows := (n + l.itemsPerRow - 1) / l.itemsPerRow
+	l.height = style.LegendPadding + rows*(style.LegendCircleSize+style.LegendSpacing) + style.LegendPadding
+	return l
+}
+
+func (l svgLegend) write(bw *bufio.Writer, gd *GameData) error {
+	if l.height == 0 {
+		return nil
+	}
+	s := l.style
+	sepY := gd.Height + s.LegendPadding/2
+	fmt.Fprintf(bw, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#c8c8c8"/>`+"\n",
+		s.LegendMargin, sepY, l.width-s.LegendMargin, sepY)
+
+	radius := s.LegendCircleSize / 2
+	fmt.Fprintf(bw, `<g font-family="sans-serif" font-size="%d" text-anchor="middle" dominant-baseline="central">`+"\n", s.LegendCircleSize*2/3)
+	for i, e := range gd.Palette {
+		row, col := i/l.itemsPerRow, i%l.itemsPerRow
+		rowItems := min(l.itemsPerRow, len(gd.Palette)-row*l.itemsPerRow)
+		rowStartX := s.LegendMargin + (l.width-2*s.LegendMargin-rowItems*l.itemWidth)/2
+		cx := rowStartX + col*l.itemWidth + radius
+		cy := gd.Height + s.LegendPadding + row*(s.LegendCircleSize+s.LegendSpacing) + radius
+
+		c, err := color.ParseHex(e.Color)
+		if err != nil {
+			return fmt.Errorf("legend entry %d: %w", e.Number, err)
+		}
+		text := "#000"
+		if !c.IsLight() {
+			text = "#fff"
+		}
+		fmt.Fprintf(bw, `<circle cx="%d" cy="%d" r="%d" fill="%s" stroke="#646464"/>`, cx, cy, radius, e.Color)
+		fmt.Fprintf(bw, `<text x="%d" y="%d" fill="%s">`, cx, cy, text)
+		s.writeLabel(bw, e.Number)
+		bw.WriteString("</text>\n")
+	}
+	bw.WriteString("</g>\n")
+	return nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
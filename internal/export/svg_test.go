@@ -0,0 +1,110 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func testSVGStyle() SVGStyle {
+	return SVGStyle{
+		LabelSize:        7,
+		StrokeWidth:      1,
+		LegendPadding:    20,
+		LegendCircleSize: 30,
+		LegendSpacing:    15,
+		LegendMargin:     20,
+	}
+}
+
+func TestWriteSVG(t *testing.T) {
+	zones, labels, cm := twoZones()
+	gd := BuildGameData(zones, labels, 5, 3, cm)
+
+	var buf bytes.Buffer
+	if err := WriteSVG(&buf, gd, testSVGStyle()); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := map[string]int{}
+	dec := xml.NewDecoder(&buf)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("invalid SVG: %v", err)
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			counts[se.Name.Local]++
+		}
+	}
+	if counts["svg"] != 1 || counts["path"] != 2 || counts["circle"] != 2 {
+		t.Errorf("elements: got %v, want 1 svg, 2 paths, 2 circles", counts)
+	}
+	// Two zone numbers and two legend numbers
+	if counts["text"] != 4 {
+		t.Errorf("got %d text elements, want 4", counts["text"])
+	}
+}
+
+func TestWriteSVG_Colors(t *testing.T) {
+	zones, labels, cm := twoZones()
+	gd := BuildGameData(zones, labels, 5, 3, cm)
+
+	var buf bytes.Buffer
+	if err := WriteSVG(&buf, gd, testSVGStyle()); err != nil {
+		t.Fatal(err)
+	}
+	if s := buf.String(); !strings.Contains(s, `fill="#fff"`) || !strings.Contains(s, `stroke="#000"`) {
+		t.Error("default SVG should be black on white")
+	}
+
+	style := testSVGStyle()
+	style.Background, style.LineColor = "#fff8e7", "#c0c0c0"
+	buf.Reset()
+	if err := WriteSVG(&buf, gd, style); err != nil {
+		t.Fatal(err)
+	}
+	s := buf.String()
+	if !strings.Contains(s, `<rect width="5" height="`) || !strings.Contains(s, `fill="#fff8e7"`) {
+		t.Error("SVG paper should be #fff8e7")
+	}
+	if !strings.Contains(s, `stroke="#c0c0c0"`) || strings.Contains(s, `stroke="#000"`) {
+		t.Error("SVG zone borders should be #c0c0c0")
+	}
+}
+
+func TestWriteSVG_Labels(t *testing.T) {
+	zones, labels, cm := twoZones()
+	gd := BuildGameData(zones, labels, 5, 3, cm)
+
+	style := testSVGStyle()
+	style.Labels = []string{"A&B"}
+	var buf bytes.Buffer
+	if err := WriteSVG(&buf, gd, style); err != nil {
+		t.Fatal(err)
+	}
+	s := buf.String()
+	// Number 1 gets the escaped label, number 2 has none and stays a digit
+	if !strings.Contains(s, ">A&amp;B</text>") || !strings.Contains(s, ">2</text>") {
+		t.Errorf("labels not drawn as expected:\n%s", s)
+	}
+	if strings.Contains(s, ">1</text>") {
+		t.Error("number 1 should be replaced by its label")
+	}
+}
+
+func TestWriteRing_AxisAlignedCommands(t *testing.T) {
+	var sb strings.Builder
+	bw := bufio.NewWriter(&sb)
+	writeRing(bw, []Point{{0, 0}, {3, 0}, {3, 2}, {0, 2}})
+	bw.Flush()
+	if got, want := sb.String(), "M0 0H3V2H0Z"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,28 @@
+package filter
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestGaussianBlur(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 21, 21))
+	for i := range img.Pix {
+		img.Pix[i] = 100
+	}
+	img.SetRGBA(10, 10, color.RGBA{255, 255, 255, 255})
+
+	out := GaussianBlur(img, 1).(*image.RGBA)
+	center, near := out.RGBAAt(10, 10), out.RGBAAt(11, 10)
+	if center.R <= near.R || near.R <= 100 || center.R >= 255 {
+		t.Errorf("speck not spread: center %v, neighbor %v", center, near)
+	}
+	// A flat area stays flat, edges included.
+	if got := out.RGBAAt(0, 0); got != (color.RGBA{100, 100, 100, 100}) {
+		t.Errorf("corner: got %v, want unchanged", got)
+	}
+	if GaussianBlur(img, 0) != image.Image(img) {
+		t.Error("sigma 0 should return the input")
+	}
+}
@@ -0,0 +1,43 @@
+// Package filter implements the image filters macoma can run on an input
+// before detection to steady it: noise, grain and gradients otherwise turn
+// into swarms of tiny zones.
+package filter
+
+import (
+	"image"
+	"image/draw"
+	"sync"
+)
+
+// toRGBA returns img as an *image.RGBA with bounds starting at (0, 0),
+// copying it unless it already is one.
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+	b := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(out, out.Rect, img, b.Min, draw.Src)
+	return out
+}
+
+// parallelRows runs fn over bands of the h rows of an image on several
+// goroutines; each band is written by one goroutine only.
+func parallelRows(h int, fn func(startY, endY int)) {
+	const numWorkers = 8
+	rowsPerWorker := (h + numWorkers - 1) / numWorkers
+	var wg sync.WaitGroup
+	for sy := 0; sy < h; sy += rowsPerWorker {
+		wg.Add(1)
+		go func(sy, ey int) {
+			defer wg.Done()
+			fn(sy, ey)
+		}(sy, min(sy+rowsPerWorker, h))
+	}
+	wg.Wait()
+}
+
+// clamp limits v to [lo, hi].
+func clamp(v, lo, hi int) int {
+	return max(lo, min(v, hi))
+}
@@ -0,0 +1,79 @@
+package filter
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// bruteMedian is the reference implementation of Median.
+func bruteMedian(src *image.RGBA, r int) *image.RGBA {
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	out := image.NewRGBA(src.Rect)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			for c := 0; c < 4; c++ {
+				var vals []int
+				for dy := -r; dy <= r; dy++ {
+					for dx := -r; dx <= r; dx++ {
+						o := src.PixOffset(clamp(x+dx, 0, w-1), clamp(y+dy, 0, h-1))
+						vals = append(vals, int(src.Pix[o+c]))
+					}
+				}
+				sort.Ints(vals)
+				out.Pix[out.PixOffset(x, y)+c] = uint8(vals[len(vals)/2])
+			}
+		}
+	}
+	return out
+}
+
+func TestMedian_MatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	src := image.NewRGBA(image.Rect(0, 0, 23, 17))
+	rng.Read(src.Pix)
+	for _, r := range []int{1, 2, 3} {
+		got := Median(src, r).(*image.RGBA)
+		want := bruteMedian(src, r)
+		for i := range want.Pix {
+			if got.Pix[i] != want.Pix[i] {
+				t.Fatalf("r=%d: byte %d = %d, want %d", r, i, got.Pix[i], want.Pix[i])
+			}
+		}
+	}
+}
+
+func TestMedian_RemovesSpeckKeepsEdge(t *testing.T) {
+	red, blue := color.RGBA{200, 30, 30, 255}, color.RGBA{30, 30, 200, 255}
+	img := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 20; x++ {
+			c := red
+			if x >= 10 {
+				c = blue
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	img.SetRGBA(4, 4, color.RGBA{255, 255, 255, 255})
+
+	out := Median(img, 1).(*image.RGBA)
+	if got := out.RGBAAt(4, 4); got != red {
+		t.Errorf("speck: got %v, want %v", got, red)
+	}
+	if got := out.RGBAAt(9, 5); got != red {
+		t.Errorf("left of edge: got %v, want %v", got, red)
+	}
+	if got := out.RGBAAt(10, 5); got != blue {
+		t.Errorf("right of edge: got %v, want %v", got, blue)
+	}
+}
+
+func TestMedian_ZeroRadius(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	if Median(img, 0) != image.Image(img) {
+		t.Error("radius 0 should return the input")
+	}
+}
@@ -0,0 +1,39 @@
+package filter
+
+import "image"
+
+// Posterize returns img with each color channel quantized to levels evenly
+// spaced values, 0 and 255 included, rounding to the nearest. Gradients
+// become flat bands, so fewer distinct colors reach detection and color
+// reduction. Alpha is kept. levels < 2 or >= 256 returns img unchanged.
+func Posterize(img image.Image, levels int) image.Image {
+	if levels < 2 || levels >= 256 {
+		return img
+	}
+	var lut [256]uint8
+	steps := levels - 1
+	for v := range lut {
+		lut[v] = uint8((v*steps + 127) / 255 * 255 / steps)
+	}
+
+	src := toRGBA(img)
+	out := image.NewRGBA(src.Rect)
+	parallelRows(src.Rect.Dy(), func(sy, ey int) {
+		for i := sy * src.Stride; i < ey*src.Stride; i += 4 {
+			a := src.Pix[i+3]
+			out.Pix[i+3] = a
+			for c := 0; c < 3; c++ {
+				// Quantize the straight color so translucent pixels land
+				// on the same levels as opaque ones.
+				v := src.Pix[i+c]
+				if a != 0 && a != 255 {
+					v = uint8(min(255, int(v)*255/int(a)))
+					out.Pix[i+c] = uint8(int(lut[v]) * int(a) / 255)
+					continue
+				}
+				out.Pix[i+c] = lut[v]
+			}
+		}
+	})
+	return out
+}
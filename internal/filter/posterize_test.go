@@ -0,0 +1,32 @@
+package filter
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestPosterize(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 1))
+	img.SetRGBA(0, 0, color.RGBA{0, 60, 64, 255})
+	img.SetRGBA(1, 0, color.RGBA{127, 128, 200, 255})
+	img.SetRGBA(2, 0, color.RGBA{255, 192, 190, 255})
+	img.SetRGBA(3, 0, color.RGBA{0, 0, 0, 0})
+
+	out := Posterize(img, 3).(*image.RGBA)
+	want := []color.RGBA{
+		{0, 0, 127, 255},
+		{127, 127, 255, 255},
+		{255, 255, 127, 255},
+		{0, 0, 0, 0},
+	}
+	for x, w := range want {
+		if got := out.RGBAAt(x, 0); got != w {
+			t.Errorf("pixel %d: got %v, want %v", x, got, w)
+		}
+	}
+
+	if Posterize(img, 1) != image.Image(img) || Posterize(img, 256) != image.Image(img) {
+		t.Error("levels outside 2-255 should return the input")
+	}
+}
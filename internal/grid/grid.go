@@ -0,0 +1,69 @@
+// Package grid samples an image on a grid of square cells, the layout of
+// cross-stitch charts.
+package grid
+
+import (
+	"image"
+
+	"github.com/maax3v3/macoma/v2/internal/color"
+)
+
+// Grid is an image averaged over Cols×Rows cells, stored row by row.
+type Grid struct {
+	Cols, Rows int
+	Colors     []color.RGBA // the opaque mean color of each cell
+	Empty      []bool       // cells that are mostly transparent
+}
+
+// Rows returns the number of rows of a grid cols cells across a w×h
+// image, so that the cells are as close to square as possible.
+func Rows(w, h, cols int) int {
+	return max(1, (h*cols+w/2)/w)
+}
+
+// Sample averages img over a grid cols cells across, with cols between 1
+// and the image width. A cell is empty when less than half of its area is
+// covered; the others get the mean of their pixels weighted by alpha.
+func Sample(img image.Image, cols int) *Grid {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	rows := Rows(w, h, cols)
+	g := &Grid{
+		Cols:   cols,
+		Rows:   rows,
+		Colors: make([]color.RGBA, cols*rows),
+		Empty:  make([]bool, cols*rows),
+	}
+
+	for cy := 0; cy < rows; cy++ {
+		y0, y1 := b.Min.Y+cy*h/rows, b.Min.Y+(cy+1)*h/rows
+		for cx := 0; cx < cols; cx++ {
+			x0, x1 := b.Min.X+cx*w/cols, b.Min.X+(cx+1)*w/cols
+			// RGBA() is alpha-premultiplied, so these sums weight each
+			// pixel by its coverage.
+			var sr, sg, sb, sa uint64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					r, gr, bl, a := img.At(x, y).RGBA()
+					sr += uint64(r)
+					sg += uint64(gr)
+					sb += uint64(bl)
+					sa += uint64(a)
+				}
+			}
+			i := cy*cols + cx
+			n := uint64((x1 - x0) * (y1 - y0))
+			if 2*sa < n*0xffff {
+				g.Empty[i] = true
+				continue
+			}
+			g.Colors[i] = color.RGBA{
+				R: uint8((sr*255 + sa/2) / sa),
+				G: uint8((sg*255 + sa/2) / sa),
+				B: uint8((sb*255 + sa/2) / sa),
+				A: 255,
+			}
+		}
+	}
+	return g
+}
@@ -0,0 +1,55 @@
+package grid
+
+import (
+	"image"
+	stdcolor "image/color"
+	"testing"
+
+	"github.com/maax3v3/macoma/v2/internal/color"
+)
+
+func TestRows(t *testing.T) {
+	tests := []struct{ w, h, cols, want int }{
+		{100, 50, 10, 5},
+		{100, 100, 7, 7},
+		{300, 10, 4, 1}, // never fewer than one row
+		{90, 100, 9, 10},
+	}
+	for _, tt := range tests {
+		if got := Rows(tt.w, tt.h, tt.cols); got != tt.want {
+			t.Errorf("Rows(%d, %d, %d) = %d, want %d", tt.w, tt.h, tt.cols, got, tt.want)
+		}
+	}
+}
+
+func TestSample(t *testing.T) {
+	// Left half red, right half blue; the bottom-right quarter transparent.
+	img := image.NewNRGBA(image.Rect(0, 0, 20, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			switch {
+			case x < 10:
+				img.SetNRGBA(x, y, stdcolor.NRGBA{255, 0, 0, 255})
+			case y < 10:
+				img.SetNRGBA(x, y, stdcolor.NRGBA{0, 0, 255, 255})
+			}
+		}
+	}
+	// One half-covered pixel in the top-left cell counts half as much.
+	img.SetNRGBA(0, 0, stdcolor.NRGBA{0, 0, 255, 128})
+
+	g := Sample(img, 2)
+	if g.Cols != 2 || g.Rows != 2 {
+		t.Fatalf("got a %dx%d grid, want 2x2", g.Cols, g.Rows)
+	}
+	if g.Empty[0] || g.Empty[1] || g.Empty[2] || !g.Empty[3] {
+		t.Errorf("empty cells: got %v, want only the bottom-right one", g.Empty)
+	}
+	if got := g.Colors[1]; got != (color.RGBA{B: 255, A: 255}) {
+		t.Errorf("top-right: got %v, want blue", got)
+	}
+	// 99 red pixels and half a blue one
+	if got := g.Colors[0]; got.R != 254 || got.B != 1 {
+		t.Errorf("top-left: got %v, want the alpha-weighted mean", got)
+	}
+}
@@ -0,0 +1,76 @@
+package imaging
+
+import (
+	"image"
+	"image/draw"
+)
+
+const (
+	// marginTolerance is how far, per 8-bit channel, a pixel may stray from
+	// the margin color and still count as blank paper.
+	marginTolerance = 24
+
+	// marginSpecks is the fraction of a row or column of blank paper that
+	// may be off color anyway: dust and scanner noise.
+	marginSpecks = 0.005
+)
+
+// TrimMargins returns img cropped to the drawing on it: the rows and
+// columns along its edges that are all the color of its top-left pixel,
+// give or take scan noise, are removed. The result starts at (0, 0). img
+// itself is returned when it has no such margin or is blank throughout.
+func TrimMargins(img image.Image) image.Image {
+	b := img.Bounds()
+	if b.Empty() {
+		return img
+	}
+	src := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Rect, img, b.Min, draw.Src)
+	w, h := b.Dx(), b.Dy()
+	paper := src.Pix[:4]
+
+	// blank reports whether the n pixels from (x, y), stepping by (dx, dy),
+	// are paper.
+	blank := func(x, y, dx, dy, n int) bool {
+		allowed := int(marginSpecks * float64(n))
+		for i := 0; i < n; i++ {
+			p := src.Pix[src.PixOffset(x+i*dx, y+i*dy):][:4]
+			for c := range p {
+				if d := int(p[c]) - int(paper[c]); d > marginTolerance || d < -marginTolerance {
+					if allowed--; allowed < 0 {
+						return false
+					}
+					break
+				}
+			}
+		}
+		return true
+	}
+
+	top := 0
+	for top < h && blank(0, top, 1, 0, w) {
+		top++
+	}
+	if top == h {
+		return img
+	}
+	bottom := h
+	for blank(0, bottom-1, 1, 0, w) {
+		bottom--
+	}
+	left := 0
+	for blank(left, top, 0, 1, bottom-top) {
+		left++
+	}
+	right := w
+	for blank(right-1, top, 0, 1, bottom-top) {
+		right--
+	}
+	if top == 0 && left == 0 && bottom == h && right == w {
+		return img
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, right-left, bottom-top))
+	draw.Draw(out, out.Rect, src, image.Pt(left, top), draw.Src)
+	return out
+}
@@ -0,0 +1,43 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestTrimMargins(t *testing.T) {
+	paper := color.RGBA{R: 250, G: 248, B: 240, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 400, 300))
+	draw.Draw(img, img.Rect, image.NewUniform(paper), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(40, 30, 340, 250), image.NewUniform(color.RGBA{R: 200, A: 255}), image.Point{}, draw.Src)
+	// Scan noise in the margins: a slightly off-white band and a dust speck.
+	draw.Draw(img, image.Rect(0, 10, 400, 12), image.NewUniform(color.RGBA{R: 240, G: 240, B: 235, A: 255}), image.Point{}, draw.Src)
+	img.SetRGBA(5, 280, color.RGBA{A: 255})
+
+	got := TrimMargins(img)
+	if b := got.Bounds(); b != image.Rect(0, 0, 300, 220) {
+		t.Fatalf("got bounds %v, want 300x220", b)
+	}
+	if c := color.RGBAModel.Convert(got.At(0, 0)); c != (color.RGBA{R: 200, A: 255}) {
+		t.Errorf("top-left pixel %v is not the drawing", c)
+	}
+}
+
+func TestTrimMargins_Unchanged(t *testing.T) {
+	blank := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	if got := TrimMargins(blank); got != image.Image(blank) {
+		t.Error("a blank image should be returned as is")
+	}
+
+	full := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	full.SetRGBA(19, 9, color.RGBA{R: 255, A: 255})
+	full.SetRGBA(0, 9, color.RGBA{R: 255, A: 255})
+	full.SetRGBA(19, 0, color.RGBA{R: 255, A: 255})
+	full.SetRGBA(1, 0, color.RGBA{R: 255, A: 255})
+	full.SetRGBA(0, 1, color.RGBA{R: 255, A: 255})
+	if got := TrimMargins(full); got != image.Image(full) {
+		t.Errorf("an image without margins should be returned as is, got %v", got.Bounds())
+	}
+}
@@ -0,0 +1,119 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// withOrientation inserts an EXIF APP1 segment recording orientation o
+// after the SOI marker of a JPEG.
+func withOrientation(t *testing.T, jpg []byte, o uint16, order binary.ByteOrder) []byte {
+	t.Helper()
+	tiff := make([]byte, 8+2+12+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)       // one entry
+	order.PutUint16(tiff[10:], 0x0112) // Orientation
+	order.PutUint16(tiff[12:], 3)      // SHORT
+	order.PutUint32(tiff[14:], 1)      // count
+	order.PutUint16(tiff[18:], o)      // value
+	seg := append([]byte("Exif\x00\x00"), tiff...)
+
+	var out bytes.Buffer
+	out.Write(jpg[:2])
+	out.Write([]byte{0xff, 0xe1})
+	binary.Write(&out, binary.BigEndian, uint16(len(seg)+2))
+	out.Write(seg)
+	out.Write(jpg[2:])
+	return out.Bytes()
+}
+
+// halves returns a 16x8 JPEG, red on the left and blue on the right.
+func halves(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 16, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 16; x++ {
+			c := color.RGBA{255, 0, 0, 255}
+			if x >= 8 {
+				c = color.RGBA{0, 0, 255, 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func isRed(c color.Color) bool {
+	r, _, b, _ := c.RGBA()
+	return r > b
+}
+
+func TestDecode_EXIFOrientation(t *testing.T) {
+	tests := []struct {
+		o           uint16
+		order       binary.ByteOrder
+		w, h        int
+		redAt, blue image.Point
+	}{
+		{1, binary.BigEndian, 16, 8, image.Pt(2, 4), image.Pt(13, 4)},
+		{3, binary.LittleEndian, 16, 8, image.Pt(13, 4), image.Pt(2, 4)},
+		{6, binary.BigEndian, 8, 16, image.Pt(4, 2), image.Pt(4, 13)},
+		{8, binary.LittleEndian, 8, 16, image.Pt(4, 13), image.Pt(4, 2)},
+	}
+	for _, tt := range tests {
+		img, err := Decode(bytes.NewReader(withOrientation(t, halves(t), tt.o, tt.order)))
+		if err != nil {
+			t.Fatalf("orientation %d: %v", tt.o, err)
+		}
+		if b := img.Bounds(); b.Dx() != tt.w || b.Dy() != tt.h {
+			t.Errorf("orientation %d: got %dx%d, want %dx%d", tt.o, b.Dx(), b.Dy(), tt.w, tt.h)
+			continue
+		}
+		if !isRed(img.At(tt.redAt.X, tt.redAt.Y)) || isRed(img.At(tt.blue.X, tt.blue.Y)) {
+			t.Errorf("orientation %d: red half not where expected", tt.o)
+		}
+	}
+}
+
+func TestOrient_AllOrientations(t *testing.T) {
+	// A 3x2 image whose pixels are numbered 0-5 in their red channel.
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	for i := 0; i < 6; i++ {
+		src.SetRGBA(i%3, i/3, color.RGBA{R: uint8(i), A: 255})
+	}
+	// Rows of each upright result, as red values.
+	want := map[int][][]uint8{
+		1: {{0, 1, 2}, {3, 4, 5}},
+		2: {{2, 1, 0}, {5, 4, 3}},
+		3: {{5, 4, 3}, {2, 1, 0}},
+		4: {{3, 4, 5}, {0, 1, 2}},
+		5: {{0, 3}, {1, 4}, {2, 5}},
+		6: {{3, 0}, {4, 1}, {5, 2}},
+		7: {{5, 2}, {4, 1}, {3, 0}},
+		8: {{2, 5}, {1, 4}, {0, 3}},
+	}
+	for o, rows := range want {
+		img := orient(src, o)
+		for y, row := range rows {
+			for x, v := range row {
+				if r, _, _, _ := img.At(x, y).RGBA(); uint8(r>>8) != v {
+					t.Errorf("orientation %d at (%d,%d): got %d, want %d", o, x, y, r>>8, v)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"io"
+	"os"
+)
+
+// EncodeGIF writes frames to w as an endlessly looping animated GIF,
+// showing frame i for delays[i] hundredths of a second. Every pixel takes
+// the nearest color of pal, without dithering, so flat zones stay flat;
+// pal holds at most 256 colors, and nil means the Plan 9 palette.
+func EncodeGIF(w io.Writer, frames []image.Image, delays []int, pal color.Palette) error {
+	if len(frames) == 0 {
+		return fmt.Errorf("encoding GIF: no frames")
+	}
+	if len(delays) != len(frames) {
+		return fmt.Errorf("encoding GIF: %d delays for %d frames", len(delays), len(frames))
+	}
+	if pal == nil {
+		pal = palette.Plan9
+	}
+	if len(pal) > 256 {
+		return fmt.Errorf("encoding GIF: palette of %d colors, at most 256", len(pal))
+	}
+
+	anim := &gif.GIF{Delay: delays}
+	index := make(map[color.RGBA]uint8)
+	for _, f := range frames {
+		anim.Image = append(anim.Image, paletted(f, pal, index))
+	}
+	if err := gif.EncodeAll(w, anim); err != nil {
+		return fmt.Errorf("encoding GIF: %w", err)
+	}
+	return nil
+}
+
+// paletted maps img onto pal. index caches the palette index of every
+// color seen, since colorings repeat few colors over many pixels.
+func paletted(img image.Image, pal color.Palette, index map[color.RGBA]uint8) *image.Paletted {
+	b := img.Bounds()
+	out := image.NewPaletted(image.Rect(0, 0, b.Dx(), b.Dy()), pal)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+			i, ok := index[c]
+			if !ok {
+				i = uint8(pal.Index(c))
+				index[c] = i
+			}
+			out.Pix[out.PixOffset(x-b.Min.X, y-b.Min.Y)] = i
+		}
+	}
+	return out
+}
+
+// SaveGIF writes an animated GIF to disk (see EncodeGIF).
+// The path is normalized: ~ is expanded and relative paths are resolved.
+func SaveGIF(path string, frames []image.Image, delays []int, pal color.Palette) error {
+	path = ExpandPath(path)
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+	defer f.Close()
+	return EncodeGIF(f, frames, delays, pal)
+}
@@ -0,0 +1,39 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+)
+
+func TestEncodeGIF(t *testing.T) {
+	red := color.RGBA{200, 30, 30, 255}
+	frames := []image.Image{
+		solid(4, 3, color.RGBA{255, 255, 255, 255}),
+		solid(4, 3, red),
+	}
+	pal := color.Palette{color.RGBA{255, 255, 255, 255}, red}
+	var buf bytes.Buffer
+	if err := EncodeGIF(&buf, frames, []int{100, 300}, pal); err != nil {
+		t.Fatal(err)
+	}
+	g, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Image) != 2 || g.Delay[0] != 100 || g.Delay[1] != 300 {
+		t.Fatalf("got %d frames with delays %v", len(g.Image), g.Delay)
+	}
+	if got := color.RGBAModel.Convert(g.Image[1].At(3, 2)); got != red {
+		t.Errorf("second frame: got %v, want the exact palette color %v", got, red)
+	}
+
+	if err := EncodeGIF(&buf, frames, []int{100}, pal); err == nil {
+		t.Error("mismatched delays: expected error")
+	}
+	if err := EncodeGIF(&buf, nil, nil, pal); err == nil {
+		t.Error("no frames: expected error")
+	}
+}
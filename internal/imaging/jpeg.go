@@ -0,0 +1,134 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"os"
+)
+
+// DefaultJPEGQuality is the JPEG quality used when none is given. Flat
+// zones and thin outlines show ringing at lower settings.
+const DefaultJPEGQuality = 90
+
+// EncodeJPEG writes img to w as baseline JPEG at quality 1–100, or
+// DefaultJPEGQuality when quality is 0. JPEG has no alpha: translucent
+// pixels are flattened onto white, as they would print on paper.
+func EncodeJPEG(w io.Writer, img image.Image, quality int) error {
+	if quality == 0 {
+		quality = DefaultJPEGQuality
+	}
+	if quality < 1 || quality > 100 {
+		return fmt.Errorf("encoding JPEG: quality must be between 1 and 100, got %d", quality)
+	}
+	if err := jpeg.Encode(w, flattenOnWhite(img), &jpeg.Options{Quality: quality}); err != nil {
+		return fmt.Errorf("encoding JPEG: %w", err)
+	}
+	return nil
+}
+
+// flattenOnWhite returns img composited over white. Opaque images are
+// returned as is.
+func flattenOnWhite(img image.Image) image.Image {
+	if o, ok := img.(interface{ Opaque() bool }); ok && o.Opaque() {
+		return img
+	}
+	b := img.Bounds()
+	out := image.NewRGBA(b)
+	draw.Draw(out, b, image.White, image.Point{}, draw.Src)
+	draw.Draw(out, b, img, b.Min, draw.Over)
+	return out
+}
+
+// SaveJPEG writes an image to disk as JPEG (see EncodeJPEG).
+// The path is normalized: ~ is expanded and relative paths are resolved.
+func SaveJPEG(path string, img image.Image, quality int) error {
+	path = ExpandPath(path)
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+	defer f.Close()
+	return EncodeJPEG(f, img, quality)
+}
+
+// decodeJPEG decodes JPEG data, baseline or progressive. 4-component
+// (CMYK) files, as print shops and prepress software export them, are
+// converted to RGB; those lacking the Adobe APP14 marker the standard
+// decoder needs to tell CMYK from YCCK are read as plain, non-inverted CMYK
+// like libjpeg does.
+func decodeJPEG(data []byte) (image.Image, error) {
+	bareCMYK := jpegComponents(data) == 4 && !hasAdobeMarker(data)
+	if bareCMYK {
+		data = withAdobeMarker(data)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	var unsupported jpeg.UnsupportedError
+	if errors.As(err, &unsupported) {
+		return nil, fmt.Errorf("%w (re-save the image as a standard 8-bit JPEG or as PNG)", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	cmyk, ok := img.(*image.CMYK)
+	if !ok {
+		return img, nil
+	}
+	if bareCMYK {
+		// The marker added above tells the decoder the file is Adobe CMYK,
+		// which stores ink inverted; undo the inversion it applied.
+		for i := range cmyk.Pix {
+			cmyk.Pix[i] = 255 - cmyk.Pix[i]
+		}
+	}
+	rgba := image.NewRGBA(cmyk.Rect)
+	draw.Draw(rgba, rgba.Rect, cmyk, cmyk.Rect.Min, draw.Src)
+	return rgba, nil
+}
+
+// jpegComponents returns the number of color components declared by the
+// start-of-frame segment of the JPEG data, or 0 when there is none.
+func jpegComponents(data []byte) int {
+	n := 0
+	jpegSegments(data, func(marker byte, seg []byte) bool {
+		// SOF0-SOF15, except DHT (c4), JPG (c8) and DAC (cc).
+		if marker >= 0xc0 && marker <= 0xcf && marker != 0xc4 && marker != 0xc8 && marker != 0xcc {
+			if len(seg) >= 6 {
+				n = int(seg[5])
+			}
+			return false
+		}
+		return true
+	})
+	return n
+}
+
+// hasAdobeMarker reports whether the JPEG data has an Adobe APP14 segment.
+func hasAdobeMarker(data []byte) bool {
+	found := false
+	jpegSegments(data, func(marker byte, seg []byte) bool {
+		found = marker == 0xee && len(seg) >= 5 && string(seg[:5]) == "Adobe"
+		return !found
+	})
+	return found
+}
+
+// withAdobeMarker returns the JPEG data with an Adobe APP14 segment after
+// the SOI marker, declaring the components as unconverted CMYK.
+func withAdobeMarker(data []byte) []byte {
+	seg := []byte{
+		0xff, 0xee, 0x00, 0x0e,
+		'A', 'd', 'o', 'b', 'e',
+		0x00, 0x64, // version
+		0x00, 0x00, 0x00, 0x00, // flags
+		0x00, // transform: none, the components are CMYK
+	}
+	out := make([]byte, 0, len(data)+len(seg))
+	out = append(out, data[:2]...)
+	out = append(out, seg...)
+	return append(out, data[2:]...)
+}
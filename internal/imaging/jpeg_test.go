@@ -0,0 +1,113 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestEncodeJPEG(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 16, 8))
+	var low, high bytes.Buffer
+	for i := range src.Pix {
+		src.Pix[i] = uint8(i * 31)
+	}
+	if err := EncodeJPEG(&low, src, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := EncodeJPEG(&high, src, 0); err != nil {
+		t.Fatal(err)
+	}
+	if SniffFormat(high.Bytes()) != "jpeg" || low.Len() >= high.Len() {
+		t.Errorf("quality 10: %d bytes, default: %d bytes", low.Len(), high.Len())
+	}
+	if err := EncodeJPEG(&bytes.Buffer{}, src, 101); err == nil {
+		t.Error("quality 101: expected error")
+	}
+}
+
+func TestEncodeJPEG_FlattensOnWhite(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeJPEG(&buf, image.NewRGBA(image.Rect(0, 0, 8, 8)), 90); err != nil {
+		t.Fatal(err)
+	}
+	img, err := Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, g, b, _ := img.At(4, 4).RGBA(); r>>8 < 250 || g>>8 < 250 || b>>8 < 250 {
+		t.Errorf("transparent pixel: got %d,%d,%d, want white", r>>8, g>>8, b>>8)
+	}
+}
+
+// grayCMYKJPEG returns an 8x8 4-component baseline JPEG, without an Adobe
+// marker, whose samples all decode to 128.
+func grayCMYKJPEG() []byte {
+	var b bytes.Buffer
+	b.Write([]byte{0xff, 0xd8})
+	b.Write([]byte{0xff, 0xdb, 0x00, 0x43, 0x00})
+	b.Write(bytes.Repeat([]byte{1}, 64))
+	b.Write([]byte{0xff, 0xc0, 0x00, 0x14, 8, 0, 8, 0, 8, 4})
+	for id := byte(1); id <= 4; id++ {
+		b.Write([]byte{id, 0x11, 0})
+	}
+	// One DC and one AC table, each with a single 1-bit code for symbol 0:
+	// a zero DC difference, and the end of block.
+	b.Write([]byte{0xff, 0xc4, 0x00, 0x26})
+	for _, class := range []byte{0x00, 0x10} {
+		b.WriteByte(class)
+		b.WriteByte(1)
+		b.Write(make([]byte, 15))
+		b.WriteByte(0)
+	}
+	b.Write([]byte{0xff, 0xda, 0x00, 0x0e, 4})
+	for id := byte(1); id <= 4; id++ {
+		b.Write([]byte{id, 0x00})
+	}
+	b.Write([]byte{0, 63, 0})
+	b.Write([]byte{0x00, 0xff, 0xd9}) // four blocks of two 0 bits, then EOI
+	return b.Bytes()
+}
+
+func TestDecode_CMYKJPEG(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want color.RGBA
+	}{
+		{"without Adobe marker", grayCMYKJPEG(), rgbaOf(color.CMYK{C: 128, M: 128, Y: 128, K: 128})},
+		// Adobe CMYK stores ink inverted.
+		{"with Adobe marker", withAdobeMarker(grayCMYKJPEG()), rgbaOf(color.CMYK{C: 127, M: 127, Y: 127, K: 127})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := Decode(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rgba, ok := img.(*image.RGBA)
+			if !ok {
+				t.Fatalf("got %T, want *image.RGBA", img)
+			}
+			if got := rgba.RGBAAt(3, 5); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func rgbaOf(c color.Color) color.RGBA {
+	return color.RGBAModel.Convert(c).(color.RGBA)
+}
+
+func TestDecode_UnsupportedJPEG(t *testing.T) {
+	// A 12-bit precision frame, which the standard decoder rejects.
+	data := grayCMYKJPEG()
+	data[bytes.Index(data, []byte{0xff, 0xc0})+4] = 12
+	_, err := Decode(bytes.NewReader(data))
+	if err == nil || !strings.Contains(err.Error(), "re-save") {
+		t.Errorf("got %v, want a hint to re-save the image", err)
+	}
+}
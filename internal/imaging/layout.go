@@ -0,0 +1,36 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// LayoutPage returns img placed on a page of opts.Paper rendered at
+// opts.DPI, as EncodePDF places it: turned to landscape if that fits it
+// larger, shrunk to the margins (or, with opts.Fit, enlarged to them) and
+// centered, on paper of color bg. Enlarging samples the nearest pixel, so
+// lines and numbers stay crisp; shrinking uses a Catmull-Rom kernel.
+func LayoutPage(img image.Image, opts PDFOptions, bg color.RGBA) *image.RGBA {
+	b := img.Bounds()
+	paper, x, y, dw, dh := placeOnPage(b.Dx(), b.Dy(), opts)
+	px := func(pt float64) int { return int(math.Round(pt * opts.DPI / 72)) }
+
+	page := image.NewRGBA(image.Rect(0, 0, px(paper.Width), px(paper.Height)))
+	xdraw.Draw(page, page.Bounds(), image.NewUniform(bg), image.Point{}, xdraw.Src)
+
+	// PDF coordinates grow upwards from the bottom of the page
+	x0, y0 := px(x), px(paper.Height-y-dh)
+	dst := image.Rect(x0, y0, x0+max(1, px(dw)), y0+max(1, px(dh)))
+	switch {
+	case dst.Size() == b.Size():
+		xdraw.Draw(page, dst, img, b.Min, xdraw.Over)
+	case dst.Dx() > b.Dx():
+		xdraw.NearestNeighbor.Scale(page, dst, img, b, xdraw.Over, nil)
+	default:
+		xdraw.CatmullRom.Scale(page, dst, img, b, xdraw.Over, nil)
+	}
+	return page
+}
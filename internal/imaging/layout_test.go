@@ -0,0 +1,46 @@
+package imaging
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestLayoutPage(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	cream := color.RGBA{255, 248, 231, 255}
+	opts := PDFOptions{Paper: PaperA5, DPI: 72, Margin: 36}
+
+	page := LayoutPage(solid(100, 50, red), opts, cream)
+	if got := page.Bounds().Size(); got.X != 420 || got.Y != 595 {
+		t.Fatalf("page: got %v, want A5 at 72 DPI", got)
+	}
+	if got := page.RGBAAt(0, 0); got != cream {
+		t.Errorf("margin: got %v, want the paper color", got)
+	}
+	// Printed at its natural size, centered
+	if got := page.RGBAAt(210, 297); got != red {
+		t.Errorf("center: got %v, want the image", got)
+	}
+	if page.RGBAAt(159, 297) != cream || page.RGBAAt(160, 297) != red {
+		t.Error("the image should start 160 pixels from the left edge")
+	}
+
+	// With Fit it fills a landscape page between the side margins
+	opts.Fit = true
+	page = LayoutPage(solid(100, 50, red), opts, cream)
+	if got := page.Bounds().Size(); got.X != 595 || got.Y != 420 {
+		t.Fatalf("fitted page: got %v, want landscape A5", got)
+	}
+	if page.RGBAAt(35, 210) != cream || page.RGBAAt(36, 210) != red || page.RGBAAt(558, 210) != red || page.RGBAAt(559, 210) != cream {
+		t.Error("a fitted image should span the page between the margins")
+	}
+}
+
+func TestPaperByName(t *testing.T) {
+	if p, ok := PaperByName("a5"); !ok || p != PaperA5 {
+		t.Errorf("a5: got %v, %v", p, ok)
+	}
+	if _, ok := PaperByName("a3"); ok {
+		t.Error("a3 should be unknown")
+	}
+}
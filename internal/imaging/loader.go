@@ -1,47 +1,137 @@
 package imaging
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
 	"image"
-	"image/jpeg"
 	"image/png"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
 
-	_ "golang.org/x/image/webp"
+	"golang.org/x/image/webp"
 )
 
-// Load reads an image file from disk. Supports PNG, JPEG, and WEBP.
+// Load reads an image file from disk. Supports PNG, JPEG, and WEBP,
+// recognized by their signature rather than the file extension.
 // The path is normalized: ~ is expanded to the user's home directory,
 // and relative paths are resolved to absolute.
 func Load(path string) (image.Image, error) {
-	path = ExpandPath(path)
-	f, err := os.Open(path)
+	f, err := os.Open(ExpandPath(path))
+	return loadFile(f, err)
+}
+
+// LoadFS is like Load but reads the image from fsys, such as an embed.FS,
+// a zip.Reader or a testing/fstest.MapFS. name is a slash-separated path
+// as fs.FS expects; it is not normalized.
+func LoadFS(fsys fs.FS, name string) (image.Image, error) {
+	return loadFile(fsys.Open(name))
+}
+
+// loadFile decodes the image in f, the result of opening it on disk or in
+// an fs.FS, and closes it.
+func loadFile(f fs.File, err error) (image.Image, error) {
 	if err != nil {
 		return nil, fmt.Errorf("opening image: %w", err)
 	}
 	defer f.Close()
+	return Decode(f)
+}
+
+// sniffLen is the number of leading bytes SniffFormat needs.
+const sniffLen = 12
 
-	ext := strings.ToLower(filepath.Ext(path))
-	switch ext {
-	case ".png":
-		return png.Decode(f)
-	case ".jpg", ".jpeg":
-		return jpeg.Decode(f)
-	case ".webp":
-		// Decoded via the blank import of golang.org/x/image/webp
-		img, _, err := image.Decode(f)
-		return img, err
+// SniffFormat reports the format whose signature starts header: "png",
+// "jpeg" or "webp", or "" for anything else. The first 12 bytes of a file
+// are enough.
+func SniffFormat(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, []byte("\x89PNG\r\n\x1a\n")):
+		return "png"
+	case bytes.HasPrefix(header, []byte{0xff, 0xd8, 0xff}):
+		return "jpeg"
+	case len(header) >= sniffLen && string(header[:4]) == "RIFF" && string(header[8:12]) == "WEBP":
+		return "webp"
+	}
+	return ""
+}
+
+// Decode reads a PNG, JPEG or WEBP image from r, recognizing the format by
+// its signature rather than a file extension, so r may be a pipe. JPEGs are
+// turned upright per their EXIF orientation, as photo viewers show them,
+// and CMYK JPEGs are converted to RGB.
+func Decode(r io.Reader) (image.Image, error) {
+	br := bufio.NewReader(r)
+	header, _ := br.Peek(sniffLen)
+	var img image.Image
+	var err error
+	switch SniffFormat(header) {
+	case "png":
+		img, err = png.Decode(br)
+	case "jpeg":
+		// The whole file is needed anyway; reading it first gives access to
+		// the EXIF orientation of phone photos.
+		var data []byte
+		if data, err = io.ReadAll(br); err == nil {
+			if img, err = decodeJPEG(data); err == nil {
+				img = orient(img, jpegOrientation(data))
+			}
+		}
+	case "webp":
+		img, err = webp.Decode(br)
 	default:
-		return nil, fmt.Errorf("unsupported image format %q (supported: png, jpg, jpeg, webp)", ext)
+		return nil, fmt.Errorf("unsupported image format (supported: png, jpeg, webp)")
 	}
+	if err != nil {
+		return nil, fmt.Errorf("decoding image: %w", err)
+	}
+	return img, nil
+}
+
+// DetectFormat reports the encoded format of an image file ("png", "jpeg",
+// "webp") from its signature, without decoding the pixels.
+func DetectFormat(path string) (string, error) {
+	f, err := os.Open(ExpandPath(path))
+	return detectFormat(f, err)
+}
+
+// DetectFormatFS is like DetectFormat but reads the file from fsys.
+func DetectFormatFS(fsys fs.FS, name string) (string, error) {
+	return detectFormat(fsys.Open(name))
+}
+
+// detectFormat sniffs the format of f, opened as in loadFile, and closes it.
+func detectFormat(f fs.File, err error) (string, error) {
+	if err != nil {
+		return "", fmt.Errorf("opening image: %w", err)
+	}
+	defer f.Close()
+
+	header := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", fmt.Errorf("reading image header: %w", err)
+	}
+	format := SniffFormat(header[:n])
+	if format == "" {
+		return "", fmt.Errorf("reading image header: unsupported image format (supported: png, jpeg, webp)")
+	}
+	return format, nil
 }
 
 // SavePNG writes an image to disk as PNG.
 // The path is normalized: ~ is expanded and relative paths are resolved.
 func SavePNG(path string, img image.Image) error {
+	return SavePNGWithOptions(path, img, PNGOptions{})
+}
+
+// SavePNGWithOptions is like SavePNG with control over compression level
+// and interlacing.
+func SavePNGWithOptions(path string, img image.Image, opts PNGOptions) error {
 	path = ExpandPath(path)
 	f, err := os.Create(path)
 	if err != nil {
@@ -49,7 +139,7 @@ func SavePNG(path string, img image.Image) error {
 	}
 	defer f.Close()
 
-	if err := png.Encode(f, img); err != nil {
+	if err := EncodePNG(f, img, opts); err != nil {
 		return fmt.Errorf("encoding PNG: %w", err)
 	}
 	return nil
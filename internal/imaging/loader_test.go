@@ -1,12 +1,17 @@
 package imaging
 
 import (
+	"bytes"
+	"errors"
 	"image"
 	"image/color"
+	"image/gif"
 	"image/jpeg"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"testing"
+	"testing/fstest"
 )
 
 func TestSavePNG_ThenLoad(t *testing.T) {
@@ -38,6 +43,73 @@ func TestSavePNG_ThenLoad(t *testing.T) {
 	}
 }
 
+func TestDecode(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	var png, jpg, gf bytes.Buffer
+	if err := EncodePNG(&png, src, PNGOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := jpeg.Encode(&jpg, src, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := gif.Encode(&gf, src, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, data := range map[string][]byte{"png": png.Bytes(), "jpeg": jpg.Bytes()} {
+		img, err := Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		} else if img.Bounds().Dx() != 3 || img.Bounds().Dy() != 2 {
+			t.Errorf("%s: got %v", name, img.Bounds())
+		}
+	}
+	// GIF decodes with image/gif registered, but is not a supported input
+	if _, err := Decode(bytes.NewReader(gf.Bytes())); err == nil {
+		t.Error("gif: expected unsupported format error")
+	}
+	if _, err := Decode(bytes.NewReader([]byte("not an image"))); err == nil {
+		t.Error("garbage: expected error")
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	dir := t.TempDir()
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+
+	pngPath := filepath.Join(dir, "a.png")
+	if err := SavePNG(pngPath, img); err != nil {
+		t.Fatal(err)
+	}
+	// The header wins over a misleading extension.
+	jpgPath := filepath.Join(dir, "actually-jpeg.png")
+	f, err := os.Create(jpgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := jpeg.Encode(f, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{pngPath, "png"},
+		{jpgPath, "jpeg"},
+	}
+	for _, tt := range tests {
+		got, err := DetectFormat(tt.path)
+		if err != nil {
+			t.Fatalf("DetectFormat(%s): %v", tt.path, err)
+		}
+		if got != tt.want {
+			t.Errorf("DetectFormat(%s) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
 func TestLoad_NonexistentFile(t *testing.T) {
 	_, err := Load("/nonexistent/path/image.png")
 	if err == nil {
@@ -144,3 +216,66 @@ func TestLoad_CorruptPNG(t *testing.T) {
 		t.Fatal("expected error for corrupt PNG")
 	}
 }
+
+func TestSniffFormat(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"\x89PNG\r\n\x1a\n\x00\x00\x00\x0d", "png"},
+		{"\xff\xd8\xff\xe0\x00\x10JFIF\x00\x01", "jpeg"},
+		{"RIFF\x24\x00\x00\x00WEBP", "webp"},
+		{"RIFF\x24\x00\x00\x00WAVE", ""},
+		{"GIF89a", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := SniffFormat([]byte(tt.header)); got != tt.want {
+			t.Errorf("SniffFormat(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestLoad_IgnoresExtension(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "actually-png.jpg")
+	if err := SavePNG(path, image.NewRGBA(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatal(err)
+	}
+	img, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if img.Bounds().Dx() != 3 || img.Bounds().Dy() != 2 {
+		t.Errorf("got %v, want 3x2", img.Bounds())
+	}
+}
+
+func TestLoadFS(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodePNG(&buf, image.NewRGBA(image.Rect(0, 0, 4, 3)), PNGOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{
+		"assets/drawing.png": {Data: buf.Bytes()},
+		"assets/notes.txt":   {Data: []byte("not an image")},
+	}
+
+	img, err := LoadFS(fsys, "assets/drawing.png")
+	if err != nil {
+		t.Fatalf("LoadFS: %v", err)
+	}
+	if img.Bounds().Dx() != 4 || img.Bounds().Dy() != 3 {
+		t.Errorf("got %v, want 4x3", img.Bounds())
+	}
+	if format, err := DetectFormatFS(fsys, "assets/drawing.png"); err != nil || format != "png" {
+		t.Errorf("DetectFormatFS = %q, %v; want png", format, err)
+	}
+
+	if _, err := LoadFS(fsys, "assets/missing.png"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing file: got %v, want fs.ErrNotExist", err)
+	}
+	if _, err := LoadFS(fsys, "assets/notes.txt"); err == nil {
+		t.Error("expected error for a non-image file")
+	}
+}
@@ -0,0 +1,237 @@
+package imaging
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"os"
+)
+
+// PaperSize is a page size in PDF points (1/72 inch), portrait.
+type PaperSize struct {
+	Width, Height float64
+}
+
+// Common paper sizes.
+var (
+	PaperA4     = PaperSize{Width: 595.28, Height: 841.89}
+	PaperA5     = PaperSize{Width: 419.53, Height: 595.28}
+	PaperLetter = PaperSize{Width: 612, Height: 792}
+)
+
+// PaperByName returns the paper size called name: "a4", "a5" or "letter".
+func PaperByName(name string) (PaperSize, bool) {
+	switch name {
+	case "a4":
+		return PaperA4, true
+	case "a5":
+		return PaperA5, true
+	case "letter":
+		return PaperLetter, true
+	}
+	return PaperSize{}, false
+}
+
+// PDFOptions controls how images are placed on PDF pages.
+type PDFOptions struct {
+	Paper PaperSize
+
+	// DPI is the print resolution: an image w pixels wide is w/DPI inches
+	// wide on paper. Images too large for the page at this resolution are
+	// scaled down to fit inside the margins, never cropped.
+	DPI float64
+
+	// Margin is the minimum blank border on every side, in points.
+	Margin float64
+
+	// Fit also enlarges images smaller than the page, so they fill it
+	// inside the margins.
+	Fit bool
+}
+
+// EncodePDF writes pages as a PDF document, one image per page, each
+// centered on its page. A page is turned to landscape when the image only
+// fits, or fits larger, that way. Images are embedded losslessly as
+// Flate-compressed RGB; translucent pixels are flattened onto white, as in
+// EncodeJPEG.
+func EncodePDF(w io.Writer, pages []image.Image, opts PDFOptions) error {
+	if len(pages) == 0 {
+		return fmt.Errorf("encoding PDF: no pages")
+	}
+	if opts.DPI <= 0 {
+		return fmt.Errorf("encoding PDF: DPI must be positive, got %g", opts.DPI)
+	}
+	if opts.Paper.Width <= 0 || opts.Paper.Height <= 0 {
+		return fmt.Errorf("encoding PDF: paper size must be positive, got %gx%g pt", opts.Paper.Width, opts.Paper.Height)
+	}
+	if opts.Margin < 0 || 2*opts.Margin >= math.Min(opts.Paper.Width, opts.Paper.Height) {
+		return fmt.Errorf("encoding PDF: margin must be at least 0 and leave room on the page, got %g pt on %gx%g pt paper",
+			opts.Margin, opts.Paper.Width, opts.Paper.Height)
+	}
+
+	pw := &pdfWriter{w: bufio.NewWriter(w)}
+	pw.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+
+	// Objects: 1 catalog, 2 page tree, then page, contents and image for
+	// each page in turn.
+	kids := make([]byte, 0, 8*len(pages))
+	for i := range pages {
+		kids = fmt.Appendf(kids, "%d 0 R ", 3+3*i)
+	}
+	pw.object(1, "<< /Type /Catalog /Pages 2 0 R >>")
+	pw.object(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", bytes.TrimSpace(kids), len(pages)))
+
+	for i, img := range pages {
+		pageID, contentID, imageID := 3+3*i, 4+3*i, 5+3*i
+		b := img.Bounds()
+		paper, x, y, dw, dh := placeOnPage(b.Dx(), b.Dy(), opts)
+
+		pw.object(pageID, fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /XObject << /Im0 %d 0 R >> >> /Contents %d 0 R >>",
+			pdfNum(paper.Width), pdfNum(paper.Height), imageID, contentID))
+
+		content := fmt.Sprintf("q %s 0 0 %s %s %s cm /Im0 Do Q\n", pdfNum(dw), pdfNum(dh), pdfNum(x), pdfNum(y))
+		pw.stream(contentID, "", []byte(content))
+
+		data, err := deflateRGB(flattenOnWhite(img))
+		if err != nil {
+			return fmt.Errorf("encoding PDF page %d: %w", i+1, err)
+		}
+		pw.stream(imageID, fmt.Sprintf(
+			"/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode ",
+			b.Dx(), b.Dy()), data)
+	}
+
+	// Cross-reference table and trailer
+	xref := pw.n
+	n := 2 + 3*len(pages)
+	pw.printf("xref\n0 %d\n0000000000 65535 f \n", n+1)
+	for id := 1; id <= n; id++ {
+		pw.printf("%010d 00000 n \n", pw.offsets[id])
+	}
+	pw.printf("trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", n+1, xref)
+
+	if pw.err != nil {
+		return fmt.Errorf("encoding PDF: %w", pw.err)
+	}
+	if err := pw.w.Flush(); err != nil {
+		return fmt.Errorf("encoding PDF: %w", err)
+	}
+	return nil
+}
+
+// placeOnPage returns the page (portrait or landscape) and the position and
+// size in points at which to draw a w×h pixel image.
+func placeOnPage(w, h int, opts PDFOptions) (paper PaperSize, x, y, dw, dh float64) {
+	natW, natH := float64(w)*72/opts.DPI, float64(h)*72/opts.DPI
+	fit := func(p PaperSize) float64 {
+		availW, availH := p.Width-2*opts.Margin, p.Height-2*opts.Margin
+		s := math.Min(availW/natW, availH/natH)
+		if !opts.Fit {
+			s = math.Min(s, 1)
+		}
+		return s
+	}
+
+	paper = opts.Paper
+	landscape := PaperSize{Width: paper.Height, Height: paper.Width}
+	scale := fit(paper)
+	if s := fit(landscape); s > scale {
+		paper, scale = landscape, s
+	}
+	dw, dh = natW*scale, natH*scale
+	return paper, (paper.Width - dw) / 2, (paper.Height - dh) / 2, dw, dh
+}
+
+// deflateRGB returns the image's pixels as zlib-compressed 8-bit RGB rows,
+// top to bottom.
+func deflateRGB(img image.Image) ([]byte, error) {
+	b := img.Bounds()
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	row := make([]byte, 3*b.Dx())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			i := 3 * (x - b.Min.X)
+			row[i], row[i+1], row[i+2] = byte(r>>8), byte(g>>8), byte(bl>>8)
+		}
+		if _, err := zw.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// pdfWriter tracks byte offsets of the objects it writes for the xref table.
+// The first write error is kept and later writes are skipped.
+type pdfWriter struct {
+	w       *bufio.Writer
+	n       int
+	offsets map[int]int
+	err     error
+}
+
+func (pw *pdfWriter) printf(format string, args ...any) {
+	if pw.err != nil {
+		return
+	}
+	var n int
+	n, pw.err = fmt.Fprintf(pw.w, format, args...)
+	pw.n += n
+}
+
+func (pw *pdfWriter) write(p []byte) {
+	if pw.err != nil {
+		return
+	}
+	var n int
+	n, pw.err = pw.w.Write(p)
+	pw.n += n
+}
+
+func (pw *pdfWriter) begin(id int) {
+	if pw.offsets == nil {
+		pw.offsets = make(map[int]int)
+	}
+	pw.offsets[id] = pw.n
+	pw.printf("%d 0 obj\n", id)
+}
+
+func (pw *pdfWriter) object(id int, body string) {
+	pw.begin(id)
+	pw.printf("%s\nendobj\n", body)
+}
+
+// stream writes a stream object; dict holds extra dictionary entries.
+func (pw *pdfWriter) stream(id int, dict string, data []byte) {
+	pw.begin(id)
+	pw.printf("<< %s/Length %d >>\nstream\n", dict, len(data))
+	pw.write(data)
+	pw.printf("\nendstream\nendobj\n")
+}
+
+// pdfNum formats a coordinate with at most two decimals.
+func pdfNum(f float64) string {
+	return fmt.Sprintf("%.2f", math.Round(f*100)/100)
+}
+
+// SavePDF writes pages to disk as a PDF document.
+// The path is normalized: ~ is expanded and relative paths are resolved.
+func SavePDF(path string, pages []image.Image, opts PDFOptions) error {
+	path = ExpandPath(path)
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+	defer f.Close()
+
+	return EncodePDF(f, pages, opts)
+}
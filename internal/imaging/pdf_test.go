@@ -0,0 +1,120 @@
+package imaging
+
+import (
+	"bytes"
+	"compress/zlib"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestEncodePDF_Structure(t *testing.T) {
+	pages := []image.Image{
+		solid(300, 200, color.RGBA{255, 0, 0, 255}),
+		solid(50, 80, color.RGBA{0, 0, 255, 255}),
+	}
+	var buf bytes.Buffer
+	opts := PDFOptions{Paper: PaperA4, DPI: 300, Margin: 36}
+	if err := EncodePDF(&buf, pages, opts); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatal("missing PDF header or trailer")
+	}
+	if got := bytes.Count(data, []byte("/Type /Page ")); got != 2 {
+		t.Errorf("got %d pages, want 2", got)
+	}
+
+	// Every xref entry must point at the start of its object
+	m := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(data)
+	if m == nil {
+		t.Fatal("no startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(data[xref:], -1)
+	if len(entries) != 8 {
+		t.Fatalf("got %d xref entries, want 8", len(entries))
+	}
+	for i, e := range entries {
+		off, _ := strconv.Atoi(string(e[1]))
+		want := strconv.Itoa(i+1) + " 0 obj"
+		if !bytes.HasPrefix(data[off:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q", i+1, data[off:off+10])
+		}
+	}
+}
+
+func TestEncodePDF_TransparentPage(t *testing.T) {
+	var buf bytes.Buffer
+	opts := PDFOptions{Paper: PaperA4, DPI: 300}
+	if err := EncodePDF(&buf, []image.Image{solid(4, 3, color.RGBA{})}, opts); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	i := bytes.Index(data, []byte("/Subtype /Image"))
+	if i < 0 {
+		t.Fatal("no image object")
+	}
+	start := bytes.Index(data[i:], []byte("stream\n"))
+	zr, err := zlib.NewReader(bytes.NewReader(data[i+start+len("stream\n"):]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pix, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pix) != 4*3*3 {
+		t.Fatalf("got %d bytes of RGB, want %d", len(pix), 4*3*3)
+	}
+	for _, v := range pix {
+		if v != 0xff {
+			t.Fatalf("transparent page: got RGB % x, want white", pix[:3])
+		}
+	}
+}
+
+func TestEncodePDF_InvalidPage(t *testing.T) {
+	pages := []image.Image{solid(30, 20, color.RGBA{255, 0, 0, 255})}
+	for name, opts := range map[string]PDFOptions{
+		"zero paper":      {DPI: 300},
+		"negative margin": {Paper: PaperA4, DPI: 300, Margin: -1},
+		"margin too wide": {Paper: PaperA4, DPI: 300, Margin: 400 * 72 / 25.4},
+		"margin fills":    {Paper: PaperA4, DPI: 300, Margin: PaperA4.Width / 2},
+	} {
+		if err := EncodePDF(&bytes.Buffer{}, pages, opts); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestPlaceOnPage(t *testing.T) {
+	opts := PDFOptions{Paper: PaperA4, DPI: 300, Margin: 36}
+
+	// Small enough: printed at 300 DPI, centered, portrait
+	paper, x, _, dw, _ := placeOnPage(600, 300, opts)
+	if paper != PaperA4 || math.Abs(dw-144) > 0.01 || math.Abs(x-(PaperA4.Width-144)/2) > 0.01 {
+		t.Errorf("small image: paper %v, x %.2f, width %.2f", paper, x, dw)
+	}
+
+	// Too wide for portrait: turned to landscape and shrunk to the margins
+	paper, _, _, dw, dh := placeOnPage(6000, 3000, opts)
+	if paper.Width != PaperA4.Height {
+		t.Errorf("wide image: want landscape, got %v", paper)
+	}
+	if dw > paper.Width-72+0.01 || dh > paper.Height-72+0.01 {
+		t.Errorf("wide image: %.1fx%.1f does not fit inside the margins", dw, dh)
+	}
+
+	// With Fit, a small image is enlarged to the margins, here in landscape
+	opts.Fit = true
+	if paper, _, _, dw, _ := placeOnPage(600, 300, opts); paper.Width != PaperA4.Height || math.Abs(dw-(PaperA4.Height-72)) > 0.01 {
+		t.Errorf("fit: paper %v, width %.2f, want %.2f", paper, dw, PaperA4.Height-72)
+	}
+}
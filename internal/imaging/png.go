@@ -0,0 +1,212 @@
+package imaging
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"sync"
+)
+
+// PNGOptions tunes PNG encoding. The zero value encodes like png.Encode.
+type PNGOptions struct {
+	// Compression trades encoding time for file size. Batch runs are often
+	// dominated by encoding at the default level; png.BestSpeed is several
+	// times faster for a modestly larger file.
+	Compression png.CompressionLevel
+
+	// Interlaced writes an Adam7-interlaced PNG, which browsers display
+	// progressively while it downloads.
+	Interlaced bool
+}
+
+// encoderPool shares the encoders' scratch buffers across calls, so batch
+// runs and web previews do not reallocate them for every image.
+type encoderPool struct{ p sync.Pool }
+
+func (ep *encoderPool) Get() *png.EncoderBuffer {
+	b, _ := ep.p.Get().(*png.EncoderBuffer)
+	return b
+}
+
+func (ep *encoderPool) Put(b *png.EncoderBuffer) { ep.p.Put(b) }
+
+var pngBuffers = &encoderPool{}
+
+// EncodePNG writes img to w as PNG with the given options.
+func EncodePNG(w io.Writer, img image.Image, opts PNGOptions) error {
+	if opts.Interlaced {
+		return encodeInterlaced(w, img, opts.Compression)
+	}
+	enc := png.Encoder{CompressionLevel: opts.Compression, BufferPool: pngBuffers}
+	return enc.Encode(w, img)
+}
+
+// adam7 lists the interlace passes as x offset, y offset, x step, y step.
+var adam7 = [7][4]int{
+	{0, 0, 8, 8},
+	{4, 0, 8, 8},
+	{0, 4, 4, 8},
+	{2, 0, 4, 4},
+	{0, 2, 2, 4},
+	{1, 0, 2, 2},
+	{0, 1, 1, 2},
+}
+
+// encodeInterlaced writes img as an 8-bit truecolor Adam7 PNG, with an alpha
+// channel unless the image reports itself opaque. image/png can decode
+// interlaced files but not write them.
+func encodeInterlaced(w io.Writer, img image.Image, level png.CompressionLevel) error {
+	b := img.Bounds()
+	if b.Dx() <= 0 || b.Dy() <= 0 {
+		return fmt.Errorf("encoding PNG: invalid image size %dx%d", b.Dx(), b.Dy())
+	}
+	opaque := false
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		opaque = o.Opaque()
+	}
+	bpp, colorType := 4, byte(6)
+	if opaque {
+		bpp, colorType = 3, 2
+	}
+
+	var idat bytes.Buffer
+	zw, err := zlib.NewWriterLevel(&idat, zlibLevel(level))
+	if err != nil {
+		return fmt.Errorf("encoding PNG: %w", err)
+	}
+	for _, pass := range adam7 {
+		x0, y0, dx, dy := pass[0], pass[1], pass[2], pass[3]
+		pw := (b.Dx() - x0 + dx - 1) / dx
+		ph := (b.Dy() - y0 + dy - 1) / dy
+		if pw <= 0 || ph <= 0 {
+			continue
+		}
+		prev := make([]byte, pw*bpp)
+		cur := make([]byte, pw*bpp)
+		for y := y0; y < b.Dy(); y += dy {
+			for i, x := 0, x0; x < b.Dx(); i, x = i+1, x+dx {
+				c := color.NRGBAModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA)
+				px := cur[i*bpp:]
+				px[0], px[1], px[2] = c.R, c.G, c.B
+				if bpp == 4 {
+					px[3] = c.A
+				}
+			}
+			if _, err := zw.Write(filterRow(cur, prev, bpp, level == png.NoCompression)); err != nil {
+				return fmt.Errorf("encoding PNG: %w", err)
+			}
+			prev, cur = cur, prev
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("encoding PNG: %w", err)
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("\x89PNG\r\n\x1a\n")
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], uint32(b.Dx()))
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(b.Dy()))
+	ihdr[8], ihdr[9] = 8, colorType // bit depth, color type
+	ihdr[12] = 1                    // Adam7
+	writeChunk(bw, "IHDR", ihdr)
+	writeChunk(bw, "IDAT", idat.Bytes())
+	writeChunk(bw, "IEND", nil)
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("encoding PNG: %w", err)
+	}
+	return nil
+}
+
+func zlibLevel(level png.CompressionLevel) int {
+	switch level {
+	case png.NoCompression:
+		return zlib.NoCompression
+	case png.BestSpeed:
+		return zlib.BestSpeed
+	case png.BestCompression:
+		return zlib.BestCompression
+	default:
+		return zlib.DefaultCompression
+	}
+}
+
+// filterRow returns the filter type byte followed by the filtered row. Like
+// image/png, it picks the filter with the smallest sum of absolute values,
+// or no filter when compression is off.
+func filterRow(cur, prev []byte, bpp int, none bool) []byte {
+	if none {
+		return append([]byte{0}, cur...)
+	}
+	var best []byte
+	bestSum := -1
+	out := make([]byte, 1+len(cur))
+	for ft := byte(0); ft <= 4; ft++ {
+		out[0] = ft
+		sum := 0
+		for i := range cur {
+			var a, c byte
+			if i >= bpp {
+				a, c = cur[i-bpp], prev[i-bpp]
+			}
+			bt := prev[i]
+			var pred byte
+			switch ft {
+			case 1:
+				pred = a
+			case 2:
+				pred = bt
+			case 3:
+				pred = byte((int(a) + int(bt)) / 2)
+			case 4:
+				pred = paeth(a, bt, c)
+			}
+			v := cur[i] - pred
+			out[1+i] = v
+			sum += abs(int(int8(v)))
+		}
+		if bestSum < 0 || sum < bestSum {
+			bestSum = sum
+			best = append(best[:0], out...)
+		}
+	}
+	return best
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	if pa <= pb && pa <= pc {
+		return a
+	}
+	if pb <= pc {
+		return b
+	}
+	return c
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func writeChunk(w *bufio.Writer, typ string, data []byte) {
+	var hdr [8]byte
+	binary.BigEndian.PutUint32(hdr[:4], uint32(len(data)))
+	copy(hdr[4:], typ)
+	w.Write(hdr[:])
+	w.Write(data)
+	crc := crc32.NewIEEE()
+	crc.Write(hdr[4:])
+	crc.Write(data)
+	binary.Write(w, binary.BigEndian, crc.Sum32())
+}
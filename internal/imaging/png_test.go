@@ -0,0 +1,74 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func gradient(w, h int, alpha bool) image.Image {
+	if alpha {
+		img := image.NewNRGBA(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				img.SetNRGBA(x, y, color.NRGBA{uint8(x * 20), uint8(y * 30), uint8(x * y), uint8(100 + x)})
+			}
+		}
+		return img
+	}
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, color.RGBA{uint8(x * 20), uint8(y * 30), uint8(x * y), 255})
+		}
+	}
+	return img
+}
+
+func TestEncodePNG_InterlacedRoundTrip(t *testing.T) {
+	sizes := []image.Point{{1, 1}, {3, 5}, {9, 9}, {13, 2}}
+	levels := []png.CompressionLevel{png.DefaultCompression, png.NoCompression, png.BestSpeed}
+	for _, size := range sizes {
+		for _, alpha := range []bool{false, true} {
+			for _, level := range levels {
+				src := gradient(size.X, size.Y, alpha)
+				var buf bytes.Buffer
+				if err := EncodePNG(&buf, src, PNGOptions{Compression: level, Interlaced: true}); err != nil {
+					t.Fatal(err)
+				}
+				// IHDR data starts at byte 16; the interlace method is its last byte.
+				if got := buf.Bytes()[16+12]; got != 1 {
+					t.Fatalf("%v: interlace method %d, want 1", size, got)
+				}
+				got, err := png.Decode(&buf)
+				if err != nil {
+					t.Fatalf("%v alpha=%v level=%d: %v", size, alpha, level, err)
+				}
+				for y := 0; y < size.Y; y++ {
+					for x := 0; x < size.X; x++ {
+						a := color.NRGBAModel.Convert(src.At(x, y))
+						b := color.NRGBAModel.Convert(got.At(x, y))
+						if a != b {
+							t.Fatalf("%v alpha=%v level=%d pixel (%d,%d): got %v, want %v", size, alpha, level, x, y, b, a)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestEncodePNG_CompressionLevels(t *testing.T) {
+	src := gradient(64, 64, false)
+	for _, level := range []png.CompressionLevel{png.NoCompression, png.BestSpeed, png.BestCompression} {
+		var buf bytes.Buffer
+		if err := EncodePNG(&buf, src, PNGOptions{Compression: level}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := png.Decode(&buf); err != nil {
+			t.Errorf("level %d: %v", level, err)
+		}
+	}
+}
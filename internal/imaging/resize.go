@@ -1,23 +1,17 @@
-package web
+package imaging
 
 import (
 	"image"
-	"io"
 	"math"
 
-	_ "image/jpeg"
-	_ "image/png"
-
 	xdraw "golang.org/x/image/draw"
-	_ "golang.org/x/image/webp"
 )
 
-func decodeImage(r io.Reader) (image.Image, error) {
-	img, _, err := image.Decode(r)
-	return img, err
-}
-
-func scaleDown(img image.Image, maxDim int) image.Image {
+// ScaleDown returns img shrunk, keeping its aspect ratio, so that neither
+// side exceeds maxDim pixels, resampled with a Catmull-Rom kernel widened to
+// the scale so fine detail averages out instead of aliasing. Images
+// already within maxDim, and any image when maxDim <= 0, are returned as is.
+func ScaleDown(img image.Image, maxDim int) image.Image {
 	if img == nil || maxDim <= 0 {
 		return img
 	}
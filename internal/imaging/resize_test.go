@@ -0,0 +1,26 @@
+package imaging
+
+import (
+	"image"
+	"testing"
+)
+
+func TestScaleDown(t *testing.T) {
+	tests := []struct {
+		w, h, max    int
+		wantW, wantH int
+	}{
+		{300, 200, 100, 100, 67},
+		{200, 300, 100, 67, 100},
+		{80, 60, 100, 80, 60},
+		{300, 200, 0, 300, 200},
+		{1000, 2, 100, 100, 1},
+	}
+	for _, tt := range tests {
+		img := image.NewRGBA(image.Rect(0, 0, tt.w, tt.h))
+		got := ScaleDown(img, tt.max).Bounds()
+		if got.Dx() != tt.wantW || got.Dy() != tt.wantH {
+			t.Errorf("ScaleDown(%dx%d, %d) = %dx%d, want %dx%d", tt.w, tt.h, tt.max, got.Dx(), got.Dy(), tt.wantW, tt.wantH)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package imaging
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"os"
+)
+
+// TIFF tag IDs and field types used by the writer.
+const (
+	tagNewSubfileType  = 254
+	tagImageWidth      = 256
+	tagImageLength     = 257
+	tagBitsPerSample   = 258
+	tagCompression     = 259
+	tagPhotometric     = 262
+	tagStripOffsets    = 273
+	tagSamplesPerPixel = 277
+	tagRowsPerStrip    = 278
+	tagStripByteCounts = 279
+	tagXResolution     = 282
+	tagYResolution     = 283
+	tagPlanarConfig    = 284
+	tagResolutionUnit  = 296
+	tagPageNumber      = 297
+	tagPredictor       = 317
+
+	dtShort    = 3
+	dtLong     = 4
+	dtRational = 5
+
+	compressionDeflate  = 8
+	photometricRGB      = 2
+	predictorHorizontal = 2
+	resolutionUnitInch  = 2
+	subfilePage         = 2
+)
+
+// tiffEntries is the number of IFD entries written per page.
+const tiffEntries = 16
+
+// tiffDPI is the resolution recorded in every page. Pixels are not
+// resampled; prepress tools use it only to pick a default print size.
+const tiffDPI = 72
+
+// EncodeTIFF writes pages as a single multi-page TIFF, one page per image,
+// in order. Pages are stored as 8-bit RGB, Deflate-compressed with the
+// horizontal predictor, which every TIFF reader used in prepress accepts.
+// Translucent pixels are flattened onto white, as in EncodeJPEG.
+func EncodeTIFF(w io.Writer, pages []image.Image) error {
+	if len(pages) == 0 {
+		return fmt.Errorf("encoding TIFF: no pages")
+	}
+
+	bw := bufio.NewWriter(w)
+	le := binary.LittleEndian
+	// Header: byte order, magic number, offset of the first IFD
+	bw.Write([]byte{'I', 'I', 42, 0})
+	binary.Write(bw, le, uint32(8))
+
+	offset := uint32(8)
+	for i, page := range pages {
+		strip, err := compressStrip(flattenOnWhite(page))
+		if err != nil {
+			return fmt.Errorf("encoding TIFF page %d: %w", i+1, err)
+		}
+		b := page.Bounds()
+
+		// Page layout: IFD, out-of-line values, then the strip
+		ifdSize := uint32(2 + tiffEntries*12 + 4)
+		bitsOff := offset + ifdSize
+		xResOff := bitsOff + 8
+		yResOff := xResOff + 8
+		stripOff := yResOff + 8
+		next := stripOff + uint32(len(strip))
+		next += next & 1 // IFDs start on a word boundary
+		if i == len(pages)-1 {
+			next = 0
+		}
+
+		entry := func(tag, typ uint16, count, value uint32) {
+			binary.Write(bw, le, tag)
+			binary.Write(bw, le, typ)
+			binary.Write(bw, le, count)
+			binary.Write(bw, le, value)
+		}
+		binary.Write(bw, le, uint16(tiffEntries))
+		entry(tagNewSubfileType, dtLong, 1, subfilePage)
+		entry(tagImageWidth, dtLong, 1, uint32(b.Dx()))
+		entry(tagImageLength, dtLong, 1, uint32(b.Dy()))
+		entry(tagBitsPerSample, dtShort, 3, bitsOff)
+		entry(tagCompression, dtShort, 1, compressionDeflate)
+		entry(tagPhotometric, dtShort, 1, photometricRGB)
+		entry(tagStripOffsets, dtLong, 1, stripOff)
+		entry(tagSamplesPerPixel, dtShort, 1, 3)
+		entry(tagRowsPerStrip, dtLong, 1, uint32(b.Dy()))
+		entry(tagStripByteCounts, dtLong, 1, uint32(len(strip)))
+		entry(tagXResolution, dtRational, 1, xResOff)
+		entry(tagYResolution, dtRational, 1, yResOff)
+		entry(tagPlanarConfig, dtShort, 1, 1)
+		entry(tagResolutionUnit, dtShort, 1, resolutionUnitInch)
+		// Two SHORTs packed into the value field: page index, page count
+		entry(tagPageNumber, dtShort, 2, uint32(i)|uint32(len(pages))<<16)
+		entry(tagPredictor, dtShort, 1, predictorHorizontal)
+		binary.Write(bw, le, next)
+
+		binary.Write(bw, le, [4]uint16{8, 8, 8, 0})
+		binary.Write(bw, le, [2]uint32{tiffDPI, 1})
+		binary.Write(bw, le, [2]uint32{tiffDPI, 1})
+		bw.Write(strip)
+		if len(strip)&1 == 1 && next != 0 {
+			bw.WriteByte(0)
+		}
+		offset = next
+	}
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("encoding TIFF: %w", err)
+	}
+	return nil
+}
+
+// compressStrip returns the page as one Deflate-compressed strip of RGB rows,
+// each row differenced against its previous pixel (TIFF predictor 2).
+func compressStrip(img image.Image) ([]byte, error) {
+	b := img.Bounds()
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	row := make([]byte, 3*b.Dx())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			i := 3 * (x - b.Min.X)
+			row[i], row[i+1], row[i+2] = byte(r>>8), byte(g>>8), byte(bl>>8)
+		}
+		for i := len(row) - 1; i >= 3; i-- {
+			row[i] -= row[i-3]
+		}
+		if _, err := zw.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SaveTIFF writes pages to disk as a multi-page TIFF.
+// The path is normalized: ~ is expanded and relative paths are resolved.
+func SaveTIFF(path string, pages []image.Image) error {
+	path = ExpandPath(path)
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+	defer f.Close()
+
+	return EncodeTIFF(f, pages)
+}
@@ -0,0 +1,93 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+
+	"golang.org/x/image/tiff"
+)
+
+func solid(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+// ifdOffsets walks the IFD chain of a little-endian TIFF.
+func ifdOffsets(t *testing.T, data []byte) []uint32 {
+	t.Helper()
+	le := binary.LittleEndian
+	var offsets []uint32
+	for off := le.Uint32(data[4:]); off != 0; {
+		if int(off)+2 > len(data) {
+			t.Fatalf("IFD offset %d out of range", off)
+		}
+		offsets = append(offsets, off)
+		n := uint32(le.Uint16(data[off:]))
+		off = le.Uint32(data[off+2+12*n:])
+	}
+	return offsets
+}
+
+func TestEncodeTIFF_Pages(t *testing.T) {
+	pages := []image.Image{
+		solid(5, 3, color.RGBA{255, 0, 0, 255}),
+		solid(7, 2, color.RGBA{0, 128, 255, 255}),
+		solid(4, 4, color.RGBA{10, 20, 30, 255}),
+	}
+	var buf bytes.Buffer
+	if err := EncodeTIFF(&buf, pages); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	offsets := ifdOffsets(t, data)
+	if len(offsets) != len(pages) {
+		t.Fatalf("got %d pages, want %d", len(offsets), len(pages))
+	}
+
+	// The decoder reads the first IFD only; point the header at each page
+	// in turn to decode all of them.
+	for i, off := range offsets {
+		page := append([]byte(nil), data...)
+		binary.LittleEndian.PutUint32(page[4:], off)
+		img, err := tiff.Decode(bytes.NewReader(page))
+		if err != nil {
+			t.Fatalf("page %d: %v", i+1, err)
+		}
+		want := pages[i].Bounds()
+		if img.Bounds().Dx() != want.Dx() || img.Bounds().Dy() != want.Dy() {
+			t.Errorf("page %d: got %v, want %v", i+1, img.Bounds(), want)
+		}
+		r, g, b, _ := img.At(want.Dx()-1, want.Dy()-1).RGBA()
+		wr, wg, wb, _ := pages[i].At(0, 0).RGBA()
+		if r != wr || g != wg || b != wb {
+			t.Errorf("page %d pixel: got (%d,%d,%d), want (%d,%d,%d)", i+1, r>>8, g>>8, b>>8, wr>>8, wg>>8, wb>>8)
+		}
+	}
+}
+
+func TestEncodeTIFF_TransparentPage(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeTIFF(&buf, []image.Image{solid(4, 3, color.RGBA{})}); err != nil {
+		t.Fatal(err)
+	}
+	img, err := tiff.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, g, b, _ := img.At(1, 1).RGBA(); r != 0xffff || g != 0xffff || b != 0xffff {
+		t.Errorf("transparent pixel: got (%d,%d,%d), want white", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestEncodeTIFF_NoPages(t *testing.T) {
+	if err := EncodeTIFF(&bytes.Buffer{}, nil); err == nil {
+		t.Fatal("expected error for zero pages")
+	}
+}
@@ -0,0 +1,411 @@
+package imaging
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math/bits"
+	"os"
+	"sort"
+)
+
+// VP8L (lossless WebP) bitstream constants.
+const (
+	vp8lSignature     = 0x2f
+	vp8lMaxDimension  = 1 << 14
+	vp8lLiterals      = 256
+	vp8lLengthCodes   = 24
+	vp8lDistanceCodes = 40
+	vp8lMinMatch      = 3
+	vp8lMaxMatch      = 4096
+	vp8lMaxCodeLength = 15
+
+	// Distance codes of the neighbors in the VP8L distance map.
+	vp8lDistAbove = 1
+	vp8lDistLeft  = 2
+)
+
+// vp8lCodeLengthOrder is the order in which the code length code lengths
+// are stored.
+var vp8lCodeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// EncodeWebP writes img to w as a lossless WebP (VP8L) image. The only
+// backward references used are runs copied from the pixel on the left or
+// above, which keeps the encoder small and still codes the flat zones of a
+// coloring page in a few bits per run.
+func EncodeWebP(w io.Writer, img image.Image) error {
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if width < 1 || height < 1 || width > vp8lMaxDimension || height > vp8lMaxDimension {
+		return fmt.Errorf("encoding WebP: size %dx%d outside 1x1 to %dx%d", width, height, vp8lMaxDimension, vp8lMaxDimension)
+	}
+	pix, hasAlpha := argbPixels(img)
+	tokens := vp8lTokens(pix, width)
+
+	var hist [5][]int
+	for i, size := range []int{vp8lLiterals + vp8lLengthCodes, vp8lLiterals, vp8lLiterals, vp8lLiterals, vp8lDistanceCodes} {
+		hist[i] = make([]int, size)
+	}
+	for _, t := range tokens {
+		if t.length == 0 {
+			hist[0][t.argb>>8&0xff]++
+			hist[1][t.argb>>16&0xff]++
+			hist[2][t.argb&0xff]++
+			hist[3][t.argb>>24]++
+			continue
+		}
+		lp, _, _ := vp8lPrefix(t.length)
+		dp, _, _ := vp8lPrefix(t.dist)
+		hist[0][vp8lLiterals+lp]++
+		hist[4][dp]++
+	}
+	var codes [5]huffmanCode
+	for i := range codes {
+		codes[i] = newHuffmanCode(hist[i], vp8lMaxCodeLength)
+	}
+
+	var bw vp8lWriter
+	bw.write(vp8lSignature, 8)
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	if hasAlpha {
+		bw.write(1, 1)
+	} else {
+		bw.write(0, 1)
+	}
+	bw.write(0, 3) // version
+	bw.write(0, 1) // no transforms
+	bw.write(0, 1) // no color cache
+	bw.write(0, 1) // one set of prefix codes for the whole image
+	for _, c := range codes {
+		bw.writeHuffmanCode(c)
+	}
+	for _, t := range tokens {
+		if t.length == 0 {
+			bw.writeSymbol(codes[0], int(t.argb>>8&0xff))
+			bw.writeSymbol(codes[1], int(t.argb>>16&0xff))
+			bw.writeSymbol(codes[2], int(t.argb&0xff))
+			bw.writeSymbol(codes[3], int(t.argb>>24))
+			continue
+		}
+		lp, ln, lx := vp8lPrefix(t.length)
+		bw.writeSymbol(codes[0], vp8lLiterals+lp)
+		bw.write(lx, ln)
+		dp, dn, dx := vp8lPrefix(t.dist)
+		bw.writeSymbol(codes[4], dp)
+		bw.write(dx, dn)
+	}
+	data := bw.bytes()
+
+	pad := len(data) & 1
+	header := make([]byte, 20)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(12+len(data)+pad))
+	copy(header[8:], "WEBPVP8L")
+	binary.LittleEndian.PutUint32(header[16:], uint32(len(data)))
+	if _, err := w.Write(header); err != nil {
+		return fmt.Errorf("encoding WebP: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("encoding WebP: %w", err)
+	}
+	if pad == 1 {
+		if _, err := w.Write([]byte{0}); err != nil {
+			return fmt.Errorf("encoding WebP: %w", err)
+		}
+	}
+	return nil
+}
+
+// SaveWebP writes an image to disk as lossless WebP.
+// The path is normalized: ~ is expanded and relative paths are resolved.
+func SaveWebP(path string, img image.Image) error {
+	path = ExpandPath(path)
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+	defer f.Close()
+	return EncodeWebP(f, img)
+}
+
+// argbPixels returns the non-premultiplied pixels of img as 0xAARRGGBB,
+// row by row, and whether any of them is not opaque.
+func argbPixels(img image.Image) ([]uint32, bool) {
+	b := img.Bounds()
+	pix := make([]uint32, 0, b.Dx()*b.Dy())
+	hasAlpha := false
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			if c.A != 0xff {
+				hasAlpha = true
+			}
+			pix = append(pix, uint32(c.A)<<24|uint32(c.R)<<16|uint32(c.G)<<8|uint32(c.B))
+		}
+	}
+	return pix, hasAlpha
+}
+
+// vp8lToken is a literal pixel, or with length > 0 a copy of length pixels
+// from the neighbor at distance code dist.
+type vp8lToken struct {
+	argb   uint32
+	length int
+	dist   int
+}
+
+// vp8lTokens splits pix into literals and runs repeating the pixel on the
+// left or above, greedily taking the longer run.
+func vp8lTokens(pix []uint32, width int) []vp8lToken {
+	run := func(i, d int) int {
+		n := 0
+		for i+n < len(pix) && n < vp8lMaxMatch && pix[i+n] == pix[i+n-d] {
+			n++
+		}
+		return n
+	}
+	var tokens []vp8lToken
+	for i := 0; i < len(pix); {
+		best, dist := 0, 0
+		if i >= 1 {
+			best, dist = run(i, 1), vp8lDistLeft
+		}
+		if i >= width {
+			if n := run(i, width); n > best {
+				best, dist = n, vp8lDistAbove
+			}
+		}
+		if best >= vp8lMinMatch {
+			tokens = append(tokens, vp8lToken{length: best, dist: dist})
+			i += best
+			continue
+		}
+		tokens = append(tokens, vp8lToken{argb: pix[i]})
+		i++
+	}
+	return tokens
+}
+
+// vp8lPrefix splits a length or distance code v >= 1 into its prefix
+// symbol and the extra bits that follow it.
+func vp8lPrefix(v int) (prefix int, nExtra uint, extra uint32) {
+	d := v - 1
+	if d < 4 {
+		return d, 0, 0
+	}
+	h := bits.Len(uint(d)) - 1
+	second := d >> (h - 1) & 1
+	return 2*h + second, uint(h - 1), uint32(d & (1<<(h-1) - 1))
+}
+
+// huffmanCode is a canonical prefix code. lengths is what the bitstream
+// declares; a code with a single symbol is declared with length 1 but
+// written with no bits at all.
+type huffmanCode struct {
+	lengths []uint8
+	codes   []uint16 // bit-reversed, ready to be written LSB first
+	single  bool
+}
+
+// newHuffmanCode builds a prefix code for the symbol frequencies freq with
+// no code longer than limit bits. An alphabet without any symbol gets
+// symbol 0, since every code needs one.
+func newHuffmanCode(freq []int, limit int) huffmanCode {
+	c := huffmanCode{lengths: make([]uint8, len(freq)), codes: make([]uint16, len(freq))}
+	var used []int
+	for s, f := range freq {
+		if f > 0 {
+			used = append(used, s)
+		}
+	}
+	if len(used) <= 1 {
+		s := 0
+		if len(used) == 1 {
+			s = used[0]
+		}
+		c.lengths[s] = 1
+		c.single = true
+		return c
+	}
+
+	weights := make([]int, len(used))
+	for i, s := range used {
+		weights[i] = freq[s]
+	}
+	for {
+		depths := huffmanDepths(weights)
+		longest := 0
+		for _, d := range depths {
+			longest = max(longest, d)
+		}
+		if longest <= limit {
+			for i, s := range used {
+				c.lengths[s] = uint8(depths[i])
+			}
+			break
+		}
+		// Flatten the distribution until the tree is shallow enough.
+		for i := range weights {
+			weights[i] = weights[i]/2 + 1
+		}
+	}
+
+	var count, next [vp8lMaxCodeLength + 1]int
+	for _, l := range c.lengths {
+		if l > 0 {
+			count[l]++
+		}
+	}
+	code := 0
+	for l := 1; l <= vp8lMaxCodeLength; l++ {
+		code = (code + count[l-1]) << 1
+		next[l] = code
+	}
+	for s, l := range c.lengths {
+		if l > 0 {
+			c.codes[s] = uint16(bits.Reverse16(uint16(next[l])) >> (16 - l))
+			next[l]++
+		}
+	}
+	return c
+}
+
+// huffmanDepths returns the depth of each leaf of a Huffman tree built over
+// weights (at least two).
+func huffmanDepths(weights []int) []int {
+	n := len(weights)
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return weights[order[a]] < weights[order[b]] })
+
+	// Two-queue construction: leaves in weight order, then internal nodes,
+	// which are created in non-decreasing weight order.
+	w := make([]int, n, 2*n-1)
+	for i, leaf := range order {
+		w[i] = weights[leaf]
+	}
+	parent := make([]int, 2*n-1)
+	leaf, inner := 0, n
+	pick := func() int {
+		if leaf < n && (inner >= len(w) || w[leaf] <= w[inner]) {
+			leaf++
+			return leaf - 1
+		}
+		inner++
+		return inner - 1
+	}
+	for len(w) < 2*n-1 {
+		a, b := pick(), pick()
+		parent[a], parent[b] = len(w), len(w)
+		w = append(w, w[a]+w[b])
+	}
+
+	depth := make([]int, 2*n-1)
+	for i := 2*n - 3; i >= 0; i-- {
+		depth[i] = depth[parent[i]] + 1
+	}
+	out := make([]int, n)
+	for i, leaf := range order {
+		out[leaf] = depth[i]
+	}
+	return out
+}
+
+// vp8lWriter packs bits LSB first, as the VP8L bitstream stores them.
+type vp8lWriter struct {
+	buf []byte
+	acc uint64
+	n   uint
+}
+
+func (bw *vp8lWriter) write(v uint32, n uint) {
+	bw.acc |= uint64(v) << bw.n
+	bw.n += n
+	for bw.n >= 8 {
+		bw.buf = append(bw.buf, byte(bw.acc))
+		bw.acc >>= 8
+		bw.n -= 8
+	}
+}
+
+func (bw *vp8lWriter) writeSymbol(c huffmanCode, s int) {
+	if !c.single {
+		bw.write(uint32(c.codes[s]), uint(c.lengths[s]))
+	}
+}
+
+// bytes flushes the last partial byte and returns the bitstream.
+func (bw *vp8lWriter) bytes() []byte {
+	if bw.n > 0 {
+		bw.buf = append(bw.buf, byte(bw.acc))
+		bw.acc, bw.n = 0, 0
+	}
+	return bw.buf
+}
+
+// writeHuffmanCode declares the code lengths of c, themselves prefix coded
+// with runs of zeros (symbols 17 and 18) and repeats (symbol 16).
+func (bw *vp8lWriter) writeHuffmanCode(c huffmanCode) {
+	type item struct {
+		sym    int
+		nExtra uint
+		extra  uint32
+	}
+	var items []item
+	lengths := c.lengths
+	for i := 0; i < len(lengths); {
+		l := lengths[i]
+		run := 1
+		for i+run < len(lengths) && lengths[i+run] == l {
+			run++
+		}
+		if l == 0 && run >= 3 {
+			r := min(run, 138)
+			if r >= 11 {
+				items = append(items, item{18, 7, uint32(r - 11)})
+			} else {
+				items = append(items, item{17, 3, uint32(r - 3)})
+			}
+			i += r
+			continue
+		}
+		items = append(items, item{sym: int(l)})
+		i++
+		if l != 0 {
+			for run--; run >= 3; {
+				r := min(run, 6)
+				items = append(items, item{16, 2, uint32(r - 3)})
+				i += r
+				run -= r
+			}
+		}
+	}
+
+	freq := make([]int, len(vp8lCodeLengthOrder))
+	for _, it := range items {
+		freq[it.sym]++
+	}
+	lc := newHuffmanCode(freq, 7)
+	n := 4
+	for i, s := range vp8lCodeLengthOrder {
+		if lc.lengths[s] > 0 {
+			n = max(n, i+1)
+		}
+	}
+
+	bw.write(0, 1) // normal, not simple, code
+	bw.write(uint32(n-4), 4)
+	for _, s := range vp8lCodeLengthOrder[:n] {
+		bw.write(uint32(lc.lengths[s]), 3)
+	}
+	bw.write(0, 1) // lengths for the whole alphabet follow
+	for _, it := range items {
+		bw.writeSymbol(lc, it.sym)
+		bw.write(it.extra, it.nExtra)
+	}
+}
@@ -0,0 +1,111 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+func TestEncodeWebP_RoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	noise := image.NewNRGBA(image.Rect(0, 0, 37, 23))
+	rng.Read(noise.Pix)
+
+	// Flat zones with a few outlines, like a coloring page.
+	page := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 300; x++ {
+			c := color.RGBA{255, 255, 255, 255}
+			switch {
+			case x == 150 || y == 100 || x == y:
+				c = color.RGBA{0, 0, 0, 255}
+			case x < 150 && y < 100:
+				c = color.RGBA{200, 30, 30, 255}
+			}
+			page.SetRGBA(x, y, c)
+		}
+	}
+
+	translucent := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for i := range translucent.Pix {
+		translucent.Pix[i] = uint8(i * 7)
+	}
+
+	tests := map[string]image.Image{
+		"noise":       noise,
+		"page":        page,
+		"translucent": translucent,
+		"one pixel":   image.NewRGBA(image.Rect(0, 0, 1, 1)),
+		"offset":      page.SubImage(image.Rect(10, 20, 60, 50)),
+	}
+	for name, src := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := EncodeWebP(&buf, src); err != nil {
+				t.Fatal(err)
+			}
+			if got := SniffFormat(buf.Bytes()); got != "webp" {
+				t.Errorf("SniffFormat = %q, want webp", got)
+			}
+			got, err := webp.Decode(&buf)
+			if err != nil {
+				t.Fatalf("decoding: %v", err)
+			}
+			b := src.Bounds()
+			if got.Bounds().Dx() != b.Dx() || got.Bounds().Dy() != b.Dy() {
+				t.Fatalf("got %v, want %v", got.Bounds(), b)
+			}
+			for y := 0; y < b.Dy(); y++ {
+				for x := 0; x < b.Dx(); x++ {
+					want := color.NRGBAModel.Convert(src.At(b.Min.X+x, b.Min.Y+y))
+					if c := color.NRGBAModel.Convert(got.At(x, y)); c != want {
+						t.Fatalf("pixel (%d,%d): got %v, want %v", x, y, c, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeWebP_FlatImageIsSmall(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 1000, 1000))
+	var buf bytes.Buffer
+	if err := EncodeWebP(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() > 2000 {
+		t.Errorf("flat 1000x1000 image encoded to %d bytes", buf.Len())
+	}
+}
+
+func TestEncodeWebP_TooLarge(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, vp8lMaxDimension+1, 1))
+	if err := EncodeWebP(&bytes.Buffer{}, img); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestNewHuffmanCode_LengthLimit(t *testing.T) {
+	// Fibonacci frequencies give the deepest possible Huffman tree.
+	freq := make([]int, 30)
+	a, b := 1, 1
+	for i := range freq {
+		freq[i] = a
+		a, b = b, a+b
+	}
+	c := newHuffmanCode(freq, vp8lMaxCodeLength)
+	kraft := 0.0
+	for s, l := range c.lengths {
+		if l == 0 || l > vp8lMaxCodeLength {
+			t.Fatalf("symbol %d: length %d", s, l)
+		}
+		kraft += 1 / float64(uint(1)<<l)
+	}
+	if kraft != 1 {
+		t.Errorf("Kraft sum %g, want 1", kraft)
+	}
+}
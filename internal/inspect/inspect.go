@@ -0,0 +1,136 @@
+// Package inspect summarizes an input image and suggests conversion settings.
+package inspect
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/maax3v3/macoma/v2/internal/color"
+)
+
+// Strategy names, matching the CLI and library values.
+const (
+	StrategyBorder = "border"
+	StrategyColor  = "color"
+)
+
+// maxSamples bounds the number of pixels read; larger images are sampled
+// with a fixed stride so inspection stays instant on huge scans.
+const maxSamples = 1 << 20
+
+// Report describes an image and the recommended delimiter settings.
+type Report struct {
+	Width, Height int
+
+	// Colors is the number of distinct RGB colors seen. When Sampled is
+	// true only a subset of pixels was read, so it is a lower bound.
+	Colors  int
+	Sampled bool
+
+	// HasAlpha reports whether any sampled pixel is not fully opaque.
+	HasAlpha bool
+
+	// DarkFraction is the share of pixels that are near-black (every
+	// channel below 64), a proxy for drawn outlines.
+	DarkFraction float64
+
+	// EdgeFraction is the share of pixels that differ noticeably (more than
+	// 10% on some channel) from their right-hand neighbor.
+	EdgeFraction float64
+
+	Strategy  string  // recommended delimiter strategy
+	Tolerance float64 // recommended tolerance % for that strategy
+	Reason    string  // short human-readable justification
+}
+
+// Inspect analyzes img and recommends a delimiter strategy and tolerance.
+func Inspect(img image.Image) Report {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	r := Report{Width: w, Height: h}
+	if w == 0 || h == 0 {
+		r.Strategy, r.Tolerance, r.Reason = StrategyColor, 10, "empty image"
+		return r
+	}
+
+	stride := 1
+	if w*h > maxSamples {
+		stride = (w*h + maxSamples - 1) / maxSamples
+		r.Sampled = true
+	}
+
+	distinct := make(map[color.RGBA]struct{})
+	var samples, dark, edges int
+	for i := 0; i < w*h; i += stride {
+		x, y := i%w, i/w
+		c := color.FromStdColor(img.At(b.Min.X+x, b.Min.Y+y))
+		samples++
+		if c.A < 255 {
+			r.HasAlpha = true
+		}
+		c.A = 255
+		distinct[c] = struct{}{}
+		if c.R < 64 && c.G < 64 && c.B < 64 {
+			dark++
+		}
+		if x+1 < w {
+			n := color.FromStdColor(img.At(b.Min.X+x+1, b.Min.Y+y))
+			if chebyshev(c, n) > 25 {
+				edges++
+			}
+		}
+	}
+	r.Colors = len(distinct)
+	r.DarkFraction = float64(dark) / float64(samples)
+	r.EdgeFraction = float64(edges) / float64(samples)
+
+	recommend(&r)
+	return r
+}
+
+// recommend fills in the strategy fields from the measured statistics.
+//
+// Line art with outlines has a moderate share of near-black pixels and few
+// colors, which the border strategy handles best. Everything else uses the
+// color strategy, with a higher tolerance for photographic or noisy inputs
+// so texture does not turn into delimiters.
+func recommend(r *Report) {
+	flat := r.Colors <= 256
+	outlined := r.DarkFraction >= 0.01 && r.DarkFraction <= 0.35
+
+	switch {
+	case outlined && flat:
+		r.Strategy, r.Tolerance = StrategyBorder, 10
+		r.Reason = fmt.Sprintf("%.1f%% near-black pixels and only %d colors: looks like outlined line art", r.DarkFraction*100, r.Colors)
+	case outlined && r.Colors <= 4096:
+		r.Strategy, r.Tolerance = StrategyBorder, 20
+		r.Reason = fmt.Sprintf("%.1f%% near-black pixels with anti-aliasing (%d colors): outlines with soft edges", r.DarkFraction*100, r.Colors)
+	case flat:
+		r.Strategy, r.Tolerance = StrategyColor, 10
+		r.Reason = fmt.Sprintf("flat colors without outlines (%d colors)", r.Colors)
+	case r.Colors > 20000 || r.EdgeFraction > 0.2:
+		r.Strategy, r.Tolerance = StrategyColor, 20
+		r.Reason = fmt.Sprintf("photographic or noisy input (%d colors, %.0f%% edge pixels): a higher tolerance ignores texture", r.Colors, r.EdgeFraction*100)
+	default:
+		r.Strategy, r.Tolerance = StrategyColor, 15
+		r.Reason = fmt.Sprintf("shaded artwork without strong outlines (%d colors)", r.Colors)
+	}
+}
+
+func chebyshev(a, b color.RGBA) int {
+	d := absDiff(a.R, b.R)
+	if g := absDiff(a.G, b.G); g > d {
+		d = g
+	}
+	if bl := absDiff(a.B, b.B); bl > d {
+		d = bl
+	}
+	return d
+}
+
+func absDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
@@ -0,0 +1,76 @@
+package inspect
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+func TestInspect_OutlinedLineArt(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			c := color.RGBA{255, 200, 0, 255}
+			if x < 50 {
+				c = color.RGBA{0, 120, 255, 255}
+			}
+			if x >= 48 && x < 52 {
+				c = color.RGBA{0, 0, 0, 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	r := Inspect(img)
+	if r.Width != 100 || r.Height != 100 {
+		t.Errorf("dimensions: got %dx%d", r.Width, r.Height)
+	}
+	if r.Colors != 3 {
+		t.Errorf("colors: got %d, want 3", r.Colors)
+	}
+	if r.HasAlpha {
+		t.Error("opaque image reported alpha")
+	}
+	if r.Strategy != StrategyBorder {
+		t.Errorf("strategy: got %q, want border (%s)", r.Strategy, r.Reason)
+	}
+}
+
+func TestInspect_FlatWithoutOutlines(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 40; x++ {
+			c := color.RGBA{200, 50, 50, 255}
+			if y >= 20 {
+				c = color.RGBA{50, 200, 50, 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	img.SetRGBA(0, 0, color.RGBA{200, 50, 50, 100})
+
+	r := Inspect(img)
+	if !r.HasAlpha {
+		t.Error("expected alpha to be detected")
+	}
+	if r.Strategy != StrategyColor || r.Tolerance != 10 {
+		t.Errorf("got %s/%v, want color/10 (%s)", r.Strategy, r.Tolerance, r.Reason)
+	}
+}
+
+func TestInspect_Noisy(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	img := image.NewRGBA(image.Rect(0, 0, 200, 200))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i] = uint8(64 + rng.Intn(192))
+		img.Pix[i+1] = uint8(64 + rng.Intn(192))
+		img.Pix[i+2] = uint8(64 + rng.Intn(192))
+		img.Pix[i+3] = 255
+	}
+
+	r := Inspect(img)
+	if r.Strategy != StrategyColor || r.Tolerance != 20 {
+		t.Errorf("got %s/%v, want color/20 (%s)", r.Strategy, r.Tolerance, r.Reason)
+	}
+}
@@ -3,12 +3,14 @@ package pipeline
 import (
 	"fmt"
 	"image"
+	"os"
 
 	"github.com/maax3v3/macoma/v2/internal/aggregation"
 	"github.com/maax3v3/macoma/v2/internal/cli"
 	"github.com/maax3v3/macoma/v2/internal/detection"
 	"github.com/maax3v3/macoma/v2/internal/imaging"
 	"github.com/maax3v3/macoma/v2/internal/renderer"
+	"github.com/maax3v3/macoma/v2/internal/stats"
 	"github.com/maax3v3/macoma/v2/internal/zone"
 )
 
@@ -26,15 +28,13 @@ func Run(cfg cli.Config, font renderer.FontRenderer) error {
 	fmt.Println("Detecting delimiter pixels...")
 	delim := delimiterFromConfig(cfg)
 	dm := delim.Detect(img)
-	delimCount := countDelimiters(dm)
-	fmt.Printf("Delimiter pixels: %d / %d (%.1f%%)\n",
-		delimCount, dm.Width*dm.Height,
-		float64(delimCount)/float64(dm.Width*dm.Height)*100)
+	strokes := detection.AnalyzeStrokes(dm)
+	fmt.Printf("Delimiter strokes: %d (median thickness %.1f px, total length %.0f px)\n",
+		len(strokes.Strokes), strokes.MedianThickness, strokes.TotalLength)
 
 	// Step 3: Find zones via flood-fill
 	fmt.Println("Finding zones...")
 	zones, labels := zone.FindZones(dm)
-	fmt.Printf("Zones found: %d\n", len(zones))
 
 	// Step 4: Compute per-zone aggregated colors
 	fmt.Println("Computing zone colors...")
@@ -44,18 +44,23 @@ func Run(cfg cli.Config, font renderer.FontRenderer) error {
 	// Step 5: Reduce colors if necessary
 	fmt.Println("Reducing colors...")
 	cm := aggregation.ReduceColors(zoneColors.Colors, cfg.MaxColors)
-	fmt.Printf("Distinct colors: %d\n", len(cm.Entries))
+	stats.Compute(dm, zones, cm).Print(os.Stdout)
 
 	// Step 6: Render output image
 	fmt.Println("Rendering output...")
 	rcfg := renderer.DefaultConfig()
 	// Scale legend elements based on image size
 	scaleLegendConfig(&rcfg, img.Bounds())
+	rcfg.LegendCoverage = cfg.LegendCoverage
+	rcfg.LegendHex = cfg.LegendHex
+	rcfg.LegendZoneCounts = cfg.LegendZoneCounts
+	rcfg.PatternFill = cfg.PatternFill
+	rcfg.RotateLabels = cfg.RotateLabels
 	output := renderer.Render(img, dm, zones, labels, cm, font, rcfg)
 
 	// Step 7: Save output
 	fmt.Printf("Saving output: %s\n", cfg.OutPath)
-	if err := imaging.SavePNG(cfg.OutPath, output); err != nil {
+	if err := save(cfg, output); err != nil {
 		return fmt.Errorf("saving output: %w", err)
 	}
 
@@ -63,29 +68,49 @@ func Run(cfg cli.Config, font renderer.FontRenderer) error {
 	return nil
 }
 
+// save writes output to cfg.OutPath in the format of cfg. Vector formats
+// need the traced zones of the library and are not supported here.
+func save(cfg cli.Config, output image.Image) error {
+	switch format := cfg.OutputFormat(); format {
+	case "png":
+		return imaging.SavePNG(cfg.OutPath, output)
+	case "jpeg":
+		return imaging.SaveJPEG(cfg.OutPath, output, cfg.JPEGQuality)
+	case "webp":
+		return imaging.SaveWebP(cfg.OutPath, output)
+	case "tiff":
+		return imaging.SaveTIFF(cfg.OutPath, []image.Image{output})
+	case "pdf":
+		paper, _ := imaging.PaperByName(cfg.Paper)
+		return imaging.SavePDF(cfg.OutPath, []image.Image{output}, imaging.PDFOptions{
+			Paper:  paper,
+			DPI:    cfg.DPI,
+			Margin: cfg.MarginMM * 72 / 25.4,
+			Fit:    cfg.FitPaper,
+		})
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+}
+
 // delimiterFromConfig builds the appropriate Delimiter from CLI config.
 func delimiterFromConfig(cfg cli.Config) detection.Delimiter {
+	if cfg.DelimiterStrategy == cli.StrategyGrid {
+		return &detection.GridDelimiter{Cols: cfg.GridCells}
+	}
 	if cfg.DelimiterStrategy == cli.StrategyBorder {
 		return &detection.BorderDelimiter{
 			Color:        cfg.BorderDelimiterColor,
 			TolerancePct: cfg.BorderDelimiterTolerance,
+			Auto:         cfg.BorderDelimiterTolerance == 0,
 		}
 	}
 	return &detection.ColorDelimiter{
 		TolerancePct: cfg.ColorDelimiterTolerance,
+		Radius:       cfg.ColorDelimiterRadius,
 	}
 }
 
-func countDelimiters(dm *detection.Map) int {
-	count := 0
-	for _, d := range dm.IsDelimiter {
-		if d {
-			count++
-		}
-	}
-	return count
-}
-
 func scaleLegendConfig(cfg *renderer.Config, bounds image.Rectangle) {
 	w := bounds.Dx()
 	if w > 1000 {
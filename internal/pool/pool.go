@@ -0,0 +1,125 @@
+// Package pool recycles the image-sized buffers of conversions. A Pool is
+// carried through the context of a conversion like a progress callback, so
+// the stages that allocate such buffers take them from it when there is
+// one, and allocate as usual otherwise.
+package pool
+
+import (
+	"context"
+	"math/bits"
+	"sync"
+)
+
+// Pool holds released buffers of each element type for reuse. The zero
+// value is ready to use, and it is safe for concurrent use.
+type Pool struct {
+	bytes classes // of *[]uint8
+	bools classes // of *[]bool
+	ints  classes // of *[]int
+}
+
+// classes keeps buffers by size class: class k holds those with a capacity
+// from 2^k to 2^(k+1)-1, so small scratch buffers and image-sized ones do
+// not displace each other.
+type classes [64]sync.Pool
+
+func class(n int) int { return bits.Len(uint(n)) - 1 }
+
+type key struct{}
+
+// WithPool returns a context whose stages take their buffers from p. A nil
+// p returns ctx unchanged.
+func WithPool(ctx context.Context, p *Pool) context.Context {
+	if p == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, key{}, p)
+}
+
+// From returns the Pool of ctx, or nil when it carries none. Its methods
+// work on a nil Pool, so stages call pool.From(ctx).Bytes(n) either way.
+func From(ctx context.Context) *Pool {
+	p, _ := ctx.Value(key{}).(*Pool)
+	return p
+}
+
+// get returns a zeroed slice of length n from c, or a new one when c has
+// none large enough: a buffer of n's class if it fits, else any of the
+// next class, which always does.
+func get[T any](c *classes, n int) []T {
+	if n > 0 {
+		k := class(n)
+		if v, ok := c[k].Get().(*[]T); ok {
+			if cap(*v) >= n {
+				return zeroed(*v, n)
+			}
+			c[k].Put(v)
+		}
+		if k+1 < len(c) {
+			if v, ok := c[k+1].Get().(*[]T); ok {
+				return zeroed(*v, n)
+			}
+		}
+	}
+	return make([]T, n)
+}
+
+func zeroed[T any](s []T, n int) []T {
+	s = s[:n]
+	clear(s)
+	return s
+}
+
+func put[T any](c *classes, s []T) {
+	if cap(s) == 0 {
+		return
+	}
+	c[class(cap(s))].Put(&s)
+}
+
+// Bytes returns a zeroed []uint8 of length n, from p if it has one. A nil
+// p allocates it.
+func (p *Pool) Bytes(n int) []uint8 {
+	if p == nil {
+		return make([]uint8, n)
+	}
+	return get[uint8](&p.bytes, n)
+}
+
+// Bools is Bytes for a []bool.
+func (p *Pool) Bools(n int) []bool {
+	if p == nil {
+		return make([]bool, n)
+	}
+	return get[bool](&p.bools, n)
+}
+
+// Ints is Bytes for an []int.
+func (p *Pool) Ints(n int) []int {
+	if p == nil {
+		return make([]int, n)
+	}
+	return get[int](&p.ints, n)
+}
+
+// PutBytes hands b back to p for reuse; the caller must not use b
+// afterwards. It does nothing on a nil p.
+func (p *Pool) PutBytes(b []uint8) {
+	if p != nil {
+		put(&p.bytes, b)
+	}
+}
+
+// PutBools is PutBytes for a []bool.
+func (p *Pool) PutBools(b []bool) {
+	if p != nil {
+		put(&p.bools, b)
+	}
+}
+
+// PutInts is PutBytes for an []int.
+func (p *Pool) PutInts(s []int) {
+	if p != nil {
+		put(&p.ints, s)
+	}
+}
@@ -0,0 +1,78 @@
+package pool
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFrom(t *testing.T) {
+	if From(context.Background()) != nil {
+		t.Error("a bare context should carry no Pool")
+	}
+	p := &Pool{}
+	if From(WithPool(context.Background(), p)) != p {
+		t.Error("From should return the Pool of WithPool")
+	}
+	if ctx := context.Background(); WithPool(ctx, nil) != ctx {
+		t.Error("WithPool(ctx, nil) should return ctx")
+	}
+}
+
+func TestNilPool(t *testing.T) {
+	var p *Pool
+	if b := p.Bytes(5); len(b) != 5 {
+		t.Errorf("Bytes: got length %d, want 5", len(b))
+	}
+	if b := p.Bools(3); len(b) != 3 {
+		t.Errorf("Bools: got length %d, want 3", len(b))
+	}
+	if s := p.Ints(4); len(s) != 4 {
+		t.Errorf("Ints: got length %d, want 4", len(s))
+	}
+	// Putting into a nil Pool is a no-op
+	p.PutBytes(make([]uint8, 1))
+	p.PutBools(make([]bool, 1))
+	p.PutInts(make([]int, 1))
+}
+
+func TestPool_ReusesZeroed(t *testing.T) {
+	p := &Pool{}
+	// sync.Pool may drop buffers at any time, so only a reused buffer is
+	// checked, and a few rounds make reuse all but certain.
+	reused := false
+	for round := 0; round < 10 && !reused; round++ {
+		s := p.Ints(1000)
+		for i := range s {
+			s[i] = i + 1
+		}
+		p.PutInts(s)
+
+		got := p.Ints(900)
+		if len(got) != 900 {
+			t.Fatalf("got length %d, want 900", len(got))
+		}
+		reused = &got[0] == &s[0]
+		for i, v := range got {
+			if v != 0 {
+				t.Fatalf("element %d of a reused buffer is %d, want 0", i, v)
+			}
+		}
+		p.PutInts(got)
+	}
+	if !reused {
+		t.Error("the released buffer was never reused")
+	}
+}
+
+func TestPool_SizeClasses(t *testing.T) {
+	p := &Pool{}
+	p.PutBytes(make([]uint8, 10))
+	// A buffer too small for the request is not returned
+	if b := p.Bytes(15); len(b) != 15 {
+		t.Errorf("got length %d, want 15", len(b))
+	}
+	// nor is a zero-length request served from the pool
+	if b := p.Bytes(0); len(b) != 0 {
+		t.Errorf("got length %d, want 0", len(b))
+	}
+}
@@ -0,0 +1,67 @@
+// Package progress carries an optional progress callback through the
+// context of a conversion, so the stages report how far along they are
+// without every signature growing a callback parameter.
+package progress
+
+import (
+	"context"
+	"sync"
+)
+
+// Stage names, in pipeline order.
+const (
+	Detection = "detection"
+	Zones     = "zones"
+	Colors    = "colors"
+	Reduction = "reduction"
+	Render    = "render"
+)
+
+// Func receives progress updates: the stage name and how much of it is
+// done, 0–100.
+type Func func(stage string, percent int)
+
+type reporter struct {
+	fn   Func
+	mu   sync.Mutex
+	last map[string]int
+}
+
+type key struct{}
+
+// WithFunc returns a context whose stages report progress to fn. fn is
+// called from one goroutine at a time, only when a stage's percentage
+// increases, and each stage ends with a call at 100.
+func WithFunc(ctx context.Context, fn Func) context.Context {
+	if fn == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, key{}, &reporter{fn: fn, last: make(map[string]int)})
+}
+
+// Report records that done of total units of stage are finished. It is
+// safe for concurrent use and does nothing when ctx carries no Func.
+func Report(ctx context.Context, stage string, done, total int) {
+	r, ok := ctx.Value(key{}).(*reporter)
+	if !ok {
+		return
+	}
+	pct := 100
+	if total > 0 && done < total {
+		pct = done * 100 / total
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if last, seen := r.last[stage]; seen && pct <= last {
+		return
+	}
+	r.last[stage] = pct
+	r.fn(stage, pct)
+}
+
+// Mute returns ctx without its Func, for work that is one part of a stage
+// and would otherwise report the whole stage done; the caller reports the
+// stage itself.
+func Mute(ctx context.Context) context.Context {
+	return context.WithValue(ctx, key{}, nil)
+}
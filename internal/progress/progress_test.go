@@ -0,0 +1,66 @@
+package progress
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestReport_MonotonicAndDeduplicated(t *testing.T) {
+	var got []int
+	ctx := WithFunc(context.Background(), func(stage string, percent int) {
+		if stage != Zones {
+			t.Errorf("stage: got %q", stage)
+		}
+		got = append(got, percent)
+	})
+	for _, done := range []int{0, 1, 1, 5, 3, 10} {
+		Report(ctx, Zones, done, 10)
+	}
+	want := []int{0, 10, 50, 100}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestReport_Concurrent(t *testing.T) {
+	calls := 0 // only touched by the callback, which is serialized
+	ctx := WithFunc(context.Background(), func(string, int) { calls++ })
+	var wg sync.WaitGroup
+	for i := 1; i <= 100; i++ {
+		wg.Add(1)
+		go func(done int) {
+			defer wg.Done()
+			Report(ctx, Render, done, 100)
+		}(i)
+	}
+	wg.Wait()
+	if calls < 1 || calls > 100 {
+		t.Errorf("got %d calls", calls)
+	}
+}
+
+func TestReport_WithoutFunc(t *testing.T) {
+	Report(context.Background(), Render, 1, 2) // must not panic
+	if ctx := context.Background(); WithFunc(ctx, nil) != ctx {
+		t.Error("WithFunc(nil) should return ctx unchanged")
+	}
+}
+
+func TestMute(t *testing.T) {
+	calls := 0
+	ctx := WithFunc(context.Background(), func(string, int) { calls++ })
+	Report(Mute(ctx), Detection, 1, 1)
+	if calls != 0 {
+		t.Errorf("muted context reported %d times", calls)
+	}
+	Report(ctx, Detection, 1, 1)
+	if calls != 1 {
+		t.Errorf("original context: got %d calls, want 1", calls)
+	}
+}
@@ -0,0 +1,458 @@
+// Package qr encodes short texts, such as URLs, as QR codes (ISO/IEC
+// 18004): byte mode, error correction level M, versions 1 to 10.
+package qr
+
+import "fmt"
+
+// MaxBytes is the longest text Encode accepts: the byte-mode capacity of
+// a version 10 code at level M.
+const MaxBytes = 213
+
+// QuietZone is the width in modules of the light margin a reader needs
+// around the code.
+const QuietZone = 4
+
+// Per version 1–10 at level M: error correction codewords per block and
+// number of blocks.
+var (
+	eccPerBlock = [11]int{0, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26}
+	numBlocks   = [11]int{0, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5}
+)
+
+// formatBitsM are the two error correction level bits of M.
+const formatBitsM = 0
+
+// Code is an encoded QR symbol of Size × Size modules, without its quiet
+// zone.
+type Code struct {
+	Size    int
+	Version int
+	Mask    int
+
+	dark     []bool
+	function []bool // finder, timing, alignment and format modules
+}
+
+// Dark reports whether module (x, y) is dark. Modules outside the symbol,
+// in the quiet zone, are light.
+func (c *Code) Dark(x, y int) bool {
+	return x >= 0 && x < c.Size && y >= 0 && y < c.Size && c.dark[y*c.Size+x]
+}
+
+// Encode encodes text in the smallest version that fits, with the mask
+// that scores the lowest penalty.
+func Encode(text string) (*Code, error) {
+	data := []byte(text)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("nothing to encode")
+	}
+	version := 0
+	for v := 1; v <= 10; v++ {
+		countBits := 8
+		if v >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) <= 8*dataCodewords(v) {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, fmt.Errorf("text too long for a QR code: %d bytes, at most %d", len(data), MaxBytes)
+	}
+
+	codewords := addECC(dataBits(data, version), version)
+
+	var best *Code
+	bestPenalty := 0
+	for mask := 0; mask < 8; mask++ {
+		c := newCode(version)
+		c.drawCodewords(codewords)
+		c.applyMask(mask)
+		c.drawFormat(mask)
+		c.Mask = mask
+		if p := c.penalty(); best == nil || p < bestPenalty {
+			best, bestPenalty = c, p
+		}
+	}
+	return best, nil
+}
+
+// rawCodewords is the number of 8-bit codewords a version holds, data and
+// error correction together.
+func rawCodewords(v int) int {
+	modules := (16*v+128)*v + 64
+	if v >= 2 {
+		align := v/7 + 2
+		modules -= (25*align-10)*align - 55
+		if v >= 7 {
+			modules -= 36
+		}
+	}
+	return modules / 8
+}
+
+func dataCodewords(v int) int {
+	return rawCodewords(v) - eccPerBlock[v]*numBlocks[v]
+}
+
+// dataBits builds the data codewords: byte mode indicator, character
+// count, the bytes, a terminator, and alternating pad codewords.
+func dataBits(data []byte, version int) []byte {
+	var bits []bool
+	appendBits := func(val, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, val>>i&1 != 0)
+		}
+	}
+	appendBits(0b0100, 4)
+	if version >= 10 {
+		appendBits(len(data), 16)
+	} else {
+		appendBits(len(data), 8)
+	}
+	for _, b := range data {
+		appendBits(int(b), 8)
+	}
+	capacity := 8 * dataCodewords(version)
+	appendBits(0, min(4, capacity-len(bits)))
+	appendBits(0, (8-len(bits)%8)%8)
+
+	out := make([]byte, 0, capacity/8)
+	for i := 0; i < len(bits); i += 8 {
+		var b byte
+		for j := 0; j < 8; j++ {
+			if bits[i+j] {
+				b |= 1 << (7 - j)
+			}
+		}
+		out = append(out, b)
+	}
+	for pad := byte(0xEC); len(out) < capacity/8; pad ^= 0xEC ^ 0x11 {
+		out = append(out, pad)
+	}
+	return out
+}
+
+// addECC splits data into blocks, appends each block's error correction
+// codewords and interleaves the blocks. Short blocks come first and are
+// one data codeword shorter than long ones.
+func addECC(data []byte, version int) []byte {
+	nBlocks, eccLen := numBlocks[version], eccPerBlock[version]
+	raw := rawCodewords(version)
+	numShort := nBlocks - raw%nBlocks
+	shortLen := raw / nBlocks // of a short block, with its ECC
+	divisor := rsDivisor(eccLen)
+
+	blocks := make([][]byte, nBlocks)
+	for i, k := 0, 0; i < nBlocks; i++ {
+		n := shortLen - eccLen
+		if i >= numShort {
+			n++
+		}
+		dat := data[k : k+n]
+		k += n
+		blocks[i] = append(append([]byte{}, dat...), rsRemainder(dat, divisor)...)
+	}
+
+	out := make([]byte, 0, raw)
+	for i := 0; i <= shortLen; i++ {
+		for j, b := range blocks {
+			// Short blocks have no codeword at the last data position
+			// of long blocks.
+			if j < numShort && i >= shortLen-eccLen {
+				if i == shortLen-eccLen {
+					continue
+				}
+				out = append(out, b[i-1])
+				continue
+			}
+			out = append(out, b[i])
+		}
+	}
+	return out
+}
+
+// rsDivisor returns the Reed–Solomon generator polynomial of the given
+// degree, leading coefficient dropped, over GF(2^8) modulo 0x11D.
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+	return result
+}
+
+// rsRemainder returns the error correction codewords of data.
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coef := range divisor {
+			result[i] ^= gfMul(coef, factor)
+		}
+	}
+	return result
+}
+
+func gfMul(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+// newCode draws the function patterns of a version: finders with their
+// separators, timing lines, alignment patterns, version information and
+// the reserved format areas.
+func newCode(version int) *Code {
+	size := 4*version + 17
+	c := &Code{Size: size, Version: version, dark: make([]bool, size*size), function: make([]bool, size*size)}
+
+	for i := 0; i < size; i++ {
+		c.set(6, i, i%2 == 0)
+		c.set(i, 6, i%2 == 0)
+	}
+	for _, p := range [][2]int{{3, 3}, {size - 4, 3}, {3, size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := p[0]+dx, p[1]+dy
+				if x < 0 || x >= size || y < 0 || y >= size {
+					continue
+				}
+				d := max(abs(dx), abs(dy))
+				c.set(x, y, d != 2 && d != 4)
+			}
+		}
+	}
+	pos := alignmentPositions(version)
+	for i, ay := range pos {
+		for j, ax := range pos {
+			// Skip the three that would overlap a finder.
+			if i == 0 && j == 0 || i == 0 && j == len(pos)-1 || i == len(pos)-1 && j == 0 {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					c.set(ax+dx, ay+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+	c.drawFormat(0) // reserve the areas; Encode redraws them
+	if version >= 7 {
+		bits := versionBits(version)
+		for i := 0; i < 18; i++ {
+			a, b := size-11+i%3, i/3
+			c.set(a, b, bits>>i&1 != 0)
+			c.set(b, a, bits>>i&1 != 0)
+		}
+	}
+	return c
+}
+
+// alignmentPositions returns the row and column centers of the alignment
+// patterns of a version.
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	n := version/7 + 2
+	step := (version*4 + n*2 + 1) / (n*2 - 2) * 2
+	pos := make([]int, n)
+	pos[0] = 6
+	for i, p := n-1, 4*version+10; i >= 1; i, p = i-1, p-step {
+		pos[i] = p
+	}
+	return pos
+}
+
+// set draws a function module.
+func (c *Code) set(x, y int, dark bool) {
+	c.dark[y*c.Size+x] = dark
+	c.function[y*c.Size+x] = true
+}
+
+// drawFormat draws both copies of the format information for level M and
+// mask, and the dark module.
+func (c *Code) drawFormat(mask int) {
+	bits := formatBits(mask)
+	bit := func(i int) bool { return bits>>i&1 != 0 }
+
+	for i := 0; i <= 5; i++ {
+		c.set(8, i, bit(i))
+	}
+	c.set(8, 7, bit(6))
+	c.set(8, 8, bit(7))
+	c.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		c.set(c.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.set(8, c.Size-15+i, bit(i))
+	}
+	c.set(8, c.Size-8, true)
+}
+
+// formatBits returns the 15-bit format information of level M and mask:
+// five data bits and a BCH(15, 5) remainder, XORed with 0x5412.
+func formatBits(mask int) int {
+	data := formatBitsM<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// versionBits returns the 18-bit version information of versions 7 and
+// up: six data bits and a BCH(18, 6) remainder.
+func versionBits(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = rem<<1 ^ (rem>>11)*0x1F25
+	}
+	return version<<12 | rem
+}
+
+// drawCodewords places the codeword bits in the zigzag of two-module
+// columns, right to left, skipping function modules. Leftover modules
+// stay light.
+func (c *Code) drawCodewords(data []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // skip the vertical timing line
+		}
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert // upward column
+				}
+				if !c.function[y*c.Size+x] && i < len(data)*8 {
+					c.dark[y*c.Size+x] = data[i>>3]>>(7-i&7)&1 != 0
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask inverts the data modules selected by mask.
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !c.function[y*c.Size+x] {
+				c.dark[y*c.Size+x] = !c.dark[y*c.Size+x]
+			}
+		}
+	}
+}
+
+// penalty scores the symbol by the four rules of the standard: long runs,
+// 2×2 blocks, finder-like patterns and dark/light imbalance.
+func (c *Code) penalty() int {
+	n := c.Size
+	score := 0
+	finderLike := func(line []bool, i int) bool {
+		// 1:1:3:1:1 dark-light-dark-light-dark with four light modules
+		// on one side.
+		pattern := [11]bool{true, false, true, true, true, false, true, false, false, false, false}
+		fwd, back := true, true
+		for k := 0; k < 11; k++ {
+			if line[i+k] != pattern[k] {
+				fwd = false
+			}
+			if line[i+k] != pattern[10-k] {
+				back = false
+			}
+		}
+		return fwd || back
+	}
+	line := make([]bool, n)
+	for pass := 0; pass < 2; pass++ {
+		for a := 0; a < n; a++ {
+			for b := 0; b < n; b++ {
+				if pass == 0 {
+					line[b] = c.dark[a*n+b]
+				} else {
+					line[b] = c.dark[b*n+a]
+				}
+			}
+			run := 1
+			for b := 1; b <= n; b++ {
+				if b < n && line[b] == line[b-1] {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += 3 + run - 5
+				}
+				run = 1
+			}
+			for b := 0; b+11 <= n; b++ {
+				if finderLike(line, b) {
+					score += 40
+				}
+			}
+		}
+	}
+	dark := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			d := c.dark[y*n+x]
+			if d {
+				dark++
+			}
+			if x+1 < n && y+1 < n && d == c.dark[y*n+x+1] && d == c.dark[(y+1)*n+x] && d == c.dark[(y+1)*n+x+1] {
+				score += 3
+			}
+		}
+	}
+	total := n * n
+	score += ((abs(dark*20-total*10)+total-1)/total - 1) * 10
+	return score
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
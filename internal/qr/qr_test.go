@@ -0,0 +1,83 @@
+package qr
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRSRemainder(t *testing.T) {
+	// The "HELLO WORLD" 1-M example of the standard's tutorials.
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := rsRemainder(data, rsDivisor(10)); !bytes.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestFormatBits(t *testing.T) {
+	want := []int{
+		0b101010000010010, 0b101000100100101, 0b101111001111100, 0b101101101001011,
+		0b100010111111001, 0b100000011001110, 0b100111110010111, 0b100101010100000,
+	}
+	for mask, w := range want {
+		if got := formatBits(mask); got != w {
+			t.Errorf("mask %d: got %015b, want %015b", mask, got, w)
+		}
+	}
+}
+
+func TestVersionBits(t *testing.T) {
+	if got := versionBits(7); got != 0x07C94 {
+		t.Errorf("version 7: got %#x, want 0x07c94", got)
+	}
+	if got := versionBits(10); got != 0x0A4D3 {
+		t.Errorf("version 10: got %#x, want 0x0a4d3", got)
+	}
+}
+
+func TestDataCodewords(t *testing.T) {
+	want := []int{0, 16, 28, 44, 64, 86, 108, 124, 154, 182, 216}
+	for v := 1; v <= 10; v++ {
+		if got := dataCodewords(v); got != want[v] {
+			t.Errorf("version %d: got %d data codewords, want %d", v, got, want[v])
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		text    string
+		version int
+	}{
+		{"a", 1},
+		{strings.Repeat("x", 14), 1},
+		{strings.Repeat("x", 15), 2},
+		{"https://example.com/coloring/cat-solution.png", 4},
+		{strings.Repeat("x", MaxBytes), 10},
+	}
+	for _, tt := range tests {
+		c, err := Encode(tt.text)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", len(tt.text), err)
+		}
+		if c.Version != tt.version || c.Size != 4*tt.version+17 {
+			t.Errorf("%d bytes: got version %d of size %d, want version %d", len(tt.text), c.Version, c.Size, tt.version)
+		}
+		// Finder centers are dark, their separators light.
+		for _, p := range [][2]int{{3, 3}, {c.Size - 4, 3}, {3, c.Size - 4}} {
+			if !c.Dark(p[0], p[1]) || c.Dark(p[0]+4, p[1]) && c.Dark(p[0]-4, p[1]) {
+				t.Errorf("%d bytes: bad finder at %v", len(tt.text), p)
+			}
+		}
+		if c.Dark(-1, 0) || c.Dark(c.Size, 0) {
+			t.Error("the quiet zone should be light")
+		}
+	}
+	if _, err := Encode(strings.Repeat("x", MaxBytes+1)); err == nil {
+		t.Error("expected an error for a text over MaxBytes")
+	}
+	if _, err := Encode(""); err == nil {
+		t.Error("expected an error for an empty text")
+	}
+}
@@ -0,0 +1,113 @@
+// Package quality scores how trustworthy a delimiter detection looks, so
+// automated pipelines can route dubious inputs to a human.
+package quality
+
+import (
+	"math"
+
+	"github.com/maax3v3/macoma/v2/internal/detection"
+	"github.com/maax3v3/macoma/v2/internal/zone"
+)
+
+// Report holds the detection confidence and the sub-scores it combines.
+// Every value is in [0, 1], higher is better.
+type Report struct {
+	Score float64 // weighted combination of the sub-scores
+
+	// Density scores the share of delimiter pixels: outlines typically
+	// cover 1–25% of a drawing; far less means missed borders, far more
+	// means texture was taken for borders.
+	Density float64
+
+	// Continuity is the share of delimiter length in strokes long enough
+	// to be real outlines rather than specks of noise.
+	Continuity float64
+
+	// Closure checks that the borders actually close into zones: it drops
+	// when most of the image leaks into a single zone, or when the drawing
+	// shatters into many tiny zones.
+	Closure float64
+}
+
+// Sub-score weights; they sum to 1.
+const (
+	densityWeight    = 0.3
+	continuityWeight = 0.3
+	closureWeight    = 0.4
+)
+
+// Thresholds used by the sub-scores.
+const (
+	minDensity      = 0.01
+	maxDensity      = 0.25
+	tinyZonePixels  = 16
+	maxLargestShare = 0.6
+)
+
+// Assess scores a delimiter map and the zones found in it.
+func Assess(dm *detection.Map, zones []zone.Zone) Report {
+	total := dm.Width * dm.Height
+	if total == 0 {
+		return Report{}
+	}
+	r := Report{
+		Density:    densityScore(float64(dm.Count()) / float64(total)),
+		Continuity: continuityScore(dm),
+		Closure:    closureScore(zones),
+	}
+	r.Score = densityWeight*r.Density + continuityWeight*r.Continuity + closureWeight*r.Closure
+	return r
+}
+
+func densityScore(d float64) float64 {
+	switch {
+	case d < minDensity:
+		return d / minDensity
+	case d > maxDensity:
+		return math.Max(0, 1-(d-maxDensity)/maxDensity)
+	}
+	return 1
+}
+
+func continuityScore(dm *detection.Map) float64 {
+	stats := detection.AnalyzeStrokes(dm)
+	if stats.TotalLength == 0 {
+		// No borders at all: nothing is broken, density and closure
+		// account for the missing outlines.
+		return 1
+	}
+	minLen := math.Max(8, 0.02*float64(min(dm.Width, dm.Height)))
+	long := 0.0
+	for _, s := range stats.Strokes {
+		if s.Length >= minLen {
+			long += s.Length
+		}
+	}
+	return long / stats.TotalLength
+}
+
+func closureScore(zones []zone.Zone) float64 {
+	if len(zones) == 0 {
+		return 0
+	}
+	filler, largest, tiny := 0, 0, 0
+	for i := range zones {
+		n := zones[i].Area()
+		filler += n
+		largest = max(largest, n)
+		if n < tinyZonePixels {
+			tiny++
+		}
+	}
+	leak := 1.0
+	if share := float64(largest) / float64(filler); share > maxLargestShare && len(zones) > 1 {
+		leak = (1 - share) / (1 - maxLargestShare)
+	}
+	if len(zones) == 1 {
+		// A single zone is a valid (if dull) coloring only for a blank
+		// image; anything with borders should have closed into more.
+		leak = 0.5
+	}
+	shatter := 1 - float64(tiny)/float64(len(zones))
+	return math.Min(leak, shatter)
+}
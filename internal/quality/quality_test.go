@@ -0,0 +1,60 @@
+package quality
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/maax3v3/macoma/v2/internal/detection"
+	"github.com/maax3v3/macoma/v2/internal/zone"
+)
+
+func assess(dm *detection.Map) Report {
+	zones, _ := zone.FindZones(dm)
+	return Assess(dm, zones)
+}
+
+func TestAssess_CleanOutlines(t *testing.T) {
+	// A 100x100 image divided into four quadrants by a 2px cross.
+	dm := detection.NewMap(100, 100)
+	for i := 0; i < 100; i++ {
+		for d := 49; d <= 50; d++ {
+			dm.IsDelimiter[i*100+d] = true
+			dm.IsDelimiter[d*100+i] = true
+		}
+	}
+	r := assess(dm)
+	if r.Score < 0.9 {
+		t.Errorf("clean outlines scored %+v, want >= 0.9", r)
+	}
+}
+
+func TestAssess_Noise(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	dm := detection.NewMap(100, 100)
+	for i := range dm.IsDelimiter {
+		dm.IsDelimiter[i] = rng.Float64() < 0.3
+	}
+	r := assess(dm)
+	if r.Score > 0.5 {
+		t.Errorf("speckle noise scored %+v, want <= 0.5", r)
+	}
+}
+
+func TestAssess_Leak(t *testing.T) {
+	// A single short line that never closes a zone.
+	dm := detection.NewMap(100, 100)
+	for x := 10; x < 60; x++ {
+		dm.IsDelimiter[50*100+x] = true
+	}
+	r := assess(dm)
+	if r.Closure > 0.5 {
+		t.Errorf("unclosed border got closure %.2f, want <= 0.5", r.Closure)
+	}
+}
+
+func TestAssess_Empty(t *testing.T) {
+	r := Assess(detection.NewMap(0, 0), nil)
+	if r != (Report{}) {
+		t.Errorf("got %+v, want zero report", r)
+	}
+}
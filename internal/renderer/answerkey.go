@@ -0,0 +1,132 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/maax3v3/macoma/v2/internal/aggregation"
+	"github.com/maax3v3/macoma/v2/internal/detection"
+	"github.com/maax3v3/macoma/v2/internal/zone"
+)
+
+// RenderAnswerKey draws the solution of a coloring: every zone filled with
+// its legend color and the delimiters in black, at the size of the drawing.
+// It has no numbers and no legend, so it can be printed next to the
+// coloring page or checked against a finished one.
+func RenderAnswerKey(dm *detection.Map, zones []zone.Zone, cm *aggregation.ColorMap, cfg Config) *image.RGBA {
+	out := image.NewRGBA(image.Rect(0, 0, dm.Width, dm.Height))
+	for i := range out.Pix {
+		out.Pix[i] = 0xff
+	}
+
+	for i := range zones {
+		c := cm.Entries[cm.ZoneMap[i]].Color.ToStdColor()
+		zones[i].Each(func(x, y int) { out.SetRGBA(x, y, c) })
+	}
+
+	var labels []int
+	if cfg.LineWidth > 0 {
+		// Only delimiter pixels are expanded into; the rest, such as a
+		// margin, is outside the drawing
+		labels = make([]int, dm.Width*dm.Height)
+		for i := range labels {
+			labels[i] = zone.Outside
+			if dm.IsDelimiter[i] {
+				labels[i] = -1
+			}
+		}
+		for i := range zones {
+			zones[i].Each(func(x, y int) { labels[y*dm.Width+x] = i })
+		}
+		// Thinner lines uncover delimiter pixels; color them like the
+		// nearest zone
+		for i, l := range zone.ExpandLabels(labels, dm.Width, dm.Height) {
+			if labels[i] < 0 && l >= 0 {
+				out.SetRGBA(i%dm.Width, i/dm.Width, cm.Entries[cm.ZoneMap[l]].Color.ToStdColor())
+			}
+		}
+	}
+	dm = borderMap(dm, labels, cfg)
+	for y := 0; y < dm.Height; y++ {
+		for x := 0; x < dm.Width; x++ {
+			if dm.At(x, y) {
+				out.SetRGBA(x, y, cfg.LineColor)
+			}
+		}
+	}
+	return out
+}
+
+// RenderPalettePreview recolors srcImg with the reduced palette: every zone
+// pixel takes its zone's legend color, while delimiters and pixels outside
+// any zone keep their source colors. With no numbers, no legend and no white
+// fill, it shows at a glance whether the palette lost colors that mattered.
+func RenderPalettePreview(srcImg image.Image, zones []zone.Zone, cm *aggregation.ColorMap) *image.RGBA {
+	b := srcImg.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(out, out.Rect, srcImg, b.Min, draw.Src)
+	for i := range zones {
+		c := cm.Entries[cm.ZoneMap[i]].Color.ToStdColor()
+		zones[i].Each(func(x, y int) { out.SetRGBA(x, y, c) })
+	}
+	return out
+}
+
+// RenderSolution draws the numbered answer key handed out with a coloring:
+// every zone filled with its legend color, its number on top in black or
+// white (whichever reads better on that color), and the legend below.
+// Numbers are placed exactly as Render places them.
+func RenderSolution(
+	srcImg image.Image,
+	dm *detection.Map,
+	zones []zone.Zone,
+	labels []int,
+	cm *aggregation.ColorMap,
+	font FontRenderer,
+	cfg Config,
+) *image.RGBA {
+	bounds := srcImg.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	// The solution shows the colors themselves, and is what the QR code
+	// would link to
+	cfg.PatternFill = false
+	cfg.LegendQR = nil
+
+	layout := newLegendLayout(cm, font, cfg, srcW, legendNotes(cm, zones, cfg))
+	out := image.NewRGBA(image.Rect(0, 0, srcW, srcH+layout.height(cfg)))
+	for i := range out.Pix {
+		out.Pix[i] = 0xff
+	}
+	key := RenderAnswerKey(dm, zones, cm, cfg)
+	copy(out.Pix, key.Pix)
+
+	fontSize := max(LabelSize(srcW, srcH, len(zones)), cfg.MinLabelSize)
+	for i := range zones {
+		z := &zones[i]
+		entry := cm.Entries[cm.ZoneMap[i]]
+		numStr := cfg.EntryLabel(entry.Number)
+		for _, pos := range labelPoints(z, cfg) {
+			angle := 0.0
+			if cfg.RotateLabels {
+				tw, th := font.MeasureString(numStr, fontSize)
+				angle = labelAngle(z, labels, srcW, srcH, pos, tw, th)
+			}
+			col := color.Color(color.Black)
+			if cfg.HighContrast {
+				clearLabelBox(out, font, numStr, pos, fontSize, angle)
+			} else if isDark(entry.Color.ToStdColor()) {
+				col = color.White
+			}
+			DrawStringRotated(font, out, numStr, pos.X, pos.Y, col, fontSize, angle)
+		}
+	}
+
+	drawLegend(out, cm, font, cfg, layout, srcW, srcH, patternCell(srcW, srcH))
+	return out
+}
+
+// isDark reports whether white text reads better than black on c.
+func isDark(c color.RGBA) bool {
+	return 299*int(c.R)+587*int(c.G)+114*int(c.B) < 128*1000
+}
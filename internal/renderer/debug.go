@@ -0,0 +1,80 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/maax3v3/macoma/v2/internal/detection"
+)
+
+// DelimiterMask draws dm as black delimiter pixels on white.
+func DelimiterMask(dm *detection.Map) *image.RGBA {
+	out := image.NewRGBA(image.Rect(0, 0, dm.Width, dm.Height))
+	for i, d := range dm.IsDelimiter {
+		v := uint8(0xff)
+		if d {
+			v = 0
+		}
+		copy(out.Pix[4*i:], []uint8{v, v, v, 0xff})
+	}
+	return out
+}
+
+// ZoneIDMap paints each zone of a w×h label map in a color derived from
+// its ID, and delimiters (label -1) in black. Zones are numbered in scan
+// order, so neighbors get clearly different hues: zones that leaked into
+// each other or shattered into specks stand out.
+func ZoneIDMap(labels []int, w, h int) *image.RGBA {
+	return paintLabels(labels, w, h, zoneIDColor)
+}
+
+// ZoneColorMap paints each zone of a w×h label map in colors[id], such as
+// its averaged source color before palette reduction, and delimiters in
+// black.
+func ZoneColorMap(labels []int, w, h int, colors []color.RGBA) *image.RGBA {
+	return paintLabels(labels, w, h, func(id int) color.RGBA { return colors[id] })
+}
+
+// paintLabels paints every labelled pixel in the color of its zone, and the
+// others in black.
+func paintLabels(labels []int, w, h int, zoneColor func(id int) color.RGBA) *image.RGBA {
+	out := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i, id := range labels {
+		c := color.RGBA{0, 0, 0, 255}
+		if id >= 0 {
+			c = zoneColor(id)
+		}
+		out.SetRGBA(i%w, i/w, c)
+	}
+	return out
+}
+
+// zoneIDColor spreads zone IDs around the hue circle by the golden ratio,
+// alternating the brightness so that even hues close together differ.
+func zoneIDColor(id int) color.RGBA {
+	hue := math.Mod(float64(id)*0.6180339887, 1) * 6
+	v := 0.95
+	if id%2 == 1 {
+		v = 0.7
+	}
+	const s = 0.75
+	f := hue - math.Floor(hue)
+	p, q, t := v*(1-s), v*(1-s*f), v*(1-s*(1-f))
+	var r, g, b float64
+	switch int(hue) {
+	case 0:
+		r, g, b = v, t, p
+	case 1:
+		r, g, b = q, v, p
+	case 2:
+		r, g, b = p, v, t
+	case 3:
+		r, g, b = p, q, v
+	case 4:
+		r, g, b = t, p, v
+	default:
+		r, g, b = v, p, q
+	}
+	return color.RGBA{uint8(r * 255), uint8(g * 255), uint8(b * 255), 255}
+}
@@ -0,0 +1,49 @@
+package renderer
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/maax3v3/macoma/v2/internal/detection"
+)
+
+func TestDelimiterMask(t *testing.T) {
+	dm := detection.NewMap(3, 1)
+	dm.IsDelimiter[1] = true
+	out := DelimiterMask(dm)
+	want := []color.RGBA{{255, 255, 255, 255}, {0, 0, 0, 255}, {255, 255, 255, 255}}
+	for x, c := range want {
+		if got := out.RGBAAt(x, 0); got != c {
+			t.Errorf("x=%d: got %v, want %v", x, got, c)
+		}
+	}
+}
+
+func TestZoneIDMap(t *testing.T) {
+	labels := []int{0, -1, 1, 1}
+	out := ZoneIDMap(labels, 4, 1)
+	if out.RGBAAt(1, 0) != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("delimiter: got %v, want black", out.RGBAAt(1, 0))
+	}
+	if out.RGBAAt(0, 0) == out.RGBAAt(2, 0) {
+		t.Errorf("zones 0 and 1 share color %v", out.RGBAAt(0, 0))
+	}
+	if out.RGBAAt(2, 0) != out.RGBAAt(3, 0) {
+		t.Error("one zone painted in two colors")
+	}
+	// Consecutive IDs must not collide over a realistic zone count.
+	for id := 0; id < 5000; id++ {
+		if zoneIDColor(id) == zoneIDColor(id+1) {
+			t.Fatalf("zones %d and %d share color %v", id, id+1, zoneIDColor(id))
+		}
+	}
+}
+
+func TestZoneColorMap(t *testing.T) {
+	red := color.RGBA{200, 0, 0, 255}
+	blue := color.RGBA{0, 0, 200, 255}
+	out := ZoneColorMap([]int{1, -1, 0}, 3, 1, []color.RGBA{red, blue})
+	if out.RGBAAt(0, 0) != blue || out.RGBAAt(2, 0) != red || out.RGBAAt(1, 0) != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("got %v %v %v", out.RGBAAt(0, 0), out.RGBAAt(1, 0), out.RGBAAt(2, 0))
+	}
+}
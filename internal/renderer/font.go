@@ -3,6 +3,8 @@ package renderer
 import (
 	"image"
 	"image/color"
+	"image/draw"
+	"math"
 )
 
 // FontRenderer is the interface for drawing text onto images.
@@ -18,7 +20,9 @@ type FontRenderer interface {
 }
 
 // BitmapFont is a simple bitmap font renderer using hardcoded glyph data
-// for digits 0-9 and a few extra characters.
+// for digits 0-9, the letters A-Z and a-z, common punctuation and a few
+// label symbols such as ♥ and ★. Other characters, such as spaces, are left
+// blank.
 type BitmapFont struct{}
 
 // NewBitmapFont creates a new BitmapFont.
@@ -26,7 +30,9 @@ func NewBitmapFont() *BitmapFont {
 	return &BitmapFont{}
 }
 
-// glyphs are 5x7 pixel bitmaps for digits 0-9.
+// glyphs are 5x7 pixel bitmaps for digits 0-9, the letters, the
+// punctuation used by legend annotations, titles and watermarks, and a few
+// symbols for labels.
 var glyphs = map[rune][7]uint8{
 	'0': {0x0E, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0E},
 	'1': {0x04, 0x0C, 0x04, 0x04, 0x04, 0x04, 0x0E},
@@ -38,6 +44,87 @@ var glyphs = map[rune][7]uint8{
 	'7': {0x1F, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08},
 	'8': {0x0E, 0x11, 0x11, 0x0E, 0x11, 0x11, 0x0E},
 	'9': {0x0E, 0x11, 0x11, 0x0F, 0x01, 0x02, 0x0C},
+	'(': {0x02, 0x04, 0x08, 0x08, 0x08, 0x04, 0x02},
+	')': {0x08, 0x04, 0x02, 0x02, 0x02, 0x04, 0x08},
+	'%': {0x18, 0x19, 0x02, 0x04, 0x08, 0x13, 0x03},
+	'.': {0x00, 0x00, 0x00, 0x00, 0x00, 0x0C, 0x0C},
+	'#': {0x0A, 0x0A, 0x1F, 0x0A, 0x1F, 0x0A, 0x0A},
+	'A': {0x0E, 0x11, 0x11, 0x1F, 0x11, 0x11, 0x11},
+	'B': {0x1E, 0x11, 0x11, 0x1E, 0x11, 0x11, 0x1E},
+	'C': {0x0E, 0x11, 0x10, 0x10, 0x10, 0x11, 0x0E},
+	'D': {0x1C, 0x12, 0x11, 0x11, 0x11, 0x12, 0x1C},
+	'E': {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x1F},
+	'F': {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x10},
+	'G': {0x0E, 0x11, 0x10, 0x17, 0x11, 0x11, 0x0F},
+	'H': {0x11, 0x11, 0x11, 0x1F, 0x11, 0x11, 0x11},
+	'I': {0x0E, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'J': {0x07, 0x02, 0x02, 0x02, 0x02, 0x12, 0x0C},
+	'K': {0x11, 0x12, 0x14, 0x18, 0x14, 0x12, 0x11},
+	'L': {0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x1F},
+	'M': {0x11, 0x1B, 0x15, 0x15, 0x11, 0x11, 0x11},
+	'N': {0x11, 0x11, 0x19, 0x15, 0x13, 0x11, 0x11},
+	'O': {0x0E, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E},
+	'P': {0x1E, 0x11, 0x11, 0x1E, 0x10, 0x10, 0x10},
+	'Q': {0x0E, 0x11, 0x11, 0x11, 0x15, 0x12, 0x0D},
+	'R': {0x1E, 0x11, 0x11, 0x1E, 0x14, 0x12, 0x11},
+	'S': {0x0F, 0x10, 0x10, 0x0E, 0x01, 0x01, 0x1E},
+	'T': {0x1F, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04},
+	'U': {0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E},
+	'V': {0x11, 0x11, 0x11, 0x11, 0x11, 0x0A, 0x04},
+	'W': {0x11, 0x11, 0x11, 0x15, 0x15, 0x15, 0x0A},
+	'X': {0x11, 0x11, 0x0A, 0x04, 0x0A, 0x11, 0x11},
+	'Y': {0x11, 0x11, 0x11, 0x0A, 0x04, 0x04, 0x04},
+	'Z': {0x1F, 0x01, 0x02, 0x04, 0x08, 0x10, 0x1F},
+	'a': {0x00, 0x00, 0x0E, 0x01, 0x0F, 0x11, 0x0F},
+	'b': {0x10, 0x10, 0x16, 0x19, 0x11, 0x11, 0x1E},
+	'c': {0x00, 0x00, 0x0E, 0x10, 0x10, 0x11, 0x0E},
+	'd': {0x01, 0x01, 0x0D, 0x13, 0x11, 0x11, 0x0F},
+	'e': {0x00, 0x00, 0x0E, 0x11, 0x1F, 0x10, 0x0E},
+	'f': {0x06, 0x09, 0x08, 0x1C, 0x08, 0x08, 0x08},
+	'g': {0x00, 0x0F, 0x11, 0x11, 0x0F, 0x01, 0x0E},
+	'h': {0x10, 0x10, 0x16, 0x19, 0x11, 0x11, 0x11},
+	'i': {0x04, 0x00, 0x0C, 0x04, 0x04, 0x04, 0x0E},
+	'j': {0x02, 0x00, 0x06, 0x02, 0x02, 0x12, 0x0C},
+	'k': {0x10, 0x10, 0x12, 0x14, 0x18, 0x14, 0x12},
+	'l': {0x0C, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'm': {0x00, 0x00, 0x1A, 0x15, 0x15, 0x11, 0x11},
+	'n': {0x00, 0x00, 0x16, 0x19, 0x11, 0x11, 0x11},
+	'o': {0x00, 0x00, 0x0E, 0x11, 0x11, 0x11, 0x0E},
+	'p': {0x00, 0x00, 0x1E, 0x11, 0x1E, 0x10, 0x10},
+	'q': {0x00, 0x00, 0x0D, 0x13, 0x0F, 0x01, 0x01},
+	'r': {0x00, 0x00, 0x16, 0x19, 0x10, 0x10, 0x10},
+	's': {0x00, 0x00, 0x0E, 0x10, 0x0E, 0x01, 0x1E},
+	't': {0x08, 0x08, 0x1C, 0x08, 0x08, 0x09, 0x06},
+	'u': {0x00, 0x00, 0x11, 0x11, 0x11, 0x13, 0x0D},
+	'v': {0x00, 0x00, 0x11, 0x11, 0x11, 0x0A, 0x04},
+	'w': {0x00, 0x00, 0x11, 0x11, 0x15, 0x15, 0x0A},
+	'x': {0x00, 0x00, 0x11, 0x0A, 0x04, 0x0A, 0x11},
+	'y': {0x00, 0x00, 0x11, 0x11, 0x0F, 0x01, 0x0E},
+	'z': {0x00, 0x00, 0x1F, 0x02, 0x04, 0x08, 0x1F},
+	'-': {0x00, 0x00, 0x00, 0x1F, 0x00, 0x00, 0x00},
+	':': {0x00, 0x0C, 0x0C, 0x00, 0x0C, 0x0C, 0x00},
+	',': {0x00, 0x00, 0x00, 0x00, 0x0C, 0x04, 0x08},
+	'!': {0x04, 0x04, 0x04, 0x04, 0x00, 0x00, 0x04},
+	'?': {0x0E, 0x11, 0x01, 0x02, 0x04, 0x00, 0x04},
+	'/': {0x00, 0x01, 0x02, 0x04, 0x08, 0x10, 0x00},
+	'&': {0x0C, 0x12, 0x14, 0x08, 0x15, 0x12, 0x0D},
+	'_': {0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x1F},
+
+	'\'': {0x0C, 0x04, 0x08, 0x00, 0x00, 0x00, 0x00},
+
+	// Symbols for labels that stand in for numbers
+	'●': {0x00, 0x0E, 0x1F, 0x1F, 0x1F, 0x0E, 0x00},
+	'■': {0x00, 0x1F, 0x1F, 0x1F, 0x1F, 0x1F, 0x00},
+	'▲': {0x00, 0x04, 0x04, 0x0E, 0x0E, 0x1F, 0x00},
+	'◆': {0x00, 0x04, 0x0E, 0x1F, 0x0E, 0x04, 0x00},
+	'♥': {0x00, 0x0A, 0x1F, 0x1F, 0x0E, 0x04, 0x00},
+	'★': {0x04, 0x04, 0x1F, 0x0E, 0x0E, 0x1B, 0x11},
+	'○': {0x00, 0x0E, 0x11, 0x11, 0x11, 0x0E, 0x00},
+	'□': {0x00, 0x1F, 0x11, 0x11, 0x11, 0x1F, 0x00},
+	'△': {0x00, 0x04, 0x04, 0x0A, 0x0A, 0x1F, 0x00},
+	'◇': {0x00, 0x04, 0x0A, 0x11, 0x0A, 0x04, 0x00},
+	'+': {0x00, 0x04, 0x04, 0x1F, 0x04, 0x04, 0x00},
+	'×': {0x00, 0x11, 0x0A, 0x04, 0x0A, 0x11, 0x00},
 }
 
 const (
@@ -45,43 +132,98 @@ const (
 	glyphHeight = 7
 )
 
+// DrawString draws text with anti-aliased edges. Above the native size, each
+// glyph is scaled smoothly rather than as blocks: the bitmap is interpolated
+// between cell centers and cut at one half, so diagonal strokes come out as
+// straight edges and corners are rounded, and each pixel is blended with the
+// share of its area inside the glyph.
 func (bf *BitmapFont) DrawString(img *image.RGBA, text string, cx, cy int, col color.Color, size int) {
 	scale := size / glyphHeight
 	if scale < 1 {
 		scale = 1
 	}
+	c := color.RGBAModel.Convert(col).(color.RGBA)
 
 	totalW, totalH := bf.MeasureString(text, size)
 	startX := cx - totalW/2
 	startY := cy - totalH/2
 
+	b := img.Bounds()
 	curX := startX
 	for _, ch := range text {
+		if ch == '–' {
+			ch = '-' // an en dash, as in "Unit 3 – The Farm"
+		}
 		glyph, ok := glyphs[ch]
 		if !ok {
 			curX += (glyphWidth + 1) * scale
 			continue
 		}
-		for row := 0; row < glyphHeight; row++ {
-			for col_bit := 0; col_bit < glyphWidth; col_bit++ {
-				if glyph[row]&(1<<(glyphWidth-1-col_bit)) != 0 {
-					// Draw a scale x scale block
-					for dy := 0; dy < scale; dy++ {
-						for dx := 0; dx < scale; dx++ {
-							px := curX + col_bit*scale + dx
-							py := startY + row*scale + dy
-							if px >= 0 && px < img.Bounds().Dx() && py >= 0 && py < img.Bounds().Dy() {
-								img.Set(px+img.Bounds().Min.X, py+img.Bounds().Min.Y, col)
-							}
-						}
-					}
+		for y := 0; y < glyphHeight*scale; y++ {
+			for x := 0; x < glyphWidth*scale; x++ {
+				px, py := curX+x, startY+y
+				if px < 0 || px >= b.Dx() || py < 0 || py >= b.Dy() {
+					continue
 				}
+				blendPixel(img, px+b.Min.X, py+b.Min.Y, c, glyphCoverage(&glyph, x, y, scale))
 			}
 		}
 		curX += (glyphWidth + 1) * scale
 	}
 }
 
+// glyphSamples is the number of subsamples per pixel side used to measure
+// glyph coverage.
+const glyphSamples = 4
+
+// glyphCoverage returns the share, from 0 to 1, of pixel (x, y) of a glyph
+// drawn scale times its native size that lies inside the smoothed glyph. At
+// scale 1 it is the bitmap itself.
+func glyphCoverage(g *[glyphHeight]uint8, x, y, scale int) float64 {
+	if scale == 1 {
+		if glyphBit(g, x, y) {
+			return 1
+		}
+		return 0
+	}
+	inside := 0
+	for sy := 0; sy < glyphSamples; sy++ {
+		for sx := 0; sx < glyphSamples; sx++ {
+			// Sample position in glyph cells, relative to cell centers
+			u := (float64(x)+(float64(sx)+0.5)/glyphSamples)/float64(scale) - 0.5
+			v := (float64(y)+(float64(sy)+0.5)/glyphSamples)/float64(scale) - 0.5
+			if glyphField(g, u, v) >= 0.5 {
+				inside++
+			}
+		}
+	}
+	return float64(inside) / (glyphSamples * glyphSamples)
+}
+
+// glyphField bilinearly interpolates the glyph bitmap at (u, v), with cell
+// (c, r) at (c, r) and 0 outside the glyph.
+func glyphField(g *[glyphHeight]uint8, u, v float64) float64 {
+	c0, r0 := int(math.Floor(u)), int(math.Floor(v))
+	fu, fv := u-float64(c0), v-float64(r0)
+	at := func(c, r int) float64 {
+		if glyphBit(g, c, r) {
+			return 1
+		}
+		return 0
+	}
+	top := at(c0, r0)*(1-fu) + at(c0+1, r0)*fu
+	bottom := at(c0, r0+1)*(1-fu) + at(c0+1, r0+1)*fu
+	return top*(1-fv) + bottom*fv
+}
+
+// glyphBit reports whether cell (c, r) of the glyph is set.
+func glyphBit(g *[glyphHeight]uint8, c, r int) bool {
+	if c < 0 || c >= glyphWidth || r < 0 || r >= glyphHeight {
+		return false
+	}
+	return g[r]&(1<<(glyphWidth-1-c)) != 0
+}
+
 func (bf *BitmapFont) MeasureString(text string, size int) (width, height int) {
 	scale := size / glyphHeight
 	if scale < 1 {
@@ -95,3 +237,70 @@ func (bf *BitmapFont) MeasureString(text string, size int) (width, height int) {
 	h := glyphHeight * scale
 	return w, h
 }
+
+// DrawStringRotated draws text centered at (cx, cy) like DrawString, turned
+// by angle radians (clockwise on screen, since image y grows downward). It
+// works with any FontRenderer: the text is drawn upright on a scratch layer,
+// resampled through the rotation and composited over img.
+func DrawStringRotated(font FontRenderer, img *image.RGBA, text string, cx, cy int, col color.Color, size int, angle float64) {
+	if angle == 0 {
+		font.DrawString(img, text, cx, cy, col, size)
+		return
+	}
+	tw, th := font.MeasureString(text, size)
+	if tw == 0 || th == 0 {
+		return
+	}
+	pad := max(1, size/4)
+	sw, sh := tw+2*pad, th+2*pad
+	upright := image.NewRGBA(image.Rect(0, 0, sw, sh))
+	font.DrawString(upright, text, sw/2, sh/2, col, size)
+
+	r := int(math.Ceil(math.Hypot(float64(sw), float64(sh)) / 2))
+	layer := image.NewRGBA(image.Rect(cx-r, cy-r, cx+r+1, cy+r+1))
+	sin, cos := math.Sincos(angle)
+	for dy := -r; dy <= r; dy++ {
+		for dx := -r; dx <= r; dx++ {
+			// Inverse rotation maps the layer pixel back onto the upright
+			// text, sampled bilinearly so the turned edges stay smooth
+			sx := cos*float64(dx) + sin*float64(dy) + float64(sw/2)
+			sy := -sin*float64(dx) + cos*float64(dy) + float64(sh/2)
+			if sx <= -1 || sx >= float64(sw) || sy <= -1 || sy >= float64(sh) {
+				continue
+			}
+			layer.SetRGBA(cx+dx, cy+dy, bilinearAt(upright, sx, sy))
+		}
+	}
+	draw.Draw(img, layer.Bounds(), layer, layer.Bounds().Min, draw.Over)
+}
+
+// bilinearAt interpolates the premultiplied pixels of img around (x, y),
+// with transparent pixels outside it.
+func bilinearAt(img *image.RGBA, x, y float64) color.RGBA {
+	x0, y0 := int(math.Floor(x)), int(math.Floor(y))
+	fx, fy := x-float64(x0), y-float64(y0)
+	var sum [4]float64
+	for _, p := range [4]struct {
+		x, y int
+		w    float64
+	}{
+		{x0, y0, (1 - fx) * (1 - fy)},
+		{x0 + 1, y0, fx * (1 - fy)},
+		{x0, y0 + 1, (1 - fx) * fy},
+		{x0 + 1, y0 + 1, fx * fy},
+	} {
+		if p.w == 0 || !(image.Point{p.x, p.y}.In(img.Rect)) {
+			continue
+		}
+		i := img.PixOffset(p.x, p.y)
+		for k := range sum {
+			sum[k] += float64(img.Pix[i+k]) * p.w
+		}
+	}
+	return color.RGBA{
+		R: uint8(sum[0] + 0.5),
+		G: uint8(sum[1] + 0.5),
+		B: uint8(sum[2] + 0.5),
+		A: uint8(sum[3] + 0.5),
+	}
+}
@@ -0,0 +1,21 @@
+package renderer
+
+import (
+	"image/color"
+
+	"github.com/maax3v3/macoma/v2/internal/aggregation"
+)
+
+// hintColors returns, for each entry of cm, its color laid over the paper
+// color bg at the given opacity (0–1): the faint tint of Config.HintOpacity.
+// Over transparent paper, the tint is itself translucent.
+func hintColors(cm *aggregation.ColorMap, bg color.RGBA, opacity float64) []color.RGBA {
+	tints := make([]color.RGBA, len(cm.Entries))
+	mix := func(c, p uint8) uint8 {
+		return uint8(float64(p) + (float64(c)-float64(p))*opacity + 0.5)
+	}
+	for i, e := range cm.Entries {
+		tints[i] = color.RGBA{R: mix(e.Color.R, bg.R), G: mix(e.Color.G, bg.G), B: mix(e.Color.B, bg.B), A: mix(255, bg.A)}
+	}
+	return tints
+}
@@ -0,0 +1,60 @@
+package renderer
+
+import (
+	"image"
+	"math"
+
+	"github.com/maax3v3/macoma/v2/internal/zone"
+)
+
+// minRotateElongation is how much longer than wide a zone must be before
+// its number may be turned along the zone; rounder zones gain nothing.
+const minRotateElongation = 2
+
+// maxLabelsPerZone caps how many times a large zone's number is repeated.
+const maxLabelsPerZone = 9
+
+// labelPoints returns where to draw a zone's number: its interior point,
+// or for zones larger than cfg.MultiLabelArea, one point per
+// MultiLabelArea pixels spread across the zone.
+func labelPoints(z *zone.Zone, cfg Config) []image.Point {
+	if cfg.MultiLabelArea <= 0 || z.Area() <= cfg.MultiLabelArea {
+		return []image.Point{z.InteriorPoint()}
+	}
+	return z.SpreadPoints(min(1+z.Area()/cfg.MultiLabelArea, maxLabelsPerZone))
+}
+
+// labelAngle picks the angle to draw a zone's number at: upright, unless the
+// zone is elongated and the number turned along its principal axis spills
+// over fewer pixels outside the zone than the upright one.
+func labelAngle(z *zone.Zone, labels []int, w, h int, pos image.Point, tw, th int) float64 {
+	upright := labelOverflow(labels, w, h, z.ID, pos, tw, th, 0)
+	if upright == 0 {
+		return 0
+	}
+	angle, elongation := z.PrincipalAxis()
+	if elongation < minRotateElongation || math.Abs(angle) < 0.1 {
+		return 0
+	}
+	if labelOverflow(labels, w, h, z.ID, pos, tw, th, angle) < upright {
+		return angle
+	}
+	return 0
+}
+
+// labelOverflow counts the points of a tw×th box centered at pos and turned
+// by angle that fall outside the zone with the given label.
+func labelOverflow(labels []int, w, h, id int, pos image.Point, tw, th int, angle float64) int {
+	sin, cos := math.Sincos(angle)
+	out := 0
+	for v := -th / 2; v <= th/2; v++ {
+		for u := -tw / 2; u <= tw/2; u++ {
+			x := pos.X + int(math.Round(cos*float64(u)-sin*float64(v)))
+			y := pos.Y + int(math.Round(sin*float64(u)+cos*float64(v)))
+			if x < 0 || x >= w || y < 0 || y >= h || labels[y*w+x] != id {
+				out++
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,93 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/maax3v3/macoma/v2/internal/zone"
+)
+
+// inkBounds returns the bounding box of non-white pixels.
+func inkBounds(img *image.RGBA) image.Rectangle {
+	var r image.Rectangle
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if img.RGBAAt(x, y) != (color.RGBA{255, 255, 255, 255}) {
+				r = r.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return r
+}
+
+func TestDrawStringRotated_QuarterTurn(t *testing.T) {
+	font := NewBitmapFont()
+	img := whiteCanvas(60, 60)
+	DrawStringRotated(font, img, "123", 30, 30, color.Black, 14, math.Pi/2)
+
+	_, th := font.MeasureString("123", 14)
+	got := inkBounds(img)
+	// A quarter turn makes the text taller than wide, no wider than a glyph
+	if got.Dx() > th+1 || got.Dy() <= 2*got.Dx() {
+		t.Errorf("ink bounds %v: want a vertical run about %d wide", got, th)
+	}
+}
+
+func TestLabelAngle_DiagonalBand(t *testing.T) {
+	// A 5-pixel-thick band along the diagonal of a 60x60 image
+	w, h := 60, 60
+	labels := make([]int, w*h)
+	for i := range labels {
+		labels[i] = -1
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if d := x - y; d >= -2 && d <= 2 {
+				labels[y*w+x] = 0
+			}
+		}
+	}
+	z := &zone.FromLabels(labels, w, 1)[0]
+
+	got := labelAngle(z, labels, w, h, image.Pt(30, 30), 17, 5)
+	if math.Abs(got-math.Pi/4) > 0.1 {
+		t.Errorf("angle: got %.3f, want about π/4", got)
+	}
+	// A number that fits upright stays upright
+	if got := labelAngle(z, labels, w, h, image.Pt(30, 30), 1, 1); got != 0 {
+		t.Errorf("tiny label angle: got %.3f, want 0", got)
+	}
+}
+
+func TestLabelPoints(t *testing.T) {
+	// A 300x100 rectangle
+	z := &zone.Zone{}
+	for y := 0; y < 100; y++ {
+		z.Runs = append(z.Runs, zone.Run{Y: int32(y), X0: 0, X1: 300})
+	}
+
+	if got := labelPoints(z, Config{}); len(got) != 1 || got[0] != z.InteriorPoint() {
+		t.Errorf("disabled: got %v, want only the interior point", got)
+	}
+	if got := labelPoints(z, Config{MultiLabelArea: 30_000}); len(got) != 1 {
+		t.Errorf("zone at the threshold: got %d points, want 1", len(got))
+	}
+
+	got := labelPoints(z, Config{MultiLabelArea: 10_000})
+	if len(got) != 4 {
+		t.Fatalf("got %d points, want 4", len(got))
+	}
+	for i, p := range got {
+		if p.X < 15 || p.X >= 285 || p.Y < 15 || p.Y >= 85 {
+			t.Errorf("point %v is within the margin of the edge", p)
+		}
+		for _, q := range got[:i] {
+			if dx, dy := p.X-q.X, p.Y-q.Y; dx*dx+dy*dy < 60*60 {
+				t.Errorf("points %v and %v are too close", p, q)
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package renderer
+
+import (
+	"github.com/maax3v3/macoma/v2/internal/detection"
+	"github.com/maax3v3/macoma/v2/internal/zone"
+)
+
+// NormalizeLines redraws the borders of a w×h label map at a uniform width
+// in pixels, whatever the stroke widths of the source. Delimiter pixels are
+// given to the nearest zone (see zone.ExpandLabels), and a line of the given
+// width is centered on where two zones then meet. Zones that touch directly,
+// like sub-zones of a subdivided zone, get no line, and strokes that do not
+// separate two zones, such as loose strokes inside a zone or a frame along
+// the image edge, are dropped. width <= 0 returns nil.
+func NormalizeLines(labels []int, w, h, width int) *detection.Map {
+	if width <= 0 {
+		return nil
+	}
+	expanded := zone.ExpandLabels(labels, w, h)
+	out := detection.NewMap(w, h)
+	// Odd widths grow a one-pixel centerline, even widths a two-pixel one
+	even := width%2 == 0
+	mark := func(i, j int) {
+		a, b := expanded[i], expanded[j]
+		if a < 0 || b < 0 || a == b || labels[i] >= 0 && labels[j] >= 0 {
+			return
+		}
+		out.IsDelimiter[i] = true
+		if even {
+			out.IsDelimiter[j] = true
+		}
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			if x+1 < w {
+				mark(i, i+1)
+			}
+			if y+1 < h {
+				mark(i, i+w)
+			}
+		}
+	}
+	return out.Dilate((width - 1) / 2)
+}
+
+// borderMap returns the delimiters to draw in black: dm, or the borders
+// redrawn at cfg.LineWidth, thickened by cfg.OutlineWidth.
+func borderMap(dm *detection.Map, labels []int, cfg Config) *detection.Map {
+	if cfg.LineWidth > 0 {
+		dm = NormalizeLines(labels, dm.Width, dm.Height, cfg.LineWidth)
+	}
+	if cfg.OutlineWidth > 0 {
+		dm = dm.Dilate(cfg.OutlineWidth)
+	}
+	return dm
+}
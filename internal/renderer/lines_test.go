@@ -0,0 +1,62 @@
+package renderer
+
+import "testing"
+
+// twoZoneLabels returns a w×h label map with zone 0 on the left and zone 1
+// on the right of a stroke covering columns [sx, ex).
+func twoZoneLabels(w, h, sx, ex int) []int {
+	labels := make([]int, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			switch {
+			case x < sx:
+				labels[y*w+x] = 0
+			case x < ex:
+				labels[y*w+x] = -1
+			default:
+				labels[y*w+x] = 1
+			}
+		}
+	}
+	return labels
+}
+
+func TestNormalizeLines(t *testing.T) {
+	w, h := 20, 4
+	labels := twoZoneLabels(w, h, 6, 13) // a 7-pixel stroke
+	for _, width := range []int{1, 2, 3, 4} {
+		dm := NormalizeLines(labels, w, h, width)
+		for y := 0; y < h; y++ {
+			n, first := 0, -1
+			for x := 0; x < w; x++ {
+				if dm.At(x, y) {
+					if first == -1 {
+						first = x
+					}
+					n++
+				}
+			}
+			if n != width {
+				t.Errorf("width %d, row %d: %d line pixels", width, y, n)
+			}
+			// Centered on the middle of the stroke, column 9
+			if first < 9-width/2-1 || first > 9-(width-1)/2 {
+				t.Errorf("width %d, row %d: line starts at %d", width, y, first)
+			}
+		}
+	}
+	if NormalizeLines(labels, w, h, 0) != nil {
+		t.Error("width 0 should return nil")
+	}
+}
+
+func TestNormalizeLines_DropsInnerStrokes(t *testing.T) {
+	// Sub-zones touching directly, and a loose stroke inside zone 1
+	w, h := 10, 3
+	labels := twoZoneLabels(w, h, 5, 5)
+	labels[1*w+8] = -1
+
+	if n := NormalizeLines(labels, w, h, 3).Count(); n != 0 {
+		t.Errorf("got %d line pixels, want 0", n)
+	}
+}
@@ -1,14 +1,21 @@
 package renderer
 
 import (
+	"context"
 	"fmt"
 	"image"
 	"image/color"
 	"math"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/maax3v3/macoma/v2/internal/aggregation"
+	mcol "github.com/maax3v3/macoma/v2/internal/color"
 	"github.com/maax3v3/macoma/v2/internal/detection"
+	"github.com/maax3v3/macoma/v2/internal/pool"
+	"github.com/maax3v3/macoma/v2/internal/progress"
+	"github.com/maax3v3/macoma/v2/internal/qr"
 	"github.com/maax3v3/macoma/v2/internal/zone"
 )
 
@@ -18,6 +25,89 @@ type Config struct {
 	LegendCircleSize int // diameter of legend color circles
 	LegendSpacing    int // horizontal spacing between legend items
 	LegendMargin     int // left/right margin for the legend area
+
+	// LegendCoverage appends a "(12%)" annotation to each legend entry
+	// giving the share of the painted area that uses that color.
+	LegendCoverage bool
+
+	// LegendHex prints each legend entry's hex code, e.g. "#C84B3A",
+	// beside its swatch, before the coverage annotation.
+	LegendHex bool
+
+	// LegendZoneCounts prints how many zones use each legend color, e.g.
+	// "× 3", after the hex code, as a checklist for colorists.
+	LegendZoneCounts bool
+
+	// LegendQR, if set, is drawn in the bottom right corner of the legend
+	// area, e.g. linking a printed sheet to its solution online. Legend
+	// items wrap left of it, and the legend grows to its height.
+	LegendQR *qr.Code
+
+	// NoLegend leaves the legend off the coloring, which then keeps the
+	// size of the drawing; RenderLegend draws it on its own. The solution
+	// keeps its legend.
+	NoLegend bool
+
+	// Background fills the paper of the coloring: its zones, margins and
+	// legend area. LineColor draws the zone borders of the coloring and
+	// the answer key. DefaultConfig sets white and black. A transparent
+	// Background leaves the paper see-through, for compositing.
+	Background color.RGBA
+	LineColor  color.RGBA
+
+	// HintOpacity, if > 0, fills each zone with its target color mixed
+	// into the Background at this opacity (0–1), e.g. 0.15 for a faint
+	// hint, instead of plain paper. Numbers then get a halo in the tint.
+	HintOpacity float64
+
+	// PatternFill hatches each zone with a black-and-white pattern unique
+	// to its color, for monochrome printing. The legend shows the patterns.
+	PatternFill bool
+
+	// MinLabelSize is the minimum height in pixels of zone numbers; 0 keeps
+	// the size derived from the image dimensions.
+	MinLabelSize int
+
+	// LineWidth, if > 0, redraws every zone border exactly this many
+	// pixels wide instead of copying the source strokes (see
+	// NormalizeLines). OutlineWidth still thickens it.
+	LineWidth int
+
+	// OutlineWidth thickens zone borders by this many pixels on each side.
+	OutlineWidth int
+
+	// HighContrast draws every number in black on a white background, in
+	// zones and in the legend, instead of directly on colors or hatching.
+	HighContrast bool
+
+	// RotateLabels turns the number of a long, thin zone along the zone
+	// when it fits better that way than upright.
+	RotateLabels bool
+
+	// MultiLabelArea, if > 0, repeats the number of zones larger than this
+	// many pixels: one number per MultiLabelArea pixels, up to
+	// maxLabelsPerZone, spread across the zone.
+	MultiLabelArea int
+
+	// LabelHalo outlines each zone number with a halo this many pixels
+	// wide in HaloColor, so numbers stay legible where they touch a border
+	// or get colored over. DefaultConfig sets 1; 0 turns it off. A zero
+	// HaloColor uses the Background.
+	LabelHalo int
+	HaloColor color.RGBA
+
+	// Labels, if set, replaces the number n of a palette entry by
+	// Labels[n-1], e.g. a letter or a symbol, in the zones and the legend.
+	// Numbers past its end, or with an empty label, stay digits.
+	Labels []string
+}
+
+// EntryLabel returns the text drawn for the palette entry numbered n.
+func (cfg Config) EntryLabel(n int) string {
+	if n >= 1 && n <= len(cfg.Labels) && cfg.Labels[n-1] != "" {
+		return cfg.Labels[n-1]
+	}
+	return strconv.Itoa(n)
 }
 
 // DefaultConfig returns sensible default rendering configuration.
@@ -27,9 +117,22 @@ func DefaultConfig() Config {
 		LegendCircleSize: 30,
 		LegendSpacing:    15,
 		LegendMargin:     20,
+		Background:       color.RGBA{255, 255, 255, 255},
+		LineColor:        color.RGBA{0, 0, 0, 255},
+		LabelHalo:        1,
 	}
 }
 
+// newCanvas is image.NewRGBA with its pixels taken from the pool of ctx,
+// if any.
+func newCanvas(ctx context.Context, w, h int) *image.RGBA {
+	return &image.RGBA{Pix: pool.From(ctx).Bytes(4 * w * h), Stride: 4 * w, Rect: image.Rect(0, 0, w, h)}
+}
+
+// renderSteps is the number of drawing steps RenderContext reports progress
+// over: background, patterns, borders, numbers and legend.
+const renderSteps = 5
+
 // Render produces the final magic coloring image.
 func Render(
 	srcImg image.Image,
@@ -40,65 +143,248 @@ func Render(
 	font FontRenderer,
 	cfg Config,
 ) *image.RGBA {
+	out, _ := RenderContext(context.Background(), srcImg, dm, zones, labels, cm, font, cfg)
+	return out
+}
+
+// RenderContext is like Render but stops early and returns ctx.Err() once
+// ctx is cancelled.
+func RenderContext(
+	ctx context.Context,
+	srcImg image.Image,
+	dm *detection.Map,
+	zones []zone.Zone,
+	labels []int,
+	cm *aggregation.ColorMap,
+	font FontRenderer,
+	cfg Config,
+) (*image.RGBA, error) {
 	bounds := srcImg.Bounds()
 	srcW := bounds.Dx()
 	srcH := bounds.Dy()
 
-	// Calculate legend dimensions
-	legendHeight := calculateLegendHeight(cm, cfg, srcW)
+	// Calculate legend dimensions; without a legend, it has no entries
+	legendCM := cm
+	if cfg.NoLegend {
+		legendCM = &aggregation.ColorMap{}
+	}
+	layout := newLegendLayout(legendCM, font, cfg, srcW, legendNotes(legendCM, zones, cfg))
+	legendHeight := layout.height(cfg)
 	totalH := srcH + legendHeight
 
-	out := image.NewRGBA(image.Rect(0, 0, srcW, totalH))
+	out := newCanvas(ctx, srcW, totalH)
 
-	// Fill entire image with white
+	// Fill entire image with the paper color
 	for y := 0; y < totalH; y++ {
 		for x := 0; x < srcW; x++ {
-			out.SetRGBA(x, y, color.RGBA{255, 255, 255, 255})
+			out.SetRGBA(x, y, cfg.Background)
 		}
 	}
 
-	// Draw delimiter pixels as black (zone borders)
+	progress.Report(ctx, progress.Render, 1, renderSteps)
+
+	// Tint zones with a hint of their color
+	var tints []color.RGBA
+	if cfg.HintOpacity > 0 {
+		tints = hintColors(cm, cfg.Background, min(cfg.HintOpacity, 1))
+		wg := sync.WaitGroup{}
+		wg.Add(len(zones))
+		for i := range zones {
+			go func(zIdx int) {
+				defer wg.Done()
+				if ctx.Err() != nil {
+					return
+				}
+				c := tints[cm.ZoneMap[zIdx]]
+				zones[zIdx].Each(func(x, y int) { out.SetRGBA(x, y, c) })
+			}(i)
+		}
+		wg.Wait()
+	}
+
+	// Hatch zones before drawing borders and numbers on top
+	cell := patternCell(srcW, srcH)
+	if cfg.PatternFill {
+		wg := sync.WaitGroup{}
+		wg.Add(len(zones))
+		for i := range zones {
+			go func(zIdx int) {
+				defer wg.Done()
+				if ctx.Err() != nil {
+					return
+				}
+				fillPattern(out, &zones[zIdx], cm.ZoneMap[zIdx], cell, color.RGBA{0, 0, 0, 255})
+			}(i)
+		}
+		wg.Wait()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	progress.Report(ctx, progress.Render, 2, renderSteps)
+
+	// Sub-zones of a subdivided zone touch without a delimiter; mark where
+	// they meet with a faint divider line
+	drawDividers(out, labels, srcW, srcH)
+
+	// Draw delimiter pixels in the line color (zone borders)
+	dm = borderMap(dm, labels, cfg)
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		black := color.RGBA{0, 0, 0, 255}
 		for y := 0; y < srcH; y++ {
 			for x := 0; x < srcW; x++ {
 				if dm.At(x, y) {
-					out.SetRGBA(x, y, black)
+					out.SetRGBA(x, y, cfg.LineColor)
 				}
 			}
 		}
 	}()
 	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	progress.Report(ctx, progress.Render, 3, renderSteps)
 
 	// Compute font size based on image size (small for in-drawing labels)
-	fontSize := computeFontSize(srcW, srcH, len(zones)) / 4
-	if fontSize < 7 {
-		fontSize = 7
-	}
+	fontSize := max(LabelSize(srcW, srcH, len(zones)), cfg.MinLabelSize)
 
 	// Draw zone numbers at centroids (parallelized)
 	wg.Add(len(zones))
 	for i := range zones {
 		go func(zIdx int) {
 			defer wg.Done()
+			if ctx.Err() != nil {
+				return
+			}
 			z := &zones[zIdx]
 			entryIdx := cm.ZoneMap[zIdx]
 			entry := cm.Entries[entryIdx]
-			pos := z.InteriorPoint()
 
-			numStr := fmt.Sprintf("%d", entry.Number)
-			font.DrawString(out, numStr, pos.X, pos.Y, color.Black, fontSize)
+			numStr := cfg.EntryLabel(entry.Number)
+			hcfg := cfg
+			if tints != nil && cfg.HaloColor == (color.RGBA{}) {
+				hcfg.HaloColor = tints[entryIdx]
+			}
+			for _, pos := range labelPoints(z, cfg) {
+				angle := 0.0
+				if cfg.RotateLabels {
+					tw, th := font.MeasureString(numStr, fontSize)
+					angle = labelAngle(z, labels, srcW, srcH, pos, tw, th)
+				}
+				if cfg.PatternFill || cfg.HighContrast {
+					clearLabelBox(out, font, numStr, pos, fontSize, angle)
+				} else if cfg.LabelHalo > 0 {
+					drawHalo(out, font, numStr, pos, fontSize, angle, hcfg)
+				}
+				DrawStringRotated(font, out, numStr, pos.X, pos.Y, color.Black, fontSize, angle)
+			}
 		}(i)
 	}
 	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	progress.Report(ctx, progress.Render, 4, renderSteps)
 
 	// Draw legend
-	drawLegend(out, cm, font, cfg, srcW, srcH)
+	drawLegend(out, legendCM, font, cfg, layout, srcW, srcH, cell)
+	progress.Report(ctx, progress.Render, renderSteps, renderSteps)
 
-	return out
+	return out, nil
+}
+
+// dividerColor is the faint gray of lines splitting an oversized zone.
+var dividerColor = color.RGBA{190, 190, 190, 255}
+
+// drawDividers draws a divider on every zone pixel whose right or lower
+// neighbor is a pixel of another zone. Real zone borders are delimiter
+// pixels, so this only happens between sub-zones of a subdivided zone.
+func drawDividers(img *image.RGBA, labels []int, w, h int) {
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			l := labels[y*w+x]
+			if l < 0 {
+				continue
+			}
+			if x+1 < w && labels[y*w+x+1] >= 0 && labels[y*w+x+1] != l ||
+				y+1 < h && labels[(y+1)*w+x] >= 0 && labels[(y+1)*w+x] != l {
+				img.SetRGBA(x, y, dividerColor)
+			}
+		}
+	}
+}
+
+// clearLabelBox whites out the area behind a zone number so it stays
+// readable over a hatch pattern.
+func clearLabelBox(img *image.RGBA, font FontRenderer, text string, pos image.Point, size int, angle float64) {
+	w, h := font.MeasureString(text, size)
+	pad := max(1, size/4)
+	hw, hh := float64(w/2+pad), float64(h/2+pad)
+	// Bounding square of the box at any angle
+	r := int(math.Ceil(math.Hypot(hw, hh)))
+	sin, cos := math.Sincos(angle)
+	b := image.Rect(pos.X-r, pos.Y-r, pos.X+r+1, pos.Y+r+1).Intersect(img.Bounds())
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			dx, dy := float64(x-pos.X), float64(y-pos.Y)
+			if math.Abs(cos*dx+sin*dy) <= hw && math.Abs(-sin*dx+cos*dy) <= hh {
+				img.SetRGBA(x, y, color.RGBA{255, 255, 255, 255})
+			}
+		}
+	}
+}
+
+// drawHalo paints the halo of text drawn at pos as DrawStringRotated draws
+// it: every pixel within cfg.LabelHalo pixels of the text's ink, blended
+// by the strongest ink around it. A transparent halo color erases instead,
+// so numbers on see-through paper still cut into the lines.
+func drawHalo(img *image.RGBA, font FontRenderer, text string, pos image.Point, size int, angle float64, cfg Config) {
+	halo := cfg.HaloColor
+	if halo == (color.RGBA{}) {
+		halo = cfg.Background
+	}
+	hw := cfg.LabelHalo
+	tw, th := font.MeasureString(text, size)
+	r := int(math.Ceil(math.Hypot(float64(tw), float64(th))/2)) + max(1, size/4) + hw
+	n := 2*r + 1
+	mask := image.NewRGBA(image.Rect(0, 0, n, n))
+	DrawStringRotated(font, mask, text, r, r, color.Black, size, angle)
+
+	b := img.Bounds()
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			px, py := pos.X-r+x, pos.Y-r+y
+			if px < b.Min.X || px >= b.Max.X || py < b.Min.Y || py >= b.Max.Y {
+				continue
+			}
+			var a uint8
+			for dy := -hw; dy <= hw; dy++ {
+				for dx := -hw; dx <= hw; dx++ {
+					mx, my := x+dx, y+dy
+					if dx*dx+dy*dy > hw*hw || mx < 0 || mx >= n || my < 0 || my >= n {
+						continue
+					}
+					a = max(a, mask.Pix[mask.PixOffset(mx, my)+3])
+				}
+			}
+			if halo.A == 0 {
+				erasePixel(img, px, py, float64(a)/255)
+			} else {
+				blendPixel(img, px, py, halo, float64(a)/255)
+			}
+		}
+	}
+}
+
+// LabelSize returns the height in pixels of the zone numbers Render draws
+// for an image of the given size and zone count, before MinLabelSize.
+func LabelSize(imgW, imgH, numZones int) int {
+	return max(computeFontSize(imgW, imgH, numZones)/4, 7)
 }
 
 func computeFontSize(imgW, imgH, numZones int) int {
@@ -120,40 +406,179 @@ func computeFontSize(imgW, imgH, numZones int) int {
 	return size
 }
 
-func calculateLegendHeight(cm *aggregation.ColorMap, cfg Config, imgW int) int {
-	if len(cm.Entries) == 0 {
-		return 0
+// legendLayout holds the legend geometry shared by sizing and drawing.
+type legendLayout struct {
+	itemWidth   int
+	itemsPerRow int
+	numRows     int
+	notes       []string // optional per-entry annotation, drawn right of the swatch
+	noteSize    int
+	qrModule    int // pixels per QR code module, 0 without a code
+	qrSide      int // QR code side in pixels, quiet zone included
+}
+
+// newLegendLayout computes how legend items wrap into rows. notes may be nil;
+// otherwise every item is widened to fit the longest annotation.
+func newLegendLayout(cm *aggregation.ColorMap, font FontRenderer, cfg Config, imgW int, notes []string) legendLayout {
+	l := legendLayout{
+		itemWidth: cfg.LegendCircleSize + cfg.LegendSpacing,
+		notes:     notes,
+		noteSize:  cfg.LegendCircleSize / 2,
 	}
-	// Calculate how many rows we need
-	itemWidth := cfg.LegendCircleSize + cfg.LegendSpacing
-	availableW := imgW - 2*cfg.LegendMargin
-	itemsPerRow := availableW / itemWidth
-	if itemsPerRow < 1 {
-		itemsPerRow = 1
+	if len(notes) > 0 {
+		noteW := 0
+		for _, n := range notes {
+			if w, _ := font.MeasureString(n, l.noteSize); w > noteW {
+				noteW = w
+			}
+		}
+		l.itemWidth += cfg.LegendSpacing/2 + noteW
+	}
+	if cfg.LegendQR != nil {
+		l.qrModule = qrModuleSize(cfg)
+		l.qrSide = (cfg.LegendQR.Size + 2*qr.QuietZone) * l.qrModule
+	}
+	availableW := l.itemsWidth(cfg, imgW)
+	l.itemsPerRow = availableW / max(l.itemWidth, 1)
+	if l.itemsPerRow < 1 {
+		l.itemsPerRow = 1
+	}
+	l.numRows = (len(cm.Entries) + l.itemsPerRow - 1) / l.itemsPerRow
+	return l
+}
+
+func (l legendLayout) height(cfg Config) int {
+	if l.numRows == 0 {
+		return 0
 	}
-	numRows := (len(cm.Entries) + itemsPerRow - 1) / itemsPerRow
 	rowHeight := cfg.LegendCircleSize + cfg.LegendSpacing
-	return cfg.LegendPadding + numRows*rowHeight + cfg.LegendPadding
+	return cfg.LegendPadding + max(l.numRows*rowHeight, l.qrSide) + cfg.LegendPadding
+}
+
+// itemsWidth is the width the legend items wrap in: the legend area
+// between its margins, less the QR code column.
+func (l legendLayout) itemsWidth(cfg Config, imgW int) int {
+	w := imgW - 2*cfg.LegendMargin
+	if l.qrSide > 0 {
+		w -= l.qrSide + cfg.LegendSpacing
+	}
+	return w
+}
+
+// qrModuleSize returns the pixels per QR code module: a tenth of the
+// legend swatches, so the code grows with large print and output scale,
+// and at least 2 so phones still read it from a print.
+func qrModuleSize(cfg Config) int {
+	return max(cfg.LegendCircleSize/10, 2)
+}
+
+// legendNotes builds the annotation text for each legend entry from the
+// enabled Config options, or returns nil if none are enabled.
+func legendNotes(cm *aggregation.ColorMap, zones []zone.Zone, cfg Config) []string {
+	var coverage []float64
+	if cfg.LegendCoverage {
+		areas := make([]int, len(zones))
+		for i := range zones {
+			areas[i] = zones[i].Area()
+		}
+		coverage = cm.Coverage(areas)
+	}
+	var counts []int
+	if cfg.LegendZoneCounts && len(cm.Entries) > 0 {
+		counts = make([]int, len(cm.Entries))
+		for _, e := range cm.ZoneMap {
+			counts[e]++
+		}
+	}
+	return entryNotes(cm, coverage, counts, cfg)
+}
+
+// entryNotes formats the annotation of each entry of cm: its hex code when
+// cfg.LegendHex is set, its zone count when cfg.LegendZoneCounts is set and
+// counts has one per entry, then its coverage when cfg.LegendCoverage is
+// set and coverage has one fraction per entry. It returns nil if none
+// applies.
+func entryNotes(cm *aggregation.ColorMap, coverage []float64, counts []int, cfg Config) []string {
+	withCoverage := cfg.LegendCoverage && len(coverage) == len(cm.Entries)
+	withCounts := cfg.LegendZoneCounts && len(counts) == len(cm.Entries)
+	if len(cm.Entries) == 0 || !cfg.LegendHex && !withCoverage && !withCounts {
+		return nil
+	}
+	notes := make([]string, len(cm.Entries))
+	for i, e := range cm.Entries {
+		var parts []string
+		if cfg.LegendHex {
+			parts = append(parts, formatHex(e.Color))
+		}
+		if withCounts {
+			parts = append(parts, fmt.Sprintf("× %d", counts[i]))
+		}
+		if withCoverage {
+			parts = append(parts, formatCoverage(coverage[i]))
+		}
+		notes[i] = strings.Join(parts, " ")
+	}
+	return notes
+}
+
+// formatHex renders a legend color as "#C84B3A", in capitals like a color
+// picker shows it, and without the alpha of the palette entry.
+func formatHex(c mcol.RGBA) string {
+	return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+}
+
+// formatCoverage renders a coverage fraction as "(12%)", keeping one decimal
+// below 10% so small but non-zero shares don't read as "(0%)".
+func formatCoverage(frac float64) string {
+	pct := frac * 100
+	if pct < 9.95 {
+		return fmt.Sprintf("(%.1f%%)", pct)
+	}
+	return fmt.Sprintf("(%.0f%%)", pct)
+}
+
+// RenderLegend draws only the legend of cm, on a white image of the given
+// width, laid out exactly as Render would lay it out below a drawing of that
+// width. coverage holds the per-entry area fractions used when
+// cfg.LegendCoverage is set and counts the per-entry zone counts used when
+// cfg.LegendZoneCounts is set; either may be nil otherwise.
+func RenderLegend(cm *aggregation.ColorMap, coverage []float64, counts []int, font FontRenderer, cfg Config, width int) *image.RGBA {
+	layout := newLegendLayout(cm, font, cfg, width, entryNotes(cm, coverage, counts, cfg))
+	img := image.NewRGBA(image.Rect(0, 0, width, layout.height(cfg)))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	drawLegend(img, cm, font, cfg, layout, width, 0, patternCell(width, width))
+	return img
+}
+
+func calculateLegendHeight(cm *aggregation.ColorMap, cfg Config, imgW int) int {
+	return newLegendLayout(cm, nil, cfg, imgW, nil).height(cfg)
 }
 
-func drawLegend(img *image.RGBA, cm *aggregation.ColorMap, font FontRenderer, cfg Config, imgW, drawingH int) {
+func drawLegend(img *image.RGBA, cm *aggregation.ColorMap, font FontRenderer, cfg Config, layout legendLayout, imgW, drawingH, cell int) {
 	if len(cm.Entries) == 0 {
 		return
 	}
 
-	// Draw a thin separator line
-	separatorY := drawingH + cfg.LegendPadding/2
-	for x := cfg.LegendMargin; x < imgW-cfg.LegendMargin; x++ {
-		img.SetRGBA(x, separatorY, color.RGBA{200, 200, 200, 255})
+	// Draw a thin separator line below the drawing, if there is one: black
+	// blended at a fifth, light gray on white and a shade darker than any
+	// other paper
+	if drawingH > 0 {
+		separatorY := drawingH + cfg.LegendPadding/2
+		for x := cfg.LegendMargin; x < imgW-cfg.LegendMargin; x++ {
+			blendClipped(img, x, separatorY, color.RGBA{0, 0, 0, 255}, 55.0/255)
+		}
 	}
 
-	itemWidth := cfg.LegendCircleSize + cfg.LegendSpacing
-	availableW := imgW - 2*cfg.LegendMargin
-	itemsPerRow := availableW / itemWidth
-	if itemsPerRow < 1 {
-		itemsPerRow = 1
+	if cfg.LegendQR != nil && layout.qrSide > 0 {
+		drawQR(img, cfg.LegendQR, imgW-cfg.LegendMargin-layout.qrSide, drawingH+cfg.LegendPadding, layout.qrModule)
 	}
 
+	itemWidth := layout.itemWidth
+	itemsPerRow := layout.itemsPerRow
+	availableW := layout.itemsWidth(cfg, imgW)
+
 	fontSize := cfg.LegendCircleSize * 2 / 3
 	radius := cfg.LegendCircleSize / 2
 
@@ -173,42 +598,115 @@ func drawLegend(img *image.RGBA, cm *aggregation.ColorMap, font FontRenderer, cf
 		cx := rowStartX + col*itemWidth + radius
 		cy := drawingH + cfg.LegendPadding + row*(cfg.LegendCircleSize+cfg.LegendSpacing) + radius
 
-		// Draw filled circle
-		fillColor := entry.Color.ToStdColor()
-		drawFilledCircle(img, cx, cy, radius, fillColor)
+		// Draw filled circle, or the zone pattern in pattern mode; the
+		// number sits on a white center in pattern and high-contrast modes
+		textColor := color.Color(color.Black)
+		if cfg.PatternFill {
+			drawPatternCircle(img, cx, cy, radius, i, cell, color.RGBA{0, 0, 0, 255})
+		} else {
+			drawFilledCircle(img, cx, cy, radius, entry.Color.ToStdColor())
+		}
+		if cfg.PatternFill {
+			drawFilledCircle(img, cx, cy, radius*3/5, color.RGBA{255, 255, 255, 255})
+		} else if cfg.HighContrast {
+			drawFilledCircle(img, cx, cy, radius*4/5, color.RGBA{255, 255, 255, 255})
+		} else if !entry.Color.IsLight() {
+			textColor = color.White
+		}
 
 		// Draw circle border
 		drawCircleBorder(img, cx, cy, radius, color.RGBA{100, 100, 100, 255})
 
 		// Draw number text
-		textColor := color.Color(color.Black)
-		if !entry.Color.IsLight() {
-			textColor = color.White
-		}
-		numStr := fmt.Sprintf("%d", entry.Number)
+		numStr := cfg.EntryLabel(entry.Number)
 		font.DrawString(img, numStr, cx, cy, textColor, fontSize)
+
+		// Draw the annotation left-aligned after the swatch
+		if i < len(layout.notes) && layout.notes[i] != "" {
+			noteW, _ := font.MeasureString(layout.notes[i], layout.noteSize)
+			noteX := cx + radius + cfg.LegendSpacing/2
+			font.DrawString(img, layout.notes[i], noteX+noteW/2, cy, color.Black, layout.noteSize)
+		}
 	}
 }
 
-func drawFilledCircle(img *image.RGBA, cx, cy, radius int, col color.RGBA) {
-	for dy := -radius; dy <= radius; dy++ {
-		for dx := -radius; dx <= radius; dx++ {
-			if dx*dx+dy*dy <= radius*radius {
-				px, py := cx+dx, cy+dy
-				if px >= 0 && px < img.Bounds().Dx() && py >= 0 && py < img.Bounds().Dy() {
-					img.SetRGBA(px, py, col)
+// drawQR draws code with its quiet zone at (x, y), module pixels per
+// module, dark on white whatever the paper, so it scans on any background.
+func drawQR(img *image.RGBA, code *qr.Code, x, y, module int) {
+	n := code.Size + 2*qr.QuietZone
+	for my := 0; my < n; my++ {
+		for mx := 0; mx < n; mx++ {
+			c := color.RGBA{255, 255, 255, 255}
+			if code.Dark(mx-qr.QuietZone, my-qr.QuietZone) {
+				c = color.RGBA{0, 0, 0, 255}
+			}
+			for py := y + my*module; py < y+(my+1)*module; py++ {
+				for px := x + mx*module; px < x+(mx+1)*module; px++ {
+					if image.Pt(px, py).In(img.Bounds()) {
+						img.SetRGBA(px, py, c)
+					}
 				}
 			}
 		}
 	}
 }
 
+// drawFilledCircle fills the disc of the given radius around the center of
+// pixel (cx, cy), blending its edge pixels by how much of them it covers.
+func drawFilledCircle(img *image.RGBA, cx, cy, radius int, col color.RGBA) {
+	r := float64(radius)
+	for dy := -radius - 1; dy <= radius+1; dy++ {
+		for dx := -radius - 1; dx <= radius+1; dx++ {
+			d := math.Hypot(float64(dx), float64(dy))
+			blendClipped(img, cx+dx, cy+dy, col, r+0.5-d)
+		}
+	}
+}
+
+// drawCircleBorder draws a one-pixel ring of the given radius around the
+// center of pixel (cx, cy), blended by the distance of each pixel to it.
 func drawCircleBorder(img *image.RGBA, cx, cy, radius int, col color.RGBA) {
-	for angle := 0.0; angle < 2*math.Pi; angle += 0.01 {
-		px := cx + int(math.Round(float64(radius)*math.Cos(angle)))
-		py := cy + int(math.Round(float64(radius)*math.Sin(angle)))
-		if px >= 0 && px < img.Bounds().Dx() && py >= 0 && py < img.Bounds().Dy() {
-			img.SetRGBA(px, py, col)
+	r := float64(radius)
+	for dy := -radius - 1; dy <= radius+1; dy++ {
+		for dx := -radius - 1; dx <= radius+1; dx++ {
+			d := math.Hypot(float64(dx), float64(dy))
+			blendClipped(img, cx+dx, cy+dy, col, 1-math.Abs(d-r))
 		}
 	}
 }
+
+// blendClipped blends col into pixel (x, y), relative to the image origin,
+// with coverage cov clamped to 0–1, if the pixel lies in img.
+func blendClipped(img *image.RGBA, x, y int, col color.RGBA, cov float64) {
+	b := img.Bounds()
+	if x < 0 || x >= b.Dx() || y < 0 || y >= b.Dy() {
+		return
+	}
+	blendPixel(img, x+b.Min.X, y+b.Min.Y, col, min(max(cov, 0), 1))
+}
+
+// erasePixel makes pixel (x, y) of img transparent at coverage cov (0–1).
+func erasePixel(img *image.RGBA, x, y int, cov float64) {
+	if cov <= 0 {
+		return
+	}
+	i := img.PixOffset(x, y)
+	for j := i; j < i+4; j++ {
+		img.Pix[j] = uint8(float64(img.Pix[j])*(1-cov) + 0.5)
+	}
+}
+
+// blendPixel composites the premultiplied col over pixel (x, y) of img at
+// coverage cov (0–1). Full coverage of an opaque color sets the pixel.
+func blendPixel(img *image.RGBA, x, y int, col color.RGBA, cov float64) {
+	if cov <= 0 {
+		return
+	}
+	i := img.PixOffset(x, y)
+	p := img.Pix[i : i+4 : i+4]
+	keep := 1 - float64(col.A)/255*cov
+	p[0] = uint8(float64(col.R)*cov + float64(p[0])*keep + 0.5)
+	p[1] = uint8(float64(col.G)*cov + float64(p[1])*keep + 0.5)
+	p[2] = uint8(float64(col.B)*cov + float64(p[2])*keep + 0.5)
+	p[3] = uint8(float64(col.A)*cov + float64(p[3])*keep + 0.5)
+}
@@ -0,0 +1,26 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/maax3v3/macoma/v2/internal/detection"
+)
+
+// HighlightDelimiters returns a copy of img, moved to the origin, with every
+// delimiter pixel of dm painted in c. Laid over the source this way, missed
+// strokes and stray detections are easy to spot while tuning tolerances.
+func HighlightDelimiters(img image.Image, dm *detection.Map, c color.RGBA) *image.RGBA {
+	b := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(out, out.Bounds(), img, b.Min, draw.Src)
+	for y := 0; y < dm.Height; y++ {
+		for x := 0; x < dm.Width; x++ {
+			if dm.At(x, y) {
+				out.SetRGBA(x, y, c)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,39 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/maax3v3/macoma/v2/internal/detection"
+)
+
+func TestHighlightDelimiters(t *testing.T) {
+	src := image.NewRGBA(image.Rect(10, 10, 13, 12))
+	gray := color.RGBA{90, 90, 90, 255}
+	for i := 0; i < len(src.Pix); i += 4 {
+		copy(src.Pix[i:], []uint8{gray.R, gray.G, gray.B, gray.A})
+	}
+	dm := detection.NewMap(3, 2)
+	dm.IsDelimiter[1] = true
+
+	magenta := color.RGBA{255, 0, 255, 255}
+	out := HighlightDelimiters(src, dm, magenta)
+	if out.Bounds() != image.Rect(0, 0, 3, 2) {
+		t.Fatalf("bounds %v, want the origin", out.Bounds())
+	}
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 3; x++ {
+			want := gray
+			if x == 1 && y == 0 {
+				want = magenta
+			}
+			if got := out.RGBAAt(x, y); got != want {
+				t.Errorf("(%d,%d): got %v, want %v", x, y, got, want)
+			}
+		}
+	}
+	if src.RGBAAt(11, 10) != gray {
+		t.Error("source image was modified")
+	}
+}
@@ -0,0 +1,91 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/maax3v3/macoma/v2/internal/zone"
+)
+
+// patternCount is the number of distinct base patterns. Palettes larger than
+// this reuse the base patterns at coarser cell sizes.
+const patternCount = 10
+
+// patternCell returns the pattern cell size for an image, so hatching stays
+// legible on both thumbnails and print-resolution scans.
+func patternCell(imgW, imgH int) int {
+	c := min(imgW, imgH) / 100
+	return max(4, min(c, 12))
+}
+
+// patternInk reports whether pixel (x, y) is inked in pattern i with cell
+// size cell. Coordinates are absolute, so zones sharing a pattern line up.
+func patternInk(i, x, y, cell int) bool {
+	cell *= 1 + i/patternCount
+	line := max(1, cell/5)
+	mx, my := mod(x, cell), mod(y, cell)
+	switch i % patternCount {
+	case 0: // diagonal lines, rising
+		return mod(x+y, cell) < line
+	case 1: // diagonal lines, falling
+		return mod(x-y, cell) < line
+	case 2: // horizontal lines
+		return my < line
+	case 3: // vertical lines
+		return mx < line
+	case 4: // grid
+		return mx < line || my < line
+	case 5: // diagonal crosshatch
+		return mod(x+y, cell) < line || mod(x-y, cell) < line
+	case 6: // dots
+		r := max(1, cell/4)
+		dx, dy := mx-cell/2, my-cell/2
+		return dx*dx+dy*dy <= r*r
+	case 7: // small squares
+		return mx < cell/2 && my < cell/2
+	case 8: // staggered dots
+		if (y/cell)%2 == 1 {
+			mx = mod(x+cell/2, cell)
+		}
+		dx, dy := mx-cell/2, my-cell/2
+		return dx*dx+dy*dy <= 1
+	default: // dense horizontal dashes
+		return my < line && mx < cell/2
+	}
+}
+
+// fillPattern inks the zone pixels with pattern i.
+func fillPattern(img *image.RGBA, z *zone.Zone, i, cell int, ink color.RGBA) {
+	z.Each(func(x, y int) {
+		if patternInk(i, x, y, cell) {
+			img.SetRGBA(x, y, ink)
+		}
+	})
+}
+
+// drawPatternCircle draws a legend swatch: a disc hatched with pattern i.
+func drawPatternCircle(img *image.RGBA, cx, cy, radius, i, cell int, ink color.RGBA) {
+	b := img.Bounds()
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			px, py := cx+dx, cy+dy
+			if dx*dx+dy*dy > radius*radius || !(image.Point{px, py}).In(b) {
+				continue
+			}
+			c := color.RGBA{255, 255, 255, 255}
+			if patternInk(i, px, py, cell) {
+				c = ink
+			}
+			img.SetRGBA(px, py, c)
+		}
+	}
+}
+
+// mod is the always non-negative remainder.
+func mod(a, m int) int {
+	r := a % m
+	if r < 0 {
+		r += m
+	}
+	return r
+}
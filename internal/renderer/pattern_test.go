@@ -0,0 +1,91 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/maax3v3/macoma/v2/internal/aggregation"
+	"github.com/maax3v3/macoma/v2/internal/detection"
+	"github.com/maax3v3/macoma/v2/internal/zone"
+)
+
+func TestPatternInk_Distinct(t *testing.T) {
+	// Every pair of patterns must differ somewhere within a few cells, and
+	// every pattern must leave both inked and blank pixels.
+	const cell, span = 8, 48
+	masks := make([][]bool, 2*patternCount)
+	for i := range masks {
+		masks[i] = make([]bool, span*span)
+		inked := 0
+		for y := 0; y < span; y++ {
+			for x := 0; x < span; x++ {
+				if patternInk(i, x, y, cell) {
+					masks[i][y*span+x] = true
+					inked++
+				}
+			}
+		}
+		if inked == 0 || inked == span*span {
+			t.Errorf("pattern %d is uniform (%d inked)", i, inked)
+		}
+	}
+	for i := range masks {
+		for j := i + 1; j < len(masks); j++ {
+			same := true
+			for k := range masks[i] {
+				if masks[i][k] != masks[j][k] {
+					same = false
+					break
+				}
+			}
+			if same {
+				t.Errorf("patterns %d and %d are identical", i, j)
+			}
+		}
+	}
+}
+
+func TestPatternInk_NegativeCoordinates(t *testing.T) {
+	// mod keeps patterns periodic across the origin.
+	for i := 0; i < patternCount; i++ {
+		if patternInk(i, -8, -8, 8) != patternInk(i, 0, 0, 8) {
+			t.Errorf("pattern %d not periodic across origin", i)
+		}
+	}
+}
+
+func TestRender_PatternFill(t *testing.T) {
+	srcW, srcH := 200, 100
+	src := image.NewRGBA(image.Rect(0, 0, srcW, srcH))
+	dm := detection.NewMap(srcW, srcH)
+	for y := 0; y < srcH; y++ {
+		dm.IsDelimiter[y*srcW+100] = true
+		for x := 0; x < srcW; x++ {
+			c := color.RGBA{255, 0, 0, 255}
+			if x > 100 {
+				c = color.RGBA{0, 0, 255, 255}
+			}
+			src.SetRGBA(x, y, c)
+		}
+	}
+	zones, labels := zone.FindZones(dm)
+	cm := aggregation.ReduceColors(zone.ComputeZoneColors(zones, src).Colors, 0)
+	cfg := DefaultConfig()
+	cfg.PatternFill = true
+
+	out := Render(src, dm, zones, labels, cm, NewBitmapFont(), cfg)
+
+	// Both zones carry ink away from their borders and labels.
+	for _, x := range []int{10, 190} {
+		inked := 0
+		for y := 0; y < 30; y++ {
+			if out.RGBAAt(x, y) == (color.RGBA{0, 0, 0, 255}) {
+				inked++
+			}
+		}
+		if inked == 0 {
+			t.Errorf("no hatching found in column %d", x)
+		}
+	}
+}
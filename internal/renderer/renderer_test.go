@@ -1,13 +1,17 @@
 package renderer
 
 import (
+	"context"
+	"errors"
 	"image"
 	"image/color"
+	"image/draw"
 	"testing"
 
 	"github.com/maax3v3/macoma/v2/internal/aggregation"
 	mcol "github.com/maax3v3/macoma/v2/internal/color"
 	"github.com/maax3v3/macoma/v2/internal/detection"
+	"github.com/maax3v3/macoma/v2/internal/qr"
 	"github.com/maax3v3/macoma/v2/internal/zone"
 )
 
@@ -95,7 +99,7 @@ func TestBitmapFont_DrawString_UnknownGlyph(t *testing.T) {
 	}
 
 	// Drawing a character with no glyph should not panic
-	bf.DrawString(img, "X", 25, 25, color.Black, 7)
+	bf.DrawString(img, "~", 25, 25, color.Black, 7)
 
 	// No black pixels expected (unknown glyph is skipped)
 	for y := 0; y < 50; y++ {
@@ -108,6 +112,67 @@ func TestBitmapFont_DrawString_UnknownGlyph(t *testing.T) {
 	}
 }
 
+func TestBitmapFont_DrawString_AntiAliased(t *testing.T) {
+	bf := NewBitmapFont()
+	img := image.NewRGBA(image.Rect(0, 0, 60, 60))
+	draw.Draw(img, img.Rect, image.White, image.Point{}, draw.Src)
+
+	// At four times the native size, the diagonal of the 7 gets blended
+	// edge pixels between black and white
+	bf.DrawString(img, "7", 30, 30, color.Black, 28)
+	black, gray := 0, 0
+	for i := 0; i < len(img.Pix); i += 4 {
+		switch v := img.Pix[i]; {
+		case v == 0:
+			black++
+		case v < 255:
+			gray++
+		}
+	}
+	if black == 0 || gray == 0 {
+		t.Errorf("got %d black and %d blended pixels, want both", black, gray)
+	}
+
+	// At the native size the glyph is drawn as is
+	clear(img.Pix)
+	bf.DrawString(img, "7", 30, 30, color.Black, 7)
+	for i := 3; i < len(img.Pix); i += 4 {
+		if a := img.Pix[i]; a != 0 && a != 255 {
+			t.Fatalf("native size: got blended alpha %d", a)
+		}
+	}
+}
+
+func TestDrawFilledCircle_AntiAliased(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 21, 21))
+	red := color.RGBA{255, 0, 0, 255}
+	drawFilledCircle(img, 10, 10, 8, red)
+
+	if got := img.RGBAAt(10, 10); got != red {
+		t.Errorf("center: got %v, want %v", got, red)
+	}
+	if got := img.RGBAAt(0, 0); got.A != 0 {
+		t.Errorf("corner: got %v, want untouched", got)
+	}
+	// A pixel the edge cuts through is partly covered
+	if a := img.RGBAAt(16, 16).A; a == 0 || a == 255 {
+		t.Errorf("edge pixel (16,16): got alpha %d, want partial coverage", a)
+	}
+}
+
+func TestBlendPixel(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	img.SetRGBA(0, 0, color.RGBA{255, 255, 255, 255})
+	blendPixel(img, 0, 0, color.RGBA{0, 0, 0, 255}, 0.5)
+	if got, want := img.RGBAAt(0, 0), (color.RGBA{128, 128, 128, 255}); got != want {
+		t.Errorf("half black over white: got %v, want %v", got, want)
+	}
+	blendPixel(img, 0, 0, color.RGBA{0, 0, 255, 255}, 1)
+	if got, want := img.RGBAAt(0, 0), (color.RGBA{0, 0, 255, 255}); got != want {
+		t.Errorf("full coverage: got %v, want %v", got, want)
+	}
+}
+
 func TestBitmapFont_ImplementsFontRenderer(t *testing.T) {
 	var _ FontRenderer = (*BitmapFont)(nil)
 }
@@ -243,6 +308,160 @@ func TestRender_NoZones(t *testing.T) {
 	}
 }
 
+func TestRender_NoLegend(t *testing.T) {
+	dm := detection.NewMap(20, 10)
+	for y := 0; y < 10; y++ {
+		dm.IsDelimiter[y*20+10] = true
+	}
+	zones, labels := zone.FindZones(dm)
+	cm := aggregation.ReduceColors([]mcol.RGBA{{R: 255, A: 255}, {B: 255, A: 255}}, 0)
+	src := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	cfg := DefaultConfig()
+	cfg.NoLegend = true
+
+	out := Render(src, dm, zones, labels, cm, NewBitmapFont(), cfg)
+	if out.Bounds() != src.Bounds() {
+		t.Errorf("bounds: got %v, want the drawing's %v", out.Bounds(), src.Bounds())
+	}
+}
+
+func TestRender_BackgroundAndLineColor(t *testing.T) {
+	dm := detection.NewMap(20, 10)
+	for y := 0; y < 10; y++ {
+		dm.IsDelimiter[y*20+10] = true
+	}
+	zones, labels := zone.FindZones(dm)
+	cm := aggregation.ReduceColors([]mcol.RGBA{{R: 255, A: 255}, {B: 255, A: 255}}, 0)
+	src := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	cfg := DefaultConfig()
+	cfg.Background = color.RGBA{255, 248, 231, 255}
+	cfg.LineColor = color.RGBA{192, 192, 192, 255}
+
+	out := Render(src, dm, zones, labels, cm, NewBitmapFont(), cfg)
+	if got := out.RGBAAt(0, 0); got != cfg.Background {
+		t.Errorf("paper: got %v, want %v", got, cfg.Background)
+	}
+	if got := out.RGBAAt(0, out.Bounds().Dy()-1); got != cfg.Background {
+		t.Errorf("legend area: got %v, want %v", got, cfg.Background)
+	}
+	if got := out.RGBAAt(10, 0); got != cfg.LineColor {
+		t.Errorf("border: got %v, want %v", got, cfg.LineColor)
+	}
+}
+
+func TestRender_HintOpacity(t *testing.T) {
+	dm := detection.NewMap(20, 10)
+	for y := 0; y < 10; y++ {
+		dm.IsDelimiter[y*20+10] = true
+	}
+	zones, labels := zone.FindZones(dm)
+	cm := aggregation.ReduceColors([]mcol.RGBA{{R: 255, A: 255}, {B: 255, A: 255}}, 0)
+	src := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	cfg := DefaultConfig()
+	cfg.HintOpacity = 0.2
+
+	out := Render(src, dm, zones, labels, cm, NewBitmapFont(), cfg)
+	if got, want := out.RGBAAt(0, 0), (color.RGBA{255, 204, 204, 255}); got != want {
+		t.Errorf("red zone: got %v, want the tint %v", got, want)
+	}
+	if got, want := out.RGBAAt(19, 0), (color.RGBA{204, 204, 255, 255}); got != want {
+		t.Errorf("blue zone: got %v, want the tint %v", got, want)
+	}
+	if got := out.RGBAAt(0, out.Bounds().Dy()-1); got != cfg.Background {
+		t.Errorf("legend area: got %v, want the paper color", got)
+	}
+}
+
+func TestRender_TransparentBackground(t *testing.T) {
+	dm := detection.NewMap(20, 10)
+	for y := 0; y < 10; y++ {
+		dm.IsDelimiter[y*20+10] = true
+	}
+	zones, labels := zone.FindZones(dm)
+	cm := aggregation.ReduceColors([]mcol.RGBA{{R: 255, A: 255}, {B: 255, A: 255}}, 0)
+	src := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	cfg := DefaultConfig()
+	cfg.Background = color.RGBA{}
+
+	out := Render(src, dm, zones, labels, cm, NewBitmapFont(), cfg)
+	if got := out.RGBAAt(0, 0); got != (color.RGBA{}) {
+		t.Errorf("paper: got %v, want transparent", got)
+	}
+	if got := out.RGBAAt(10, 0); got != cfg.LineColor {
+		t.Errorf("border: got %v, want %v", got, cfg.LineColor)
+	}
+}
+
+func TestErasePixel(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	img.SetRGBA(0, 0, color.RGBA{0, 0, 0, 255})
+	erasePixel(img, 0, 0, 0.5)
+	if got := img.RGBAAt(0, 0); got != (color.RGBA{0, 0, 0, 128}) {
+		t.Errorf("got %v, want half-transparent black", got)
+	}
+}
+
+func TestRender_LabelHalo(t *testing.T) {
+	// A zone exactly as tall as the digits, between two border rows
+	dm := detection.NewMap(31, 9)
+	for x := 0; x < 31; x++ {
+		dm.IsDelimiter[x] = true
+		dm.IsDelimiter[8*31+x] = true
+	}
+	zones, labels := zone.FindZones(dm)
+	cm := aggregation.ReduceColors([]mcol.RGBA{{R: 255, A: 255}}, 0)
+	src := image.NewRGBA(image.Rect(0, 0, 31, 9))
+
+	carved := func(cfg Config) int {
+		cfg.MinLabelSize = 7
+		cfg.NoLegend = true
+		out := Render(src, dm, zones, labels, cm, NewBitmapFont(), cfg)
+		n := 0
+		for x := 0; x < 31; x++ {
+			if out.RGBAAt(x, 0).R > 0 {
+				n++
+			}
+		}
+		return n
+	}
+	if n := carved(DefaultConfig()); n == 0 {
+		t.Error("the halo should lighten the border above the digit")
+	}
+	cfg := DefaultConfig()
+	cfg.LabelHalo = 0
+	if n := carved(cfg); n != 0 {
+		t.Errorf("without a halo, %d border pixels changed", n)
+	}
+}
+
+func TestEntryLabel(t *testing.T) {
+	cfg := DefaultConfig()
+	if got := cfg.EntryLabel(12); got != "12" {
+		t.Errorf("without labels: got %q, want 12", got)
+	}
+	cfg.Labels = []string{"A", "", "♥"}
+	for n, want := range map[int]string{1: "A", 2: "2", 3: "♥", 4: "4"} {
+		if got := cfg.EntryLabel(n); got != want {
+			t.Errorf("EntryLabel(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestBitmapFont_Symbols(t *testing.T) {
+	bf := NewBitmapFont()
+	for _, sym := range []string{"●", "■", "▲", "◆", "♥", "★", "○", "□", "△", "◇", "+"} {
+		img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+		bf.DrawString(img, sym, 5, 5, color.Black, 7)
+		inked := false
+		for i := 3; i < len(img.Pix); i += 4 {
+			inked = inked || img.Pix[i] != 0
+		}
+		if !inked {
+			t.Errorf("%s has no glyph", sym)
+		}
+	}
+}
+
 func TestComputeFontSize(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -289,3 +508,353 @@ func TestCalculateLegendHeight_WithEntries(t *testing.T) {
 		t.Errorf("expected positive legend height, got %d", h)
 	}
 }
+
+func TestFormatCoverage(t *testing.T) {
+	tests := []struct {
+		frac float64
+		want string
+	}{
+		{0.12, "(12%)"},
+		{1, "(100%)"},
+		{0.004, "(0.4%)"},
+		{0.0999, "(10%)"},
+	}
+	for _, tt := range tests {
+		if got := formatCoverage(tt.frac); got != tt.want {
+			t.Errorf("formatCoverage(%v) = %q, want %q", tt.frac, got, tt.want)
+		}
+	}
+}
+
+func TestLegendNotes_Coverage(t *testing.T) {
+	zones := []zone.Zone{
+		{ID: 0, Runs: []zone.Run{{X1: 3}}},
+		{ID: 1, Runs: []zone.Run{{Y: 1, X1: 1}}},
+	}
+	cm := &aggregation.ColorMap{
+		Entries: []aggregation.ColorEntry{{Number: 1}, {Number: 2}},
+		ZoneMap: []int{0, 1},
+	}
+
+	cfg := DefaultConfig()
+	if notes := legendNotes(cm, zones, cfg); notes != nil {
+		t.Errorf("expected no notes when coverage is disabled, got %v", notes)
+	}
+
+	cfg.LegendCoverage = true
+	notes := legendNotes(cm, zones, cfg)
+	want := []string{"(75%)", "(25%)"}
+	for i := range want {
+		if notes[i] != want[i] {
+			t.Errorf("note %d: got %q, want %q", i, notes[i], want[i])
+		}
+	}
+}
+
+func TestEntryNotes(t *testing.T) {
+	cm := &aggregation.ColorMap{Entries: []aggregation.ColorEntry{
+		{Number: 1, Color: mcol.RGBA{R: 200, G: 75, B: 58, A: 255}},
+		{Number: 2, Color: mcol.RGBA{R: 10, G: 11, B: 255, A: 128}},
+	}}
+	coverage := []float64{0.75, 0.25}
+
+	cfg := DefaultConfig()
+	if notes := entryNotes(cm, coverage, nil, cfg); notes != nil {
+		t.Errorf("expected no notes with no annotation enabled, got %v", notes)
+	}
+	cfg.LegendHex = true
+	cfg.LegendCoverage = true
+	want := []string{"#C84B3A (75%)", "#0A0BFF (25%)"}
+	notes := entryNotes(cm, coverage, nil, cfg)
+	for i := range want {
+		if notes[i] != want[i] {
+			t.Errorf("note %d: got %q, want %q", i, notes[i], want[i])
+		}
+	}
+	// Without coverage fractions only the hex code is printed
+	if notes := entryNotes(cm, nil, nil, cfg); notes[0] != "#C84B3A" {
+		t.Errorf("got %q, want the hex code alone", notes[0])
+	}
+	// Zone counts go between the hex code and the coverage
+	cfg.LegendZoneCounts = true
+	if notes := entryNotes(cm, coverage, []int{2, 1}, cfg); notes[0] != "#C84B3A × 2 (75%)" {
+		t.Errorf("got %q, want the zone count after the hex code", notes[0])
+	}
+	// and every character has a glyph in the bitmap font
+	for _, r := range "#0123456789ABCDEF×" {
+		if _, ok := glyphs[r]; !ok {
+			t.Errorf("no glyph for %q", r)
+		}
+	}
+}
+
+func TestLegendLayout_NotesWidenItems(t *testing.T) {
+	cm := &aggregation.ColorMap{
+		Entries: []aggregation.ColorEntry{{Number: 1}, {Number: 2}, {Number: 3}},
+	}
+	cfg := DefaultConfig()
+	font := NewBitmapFont()
+
+	plain := newLegendLayout(cm, font, cfg, 400, nil)
+	annotated := newLegendLayout(cm, font, cfg, 400, []string{"(50%)", "(25%)", "(25%)"})
+	if annotated.itemWidth <= plain.itemWidth {
+		t.Errorf("annotated item width %d should exceed plain %d", annotated.itemWidth, plain.itemWidth)
+	}
+}
+
+func TestDrawDividers(t *testing.T) {
+	// Two sub-zones touching directly, then a delimiter, then a third zone.
+	labels := []int{0, 0, 1, -1, 2}
+	img := image.NewRGBA(image.Rect(0, 0, 5, 1))
+	drawDividers(img, labels, 5, 1)
+
+	for x := 0; x < 5; x++ {
+		got := img.RGBAAt(x, 0) == dividerColor
+		if want := x == 1; got != want {
+			t.Errorf("pixel %d: divider=%v, want %v", x, got, want)
+		}
+	}
+}
+
+func TestRender_OutlineWidthAndMinLabelSize(t *testing.T) {
+	srcW, srcH := 60, 60
+	src := image.NewRGBA(image.Rect(0, 0, srcW, srcH))
+	dm := detection.NewMap(srcW, srcH)
+	for y := 0; y < srcH; y++ {
+		dm.IsDelimiter[y*srcW+30] = true
+	}
+	zones, labels := zone.FindZones(dm)
+	cm := aggregation.ReduceColors(zone.ComputeZoneColors(zones, src).Colors, 0)
+	cfg := DefaultConfig()
+	cfg.OutlineWidth = 2
+	cfg.MinLabelSize = 21
+
+	out := Render(src, dm, zones, labels, cm, NewBitmapFont(), cfg)
+
+	for x := 28; x <= 32; x++ {
+		if out.RGBAAt(x, 2) != (color.RGBA{0, 0, 0, 255}) {
+			t.Errorf("pixel (%d,2) should be part of the thickened outline", x)
+		}
+	}
+	// A 21px label uses scale 3: its glyph spans more rows than the
+	// natural 7px size would.
+	inked := 0
+	for y := 0; y < srcH; y++ {
+		if out.RGBAAt(15, y) == (color.RGBA{0, 0, 0, 255}) {
+			inked++
+		}
+	}
+	if inked < 8 {
+		t.Errorf("expected an enlarged label, found %d inked rows in its column", inked)
+	}
+	if LabelSize(100, 100, 1) != 7 {
+		t.Errorf("LabelSize should clamp small images to 7, got %d", LabelSize(100, 100, 1))
+	}
+}
+
+func TestRenderLegend_ZeroSizes(t *testing.T) {
+	cm := &aggregation.ColorMap{Entries: []aggregation.ColorEntry{{Number: 1, Color: mcol.RGBA{R: 255, A: 255}}}}
+	cfg := DefaultConfig()
+	cfg.LegendCircleSize, cfg.LegendSpacing, cfg.LegendPadding, cfg.LegendMargin = 0, 0, 0, 0
+
+	// Must not divide by a zero item width.
+	if img := RenderLegend(cm, nil, nil, NewBitmapFont(), cfg, 100); img.Bounds().Dx() != 100 {
+		t.Errorf("width: got %d, want 100", img.Bounds().Dx())
+	}
+}
+
+func TestRenderLegend(t *testing.T) {
+	cm := &aggregation.ColorMap{Entries: []aggregation.ColorEntry{
+		{Number: 1, Color: mcol.RGBA{R: 255, A: 255}},
+		{Number: 2, Color: mcol.RGBA{B: 255, A: 255}},
+	}}
+	cfg := DefaultConfig()
+	cfg.LegendCoverage = true
+
+	img := RenderLegend(cm, []float64{0.75, 0.25}, nil, NewBitmapFont(), cfg, 300)
+	if img.Bounds().Dx() != 300 {
+		t.Errorf("width: got %d, want 300", img.Bounds().Dx())
+	}
+	notes := entryNotes(cm, []float64{0.75, 0.25}, nil, cfg)
+	if want := newLegendLayout(cm, NewBitmapFont(), cfg, 300, notes).height(cfg); img.Bounds().Dy() != want {
+		t.Errorf("height: got %d, want %d", img.Bounds().Dy(), want)
+	}
+
+	// The first swatch is red somewhere and the background is white.
+	red := 0
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			if img.RGBAAt(x, y) == (color.RGBA{255, 0, 0, 255}) {
+				red++
+			}
+		}
+	}
+	if red == 0 {
+		t.Error("expected a red swatch")
+	}
+	if img.RGBAAt(0, 0) != (color.RGBA{255, 255, 255, 255}) {
+		t.Error("expected a white background")
+	}
+}
+
+func TestRenderLegend_QR(t *testing.T) {
+	cm := &aggregation.ColorMap{Entries: []aggregation.ColorEntry{
+		{Number: 1, Color: mcol.RGBA{R: 255, A: 255}},
+	}}
+	code, err := qr.Encode("https://example.com/cat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultConfig()
+	plain := newLegendLayout(cm, nil, cfg, 300, nil)
+	cfg.LegendQR = code
+	l := newLegendLayout(cm, nil, cfg, 300, nil)
+
+	side := (code.Size + 2*qr.QuietZone) * 3
+	if l.qrSide != side {
+		t.Fatalf("QR side: got %d, want %d", l.qrSide, side)
+	}
+	if got, want := l.height(cfg), 2*cfg.LegendPadding+side; got != want || got <= plain.height(cfg) {
+		t.Errorf("height: got %d, want %d, taller than %d without the code", got, want, plain.height(cfg))
+	}
+	if l.itemsWidth(cfg, 300) != plain.itemsWidth(cfg, 300)-side-cfg.LegendSpacing {
+		t.Errorf("items should wrap left of the code")
+	}
+
+	img := RenderLegend(cm, nil, nil, NewBitmapFont(), cfg, 300)
+	// The top left finder module of the code, past its quiet zone.
+	x := 300 - cfg.LegendMargin - side + qr.QuietZone*3
+	y := cfg.LegendPadding + qr.QuietZone*3
+	if img.RGBAAt(x, y) != (color.RGBA{0, 0, 0, 255}) || img.RGBAAt(x-1, y) != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("expected the finder corner at (%d,%d)", x, y)
+	}
+}
+
+func TestRenderAnswerKey(t *testing.T) {
+	// A 5x1 strip split by a delimiter at x=2.
+	dm := detection.NewMap(5, 1)
+	dm.IsDelimiter[2] = true
+	zones, _ := zone.FindZones(dm)
+	red := mcol.RGBA{R: 255, A: 255}
+	blue := mcol.RGBA{B: 255, A: 255}
+	cm := aggregation.ReduceColors([]mcol.RGBA{red, blue}, 0)
+
+	img := RenderAnswerKey(dm, zones, cm, DefaultConfig())
+	if img.Bounds().Dx() != 5 || img.Bounds().Dy() != 1 {
+		t.Fatalf("bounds: got %v, want 5x1 with no legend", img.Bounds())
+	}
+	want := []color.RGBA{red.ToStdColor(), red.ToStdColor(), {0, 0, 0, 255}, blue.ToStdColor(), blue.ToStdColor()}
+	for x, w := range want {
+		if got := img.RGBAAt(x, 0); got != w {
+			t.Errorf("pixel %d: got %v, want %v", x, got, w)
+		}
+	}
+}
+
+func TestRenderPalettePreview(t *testing.T) {
+	// A 5x1 strip split by a gray delimiter at x=2.
+	dm := detection.NewMap(5, 1)
+	dm.IsDelimiter[2] = true
+	zones, _ := zone.FindZones(dm)
+	red := mcol.RGBA{R: 255, A: 255}
+	blue := mcol.RGBA{B: 255, A: 255}
+	cm := aggregation.ReduceColors([]mcol.RGBA{red, blue}, 0)
+	src := image.NewRGBA(image.Rect(0, 0, 5, 1))
+	gray := color.RGBA{90, 90, 90, 255}
+	for x := 0; x < 5; x++ {
+		src.SetRGBA(x, 0, gray)
+	}
+
+	img := RenderPalettePreview(src, zones, cm)
+	want := []color.RGBA{red.ToStdColor(), red.ToStdColor(), gray, blue.ToStdColor(), blue.ToStdColor()}
+	for x, w := range want {
+		if got := img.RGBAAt(x, 0); got != w {
+			t.Errorf("pixel %d: got %v, want %v", x, got, w)
+		}
+	}
+}
+
+func TestRenderSolution(t *testing.T) {
+	// A 40x20 image split by a delimiter column at x=20: navy left, yellow right.
+	dm := detection.NewMap(40, 20)
+	for y := 0; y < 20; y++ {
+		dm.IsDelimiter[y*40+20] = true
+	}
+	zones, labels := zone.FindZones(dm)
+	navy := mcol.RGBA{B: 128, A: 255}
+	yellow := mcol.RGBA{R: 255, G: 255, A: 255}
+	cm := aggregation.ReduceColors([]mcol.RGBA{navy, yellow}, 0)
+	src := image.NewRGBA(image.Rect(0, 0, 40, 20))
+
+	img := RenderSolution(src, dm, zones, labels, cm, NewBitmapFont(), DefaultConfig())
+	if img.Bounds().Dy() <= 20 {
+		t.Fatalf("height %d: want the legend below the drawing", img.Bounds().Dy())
+	}
+	// Each zone keeps its color and carries its number in a readable ink
+	count := func(x0, x1 int, c color.RGBA) int {
+		n := 0
+		for y := 0; y < 20; y++ {
+			for x := x0; x < x1; x++ {
+				if img.RGBAAt(x, y) == c {
+					n++
+				}
+			}
+		}
+		return n
+	}
+	if count(0, 20, navy.ToStdColor()) == 0 || count(0, 20, color.RGBA{255, 255, 255, 255}) == 0 {
+		t.Error("navy zone: want its color with a white number")
+	}
+	if count(21, 40, yellow.ToStdColor()) == 0 || count(21, 40, color.RGBA{0, 0, 0, 255}) == 0 {
+		t.Error("yellow zone: want its color with a black number")
+	}
+}
+
+func TestRenderWorksheets(t *testing.T) {
+	// A 40x20 image split by a delimiter column at x=20.
+	dm := detection.NewMap(40, 20)
+	for y := 0; y < 20; y++ {
+		dm.IsDelimiter[y*40+20] = true
+	}
+	zones, labels := zone.FindZones(dm)
+	cm := aggregation.ReduceColors([]mcol.RGBA{{R: 255, A: 255}, {B: 255, A: 255}}, 0)
+	src := image.NewRGBA(image.Rect(0, 0, 40, 20))
+
+	pages := RenderWorksheets(src, dm, zones, labels, cm, NewBitmapFont(), DefaultConfig())
+	if len(pages) != 2 {
+		t.Fatalf("got %d pages, want one per color", len(pages))
+	}
+	for e, page := range pages {
+		for i := range zones {
+			// A corner pixel, away from the number
+			p := zones[i].Points()[0]
+			got := page.RGBAAt(p.X, p.Y)
+			want := color.RGBA{255, 255, 255, 255}
+			if cm.ZoneMap[i] == e {
+				want = worksheetShade
+			}
+			if got != want {
+				t.Errorf("page %d zone %d: got %v, want %v", e, i, got, want)
+			}
+		}
+		if got := page.RGBAAt(20, 0); got != worksheetFaint {
+			t.Errorf("page %d delimiter: got %v, want faint", e, got)
+		}
+		if page.Bounds().Dy() <= 20 {
+			t.Errorf("page %d has no legend", e)
+		}
+	}
+}
+
+func TestRenderContext_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	dm := detection.NewMap(40, 20)
+	zones, labels := zone.FindZones(dm)
+	cm := aggregation.ReduceColors([]mcol.RGBA{{R: 255, A: 255}}, 0)
+	src := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	out, err := RenderContext(ctx, src, dm, zones, labels, cm, NewBitmapFont(), DefaultConfig())
+	if !errors.Is(err, context.Canceled) || out != nil {
+		t.Errorf("got %v, %v; want context.Canceled", out, err)
+	}
+}
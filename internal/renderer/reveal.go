@@ -0,0 +1,34 @@
+package renderer
+
+import (
+	"image"
+
+	"github.com/maax3v3/macoma/v2/internal/aggregation"
+	"github.com/maax3v3/macoma/v2/internal/zone"
+)
+
+// RenderReveal returns the frames of a progressive reveal of coloring: the
+// coloring itself, then one frame per palette entry, in legend order, with
+// the zones of that entry and of all earlier ones filled with their color.
+// The zones are drawn top pixels down, e.g. below a title band.
+func RenderReveal(coloring *image.RGBA, top int, zones []zone.Zone, cm *aggregation.ColorMap) []*image.RGBA {
+	byEntry := make([][]int, len(cm.Entries))
+	for i, e := range cm.ZoneMap {
+		byEntry[e] = append(byEntry[e], i)
+	}
+
+	frames := make([]*image.RGBA, 0, len(cm.Entries)+1)
+	frame := coloring
+	frames = append(frames, frame)
+	for e, ids := range byEntry {
+		next := image.NewRGBA(frame.Rect)
+		copy(next.Pix, frame.Pix)
+		c := cm.Entries[e].Color.ToStdColor()
+		for _, i := range ids {
+			zones[i].Each(func(x, y int) { next.SetRGBA(x, y+top, c) })
+		}
+		frames = append(frames, next)
+		frame = next
+	}
+	return frames
+}
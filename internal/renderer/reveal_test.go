@@ -0,0 +1,47 @@
+package renderer
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/maax3v3/macoma/v2/internal/aggregation"
+	mcol "github.com/maax3v3/macoma/v2/internal/color"
+	"github.com/maax3v3/macoma/v2/internal/detection"
+	"github.com/maax3v3/macoma/v2/internal/zone"
+)
+
+func TestRenderReveal(t *testing.T) {
+	dm := detection.NewMap(20, 10)
+	for y := 0; y < 10; y++ {
+		dm.IsDelimiter[y*20+10] = true
+	}
+	zones, _ := zone.FindZones(dm)
+	cm := aggregation.ReduceColors([]mcol.RGBA{{R: 255, A: 255}, {B: 255, A: 255}}, 0)
+	coloring := whiteCanvas(20, 14)
+
+	frames := RenderReveal(coloring, 4, zones, cm)
+	if len(frames) != 3 || frames[0] != coloring {
+		t.Fatalf("got %d frames, want the coloring and one per color", len(frames))
+	}
+	white := color.RGBA{255, 255, 255, 255}
+	// Frame k fills the zones of the first k entries; zone 0 lies left of
+	// the delimiter and zone 1 right of it, both 4 pixels down
+	xs := []int{0, 19}
+	for k := 1; k < len(frames); k++ {
+		for z, e := range cm.ZoneMap {
+			want := white
+			if e < k {
+				want = cm.Entries[e].Color.ToStdColor()
+			}
+			if got := frames[k].RGBAAt(xs[z], 4); got != want {
+				t.Errorf("frame %d, zone %d: got %v, want %v", k, z, got, want)
+			}
+		}
+	}
+	if got := frames[2].RGBAAt(0, 3); got != white {
+		t.Errorf("the title band above the drawing should stay blank, got %v", got)
+	}
+	if got := coloring.RGBAAt(0, 4); got != white {
+		t.Error("the coloring itself should be left unchanged")
+	}
+}
@@ -0,0 +1,29 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// SideBySide returns ref to the left of img on one canvas of the paper
+// color, separated by a gap of cfg.LegendMargin pixels with a thin vertical
+// divider in its middle, drawn like the legend separator. ref is placed top
+// pixels down, e.g. level with the drawing below a title band.
+func SideBySide(ref, img image.Image, top int, cfg Config) *image.RGBA {
+	rb, ib := ref.Bounds(), img.Bounds()
+	gap := max(cfg.LegendMargin, 2)
+	w := rb.Dx() + gap + ib.Dx()
+	h := max(top+rb.Dy(), ib.Dy())
+
+	out := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(out, out.Rect, image.NewUniform(cfg.Background), image.Point{}, draw.Src)
+	draw.Draw(out, image.Rect(0, top, rb.Dx(), top+rb.Dy()), ref, rb.Min, draw.Over)
+	draw.Draw(out, image.Rect(rb.Dx()+gap, 0, w, ib.Dy()), img, ib.Min, draw.Src)
+
+	x := rb.Dx() + gap/2
+	for y := 0; y < h; y++ {
+		blendClipped(out, x, y, color.RGBA{0, 0, 0, 255}, 55.0/255)
+	}
+	return out
+}
@@ -0,0 +1,31 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestSideBySide(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	ref := image.NewRGBA(image.Rect(0, 0, 30, 20))
+	draw.Draw(ref, ref.Rect, image.NewUniform(red), image.Point{}, draw.Src)
+	cfg := DefaultConfig()
+	cfg.LegendMargin = 10
+	cfg.Background = color.RGBA{255, 248, 231, 255}
+
+	out := SideBySide(ref, whiteCanvas(40, 50), 5, cfg)
+	if got := out.Bounds().Size(); got.X != 80 || got.Y != 50 {
+		t.Fatalf("size: got %v, want 80x50", got)
+	}
+	if out.RGBAAt(0, 4) != cfg.Background || out.RGBAAt(0, 5) != red || out.RGBAAt(29, 24) != red || out.RGBAAt(0, 25) != cfg.Background {
+		t.Error("the reference should sit 5 pixels down on the left")
+	}
+	if got := out.RGBAAt(35, 0); got.R >= cfg.Background.R {
+		t.Errorf("divider: got %v, want a darker line in the gap", got)
+	}
+	if got := out.RGBAAt(40, 0); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("the coloring should start after the gap, got %v", got)
+	}
+}
@@ -0,0 +1,117 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strconv"
+)
+
+// StitchThread is one thread of a cross-stitch chart: its symbol on the
+// grid and its line in the key.
+type StitchThread struct {
+	Symbol string
+	Color  color.RGBA
+	Label  string // key text, e.g. "321 Red (120)"
+}
+
+// RenderStitchChart draws a cross-stitch chart on white paper. cells holds
+// a cols×rows grid, row by row, of indexes into threads, -1 for a cell
+// left bare. Each cell is cell pixels square, filled with its thread color
+// and marked with its symbol. Thin lines separate the cells, heavier ones
+// every ten, which are numbered along the top and left edges. The key
+// below lists each thread's swatch and label.
+func RenderStitchChart(cells []int, cols, rows int, threads []StitchThread, cell int, font FontRenderer) *image.RGBA {
+	numSize := max(7, cell*2/3)
+	numW, numH := font.MeasureString(strconv.Itoa(max(cols, rows)), numSize)
+	ox, oy := numW+cell, numH+cell
+	chartW, chartH := cols*cell, rows*cell
+
+	// Key layout: a swatch, then the label, in as many columns as fit.
+	textSize := max(7, cell*3/4)
+	labelW, labelH := 0, 0
+	for _, t := range threads {
+		tw, th := font.MeasureString(t.Label, textSize)
+		labelW, labelH = max(labelW, tw), max(labelH, th)
+	}
+	colW := cell + cell/2 + labelW + 2*cell
+	rowH := max(cell, labelH) + cell/2
+	w := max(ox+chartW+cell, ox+colW)
+	perRow := max(1, (w-ox)/colW)
+	keyRows := (len(threads) + perRow - 1) / perRow
+	keyTop := oy + chartH + 2*cell
+	h := keyTop + keyRows*rowH + cell
+
+	out := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(out, out.Rect, image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	symSize := max(7, cell*3/4)
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			t := cells[y*cols+x]
+			if t < 0 {
+				continue
+			}
+			drawSwatch(out, font, threads[t], ox+x*cell, oy+y*cell, cell, symSize)
+		}
+	}
+
+	light := color.RGBA{170, 170, 170, 255}
+	black := color.RGBA{0, 0, 0, 255}
+	for x := 0; x <= cols; x++ {
+		px := ox + x*cell
+		if x%10 == 0 || x == cols {
+			fillRect(out, px-1, oy-1, 2, chartH+2, black)
+		} else {
+			fillRect(out, px, oy, 1, chartH, light)
+		}
+		if x%10 == 0 && x > 0 {
+			font.DrawString(out, strconv.Itoa(x), px, oy-cell/2-numH/2, black, numSize)
+		}
+	}
+	for y := 0; y <= rows; y++ {
+		py := oy + y*cell
+		if y%10 == 0 || y == rows {
+			fillRect(out, ox-1, py-1, chartW+2, 2, black)
+		} else {
+			fillRect(out, ox, py, chartW, 1, light)
+		}
+		if y%10 == 0 && y > 0 {
+			font.DrawString(out, strconv.Itoa(y), ox-cell/2-numW/2, py, black, numSize)
+		}
+	}
+
+	for i, t := range threads {
+		x := ox + (i%perRow)*colW
+		y := keyTop + (i/perRow)*rowH
+		drawSwatch(out, font, t, x, y, cell, symSize)
+		strokeRect(out, x, y, cell, cell, black)
+		tw, _ := font.MeasureString(t.Label, textSize)
+		font.DrawString(out, t.Label, x+cell+cell/2+tw/2, y+cell/2, black, textSize)
+	}
+	return out
+}
+
+// drawSwatch fills the cell-sized square at (x, y) with the thread color
+// and centers its symbol on it, in white on dark threads.
+func drawSwatch(img *image.RGBA, font FontRenderer, t StitchThread, x, y, cell, size int) {
+	fillRect(img, x, y, cell, cell, t.Color)
+	ink := color.RGBA{0, 0, 0, 255}
+	if isDark(t.Color) {
+		ink = color.RGBA{255, 255, 255, 255}
+	}
+	font.DrawString(img, t.Symbol, x+cell/2, y+cell/2, ink, size)
+}
+
+// fillRect paints the w×h rectangle at (x, y), clipped to img.
+func fillRect(img *image.RGBA, x, y, w, h int, c color.RGBA) {
+	draw.Draw(img, image.Rect(x, y, x+w, y+h).Intersect(img.Rect), image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+// strokeRect outlines the w×h rectangle at (x, y) with a 1-pixel line.
+func strokeRect(img *image.RGBA, x, y, w, h int, c color.RGBA) {
+	fillRect(img, x, y, w, 1, c)
+	fillRect(img, x, y+h-1, w, 1, c)
+	fillRect(img, x, y, 1, h, c)
+	fillRect(img, x+w-1, y, 1, h, c)
+}
@@ -0,0 +1,42 @@
+package renderer
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestRenderStitchChart(t *testing.T) {
+	red := color.RGBA{200, 30, 40, 255}
+	threads := []StitchThread{{Symbol: "●", Color: red, Label: "321 Red (3)"}}
+	cells := []int{0, 0, -1, 0}
+	out := RenderStitchChart(cells, 2, 2, threads, 12, NewBitmapFont())
+
+	// Locate the chart by its first red pixel: the top-left cell.
+	ox, oy := -1, -1
+	for y := 0; y < out.Bounds().Dy() && ox < 0; y++ {
+		for x := 0; x < out.Bounds().Dx(); x++ {
+			if out.RGBAAt(x, y) == red {
+				ox, oy = x, y
+				break
+			}
+		}
+	}
+	if ox < 0 {
+		t.Fatal("no stitched cell drawn")
+	}
+	// The bare bottom-left cell stays paper white.
+	if got := out.RGBAAt(ox+2, oy+12+2); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("bare cell: got %v, want white", got)
+	}
+	if got := out.RGBAAt(ox+12+2, oy+12+2); got != red {
+		t.Errorf("bottom-right cell: got %v, want the thread color", got)
+	}
+	// Red is dark, so its symbol is drawn in white at the cell center.
+	if got := out.RGBAAt(ox+6, oy+6); got.G < 128 {
+		t.Errorf("cell center: got %v, want the white symbol", got)
+	}
+	// The key sits below the two rows of the chart.
+	if out.Bounds().Dy() < oy+2*12+12 {
+		t.Errorf("chart height %d leaves no room for the key", out.Bounds().Dy())
+	}
+}
@@ -0,0 +1,71 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+)
+
+// Title alignments.
+const (
+	AlignLeft   = "left"
+	AlignCenter = "center"
+	AlignRight  = "right"
+)
+
+// Title describes a caption drawn in a band above an image, e.g. a
+// worksheet header.
+type Title struct {
+	Text   string
+	Size   int    // text height in pixels; 0 picks one from the image width
+	Align  string // one of the Align* constants; default center
+	Margin int    // distance from the side edges in pixels
+}
+
+// TitleSize is the text height of a title over an image w pixels wide when
+// Title.Size is 0.
+func TitleSize(w int) int {
+	return max(14, w/20)
+}
+
+// AddTitle returns img below a band of the paper color bg holding the
+// caption in black. The text shrinks until it fits between the margins. An
+// empty title returns img itself.
+func AddTitle(img *image.RGBA, t Title, font FontRenderer, bg color.RGBA) *image.RGBA {
+	if t.Text == "" {
+		return img
+	}
+	b := img.Bounds()
+	w := b.Dx()
+	size := t.Size
+	if size <= 0 {
+		size = TitleSize(w)
+	}
+	tw, th := font.MeasureString(t.Text, size)
+	for tw > w-2*t.Margin && size > 1 {
+		size--
+		tw, th = font.MeasureString(t.Text, size)
+	}
+	pad := max(4, size/2)
+	bandH := th + 2*pad
+
+	out := image.NewRGBA(image.Rect(0, 0, w, b.Dy()+bandH))
+	for y := 0; y < bandH; y++ {
+		for x := 0; x < w; x++ {
+			out.SetRGBA(x, y, bg)
+		}
+	}
+	for y := 0; y < b.Dy(); y++ {
+		i := img.PixOffset(b.Min.X, b.Min.Y+y)
+		copy(out.Pix[out.PixOffset(0, bandH+y):], img.Pix[i:i+4*w])
+	}
+
+	cx := w / 2
+	switch t.Align {
+	case AlignLeft:
+		cx = t.Margin + tw/2
+	case AlignRight:
+		cx = w - t.Margin - (tw+1)/2
+	}
+	font.DrawString(out, t.Text, cx, pad+th/2, color.Black, size)
+	return out
+}
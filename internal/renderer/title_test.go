@@ -0,0 +1,70 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// inkSpan returns the first and last columns of img holding a dark pixel
+// in rows [y0, y1).
+func inkSpan(img *image.RGBA, y0, y1 int) (first, last int) {
+	first, last = -1, -1
+	for x := 0; x < img.Bounds().Dx(); x++ {
+		for y := y0; y < y1; y++ {
+			if img.RGBAAt(x, y).R < 128 {
+				if first < 0 {
+					first = x
+				}
+				last = x
+				break
+			}
+		}
+	}
+	return first, last
+}
+
+func TestAddTitle(t *testing.T) {
+	img := whiteCanvas(200, 50)
+	img.SetRGBA(0, 0, color.RGBA{255, 0, 0, 255})
+	cream := color.RGBA{255, 248, 231, 255}
+
+	out := AddTitle(img, Title{Text: "Farm", Size: 14}, NewBitmapFont(), cream)
+	band := out.Bounds().Dy() - 50
+	if band <= 14 {
+		t.Fatalf("band height %d should exceed the text height", band)
+	}
+	if got := out.RGBAAt(199, 0); got != cream {
+		t.Errorf("band: got %v, want the paper color", got)
+	}
+	if got := out.RGBAAt(0, band); got != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("the image should start below the band, got %v at its origin", got)
+	}
+	first, last := inkSpan(out, 0, band)
+	if first < 0 || (first+last)/2 < 95 || (first+last)/2 > 105 {
+		t.Errorf("centered title spans columns %d-%d", first, last)
+	}
+}
+
+func TestAddTitle_Align(t *testing.T) {
+	font := NewBitmapFont()
+	left := AddTitle(whiteCanvas(200, 10), Title{Text: "Farm", Align: AlignLeft, Margin: 20}, font, color.RGBA{255, 255, 255, 255})
+	if first, _ := inkSpan(left, 0, left.Bounds().Dy()-10); first != 20 {
+		t.Errorf("left: text starts at column %d, want 20", first)
+	}
+	right := AddTitle(whiteCanvas(200, 10), Title{Text: "Farm", Align: AlignRight, Margin: 20}, font, color.RGBA{255, 255, 255, 255})
+	if _, last := inkSpan(right, 0, right.Bounds().Dy()-10); last != 179 {
+		t.Errorf("right: text ends at column %d, want 179", last)
+	}
+}
+
+func TestAddTitle_ShrinksToFit(t *testing.T) {
+	font := NewBitmapFont()
+	out := AddTitle(whiteCanvas(60, 10), Title{Text: "Unit 3 - The Farm", Size: 70}, font, color.RGBA{255, 255, 255, 255})
+	if first, last := inkSpan(out, 0, out.Bounds().Dy()-10); first < 0 || last >= 60 {
+		t.Errorf("title should fit the width, spans columns %d-%d", first, last)
+	}
+	if img := whiteCanvas(60, 10); AddTitle(img, Title{}, font, color.RGBA{}) != img {
+		t.Error("an empty title should return the image unchanged")
+	}
+}
@@ -0,0 +1,110 @@
+package renderer
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// TTFFont renders text with a TrueType or OpenType font. Glyphs are
+// anti-aliased, so numbers stay smooth at any size.
+//
+// The size passed to DrawString and MeasureString is the height of the
+// digits in pixels, as with BitmapFont, so the two can be swapped without
+// changing the layout. A TTFFont is safe for concurrent use.
+type TTFFont struct {
+	font *opentype.Font
+
+	// digitRatio is the height of the digits per pixel of em size.
+	digitRatio float64
+
+	mu    sync.Mutex
+	faces map[int]font.Face
+}
+
+// ttfProbeSize is the em size, in pixels, at which the digit height is
+// measured once when the font is loaded.
+const ttfProbeSize = 100
+
+// NewTTFFont parses TTF or OTF font data.
+func NewTTFFont(data []byte) (*TTFFont, error) {
+	f, err := opentype.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing font: %w", err)
+	}
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: ttfProbeSize, DPI: 72})
+	if err != nil {
+		return nil, fmt.Errorf("parsing font: %w", err)
+	}
+	defer face.Close()
+	b, _ := font.BoundString(face, "0123456789")
+	h := (b.Max.Y - b.Min.Y).Ceil()
+	if h <= 0 {
+		return nil, fmt.Errorf("parsing font: no digit glyphs")
+	}
+	return &TTFFont{
+		font:       f,
+		digitRatio: float64(h) / ttfProbeSize,
+		faces:      make(map[int]font.Face),
+	}, nil
+}
+
+// face returns the cached face whose digits are size pixels tall.
+// t.mu must be held.
+func (t *TTFFont) face(size int) font.Face {
+	size = max(size, 1)
+	if f, ok := t.faces[size]; ok {
+		return f
+	}
+	f, err := opentype.NewFace(t.font, &opentype.FaceOptions{
+		Size:    float64(size) / t.digitRatio,
+		DPI:     72,
+		Hinting: font.HintingNone,
+	})
+	if err != nil {
+		// Only a non-positive size can fail, which max above rules out
+		panic(err)
+	}
+	t.faces[size] = f
+	return f
+}
+
+func (t *TTFFont) DrawString(img *image.RGBA, text string, cx, cy int, col color.Color, size int) {
+	if text == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	face := t.face(size)
+	b, advance := font.BoundString(face, text)
+	// Center the ink box on (cx, cy), relative to the image origin like
+	// BitmapFont.
+	origin := img.Bounds().Min
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(col),
+		Face: face,
+	}
+	d.Dot = fixed.Point26_6{
+		X: fixed.I(cx+origin.X) - advance/2,
+		Y: fixed.I(cy+origin.Y) - (b.Min.Y+b.Max.Y)/2,
+	}
+	d.DrawString(text)
+}
+
+func (t *TTFFont) MeasureString(text string, size int) (width, height int) {
+	if text == "" {
+		return 0, 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b, advance := font.BoundString(t.face(size), text)
+	return advance.Ceil(), (b.Max.Y - b.Min.Y).Ceil()
+}
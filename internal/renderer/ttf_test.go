@@ -0,0 +1,67 @@
+package renderer
+
+import (
+	"image"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestTTFFont_ImplementsFontRenderer(t *testing.T) {
+	var _ FontRenderer = (*TTFFont)(nil)
+}
+
+func TestNewTTFFont_InvalidData(t *testing.T) {
+	if _, err := NewTTFFont([]byte("not a font")); err == nil {
+		t.Error("expected an error for invalid font data")
+	}
+}
+
+func TestTTFFont_MeasureString(t *testing.T) {
+	f, err := NewTTFFont(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w, h := f.MeasureString("", 40); w != 0 || h != 0 {
+		t.Errorf("empty string measured %dx%d", w, h)
+	}
+	for _, size := range []int{7, 14, 40} {
+		_, h := f.MeasureString("0", size)
+		if h < size-1 || h > size+1 {
+			t.Errorf("size %d: digit height %d", size, h)
+		}
+	}
+	w1, _ := f.MeasureString("1", 40)
+	w12, _ := f.MeasureString("12", 40)
+	if w12 <= w1 {
+		t.Errorf("\"12\" (%d) should be wider than \"1\" (%d)", w12, w1)
+	}
+}
+
+func TestTTFFont_DrawString_Centered(t *testing.T) {
+	f, err := NewTTFFont(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := whiteCanvas(100, 100)
+	f.DrawString(img, "8", 50, 50, image.Black.C, 40)
+
+	var ink image.Rectangle
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 100; x++ {
+			if img.RGBAAt(x, y).R < 128 {
+				ink = ink.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	if ink.Empty() {
+		t.Fatal("DrawString did not write any pixels")
+	}
+	c := image.Pt((ink.Min.X+ink.Max.X)/2, (ink.Min.Y+ink.Max.Y)/2)
+	if c.X < 48 || c.X > 52 || c.Y < 48 || c.Y > 52 {
+		t.Errorf("ink centered at %v, want about (50, 50)", c)
+	}
+	if h := ink.Dy(); h < 38 || h > 42 {
+		t.Errorf("ink height %d, want about 40", h)
+	}
+}
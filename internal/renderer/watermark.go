@@ -0,0 +1,103 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Watermark positions.
+const (
+	PositionTopLeft     = "top-left"
+	PositionTopRight    = "top-right"
+	PositionBottomLeft  = "bottom-left"
+	PositionBottomRight = "bottom-right"
+	PositionCenter      = "center"
+)
+
+// Watermark describes a text or image stamp blended onto the output.
+// If Image is set it takes precedence over Text.
+type Watermark struct {
+	Text     string
+	Image    image.Image
+	Position string  // one of the Position* constants; default bottom-right
+	Opacity  float64 // 0–1; 0 means the default of 0.5
+	Size     int     // text height in pixels; 0 picks one from the image size
+	Margin   int     // distance from the edges in pixels; 0 means 8
+}
+
+// DrawWatermark blends the watermark onto dst at the configured position.
+func DrawWatermark(dst *image.RGBA, wm Watermark, font FontRenderer) {
+	layer := watermarkLayer(dst.Bounds(), wm, font)
+	if layer == nil {
+		return
+	}
+
+	opacity := wm.Opacity
+	if opacity <= 0 {
+		opacity = 0.5
+	}
+	if opacity > 1 {
+		opacity = 1
+	}
+	margin := wm.Margin
+	if margin <= 0 {
+		margin = 8
+	}
+
+	lb := layer.Bounds()
+	pos := watermarkOrigin(dst.Bounds(), lb.Size(), wm.Position, margin)
+	mask := image.NewUniform(color.Alpha{A: uint8(opacity*255 + 0.5)})
+	draw.DrawMask(dst, image.Rectangle{Min: pos, Max: pos.Add(lb.Size())}, layer, lb.Min, mask, image.Point{}, draw.Over)
+}
+
+// watermarkLayer returns the opaque artwork to stamp, rendering text onto a
+// transparent canvas when no image is given. Returns nil for an empty
+// watermark.
+func watermarkLayer(dstBounds image.Rectangle, wm Watermark, font FontRenderer) image.Image {
+	if wm.Image != nil {
+		return wm.Image
+	}
+	if wm.Text == "" {
+		return nil
+	}
+	size := wm.Size
+	if size <= 0 {
+		size = min(dstBounds.Dx(), dstBounds.Dy()) / 40
+		if size < glyphHeight {
+			size = glyphHeight
+		}
+	}
+	tw, th := font.MeasureString(wm.Text, size)
+	if tw <= 0 || th <= 0 {
+		return nil
+	}
+	layer := image.NewRGBA(image.Rect(0, 0, tw, th))
+	font.DrawString(layer, wm.Text, tw/2, th/2, color.RGBA{60, 60, 60, 255}, size)
+	return layer
+}
+
+// watermarkOrigin returns the top-left corner at which a stamp of the given
+// size is placed inside bounds.
+func watermarkOrigin(bounds image.Rectangle, size image.Point, position string, margin int) image.Point {
+	left := bounds.Min.X + margin
+	right := bounds.Max.X - margin - size.X
+	top := bounds.Min.Y + margin
+	bottom := bounds.Max.Y - margin - size.Y
+
+	switch position {
+	case PositionTopLeft:
+		return image.Pt(left, top)
+	case PositionTopRight:
+		return image.Pt(right, top)
+	case PositionBottomLeft:
+		return image.Pt(left, bottom)
+	case PositionCenter:
+		return image.Pt(
+			bounds.Min.X+(bounds.Dx()-size.X)/2,
+			bounds.Min.Y+(bounds.Dy()-size.Y)/2,
+		)
+	default:
+		return image.Pt(right, bottom)
+	}
+}
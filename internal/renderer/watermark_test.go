@@ -0,0 +1,99 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func whiteCanvas(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	return img
+}
+
+func TestWatermarkOrigin(t *testing.T) {
+	bounds := image.Rect(0, 0, 100, 50)
+	size := image.Pt(20, 10)
+	tests := []struct {
+		position string
+		want     image.Point
+	}{
+		{PositionTopLeft, image.Pt(5, 5)},
+		{PositionTopRight, image.Pt(75, 5)},
+		{PositionBottomLeft, image.Pt(5, 35)},
+		{PositionBottomRight, image.Pt(75, 35)},
+		{"", image.Pt(75, 35)},
+		{PositionCenter, image.Pt(40, 20)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.position, func(t *testing.T) {
+			if got := watermarkOrigin(bounds, size, tt.position, 5); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDrawWatermark_TextIsBlended(t *testing.T) {
+	img := whiteCanvas(200, 100)
+	DrawWatermark(img, Watermark{Text: "123", Position: PositionTopLeft, Opacity: 0.5, Size: 14}, NewBitmapFont())
+
+	darkened := 0
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 200; x++ {
+			c := img.RGBAAt(x, y)
+			if c.R < 255 {
+				darkened++
+				// Half-opacity dark gray over white never reaches black.
+				if c.R < 100 {
+					t.Fatalf("pixel (%d,%d) too dark for 50%% opacity: %v", x, y, c)
+				}
+				if x > 100 || y > 50 {
+					t.Fatalf("pixel (%d,%d) outside the top-left corner was stamped", x, y)
+				}
+			}
+		}
+	}
+	if darkened == 0 {
+		t.Error("watermark text was not drawn")
+	}
+}
+
+func TestDrawWatermark_Image(t *testing.T) {
+	img := whiteCanvas(50, 50)
+	stamp := image.NewUniform(color.RGBA{0, 0, 0, 255})
+	wm := Watermark{
+		Image:   &boundedImage{Image: stamp, r: image.Rect(0, 0, 4, 4)},
+		Opacity: 1,
+		Margin:  2,
+	}
+	DrawWatermark(img, wm, NewBitmapFont())
+
+	if c := img.RGBAAt(45, 45); c != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("expected stamped pixel at bottom-right, got %v", c)
+	}
+	if c := img.RGBAAt(0, 0); c != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("expected untouched pixel at top-left, got %v", c)
+	}
+}
+
+func TestDrawWatermark_EmptyIsNoop(t *testing.T) {
+	img := whiteCanvas(10, 10)
+	DrawWatermark(img, Watermark{}, NewBitmapFont())
+	for _, v := range img.Pix {
+		if v != 0xff {
+			t.Fatal("empty watermark modified the image")
+		}
+	}
+}
+
+// boundedImage gives an infinite image (like image.Uniform) finite bounds.
+type boundedImage struct {
+	image.Image
+	r image.Rectangle
+}
+
+func (b *boundedImage) Bounds() image.Rectangle { return b.r }
@@ -0,0 +1,96 @@
+package renderer
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/maax3v3/macoma/v2/internal/aggregation"
+	"github.com/maax3v3/macoma/v2/internal/detection"
+	"github.com/maax3v3/macoma/v2/internal/zone"
+)
+
+var (
+	// worksheetShade fills the zones a worksheet asks to find; gray prints
+	// the same on color and monochrome printers.
+	worksheetShade = color.RGBA{215, 215, 215, 255}
+	// worksheetFaint draws everything else: outlines and other numbers.
+	worksheetFaint = color.RGBA{200, 200, 200, 255}
+)
+
+// RenderWorksheets draws one "find all the 3s" page per legend entry: the
+// zones of that color shaded and numbered in black, the rest of the drawing
+// faint, and a legend holding only that color below. Pages are in legend
+// order.
+func RenderWorksheets(
+	srcImg image.Image,
+	dm *detection.Map,
+	zones []zone.Zone,
+	labels []int,
+	cm *aggregation.ColorMap,
+	font FontRenderer,
+	cfg Config,
+) []*image.RGBA {
+	bounds := srcImg.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	dm = borderMap(dm, labels, cfg)
+	// Worksheets are about finding zones, not painting them
+	cfg.PatternFill = false
+	cfg.LegendCoverage = false
+
+	// Label placement is shared by all pages
+	fontSize := max(LabelSize(srcW, srcH, len(zones)), cfg.MinLabelSize)
+	type label struct {
+		zone  int
+		text  string
+		pos   image.Point
+		angle float64
+	}
+	var placed []label
+	for i := range zones {
+		z := &zones[i]
+		text := cfg.EntryLabel(cm.Entries[cm.ZoneMap[i]].Number)
+		for _, pos := range labelPoints(z, cfg) {
+			l := label{zone: i, text: text, pos: pos}
+			if cfg.RotateLabels {
+				tw, th := font.MeasureString(l.text, fontSize)
+				l.angle = labelAngle(z, labels, srcW, srcH, l.pos, tw, th)
+			}
+			placed = append(placed, l)
+		}
+	}
+
+	pages := make([]*image.RGBA, len(cm.Entries))
+	for e := range cm.Entries {
+		single := &aggregation.ColorMap{Entries: cm.Entries[e : e+1]}
+		layout := newLegendLayout(single, font, cfg, srcW, nil)
+		out := image.NewRGBA(image.Rect(0, 0, srcW, srcH+layout.height(cfg)))
+		for i := range out.Pix {
+			out.Pix[i] = 0xff
+		}
+
+		for i := range zones {
+			if cm.ZoneMap[i] != e {
+				continue
+			}
+			zones[i].Each(func(x, y int) { out.SetRGBA(x, y, worksheetShade) })
+		}
+		for y := 0; y < srcH; y++ {
+			for x := 0; x < srcW; x++ {
+				if dm.At(x, y) {
+					out.SetRGBA(x, y, worksheetFaint)
+				}
+			}
+		}
+		for _, l := range placed {
+			col := color.Color(worksheetFaint)
+			if cm.ZoneMap[l.zone] == e {
+				col = color.Black
+			}
+			DrawStringRotated(font, out, l.text, l.pos.X, l.pos.Y, col, fontSize, l.angle)
+		}
+
+		drawLegend(out, single, font, cfg, layout, srcW, srcH, patternCell(srcW, srcH))
+		pages[e] = out
+	}
+	return pages
+}
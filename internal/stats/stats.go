@@ -0,0 +1,74 @@
+// Package stats summarizes a conversion in numbers: its zones, the share of
+// the drawing taken by delimiter lines, and how the palette is used.
+package stats
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/maax3v3/macoma/v2/internal/aggregation"
+	"github.com/maax3v3/macoma/v2/internal/detection"
+	"github.com/maax3v3/macoma/v2/internal/zone"
+)
+
+// Report is the summary of a conversion.
+type Report struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	Zones  int `json:"zones"`
+
+	DelimiterPixels   int     `json:"delimiter_pixels"`
+	DelimiterFraction float64 `json:"delimiter_fraction"` // 0–1 of the drawing
+
+	Colors []Color `json:"colors"` // one per palette entry, in palette order
+}
+
+// Color is the use of one palette entry.
+type Color struct {
+	Number   int     `json:"number"`
+	Color    string  `json:"color"`    // "#rrggbb"
+	Zones    int     `json:"zones"`    // zones painted with it
+	Area     int     `json:"area"`     // pixels of those zones, excluding delimiters
+	Coverage float64 `json:"coverage"` // 0–1 of the total zone area
+}
+
+// Compute builds the report of a conversion from its delimiter map, its
+// zones and the palette they were mapped onto.
+func Compute(dm *detection.Map, zones []zone.Zone, cm *aggregation.ColorMap) *Report {
+	r := &Report{
+		Width:           dm.Width,
+		Height:          dm.Height,
+		Zones:           len(zones),
+		DelimiterPixels: dm.Count(),
+		Colors:          make([]Color, len(cm.Entries)),
+	}
+	if n := dm.Width * dm.Height; n > 0 {
+		r.DelimiterFraction = float64(r.DelimiterPixels) / float64(n)
+	}
+
+	areas := make([]int, len(zones))
+	for i := range zones {
+		areas[i] = zones[i].Area()
+	}
+	coverage := cm.Coverage(areas)
+	for i, e := range cm.Entries {
+		r.Colors[i] = Color{Number: e.Number, Color: e.Color.Hex(), Coverage: coverage[i]}
+	}
+	for zID, entry := range cm.ZoneMap {
+		r.Colors[entry].Zones++
+		r.Colors[entry].Area += areas[zID]
+	}
+	return r
+}
+
+// Print writes the report as the console lines of a conversion: the
+// delimiter share, the zone count, then one line per palette entry.
+func (r *Report) Print(w io.Writer) {
+	fmt.Fprintf(w, "Delimiter pixels: %d / %d (%.1f%%)\n",
+		r.DelimiterPixels, r.Width*r.Height, r.DelimiterFraction*100)
+	fmt.Fprintf(w, "Zones found: %d\n", r.Zones)
+	fmt.Fprintf(w, "Distinct colors: %d\n", len(r.Colors))
+	for _, c := range r.Colors {
+		fmt.Fprintf(w, "  %3d %s  %d zones, %d px (%.1f%%)\n", c.Number, c.Color, c.Zones, c.Area, c.Coverage*100)
+	}
+}
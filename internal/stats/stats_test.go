@@ -0,0 +1,57 @@
+package stats
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/maax3v3/macoma/v2/internal/aggregation"
+	"github.com/maax3v3/macoma/v2/internal/color"
+	"github.com/maax3v3/macoma/v2/internal/detection"
+	"github.com/maax3v3/macoma/v2/internal/zone"
+)
+
+func TestCompute(t *testing.T) {
+	// A 7x1 strip cut into three zones of 2, 1 and 2 pixels; the outer two
+	// share a color.
+	dm := detection.NewMap(7, 1)
+	dm.IsDelimiter[2] = true
+	dm.IsDelimiter[4] = true
+	zones, _ := zone.FindZones(dm)
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	cm := aggregation.ReduceColors([]color.RGBA{red, blue, red}, 0)
+
+	r := Compute(dm, zones, cm)
+	if r.Width != 7 || r.Height != 1 || r.Zones != 3 || r.DelimiterPixels != 2 {
+		t.Errorf("got %+v", r)
+	}
+	if want := 2.0 / 7; r.DelimiterFraction != want {
+		t.Errorf("delimiter fraction: got %v, want %v", r.DelimiterFraction, want)
+	}
+	if len(r.Colors) != 2 {
+		t.Fatalf("got %d colors, want 2", len(r.Colors))
+	}
+	for _, c := range r.Colors {
+		switch c.Color {
+		case red.Hex():
+			if c.Zones != 2 || c.Area != 4 || c.Coverage != 0.8 {
+				t.Errorf("red: got %+v", c)
+			}
+		case blue.Hex():
+			if c.Zones != 1 || c.Area != 1 || c.Coverage != 0.2 {
+				t.Errorf("blue: got %+v", c)
+			}
+		default:
+			t.Errorf("unexpected color %+v", c)
+		}
+	}
+
+	var buf bytes.Buffer
+	r.Print(&buf)
+	for _, want := range []string{"Delimiter pixels: 2 / 7 (28.6%)", "Zones found: 3", "Distinct colors: 2", "2 zones, 4 px (80.0%)"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// The sliders re-render the coloring through /api/render. Each change
+// aborts the request still in flight, so the preview follows the last
+// position of the slider rather than every step on the way.
+const sliders = ["tolerance", "grid_cells", "max_colors", "min_zone_size"];
+const $ = (id) => document.getElementById(id);
+
+let imageName = "";
+let inFlight = null;
+let debounceTimer = null;
+let resultUrl = "";
+
+function settings() {
+  const s = { strategy: $("strategy").value };
+  for (const id of sliders) {
+    s[id] = $(id).value;
+  }
+  return s;
+}
+
+function showValues() {
+  const s = settings();
+  $("tolerance-value").textContent = `${s.tolerance}%`;
+  $("grid_cells-value").textContent = s.grid_cells;
+  $("max_colors-value").textContent = s.max_colors === "0" ? "unlimited" : s.max_colors;
+  $("min_zone_size-value").textContent = s.min_zone_size === "0" ? "off" : `${s.min_zone_size}%`;
+  $("tolerance-field").hidden = s.strategy === "grid";
+  $("grid-field").hidden = s.strategy !== "grid";
+  $("command").textContent = commandLine(s);
+}
+
+// commandLine spells the settings out as the equivalent "macoma convert"
+// invocation, to run on the full-size image once tuned.
+function commandLine(s) {
+  const args = ["macoma convert", `--in=${quote(imageName || "drawing.png")}`, "--out=coloring.png", `--delimiter-strategy=${s.strategy}`];
+  if (s.strategy === "color") {
+    args.push(`--color-delimiter-tolerance=${s.tolerance}`);
+  } else if (s.strategy === "border") {
+    args.push(`--border-delimiter-tolerance=${s.tolerance}`);
+  } else {
+    args.push(`--grid-cells=${s.grid_cells}`);
+  }
+  args.push(`--max-colors=${s.max_colors}`);
+  if (s.min_zone_size !== "0") {
+    args.push(`--min-zone-size=${s.min_zone_size}%`);
+  }
+  return args.join(" ");
+}
+
+function quote(s) {
+  return /^[\w./-]+$/.test(s) ? s : `'${s.replace(/'/g, "'\\''")}'`;
+}
+
+function onChange() {
+  showValues();
+  clearTimeout(debounceTimer);
+  debounceTimer = setTimeout(render, 120);
+}
+
+async function render() {
+  if (!imageName) {
+    return;
+  }
+  if (inFlight) {
+    inFlight.abort();
+  }
+  inFlight = new AbortController();
+  $("preview").classList.add("stale");
+  try {
+    const resp = await fetch(`/api/render?${new URLSearchParams(settings())}`, { signal: inFlight.signal });
+    if (!resp.ok) {
+      throw await toError(resp);
+    }
+    const blob = await resp.blob();
+    if (resultUrl) {
+      URL.revokeObjectURL(resultUrl);
+    }
+    resultUrl = URL.createObjectURL(blob);
+    $("preview").src = resultUrl;
+    $("preview").hidden = false;
+    $("placeholder").hidden = true;
+    $("download").href = resultUrl;
+    $("download").download = `${imageName.replace(/\.[^.]+$/, "")}-coloring.png`;
+    $("download").hidden = false;
+    const h = (name) => resp.headers.get(`X-Macoma-${name}`);
+    $("stats").textContent = `${h("Zones")} zones, ${h("Colors")} colors, confidence ${h("Confidence")}, ${h("Millis")} ms`;
+    $("error").textContent = "";
+  } catch (err) {
+    if (err.name !== "AbortError") {
+      $("error").textContent = err.message || "Rendering failed.";
+    }
+    return;
+  }
+  $("preview").classList.remove("stale");
+}
+
+async function toError(resp) {
+  try {
+    const data = await resp.json();
+    if (data && data.error) {
+      return new Error(data.error);
+    }
+  } catch (_) {
+  }
+  return new Error(`Request failed (${resp.status})`);
+}
+
+function applyState(state) {
+  imageName = state.name || "";
+  $("image-name").textContent = imageName
+    ? `${imageName} (${state.width}×${state.height}) — drop another to replace it`
+    : "Drop an image here or choose a file";
+  const strategy = $("strategy");
+  if (!strategy.options.length) {
+    for (const name of state.strategies) {
+      strategy.add(new Option(name, name));
+    }
+    strategy.value = state.settings.strategy;
+    for (const id of sliders) {
+      $(id).value = state.settings[id];
+    }
+  }
+  showValues();
+  render();
+}
+
+async function upload(file) {
+  if (!file) {
+    return;
+  }
+  $("error").textContent = "";
+  try {
+    const resp = await fetch(`/api/image?name=${encodeURIComponent(file.name)}`, { method: "POST", body: file });
+    if (!resp.ok) {
+      throw await toError(resp);
+    }
+    applyState(await resp.json());
+  } catch (err) {
+    $("error").textContent = err.message || "Upload failed.";
+  }
+}
+
+document.addEventListener("DOMContentLoaded", async () => {
+  $("strategy").addEventListener("change", onChange);
+  for (const id of sliders) {
+    $(id).addEventListener("input", onChange);
+  }
+  $("file").addEventListener("change", (e) => upload(e.target.files[0]));
+  const drop = $("dropzone");
+  drop.addEventListener("dragover", (e) => {
+    e.preventDefault();
+    drop.classList.add("dragging");
+  });
+  drop.addEventListener("dragleave", () => drop.classList.remove("dragging"));
+  drop.addEventListener("drop", (e) => {
+    e.preventDefault();
+    drop.classList.remove("dragging");
+    upload(e.dataTransfer.files[0]);
+  });
+
+  const resp = await fetch("/api/state");
+  applyState(await resp.json());
+});
@@ -0,0 +1,260 @@
+// Package ui serves the local tuning page of "macoma ui": sliders for the
+// strategy, tolerance, colors and zone size, and a preview that is
+// re-rendered as they move. Conversions run in a macoma.Session, so
+// moving a slider that only affects later stages skips detection.
+package ui
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"io/fs"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/maax3v3/macoma/v2"
+	"github.com/maax3v3/macoma/v2/internal/imaging"
+)
+
+//go:embed static/*
+var staticFS embed.FS
+
+// DefaultMaxDimension is the default size the page works at: larger
+// images are scaled down on load so the preview keeps up with the sliders.
+const DefaultMaxDimension = 1200
+
+// maxImageBytes bounds uploaded images.
+const maxImageBytes = 50 << 20
+
+// Config configures the page.
+type Config struct {
+	// Options are the starting settings; the sliders override the
+	// strategy, tolerances, grid cells, max colors and min zone size.
+	Options macoma.Options
+
+	// Image, if set, is opened on start, under Name. Otherwise the page
+	// asks for one.
+	Image image.Image
+	Name  string
+
+	// MaxDimension scales larger images down on load (0 =
+	// DefaultMaxDimension).
+	MaxDimension int
+}
+
+// Settings are the slider values, as the page sends them.
+type Settings struct {
+	Strategy  string  `json:"strategy"`
+	Tolerance float64 `json:"tolerance"` // of the border or color strategy
+	GridCells int     `json:"grid_cells"`
+	MaxColors int     `json:"max_colors"`
+	MinZone   float64 `json:"min_zone_size"` // percent of the image
+}
+
+// state is what GET /api/state reports.
+type state struct {
+	Name       string   `json:"name,omitempty"`
+	Width      int      `json:"width,omitempty"`
+	Height     int      `json:"height,omitempty"`
+	Strategies []string `json:"strategies"`
+	Settings   Settings `json:"settings"`
+}
+
+type server struct {
+	cfg Config
+
+	mu      sync.Mutex
+	name    string
+	img     image.Image
+	session *macoma.Session
+}
+
+// Handler returns the HTTP handler of the page and its API.
+func Handler(cfg Config) (http.Handler, error) {
+	if cfg.MaxDimension <= 0 {
+		cfg.MaxDimension = DefaultMaxDimension
+	}
+	static, err := fs.Sub(staticFS, "static")
+	if err != nil {
+		return nil, fmt.Errorf("loading static assets: %w", err)
+	}
+	s := &server{cfg: cfg}
+	if cfg.Image != nil {
+		s.open(cfg.Name, cfg.Image)
+	}
+
+	r := chi.NewRouter()
+	r.Get("/api/state", s.serveState)
+	r.Post("/api/image", s.serveUpload)
+	r.Get("/api/render", s.serveRender)
+	r.Handle("/*", http.FileServer(http.FS(static)))
+	return r, nil
+}
+
+// open makes img, scaled down to the working size, the image of the page.
+func (s *server) open(name string, img image.Image) {
+	img = imaging.ScaleDown(img, s.cfg.MaxDimension)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.name, s.img, s.session = name, img, macoma.NewSession(img)
+}
+
+func (s *server) current() (string, image.Image, *macoma.Session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.name, s.img, s.session
+}
+
+func (s *server) serveState(w http.ResponseWriter, _ *http.Request) {
+	name, img, _ := s.current()
+	st := state{Name: name, Strategies: []string{macoma.StrategyColor, macoma.StrategyBorder, macoma.StrategyGrid}}
+	area := 0
+	if img != nil {
+		st.Width, st.Height = img.Bounds().Dx(), img.Bounds().Dy()
+		area = st.Width * st.Height
+	}
+	st.Settings = initialSettings(s.cfg.Options, area)
+	writeJSON(w, http.StatusOK, st)
+}
+
+// initialSettings returns the slider values of opts for an image of area
+// pixels, 0 if none is open yet.
+func initialSettings(opts macoma.Options, area int) Settings {
+	st := Settings{
+		Strategy:  opts.DelimiterStrategy,
+		Tolerance: opts.ColorDelimiterTolerance,
+		GridCells: opts.GridCells,
+		MaxColors: opts.MaxColors,
+		MinZone:   opts.MinZoneSize.Percent,
+	}
+	switch st.Strategy {
+	case "":
+		st.Strategy = macoma.StrategyColor
+	case macoma.StrategyBorder:
+		st.Tolerance = opts.BorderDelimiterTolerance
+	}
+	if st.GridCells <= 0 {
+		st.GridCells = macoma.DefaultGridCells
+	}
+	if px := opts.MinZoneSize.Pixels; px > 0 && area > 0 {
+		st.MinZone = 100 * float64(px) / float64(area)
+	}
+	return st
+}
+
+func (s *server) serveUpload(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxImageBytes))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, "image too large")
+		return
+	}
+	img, err := imaging.Decode(bytes.NewReader(data))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid image: %v", err))
+		return
+	}
+	s.open(r.URL.Query().Get("name"), img)
+	s.serveState(w, r)
+}
+
+// serveRender converts the open image with the slider values of the
+// query and answers the PNG, with the zone and color counts, the
+// detection confidence and the conversion time in X-Macoma-* headers.
+func (s *server) serveRender(w http.ResponseWriter, r *http.Request) {
+	_, _, session := s.current()
+	if session == nil {
+		writeError(w, http.StatusConflict, "no image open")
+		return
+	}
+	st, err := parseSettings(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	start := time.Now()
+	res, err := session.ConvertDetailedContext(r.Context(), st.apply(s.cfg.Options))
+	if err != nil {
+		if errors.Is(err, r.Context().Err()) {
+			return // superseded by a newer request
+		}
+		writeError(w, http.StatusUnprocessableEntity, fmt.Sprintf("converting image: %v", err))
+		return
+	}
+	elapsed := time.Since(start)
+
+	var buf bytes.Buffer
+	if err := imaging.EncodePNG(&buf, res.Image, imaging.PNGOptions{Compression: png.BestSpeed}); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("encoding png: %v", err))
+		return
+	}
+	h := w.Header()
+	h.Set("Content-Type", "image/png")
+	h.Set("Cache-Control", "no-store")
+	h.Set("X-Macoma-Zones", strconv.Itoa(len(res.Zones())))
+	h.Set("X-Macoma-Colors", strconv.Itoa(len(res.Legend())))
+	h.Set("X-Macoma-Confidence", strconv.FormatFloat(res.Confidence.Score, 'f', 2, 64))
+	h.Set("X-Macoma-Millis", strconv.FormatInt(elapsed.Milliseconds(), 10))
+	_, _ = w.Write(buf.Bytes())
+}
+
+// parseSettings reads and checks the slider values of a render request.
+func parseSettings(r *http.Request) (Settings, error) {
+	q := r.URL.Query()
+	var st Settings
+	st.Strategy = q.Get("strategy")
+	switch st.Strategy {
+	case macoma.StrategyColor, macoma.StrategyBorder, macoma.StrategyGrid:
+	default:
+		return st, fmt.Errorf("strategy must be color, border or grid, got %q", st.Strategy)
+	}
+	var err error
+	if st.Tolerance, err = strconv.ParseFloat(q.Get("tolerance"), 64); err != nil || st.Tolerance < 0 || st.Tolerance > 100 {
+		return st, fmt.Errorf("tolerance must be a number from 0 to 100")
+	}
+	if st.GridCells, err = strconv.Atoi(q.Get("grid_cells")); err != nil || st.GridCells < 1 || st.GridCells > macoma.MaxGridCells {
+		return st, fmt.Errorf("grid_cells must be an integer from 1 to %d", macoma.MaxGridCells)
+	}
+	if st.MaxColors, err = strconv.Atoi(q.Get("max_colors")); err != nil || st.MaxColors < 0 {
+		return st, fmt.Errorf("max_colors must be a non-negative integer")
+	}
+	if st.MinZone, err = strconv.ParseFloat(q.Get("min_zone_size"), 64); err != nil || st.MinZone < 0 || st.MinZone > 100 {
+		return st, fmt.Errorf("min_zone_size must be a percentage from 0 to 100")
+	}
+	return st, nil
+}
+
+// apply returns opts with the slider values. The tolerance goes to the
+// chosen strategy only.
+func (st Settings) apply(opts macoma.Options) macoma.Options {
+	opts.DelimiterStrategy = st.Strategy
+	opts.Delimiters = nil
+	switch st.Strategy {
+	case macoma.StrategyColor:
+		opts.ColorDelimiterTolerance = st.Tolerance
+	case macoma.StrategyBorder:
+		opts.BorderDelimiterTolerance = st.Tolerance
+	}
+	opts.GridCells = st.GridCells
+	opts.MaxColors = st.MaxColors
+	opts.MinZoneSize = macoma.ZoneSize{Percent: st.MinZone}
+	return opts
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+func writeJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(data)
+}
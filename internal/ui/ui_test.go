@@ -0,0 +1,156 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/maax3v3/macoma/v2"
+)
+
+const renderQuery = "/api/render?strategy=border&tolerance=10&grid_cells=20&max_colors=8&min_zone_size=0"
+
+func TestHandler_UploadAndRender(t *testing.T) {
+	h, err := Handler(Config{Options: macoma.DefaultOptions(), MaxDimension: 100})
+	if err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+
+	rec := serve(h, httptest.NewRequest(http.MethodGet, renderQuery, nil))
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("render without image: got %d, want %d", rec.Code, http.StatusConflict)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, quadrants(200, 160)); err != nil {
+		t.Fatal(err)
+	}
+	rec = serve(h, httptest.NewRequest(http.MethodPost, "/api/image?name=quads.png", &buf))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("upload: got %d body=%s", rec.Code, rec.Body.String())
+	}
+	var st state
+	if err := json.Unmarshal(rec.Body.Bytes(), &st); err != nil {
+		t.Fatalf("decoding state: %v", err)
+	}
+	if st.Name != "quads.png" || st.Width != 100 || st.Height != 80 {
+		t.Fatalf("state: got %q %dx%d, want quads.png scaled to 100x80", st.Name, st.Width, st.Height)
+	}
+
+	first := serve(h, httptest.NewRequest(http.MethodGet, renderQuery, nil))
+	if first.Code != http.StatusOK {
+		t.Fatalf("render: got %d body=%s", first.Code, first.Body.String())
+	}
+	if ct := first.Header().Get("Content-Type"); ct != "image/png" {
+		t.Fatalf("content-type: %q", ct)
+	}
+	if got := first.Header().Get("X-Macoma-Zones"); got != "4" {
+		t.Fatalf("X-Macoma-Zones: got %q, want 4", got)
+	}
+	if got := first.Header().Get("X-Macoma-Colors"); got != "4" {
+		t.Fatalf("X-Macoma-Colors: got %q, want 4", got)
+	}
+
+	// The second render reuses the cached delimiters and must not differ.
+	second := serve(h, httptest.NewRequest(http.MethodGet, renderQuery, nil))
+	if !bytes.Equal(first.Body.Bytes(), second.Body.Bytes()) {
+		t.Fatal("cached render differs from the first one")
+	}
+}
+
+func TestHandler_State(t *testing.T) {
+	opts := macoma.DefaultOptions()
+	opts.DelimiterStrategy = macoma.StrategyBorder
+	opts.BorderDelimiterTolerance = 25
+	opts.MaxColors = 6
+	opts.MinZoneSize = macoma.ZoneSize{Pixels: 80}
+	h, err := Handler(Config{Options: opts, Image: quadrants(40, 20), Name: "in.png"})
+	if err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+
+	rec := serve(h, httptest.NewRequest(http.MethodGet, "/api/state", nil))
+	var st state
+	if err := json.Unmarshal(rec.Body.Bytes(), &st); err != nil {
+		t.Fatalf("decoding state: %v", err)
+	}
+	want := Settings{Strategy: macoma.StrategyBorder, Tolerance: 25, GridCells: macoma.DefaultGridCells, MaxColors: 6, MinZone: 10}
+	if st.Settings != want {
+		t.Fatalf("settings: got %+v, want %+v", st.Settings, want)
+	}
+	if st.Name != "in.png" || st.Width != 40 || st.Height != 20 {
+		t.Fatalf("state: got %q %dx%d", st.Name, st.Width, st.Height)
+	}
+}
+
+func TestHandler_RenderValidation(t *testing.T) {
+	h, err := Handler(Config{Options: macoma.DefaultOptions(), Image: quadrants(40, 40)})
+	if err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+	for _, query := range []string{
+		"strategy=sobel&tolerance=10&grid_cells=20&max_colors=8&min_zone_size=0",
+		"strategy=color&tolerance=150&grid_cells=20&max_colors=8&min_zone_size=0",
+		"strategy=grid&tolerance=10&grid_cells=0&max_colors=8&min_zone_size=0",
+		"strategy=color&tolerance=10&grid_cells=20&max_colors=-1&min_zone_size=0",
+		"strategy=color&tolerance=10&grid_cells=20&max_colors=8",
+	} {
+		rec := serve(h, httptest.NewRequest(http.MethodGet, "/api/render?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want %d", query, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestHandler_InvalidUpload(t *testing.T) {
+	h, err := Handler(Config{})
+	if err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+	rec := serve(h, httptest.NewRequest(http.MethodPost, "/api/image", bytes.NewReader([]byte("not an image"))))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("got %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func serve(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return rec
+}
+
+// quadrants returns four colored quadrants split by black lines, thick
+// enough to survive scaling down by half.
+func quadrants(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	fills := []color.RGBA{{255, 0, 0, 255}, {0, 200, 0, 255}, {0, 0, 255, 255}, {255, 255, 0, 255}}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			q := 0
+			if x >= w/2 {
+				q++
+			}
+			if y >= h/2 {
+				q += 2
+			}
+			c := fills[q]
+			if abs(x-w/2) < 2 || abs(y-h/2) < 2 {
+				c = color.RGBA{A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
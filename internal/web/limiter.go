@@ -0,0 +1,77 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// errQueueFull is returned by limiter.acquire when no worker is free and the
+// wait queue is at capacity.
+var errQueueFull = errors.New("server busy: conversion queue is full")
+
+// limiter bounds concurrent conversions to a fixed number of workers, with a
+// bounded number of requests allowed to wait for one.
+type limiter struct {
+	slots    chan struct{}
+	maxQueue int64
+	queued   atomic.Int64
+}
+
+func newLimiter(workers, maxQueue int) *limiter {
+	return &limiter{
+		slots:    make(chan struct{}, workers),
+		maxQueue: int64(maxQueue),
+	}
+}
+
+// acquire takes a worker slot, waiting in the queue if all are busy. It
+// fails immediately with errQueueFull when the queue is full, or with the
+// context error if ctx ends while waiting.
+func (l *limiter) acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if l.queued.Add(1) > l.maxQueue {
+		l.queued.Add(-1)
+		return errQueueFull
+	}
+	defer l.queued.Add(-1)
+
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a slot taken by acquire.
+func (l *limiter) release() {
+	<-l.slots
+}
+
+// limiterStatus is the /readyz payload.
+type limiterStatus struct {
+	Ready         bool  `json:"ready"`
+	Workers       int   `json:"workers"`
+	Busy          int   `json:"busy"`
+	Queued        int64 `json:"queued"`
+	QueueCapacity int64 `json:"queue_capacity"`
+}
+
+// status reports worker and queue usage. The server is ready while new
+// requests can still be accepted, i.e. while the queue is not full.
+func (l *limiter) status() limiterStatus {
+	queued := l.queued.Load()
+	return limiterStatus{
+		Ready:         queued < l.maxQueue || len(l.slots) < cap(l.slots),
+		Workers:       cap(l.slots),
+		Busy:          len(l.slots),
+		Queued:        queued,
+		QueueCapacity: l.maxQueue,
+	}
+}
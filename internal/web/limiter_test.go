@@ -0,0 +1,80 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLimiter_QueueFull(t *testing.T) {
+	l := newLimiter(1, 1)
+	if err := l.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// One request may wait...
+	waited := make(chan error, 1)
+	go func() { waited <- l.acquire(context.Background()) }()
+	for l.status().Queued != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	if st := l.status(); st.Ready || st.Busy != 1 {
+		t.Errorf("saturated limiter status: %+v", st)
+	}
+
+	// ...but the next is turned away.
+	if err := l.acquire(context.Background()); !errors.Is(err, errQueueFull) {
+		t.Errorf("got %v, want errQueueFull", err)
+	}
+
+	l.release()
+	if err := <-waited; err != nil {
+		t.Errorf("queued request: %v", err)
+	}
+	l.release()
+	if st := l.status(); !st.Ready || st.Busy != 0 || st.Queued != 0 {
+		t.Errorf("idle limiter status: %+v", st)
+	}
+}
+
+func TestLimiter_ContextCancelled(t *testing.T) {
+	l := newLimiter(1, 1)
+	if err := l.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.acquire(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+	if q := l.status().Queued; q != 0 {
+		t.Errorf("cancelled request left in queue: %d", q)
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Workers = 2
+	cfg.MaxQueue = 3
+	h, err := Handler(cfg)
+	if err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("readyz status: %d", rec.Code)
+	}
+	var st limiterStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &st); err != nil {
+		t.Fatal(err)
+	}
+	if !st.Ready || st.Workers != 2 || st.QueueCapacity != 3 {
+		t.Errorf("got %+v", st)
+	}
+}
@@ -0,0 +1,68 @@
+package web
+
+import (
+	"expvar"
+	"io"
+	"sync"
+	"time"
+)
+
+// expvarMetrics is the macoma.Metrics of the server. It publishes the
+// expvar map "macoma", served as JSON at /debug/vars when Config.Metrics is
+// set:
+//
+//	conversions, failures    finished and failed conversions
+//	zones, colors            totals over the finished conversions
+//	stage_seconds.<stage>    total time spent in each stage
+//	output_bytes.<format>    total size of the encoded responses
+type expvarMetrics struct {
+	conversions, failures, zones, colors expvar.Int
+	stageSeconds, outputBytes            expvar.Map
+}
+
+var (
+	metricsOnce sync.Once
+	metrics     *expvarMetrics
+)
+
+// publishedMetrics returns the process-wide metrics, publishing them on
+// first use: expvar names cannot be published twice.
+func publishedMetrics() *expvarMetrics {
+	metricsOnce.Do(func() {
+		metrics = &expvarMetrics{}
+		m := expvar.NewMap("macoma")
+		m.Set("conversions", &metrics.conversions)
+		m.Set("failures", &metrics.failures)
+		m.Set("zones", &metrics.zones)
+		m.Set("colors", &metrics.colors)
+		m.Set("stage_seconds", &metrics.stageSeconds)
+		m.Set("output_bytes", &metrics.outputBytes)
+	})
+	return metrics
+}
+
+func (m *expvarMetrics) StageDuration(stage string, d time.Duration) {
+	m.stageSeconds.AddFloat(stage, d.Seconds())
+}
+
+func (m *expvarMetrics) Converted(zones, colors int) {
+	m.conversions.Add(1)
+	m.zones.Add(int64(zones))
+	m.colors.Add(int64(colors))
+}
+
+func (m *expvarMetrics) OutputBytes(format string, n int) {
+	m.outputBytes.Add(format, int64(n))
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += n
+	return n, err
+}
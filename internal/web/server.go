@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"expvar"
 	"fmt"
 	"image"
 	"image/png"
 	"io"
 	"io/fs"
 	"net/http"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
@@ -17,6 +19,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/maax3v3/macoma/v2"
+	"github.com/maax3v3/macoma/v2/internal/imaging"
 )
 
 const (
@@ -27,17 +30,29 @@ const (
 
 // Config configures the web server behavior.
 type Config struct {
-	MaxBodyBytes       int64
-	RequestTimeout     time.Duration
+	MaxBodyBytes        int64
+	RequestTimeout      time.Duration
 	PreviewMaxDimension int
+
+	// Workers is the number of conversions run concurrently and MaxQueue
+	// the number of requests allowed to wait for a worker; further requests
+	// get 503 and /readyz reports not ready.
+	Workers  int
+	MaxQueue int
+
+	// Metrics records stage durations, zone and palette counts and response
+	// sizes, served as expvar JSON at /debug/vars.
+	Metrics bool
 }
 
 // DefaultConfig returns sensible defaults for web operation.
 func DefaultConfig() Config {
 	return Config{
-		MaxBodyBytes:       defaultMaxBodyBytes,
-		RequestTimeout:     30 * time.Second,
+		MaxBodyBytes:        defaultMaxBodyBytes,
+		RequestTimeout:      30 * time.Second,
 		PreviewMaxDimension: PreviewMaxDimension,
+		Workers:             runtime.NumCPU(),
+		MaxQueue:            4 * runtime.NumCPU(),
 	}
 }
 
@@ -52,6 +67,15 @@ func Handler(cfg Config) (http.Handler, error) {
 	if cfg.PreviewMaxDimension <= 0 {
 		cfg.PreviewMaxDimension = PreviewMaxDimension
 	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = runtime.NumCPU()
+	}
+	if cfg.MaxQueue < 0 {
+		cfg.MaxQueue = 0
+	}
+	lim := newLimiter(cfg.Workers, cfg.MaxQueue)
+	// Requests share one Converter so their buffers are reused.
+	conv := macoma.NewConverter()
 
 	staticSub, err := fs.Sub(staticFS, "static")
 	if err != nil {
@@ -69,15 +93,27 @@ func Handler(cfg Config) (http.Handler, error) {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("ok"))
 	})
+	r.Get("/readyz", func(w http.ResponseWriter, _ *http.Request) {
+		st := lim.status()
+		status := http.StatusOK
+		if !st.Ready {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, st)
+	})
+	if cfg.Metrics {
+		publishedMetrics()
+		r.Handle("/debug/vars", expvar.Handler())
+	}
 	r.Get("/favicon.ico", func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusNoContent)
 	})
 
 	r.Post("/api/preview", func(w http.ResponseWriter, r *http.Request) {
-		serveConvert(w, r, cfg, true)
+		serveConvert(w, r, cfg, lim, conv, true)
 	})
 	r.Post("/api/render", func(w http.ResponseWriter, r *http.Request) {
-		serveConvert(w, r, cfg, false)
+		serveConvert(w, r, cfg, lim, conv, false)
 	})
 
 	r.Handle("/*", http.FileServer(http.FS(staticSub)))
@@ -85,33 +121,85 @@ func Handler(cfg Config) (http.Handler, error) {
 	return r, nil
 }
 
-func serveConvert(w http.ResponseWriter, r *http.Request, cfg Config, preview bool) {
+func serveConvert(w http.ResponseWriter, r *http.Request, cfg Config, lim *limiter, conv *macoma.Converter, preview bool) {
 	input, opts, err := parseRequest(w, r, cfg.MaxBodyBytes)
 	if err != nil {
 		writeError(w, err)
 		return
 	}
+	format := r.FormValue("format")
+	switch format {
+	case "", "png", "svg", "svgz":
+	default:
+		writeError(w, badRequest(fmt.Sprintf("format must be png, svg or svgz, got %q", format)))
+		return
+	}
+
+	if err := lim.acquire(r.Context()); err != nil {
+		w.Header().Set("Retry-After", "1")
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+		return
+	}
+	defer lim.release()
 
 	if preview {
-		input = scaleDown(input, cfg.PreviewMaxDimension)
+		input = imaging.ScaleDown(input, cfg.PreviewMaxDimension)
 	}
 
-	out, err := macoma.Convert(input, opts)
+	var m *expvarMetrics
+	if cfg.Metrics {
+		m = publishedMetrics()
+		opts.Metrics = m
+	}
+	res, err := conv.ConvertDetailedContext(r.Context(), input, opts)
 	if err != nil {
+		if m != nil {
+			m.failures.Add(1)
+		}
 		writeJSON(w, http.StatusInternalServerError, map[string]string{
 			"error": fmt.Sprintf("converting image: %v", err),
 		})
 		return
 	}
+	defer res.Release()
+
+	// Vector output is streamed: it can run to megabytes, and once the
+	// conversion has succeeded writing it cannot fail in a way worth
+	// reporting as JSON.
+	if format == "svg" || format == "svgz" {
+		write := res.WriteSVG
+		w.Header().Set("Content-Type", "image/svg+xml")
+		if format == "svgz" {
+			write = res.WriteSVGZ
+			w.Header().Set("Content-Encoding", "gzip")
+		}
+		w.WriteHeader(http.StatusOK)
+		cw := &countingWriter{w: w}
+		_ = write(cw)
+		if m != nil {
+			m.OutputBytes(format, cw.n)
+		}
+		return
+	}
+	out := res.Image
 
 	var buf bytes.Buffer
-	if err := png.Encode(&buf, out); err != nil {
+	// Previews are interlaced so the browser can show them progressively,
+	// and favor encoding speed since they are replaced on every change.
+	pngOpts := imaging.PNGOptions{}
+	if preview {
+		pngOpts = imaging.PNGOptions{Compression: png.BestSpeed, Interlaced: true}
+	}
+	if err := imaging.EncodePNG(&buf, out, pngOpts); err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{
 			"error": fmt.Sprintf("encoding png: %v", err),
 		})
 		return
 	}
 
+	if m != nil {
+		m.OutputBytes("png", buf.Len())
+	}
 	w.Header().Set("Content-Type", "image/png")
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write(buf.Bytes())
@@ -141,7 +229,7 @@ func parseRequest(w http.ResponseWriter, r *http.Request, maxBodyBytes int64) (i
 	if err != nil {
 		return nil, macoma.Options{}, badRequest("unable to read image")
 	}
-	img, err := decodeImage(bytes.NewReader(data))
+	img, err := imaging.Decode(bytes.NewReader(data))
 	if err != nil {
 		return nil, macoma.Options{}, badRequest(fmt.Sprintf("invalid image: %v", err))
 	}
@@ -166,8 +254,8 @@ func optionsFromForm(values map[string][]string) (macoma.Options, error) {
 	}
 
 	if strategy := get("delimiter_strategy"); strategy != "" {
-		if strategy != macoma.StrategyColor && strategy != macoma.StrategyBorder {
-			return opts, fmt.Errorf("delimiter_strategy must be %q or %q", macoma.StrategyColor, macoma.StrategyBorder)
+		if err := macoma.CheckStrategy(strategy); err != nil {
+			return opts, fmt.Errorf("delimiter_strategy: %v", err)
 		}
 		opts.DelimiterStrategy = strategy
 	}
@@ -202,6 +290,17 @@ func optionsFromForm(values map[string][]string) (macoma.Options, error) {
 		opts.ColorDelimiterTolerance = v
 	}
 
+	if raw := get("grid_cells"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return opts, fmt.Errorf("grid_cells must be an integer")
+		}
+		if v < 1 || v > macoma.MaxGridCells {
+			return opts, fmt.Errorf("grid_cells must be between 1 and %d", macoma.MaxGridCells)
+		}
+		opts.GridCells = v
+	}
+
 	if raw := get("max_colors"); raw != "" {
 		v, err := strconv.Atoi(raw)
 		if err != nil {
@@ -213,6 +312,34 @@ func optionsFromForm(values map[string][]string) (macoma.Options, error) {
 		opts.MaxColors = v
 	}
 
+	if raw := get("max_zone_area"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return opts, fmt.Errorf("max_zone_area must be an integer")
+		}
+		if v < 0 {
+			return opts, fmt.Errorf("max_zone_area must be >= 0")
+		}
+		opts.MaxZoneArea = v
+	}
+
+	for key, dst := range map[string]*bool{
+		"legend_coverage":    &opts.LegendCoverage,
+		"legend_hex":         &opts.LegendHex,
+		"legend_zone_counts": &opts.LegendZoneCounts,
+		"pattern_fill":       &opts.PatternFill,
+		"large_print":        &opts.LargePrint,
+		"skip_background":    &opts.SkipBackground,
+	} {
+		if raw := get(key); raw != "" {
+			v, err := strconv.ParseBool(raw)
+			if err != nil {
+				return opts, fmt.Errorf("%s must be true or false", key)
+			}
+			*dst = v
+		}
+	}
+
 	return opts, nil
 }
 
@@ -2,10 +2,12 @@ package web
 
 import (
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"image"
 	"image/color"
 	"image/png"
+	"io"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
@@ -24,10 +26,10 @@ func TestPreviewAndRenderSuccess(t *testing.T) {
 	src := createSamplePNG(t, 300, 200)
 
 	previewReq := multipartRequest(t, "/api/preview", src, map[string]string{
-		"delimiter_strategy": "border",
-		"border_delimiter_color": "#000",
+		"delimiter_strategy":         "border",
+		"border_delimiter_color":     "#000",
 		"border_delimiter_tolerance": "10",
-		"max_colors": "8",
+		"max_colors":                 "8",
 	})
 	previewRec := httptest.NewRecorder()
 	h.ServeHTTP(previewRec, previewReq)
@@ -43,10 +45,10 @@ func TestPreviewAndRenderSuccess(t *testing.T) {
 	}
 
 	renderReq := multipartRequest(t, "/api/render", src, map[string]string{
-		"delimiter_strategy": "border",
-		"border_delimiter_color": "#000",
+		"delimiter_strategy":         "border",
+		"border_delimiter_color":     "#000",
 		"border_delimiter_tolerance": "10",
-		"max_colors": "8",
+		"max_colors":                 "8",
 	})
 	renderRec := httptest.NewRecorder()
 	h.ServeHTTP(renderRec, renderReq)
@@ -59,6 +61,39 @@ func TestPreviewAndRenderSuccess(t *testing.T) {
 	}
 }
 
+func TestRenderSVGZ(t *testing.T) {
+	h, err := Handler(DefaultConfig())
+	if err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+
+	req := multipartRequest(t, "/api/render", createSamplePNG(t, 120, 80), map[string]string{
+		"format": "svgz",
+	})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: got %d body=%s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "image/svg+xml" {
+		t.Errorf("content-type: %q", ct)
+	}
+	if ce := rec.Header().Get("Content-Encoding"); ce != "gzip" {
+		t.Errorf("content-encoding: %q", ce)
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	if !bytes.HasPrefix(body, []byte("<svg")) {
+		t.Errorf("body does not start with <svg: %.40q", body)
+	}
+}
+
 func TestValidationErrors(t *testing.T) {
 	cfg := DefaultConfig()
 	h, err := Handler(cfg)
@@ -92,6 +127,22 @@ func TestValidationErrors(t *testing.T) {
 			}),
 			wantStatus: http.StatusBadRequest,
 		},
+		{
+			name: "zero grid cells",
+			req: multipartRequest(t, "/api/preview", createSamplePNG(t, 64, 64), map[string]string{
+				"delimiter_strategy": "grid",
+				"grid_cells":         "0",
+			}),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "too many grid cells",
+			req: multipartRequest(t, "/api/preview", createSamplePNG(t, 64, 64), map[string]string{
+				"delimiter_strategy": "grid",
+				"grid_cells":         "1000",
+			}),
+			wantStatus: http.StatusBadRequest,
+		},
 		{
 			name: "negative max colors",
 			req: multipartRequest(t, "/api/preview", createSamplePNG(t, 64, 64), map[string]string{
@@ -100,8 +151,29 @@ func TestValidationErrors(t *testing.T) {
 			wantStatus: http.StatusBadRequest,
 		},
 		{
-			name: "unsupported image",
-			req: multipartRequestWithContent(t, "/api/preview", "image", "bad.txt", []byte("not an image"), map[string]string{}),
+			name: "unsupported format",
+			req: multipartRequest(t, "/api/render", createSamplePNG(t, 64, 64), map[string]string{
+				"format": "gif",
+			}),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "negative max zone area",
+			req: multipartRequest(t, "/api/preview", createSamplePNG(t, 64, 64), map[string]string{
+				"max_zone_area": "-1",
+			}),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid boolean",
+			req: multipartRequest(t, "/api/preview", createSamplePNG(t, 64, 64), map[string]string{
+				"pattern_fill": "maybe",
+			}),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unsupported image",
+			req:        multipartRequestWithContent(t, "/api/preview", "image", "bad.txt", []byte("not an image"), map[string]string{}),
 			wantStatus: http.StatusBadRequest,
 		},
 	}
@@ -124,6 +196,25 @@ func TestValidationErrors(t *testing.T) {
 	}
 }
 
+func TestOptionsFromForm(t *testing.T) {
+	opts, err := optionsFromForm(map[string][]string{
+		"max_colors":         {"4"},
+		"pattern_fill":       {"true"},
+		"legend_coverage":    {"true"},
+		"legend_hex":         {"true"},
+		"legend_zone_counts": {"true"},
+		"max_zone_area":      {"5000"},
+		"skip_background":    {"true"},
+		"grid_cells":         {"30"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.MaxColors != 4 || opts.GridCells != 30 || !opts.PatternFill || !opts.LegendCoverage || !opts.LegendHex || !opts.LegendZoneCounts || opts.MaxZoneArea != 5000 || !opts.SkipBackground || opts.LargePrint {
+		t.Errorf("fields not applied: %+v", opts)
+	}
+}
+
 func TestBodyTooLarge(t *testing.T) {
 	cfg := DefaultConfig()
 	cfg.MaxBodyBytes = 256
@@ -169,6 +260,9 @@ func TestStaticAndHealth(t *testing.T) {
 	if !strings.Contains(rootRec.Body.String(), "crossorigin=\"anonymous\"") {
 		t.Fatalf("root html missing crossorigin attribute")
 	}
+	if !strings.Contains(rootRec.Body.String(), "dropzone") {
+		t.Fatalf("root html missing drop zone")
+	}
 }
 
 func multipartRequest(t *testing.T, target string, imageContent []byte, fields map[string]string) *http.Request {
@@ -261,3 +355,47 @@ func decodePNG(t *testing.T, data []byte) image.Image {
 	}
 	return img
 }
+
+func TestMetrics(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Metrics = true
+	h, err := Handler(cfg)
+	if err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, multipartRequest(t, "/api/render", createSamplePNG(t, 60, 40), map[string]string{"max_colors": "4"}))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("render status: got %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/vars", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("vars status: got %d", rec.Code)
+	}
+	var vars struct {
+		Macoma struct {
+			Conversions  int                `json:"conversions"`
+			Zones        int                `json:"zones"`
+			StageSeconds map[string]float64 `json:"stage_seconds"`
+			OutputBytes  map[string]int     `json:"output_bytes"`
+		} `json:"macoma"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &vars); err != nil {
+		t.Fatalf("decoding vars: %v", err)
+	}
+	m := vars.Macoma
+	if m.Conversions < 1 || m.Zones < 1 {
+		t.Errorf("got %d conversions and %d zones, want at least 1", m.Conversions, m.Zones)
+	}
+	for _, stage := range []string{"detection", "zones", "colors", "reduction", "render"} {
+		if _, ok := m.StageSeconds[stage]; !ok {
+			t.Errorf("stage_seconds has no %q", stage)
+		}
+	}
+	if m.OutputBytes["png"] <= 0 {
+		t.Errorf("output_bytes.png = %d, want > 0", m.OutputBytes["png"])
+	}
+}
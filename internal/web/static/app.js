@@ -1,7 +1,9 @@
 function createMacomaApp() {
   return {
     file: null,
+    dragging: false,
     previewUrl: "",
+    resultUrl: "",
     livePreview: false,
     busy: false,
     error: "",
@@ -13,12 +15,37 @@ function createMacomaApp() {
       border_delimiter_color: "#000000",
       border_delimiter_tolerance: "10",
       color_delimiter_tolerance: "10",
-      max_colors: "10"
+      grid_cells: "20",
+      max_colors: "10",
+      max_zone_area: "0",
+      legend_coverage: false,
+      legend_hex: false,
+      legend_zone_counts: false,
+      pattern_fill: false,
+      large_print: false
     },
 
     onFileChange(event) {
-      this.file = event.target.files && event.target.files[0] ? event.target.files[0] : null;
+      this.setFile(event.target.files && event.target.files[0] ? event.target.files[0] : null);
+    },
+
+    onDrop(event) {
+      this.dragging = false;
+      const file = event.dataTransfer.files && event.dataTransfer.files[0];
+      if (!file) {
+        return;
+      }
+      if (!file.type.startsWith("image/")) {
+        this.error = "Please drop an image file.";
+        return;
+      }
+      this.setFile(file);
+    },
+
+    setFile(file) {
+      this.file = file;
       this.error = "";
+      this.clearResult();
       if (!this.file) {
         this.previewUrl = "";
         this.status = "No image selected.";
@@ -29,6 +56,7 @@ function createMacomaApp() {
     },
 
     onSettingsChange() {
+      this.clearResult();
       if (!this.livePreview || !this.file) {
         return;
       }
@@ -43,10 +71,29 @@ function createMacomaApp() {
       fd.append("border_delimiter_color", this.form.border_delimiter_color);
       fd.append("border_delimiter_tolerance", String(this.form.border_delimiter_tolerance));
       fd.append("color_delimiter_tolerance", String(this.form.color_delimiter_tolerance));
+      fd.append("grid_cells", String(this.form.grid_cells));
       fd.append("max_colors", String(this.form.max_colors));
+      fd.append("max_zone_area", String(this.form.max_zone_area));
+      fd.append("legend_coverage", String(this.form.legend_coverage));
+      fd.append("legend_hex", String(this.form.legend_hex));
+      fd.append("legend_zone_counts", String(this.form.legend_zone_counts));
+      fd.append("pattern_fill", String(this.form.pattern_fill));
+      fd.append("large_print", String(this.form.large_print));
       return fd;
     },
 
+    clearResult() {
+      if (this.resultUrl) {
+        URL.revokeObjectURL(this.resultUrl);
+        this.resultUrl = "";
+      }
+    },
+
+    downloadName() {
+      const base = this.file ? this.file.name.replace(/\.[^.]+$/, "") : "macoma";
+      return `${base}-coloring.png`;
+    },
+
     async requestPreview() {
       if (!this.file) {
         this.error = "Please select an input image.";
@@ -101,13 +148,9 @@ function createMacomaApp() {
           throw await this.toError(resp);
         }
         const blob = await resp.blob();
-        const url = URL.createObjectURL(blob);
-        const a = document.createElement("a");
-        a.href = url;
-        a.download = "macoma-output.png";
-        a.click();
-        URL.revokeObjectURL(url);
-        this.status = "Render complete. Download started.";
+        this.clearResult();
+        this.resultUrl = URL.createObjectURL(blob);
+        this.status = "Render complete.";
       } catch (err) {
         this.error = err.message || "Render request failed.";
         this.status = "";
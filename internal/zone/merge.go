@@ -0,0 +1,132 @@
+package zone
+
+import "sort"
+
+// MergeSmall merges every zone smaller than minArea pixels into its largest
+// neighboring zone, as found by Adjacency, so specks left by anti-aliasing
+// or scan noise do not each get a number. Zones are merged smallest first,
+// and a zone grown by merges counts with its new size, so a cluster of
+// specks ends up in the large zone around it. A small zone with no
+// neighbors is kept.
+//
+// The delimiter pixels around a merged speck stay delimiters. It returns
+// the zones (renumbered from 0 in order of their first original zone) and
+// the matching label map for a w×h image. minArea <= 1 returns the input
+// unchanged.
+func MergeSmall(zones []Zone, labels []int, w, h, minArea int) ([]Zone, []int) {
+	if minArea <= 1 || len(zones) < 2 {
+		return zones, labels
+	}
+	adj := Adjacency(labels, w, h, len(zones))
+
+	// Union-find over zone indices; the root of a group holds its size
+	// and the union of its members' neighbors.
+	root := make([]int, len(zones))
+	size := make([]int, len(zones))
+	neighbors := make([]map[int]struct{}, len(zones))
+	for i := range zones {
+		root[i] = i
+		size[i] = zones[i].Area()
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if root[i] != i {
+			root[i] = find(root[i])
+		}
+		return root[i]
+	}
+	neighborsOf := func(r int) map[int]struct{} {
+		if neighbors[r] == nil {
+			neighbors[r] = make(map[int]struct{}, len(adj[r]))
+			for _, n := range adj[r] {
+				neighbors[r][n] = struct{}{}
+			}
+		}
+		return neighbors[r]
+	}
+
+	order := make([]int, len(zones))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return size[order[a]] < size[order[b]] })
+
+	for _, i := range order {
+		r := find(i)
+		if size[r] >= minArea {
+			continue
+		}
+		// Largest neighboring group; ties go to the lower index
+		best := -1
+		for n := range neighborsOf(r) {
+			nr := find(n)
+			if nr == r {
+				continue
+			}
+			if best == -1 || size[nr] > size[best] || (size[nr] == size[best] && nr < best) {
+				best = nr
+			}
+		}
+		if best == -1 {
+			continue
+		}
+		root[r] = best
+		size[best] += size[r]
+		into := neighborsOf(best)
+		for n := range neighborsOf(r) {
+			into[n] = struct{}{}
+		}
+		neighbors[r] = nil
+	}
+
+	// Renumber groups in order of their first member
+	id := make([]int, len(zones))
+	for i := range id {
+		id[i] = -1
+	}
+	n := 0
+	for i := range zones {
+		if r := find(i); id[r] == -1 {
+			id[r] = n
+			n++
+		}
+	}
+
+	merged := make([]int, len(labels))
+	for i, l := range labels {
+		if l >= 0 {
+			l = id[find(l)]
+		}
+		merged[i] = l
+	}
+	return FromLabels(merged, w, n), merged
+}
+
+// Remove drops the zones for which drop is true, as lying outside the
+// drawing. It returns the remaining zones, renumbered from 0 in their
+// original order, and the matching label map, where the pixels of dropped
+// zones are Outside and render blank.
+func Remove(zones []Zone, labels []int, drop []bool) ([]Zone, []int) {
+	id := make([]int, len(zones))
+	out := make([]Zone, 0, len(zones))
+	for i, z := range zones {
+		if drop[i] {
+			id[i] = Outside
+			continue
+		}
+		id[i] = len(out)
+		z.ID = len(out)
+		out = append(out, z)
+	}
+	if len(out) == len(zones) {
+		return zones, labels
+	}
+	kept := make([]int, len(labels))
+	for i, l := range labels {
+		if l >= 0 {
+			l = id[l]
+		}
+		kept[i] = l
+	}
+	return out, kept
+}
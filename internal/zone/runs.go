@@ -0,0 +1,102 @@
+package zone
+
+import (
+	"image"
+	"sort"
+)
+
+// Run is a horizontal span of zone pixels: columns X0 to X1-1 of row Y.
+// Coordinates are int32 to keep a run at 12 bytes.
+type Run struct {
+	Y, X0, X1 int32
+}
+
+// FromPixels returns the zone of the given pixels, in any order. Duplicate
+// pixels count once.
+func FromPixels(id int, pixels []image.Point) Zone {
+	runs := make([]Run, len(pixels))
+	for i, p := range pixels {
+		runs[i] = Run{Y: int32(p.Y), X0: int32(p.X), X1: int32(p.X) + 1}
+	}
+	return Zone{ID: id, Runs: normalizeRuns(runs)}
+}
+
+// FromLabels builds the n zones of a label map for a w-wide image: zone i
+// holds the pixels labelled i, and has ID i. Other labels are ignored.
+func FromLabels(labels []int, w, n int) []Zone {
+	zones := make([]Zone, n)
+	for i := range zones {
+		zones[i].ID = i
+	}
+	if w == 0 {
+		return zones
+	}
+	for y := 0; y < len(labels)/w; y++ {
+		row := labels[y*w : (y+1)*w]
+		for x := 0; x < w; {
+			l := row[x]
+			x0 := x
+			for x < w && row[x] == l {
+				x++
+			}
+			if l >= 0 && l < n {
+				zones[l].Runs = append(zones[l].Runs, Run{Y: int32(y), X0: int32(x0), X1: int32(x)})
+			}
+		}
+	}
+	return zones
+}
+
+// normalizeRuns sorts runs row by row and left to right, and joins those
+// that overlap or touch.
+func normalizeRuns(runs []Run) []Run {
+	sort.Slice(runs, func(i, j int) bool {
+		if runs[i].Y != runs[j].Y {
+			return runs[i].Y < runs[j].Y
+		}
+		return runs[i].X0 < runs[j].X0
+	})
+	out := runs[:0]
+	for _, r := range runs {
+		if n := len(out); n > 0 && out[n-1].Y == r.Y && r.X0 <= out[n-1].X1 {
+			out[n-1].X1 = max(out[n-1].X1, r.X1)
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// Area returns the number of pixels of the zone.
+func (z *Zone) Area() int {
+	n := 0
+	for _, r := range z.Runs {
+		n += int(r.X1 - r.X0)
+	}
+	return n
+}
+
+// Each calls fn for every pixel of the zone, run by run.
+func (z *Zone) Each(fn func(x, y int)) {
+	for _, r := range z.Runs {
+		for x := r.X0; x < r.X1; x++ {
+			fn(int(x), int(r.Y))
+		}
+	}
+}
+
+// Points returns every pixel of the zone, run by run.
+func (z *Zone) Points() []image.Point {
+	pts := make([]image.Point, 0, z.Area())
+	z.Each(func(x, y int) { pts = append(pts, image.Pt(x, y)) })
+	return pts
+}
+
+// Translate returns a copy of the zone moved by d.
+func (z *Zone) Translate(d image.Point) Zone {
+	runs := make([]Run, len(z.Runs))
+	for i, r := range z.Runs {
+		runs[i] = Run{Y: r.Y + int32(d.Y), X0: r.X0 + int32(d.X), X1: r.X1 + int32(d.X)}
+	}
+	return Zone{ID: z.ID, Runs: runs}
+}
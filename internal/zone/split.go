@@ -0,0 +1,112 @@
+package zone
+
+import (
+	"image"
+	"sort"
+)
+
+// Subdivide splits every zone larger than maxArea pixels into sub-zones of
+// at most roughly maxArea pixels, by recursively cutting along the longer
+// side of the bounding box at a straight line. Each connected piece becomes
+// a zone of its own. It returns the new zones (renumbered from 0), the
+// matching label map for a w-wide image, and parent, mapping each new zone
+// to the index of the zone it came from.
+//
+// Sub-zones of one parent touch directly, without a delimiter between them,
+// which is how renderers can tell divider lines from real borders.
+func Subdivide(zones []Zone, labels []int, w, maxArea int) ([]Zone, []int, []int) {
+	out := make([]int, len(labels))
+	copy(out, labels)
+	var subs []Zone
+	var parent []int
+
+	for i := range zones {
+		z := &zones[i]
+		if maxArea <= 0 || z.Area() <= maxArea {
+			subs = append(subs, Zone{ID: len(subs), Runs: z.Runs})
+			parent = append(parent, i)
+			continue
+		}
+		for _, part := range bisect(z.Points(), maxArea) {
+			for _, px := range components(part) {
+				subs = append(subs, FromPixels(len(subs), px))
+				parent = append(parent, i)
+			}
+		}
+	}
+	for id := range subs {
+		for _, r := range subs[id].Runs {
+			row := out[int(r.Y)*w:]
+			for x := r.X0; x < r.X1; x++ {
+				row[x] = id
+			}
+		}
+	}
+	return subs, out, parent
+}
+
+// bisect recursively cuts pixels into parts of at most maxArea pixels.
+func bisect(pixels []image.Point, maxArea int) [][]image.Point {
+	n := len(pixels)
+	if n <= maxArea {
+		return [][]image.Point{pixels}
+	}
+	parts := (n + maxArea - 1) / maxArea
+
+	bb := image.Rectangle{Min: pixels[0], Max: pixels[0]}
+	for _, p := range pixels {
+		bb = bb.Union(image.Rectangle{Min: p, Max: p.Add(image.Pt(1, 1))})
+	}
+	coord := func(p image.Point) int { return p.Y }
+	if bb.Dx() >= bb.Dy() {
+		coord = func(p image.Point) int { return p.X }
+	}
+	sorted := make([]image.Point, n)
+	copy(sorted, pixels)
+	sort.SliceStable(sorted, func(a, b int) bool { return coord(sorted[a]) < coord(sorted[b]) })
+
+	// Cut between whole rows/columns so divider lines are straight.
+	cut := n * (parts / 2) / parts
+	for cut > 0 && coord(sorted[cut-1]) == coord(sorted[cut]) {
+		cut--
+	}
+	if cut == 0 {
+		cut = n * (parts / 2) / parts
+		for cut < n && coord(sorted[cut-1]) == coord(sorted[cut]) {
+			cut++
+		}
+		if cut == n {
+			// A single row or column: cut it anywhere.
+			cut = n * (parts / 2) / parts
+		}
+	}
+	return append(bisect(sorted[:cut], maxArea), bisect(sorted[cut:], maxArea)...)
+}
+
+// components splits pixels into 4-connected pieces.
+func components(pixels []image.Point) [][]image.Point {
+	members := make(map[image.Point]bool, len(pixels))
+	for _, p := range pixels {
+		members[p] = true
+	}
+	var out [][]image.Point
+	for _, start := range pixels {
+		if !members[start] {
+			continue
+		}
+		members[start] = false
+		piece := []image.Point{start}
+		for k := 0; k < len(piece); k++ {
+			p := piece[k]
+			for _, d := range [4]image.Point{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+				q := p.Add(d)
+				if members[q] {
+					members[q] = false
+					piece = append(piece, q)
+				}
+			}
+		}
+		out = append(out, piece)
+	}
+	return out
+}
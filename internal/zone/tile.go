@@ -0,0 +1,121 @@
+package zone
+
+import (
+	"context"
+
+	"github.com/maax3v3/macoma/v2/internal/detection"
+	"github.com/maax3v3/macoma/v2/internal/pool"
+	"github.com/maax3v3/macoma/v2/internal/progress"
+)
+
+// FindZonesTiled is like FindZonesContext, but flood-fills bands of
+// tileHeight rows separately and then stitches the pieces of zones that
+// cross band boundaries, so the flood fill's queue is bounded by a band
+// instead of the largest zone. Zones, IDs and labels are those of
+// FindZonesContext. A tileHeight <= 0 fills the whole map at once.
+func FindZonesTiled(ctx context.Context, dm *detection.Map, conn Connectivity, tileHeight int) ([]Zone, []int, error) {
+	w, h := dm.Width, dm.Height
+	if tileHeight <= 0 || tileHeight >= h {
+		return FindZonesContext(ctx, dm, conn)
+	}
+	dirs := neighbors4
+	if conn == Connect8 {
+		dirs = neighbors8
+	}
+	bufs := pool.From(ctx)
+	labels := bufs.Ints(w * h)
+	for i := range labels {
+		labels[i] = -1
+	}
+
+	// Label the pieces of every band; parent links pieces of one zone
+	var parent []int
+	var queue []int
+	if bufs != nil {
+		// A pooled queue is taken at its largest size, the whole map.
+		queue = bufs.Ints(w * h)[:0]
+		defer func() { bufs.PutInts(queue) }()
+	}
+	filled := 0
+	for y0 := 0; y0 < h; y0 += tileHeight {
+		y1 := min(y0+tileHeight, h)
+		for idx := y0 * w; idx < y1*w; idx++ {
+			if dm.IsDelimiter[idx] || labels[idx] != -1 {
+				continue
+			}
+			piece := len(parent)
+			parent = append(parent, piece)
+			queue = append(queue[:0], idx)
+			labels[idx] = piece
+			for head := 0; head < len(queue); head++ {
+				i := queue[head]
+				if filled++; filled%cancelCheckInterval == 0 && ctx.Err() != nil {
+					return nil, nil, ctx.Err()
+				}
+				px, py := i%w, i/w
+				for _, d := range dirs {
+					nx, ny := px+d.X, py+d.Y
+					if nx < 0 || nx >= w || ny < y0 || ny >= y1 {
+						continue
+					}
+					ni := ny*w + nx
+					if dm.IsDelimiter[ni] || labels[ni] != -1 {
+						continue
+					}
+					labels[ni] = piece
+					queue = append(queue, ni)
+				}
+			}
+		}
+		progress.Report(ctx, progress.Zones, y1, h)
+	}
+
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	// Stitch across each boundary: a piece and the one it touches in the
+	// row above are the same zone
+	for y := tileHeight; y < h; y += tileHeight {
+		for x := 0; x < w; x++ {
+			l := labels[y*w+x]
+			if l < 0 {
+				continue
+			}
+			for _, d := range dirs {
+				nx := x + d.X
+				if d.Y != -1 || nx < 0 || nx >= w {
+					continue
+				}
+				if n := labels[(y-1)*w+nx]; n >= 0 {
+					if a, b := find(l), find(n); a != b {
+						parent[max(a, b)] = min(a, b)
+					}
+				}
+			}
+		}
+	}
+
+	// Number zones in raster order of their first pixel, as the whole-map
+	// flood fill does
+	id := make([]int, len(parent))
+	for i := range id {
+		id[i] = -1
+	}
+	n := 0
+	for i, l := range labels {
+		if l < 0 {
+			continue
+		}
+		r := find(l)
+		if id[r] == -1 {
+			id[r] = n
+			n++
+		}
+		labels[i] = id[r]
+	}
+	return FromLabels(labels, w, n), labels, nil
+}
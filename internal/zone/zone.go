@@ -1,32 +1,111 @@
 package zone
 
 import (
+	"context"
 	"image"
+	"math"
+	"sort"
 
 	"github.com/maax3v3/macoma/v2/internal/color"
 	"github.com/maax3v3/macoma/v2/internal/detection"
+	"github.com/maax3v3/macoma/v2/internal/pool"
+	"github.com/maax3v3/macoma/v2/internal/progress"
 )
 
 // Zone represents a connected region of filler (non-delimiter) pixels.
+// Its pixels are stored as horizontal runs rather than one point each, so
+// large zones of big scans stay small in memory.
 type Zone struct {
-	ID     int
-	Pixels []image.Point // all pixel coordinates in this zone
+	ID   int
+	Runs []Run // row by row, top to bottom, and left to right in a row
 }
 
 // Centroid returns the geometric center of the zone.
 func (z *Zone) Centroid() image.Point {
-	if len(z.Pixels) == 0 {
+	var sx, sy, n int
+	for _, r := range z.Runs {
+		k := int(r.X1 - r.X0)
+		sx += (int(r.X0) + int(r.X1) - 1) * k / 2
+		sy += int(r.Y) * k
+		n += k
+	}
+	if n == 0 {
 		return image.Point{}
 	}
-	var sx, sy int
-	for _, p := range z.Pixels {
-		sx += p.X
-		sy += p.Y
+	return image.Point{X: sx / n, Y: sy / n}
+}
+
+// BoundingBox returns the smallest rectangle containing every zone pixel.
+// An empty zone yields the zero rectangle.
+func (z *Zone) BoundingBox() image.Rectangle {
+	var r image.Rectangle
+	for i, run := range z.Runs {
+		b := image.Rect(int(run.X0), int(run.Y), int(run.X1), int(run.Y)+1)
+		if i == 0 {
+			r = b
+		} else {
+			r = r.Union(b)
+		}
+	}
+	return r
+}
+
+// PrincipalAxis returns the direction along which the zone is longest, as an
+// angle in radians in (-π/2, π/2] measured clockwise from the x axis (image
+// y grows downward), and the elongation: the ratio of the zone's spread
+// along that axis to its spread across it. A disc or square has elongation
+// 1; a band ten times longer than wide has about 10. Single-pixel-wide
+// bands report +Inf.
+func (z *Zone) PrincipalAxis() (angle, elongation float64) {
+	area := z.Area()
+	if area == 0 {
+		return 0, 1
+	}
+	n := float64(area)
+	var mx, my float64
+	z.Each(func(x, y int) {
+		mx += float64(x)
+		my += float64(y)
+	})
+	mx, my = mx/n, my/n
+	var sxx, syy, sxy float64
+	z.Each(func(x, y int) {
+		dx, dy := float64(x)-mx, float64(y)-my
+		sxx += dx * dx
+		syy += dy * dy
+		sxy += dx * dy
+	})
+	sxx, syy, sxy = sxx/n, syy/n, sxy/n
+
+	angle = 0.5 * math.Atan2(2*sxy, sxx-syy)
+	if angle <= -math.Pi/2 {
+		angle += math.Pi
 	}
-	return image.Point{
-		X: sx / len(z.Pixels),
-		Y: sy / len(z.Pixels),
+	// Eigenvalues of the covariance matrix: variance along and across
+	mean := (sxx + syy) / 2
+	diff := math.Hypot((sxx-syy)/2, sxy)
+	major, minor := mean+diff, mean-diff
+	if minor <= 1e-9 {
+		if major <= 1e-9 {
+			return angle, 1
+		}
+		return angle, math.Inf(1)
 	}
+	return angle, math.Sqrt(major / minor)
+}
+
+// Mask returns an alpha mask covering the zone's bounding box (in image
+// coordinates) where zone pixels are opaque and everything else is
+// transparent. It can be passed directly to draw.DrawMask.
+func (z *Zone) Mask() *image.Alpha {
+	mask := image.NewAlpha(z.BoundingBox())
+	for _, r := range z.Runs {
+		row := mask.Pix[mask.PixOffset(int(r.X0), int(r.Y)):][:r.X1-r.X0]
+		for i := range row {
+			row[i] = 0xff
+		}
+	}
+	return mask
 }
 
 // InteriorPoint returns a point guaranteed to be inside the zone.
@@ -37,62 +116,92 @@ func (z *Zone) Centroid() image.Point {
 // Uses BFS from boundary pixels to compute distance-to-edge in O(n),
 // making it independent of the margin value.
 func (z *Zone) InteriorPoint() image.Point {
-	if len(z.Pixels) == 0 {
+	if len(z.Runs) == 0 {
 		return image.Point{}
 	}
-	centroid := z.Centroid()
+	return z.interiorPoint(z.edgeDistances(), z.labelMargin())
+}
 
-	// Build a set for O(1) membership check
-	members := make(map[image.Point]struct{}, len(z.Pixels))
-	for _, p := range z.Pixels {
-		members[p] = struct{}{}
+// labelMargin is the desired margin of a label point from the zone boundary.
+func (z *Zone) labelMargin() int {
+	if z.Area() < 100 {
+		return 5
 	}
+	return 15
+}
 
-	// Desired margin from zone boundary
-	margin := 15
-	if len(z.Pixels) < 100 {
-		margin = 5
+// edgeGrid holds the distance of every zone pixel to the zone boundary,
+// over the zone's bounding box.
+type edgeGrid struct {
+	box  image.Rectangle
+	dist []int32 // row-major over box; -1 outside the zone
+}
+
+// at returns the distance of p to the boundary, or -1 if p is not a zone
+// pixel.
+func (g *edgeGrid) at(p image.Point) int {
+	if !p.In(g.box) {
+		return -1
 	}
+	return int(g.dist[(p.Y-g.box.Min.Y)*g.box.Dx()+p.X-g.box.Min.X])
+}
 
-	// Compute distance-to-boundary for every zone pixel via BFS.
-	// Boundary pixels are zone pixels that have at least one 4-neighbor
-	// outside the zone. Their distance is 0. We propagate inward.
-	dist := make(map[image.Point]int, len(z.Pixels))
-	var queue []image.Point
-	dirs := [4]image.Point{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+// edgeDistances returns, for every zone pixel, its distance in 4-connected
+// steps to the nearest boundary pixel. Boundary pixels are zone pixels that
+// have at least one 4-neighbor outside the zone; their distance is 0.
+func (z *Zone) edgeDistances() *edgeGrid {
+	box := z.BoundingBox()
+	w, h := box.Dx(), box.Dy()
+	g := &edgeGrid{box: box, dist: make([]int32, w*h)}
+	const unvisited = math.MaxInt32
+	for i := range g.dist {
+		g.dist[i] = -1
+	}
+	for _, r := range z.Runs {
+		row := g.dist[(int(r.Y)-box.Min.Y)*w:]
+		for x := int(r.X0) - box.Min.X; x < int(r.X1)-box.Min.X; x++ {
+			row[x] = unvisited
+		}
+	}
 
-	for _, p := range z.Pixels {
-		isBoundary := false
-		for _, d := range dirs {
-			n := image.Point{X: p.X + d.X, Y: p.Y + d.Y}
-			if _, ok := members[n]; !ok {
-				isBoundary = true
-				break
+	// Compute distance-to-boundary for every zone pixel via BFS,
+	// propagating inward from the boundary.
+	member := func(x, y int) bool {
+		return x >= 0 && x < w && y >= 0 && y < h && g.dist[y*w+x] != -1
+	}
+	var queue []int32
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			if g.dist[i] == -1 {
+				continue
+			}
+			if !member(x-1, y) || !member(x+1, y) || !member(x, y-1) || !member(x, y+1) {
+				g.dist[i] = 0
+				queue = append(queue, int32(i))
 			}
-		}
-		if isBoundary {
-			dist[p] = 0
-			queue = append(queue, p)
-		} else {
-			dist[p] = -1 // unvisited
 		}
 	}
-
-	for len(queue) > 0 {
-		p := queue[0]
-		queue = queue[1:]
-		nd := dist[p] + 1
-		for _, d := range dirs {
-			n := image.Point{X: p.X + d.X, Y: p.Y + d.Y}
-			if dd, ok := dist[n]; ok && dd == -1 {
-				dist[n] = nd
-				queue = append(queue, n)
+	for head := 0; head < len(queue); head++ {
+		i := int(queue[head])
+		x, y := i%w, i/w
+		nd := g.dist[i] + 1
+		for _, n := range [4][2]int{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}} {
+			if member(n[0], n[1]) && g.dist[n[1]*w+n[0]] == unvisited {
+				g.dist[n[1]*w+n[0]] = nd
+				queue = append(queue, int32(n[1]*w+n[0]))
 			}
 		}
 	}
+	return g
+}
+
+// interiorPoint implements InteriorPoint given the zone's edge distances.
+func (z *Zone) interiorPoint(dist *edgeGrid, margin int) image.Point {
+	centroid := z.Centroid()
 
 	// Check centroid first
-	if d, ok := dist[centroid]; ok && d >= margin {
+	if dist.at(centroid) >= margin {
 		return centroid
 	}
 
@@ -100,19 +209,17 @@ func (z *Zone) InteriorPoint() image.Point {
 	bestSq := int(^uint(0) >> 1)
 	best := image.Point{}
 	found := false
-	for _, p := range z.Pixels {
-		if dist[p] < margin {
-			continue
+	z.Each(func(x, y int) {
+		p := image.Pt(x, y)
+		if dist.at(p) < margin {
+			return
 		}
-		dx := p.X - centroid.X
-		dy := p.Y - centroid.Y
-		sq := dx*dx + dy*dy
-		if sq < bestSq {
+		if sq := sqDist(p, centroid); sq < bestSq {
 			bestSq = sq
 			best = p
 			found = true
 		}
-	}
+	})
 	if found {
 		return best
 	}
@@ -122,33 +229,175 @@ func (z *Zone) InteriorPoint() image.Point {
 	// proximity to centroid).
 	bestEdgeDist := -1
 	bestSq = int(^uint(0) >> 1)
-	for _, p := range z.Pixels {
-		d := dist[p]
-		dx := p.X - centroid.X
-		dy := p.Y - centroid.Y
-		sq := dx*dx + dy*dy
+	z.Each(func(x, y int) {
+		p := image.Pt(x, y)
+		d := dist.at(p)
+		sq := sqDist(p, centroid)
 		if d > bestEdgeDist || (d == bestEdgeDist && sq < bestSq) {
 			bestEdgeDist = d
 			bestSq = sq
 			best = p
 		}
-	}
+	})
 	return best
 }
 
-// FindZones performs flood-fill on filler pixels to identify connected zones.
-// Returns a slice of zones and a label map (same dimensions as the delimiter map)
-// where each filler pixel's value is its zone index (0-based), and delimiter
-// pixels have value -1.
+// SpreadPoints returns up to n points inside the zone, spread evenly over
+// it, for zones large enough to carry their number several times. Only
+// pixels at least the label margin away from the zone boundary are used.
+// Points are seeded by farthest-point sampling from InteriorPoint, which
+// stops early, returning fewer points, once no pixel is at least three
+// margins away from all chosen points. A few k-means rounds then move each
+// point to the middle of the part of the zone closest to it, so the points
+// sit evenly rather than in the far corners.
+func (z *Zone) SpreadPoints(n int) []image.Point {
+	if len(z.Runs) == 0 || n <= 0 {
+		return nil
+	}
+	dist := z.edgeDistances()
+	margin := z.labelMargin()
+	first := z.interiorPoint(dist, margin)
+	points := []image.Point{first}
+
+	// Candidates keep the margin, or as much of it as the zone allows
+	deepest := 0
+	for _, d := range dist.dist {
+		deepest = max(deepest, int(d))
+	}
+	var cands []image.Point
+	z.Each(func(x, y int) {
+		if p := image.Pt(x, y); dist.at(p) >= min(margin, deepest) {
+			cands = append(cands, p)
+		}
+	})
+	nearest := make([]int, len(cands)) // squared distance to the closest chosen point
+	for i, p := range cands {
+		nearest[i] = sqDist(p, first)
+	}
+
+	spacing := 3 * margin
+	for len(points) < n {
+		best := -1
+		for i := range cands {
+			if best == -1 || nearest[i] > nearest[best] {
+				best = i
+			}
+		}
+		if best == -1 || nearest[best] < spacing*spacing {
+			break
+		}
+		p := cands[best]
+		points = append(points, p)
+		for i, c := range cands {
+			nearest[i] = min(nearest[i], sqDist(c, p))
+		}
+	}
+	if len(points) > 1 {
+		spreadEvenly(points, cands)
+	}
+	return points
+}
+
+// spreadRounds is the number of k-means rounds SpreadPoints runs, and
+// spreadSamples caps the candidate pixels they visit.
+const (
+	spreadRounds  = 8
+	spreadSamples = 1 << 14
+)
+
+// spreadEvenly moves each point to the mean of the candidates closest to
+// it, snapped back onto the nearest candidate so it stays inside the zone.
+func spreadEvenly(points, cands []image.Point) {
+	stride := max(1, len(cands)/spreadSamples)
+	sums := make([]image.Point, len(points))
+	counts := make([]int, len(points))
+	for round := 0; round < spreadRounds; round++ {
+		clear(sums)
+		clear(counts)
+		for i := 0; i < len(cands); i += stride {
+			c := cands[i]
+			k := 0
+			for j := range points {
+				if sqDist(c, points[j]) < sqDist(c, points[k]) {
+					k = j
+				}
+			}
+			sums[k] = sums[k].Add(c)
+			counts[k]++
+		}
+		for j := range points {
+			if counts[j] == 0 {
+				continue
+			}
+			mean := sums[j].Div(counts[j])
+			best := points[j]
+			for i := 0; i < len(cands); i += stride {
+				if sqDist(cands[i], mean) < sqDist(best, mean) {
+					best = cands[i]
+				}
+			}
+			points[j] = best
+		}
+	}
+}
+
+func sqDist(a, b image.Point) int {
+	dx, dy := a.X-b.X, a.Y-b.Y
+	return dx*dx + dy*dy
+}
+
+// Connectivity is the pixel neighborhood used by the flood fill.
+type Connectivity int
+
+const (
+	// Connect4 joins pixels that share an edge, so a diagonal delimiter
+	// line one pixel thick keeps the zones on either side apart.
+	Connect4 Connectivity = 4
+	// Connect8 also joins pixels that only touch at a corner, so diagonal
+	// filler pixels count as one zone, but one-pixel diagonal lines leak.
+	Connect8 Connectivity = 8
+)
+
+var (
+	neighbors4 = []image.Point{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+	neighbors8 = []image.Point{{-1, 0}, {1, 0}, {0, -1}, {0, 1}, {-1, -1}, {1, -1}, {-1, 1}, {1, 1}}
+)
+
+// FindZones performs flood-fill on filler pixels to identify 4-connected
+// zones. Returns a slice of zones and a label map (same dimensions as the
+// delimiter map) where each filler pixel's value is its zone index
+// (0-based), and delimiter pixels have value -1.
 func FindZones(dm *detection.Map) ([]Zone, []int) {
+	zones, labels, _ := FindZonesContext(context.Background(), dm, Connect4)
+	return zones, labels
+}
+
+// cancelCheckInterval is the number of pixels flood-filled between checks
+// for cancellation.
+const cancelCheckInterval = 1 << 16
+
+// FindZonesContext is like FindZones with a choice of connectivity (0 means
+// Connect4), and stops early and returns ctx.Err() once ctx is cancelled.
+func FindZonesContext(ctx context.Context, dm *detection.Map, conn Connectivity) ([]Zone, []int, error) {
+	dirs := neighbors4
+	if conn == Connect8 {
+		dirs = neighbors8
+	}
 	w, h := dm.Width, dm.Height
-	labels := make([]int, w*h)
+	bufs := pool.From(ctx)
+	labels := bufs.Ints(w * h)
 	for i := range labels {
 		labels[i] = -1
 	}
 
-	var zones []Zone
 	zoneID := 0
+	filled := 0
+	var queue []int
+	if bufs != nil {
+		// A pooled queue is taken at its largest size, the whole map.
+		queue = bufs.Ints(w * h)[:0]
+		defer func() { bufs.PutInts(queue) }()
+	}
 
 	for y := 0; y < h; y++ {
 		for x := 0; x < w; x++ {
@@ -157,18 +406,18 @@ func FindZones(dm *detection.Map) ([]Zone, []int) {
 				continue
 			}
 			// BFS flood-fill
-			zone := Zone{ID: zoneID}
-			queue := []image.Point{{X: x, Y: y}}
+			queue = append(queue[:0], idx)
 			labels[idx] = zoneID
 
-			for len(queue) > 0 {
-				p := queue[0]
-				queue = queue[1:]
-				zone.Pixels = append(zone.Pixels, p)
+			for head := 0; head < len(queue); head++ {
+				i := queue[head]
+				if filled++; filled%cancelCheckInterval == 0 && ctx.Err() != nil {
+					return nil, nil, ctx.Err()
+				}
 
-				// 4-connected neighbors
-				for _, d := range [4]image.Point{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
-					nx, ny := p.X+d.X, p.Y+d.Y
+				px, py := i%w, i/w
+				for _, d := range dirs {
+					nx, ny := px+d.X, py+d.Y
 					if nx < 0 || nx >= w || ny < 0 || ny >= h {
 						continue
 					}
@@ -177,16 +426,16 @@ func FindZones(dm *detection.Map) ([]Zone, []int) {
 						continue
 					}
 					labels[ni] = zoneID
-					queue = append(queue, image.Point{X: nx, Y: ny})
+					queue = append(queue, ni)
 				}
 			}
-
-			zones = append(zones, zone)
 			zoneID++
 		}
+		progress.Report(ctx, progress.Zones, y+1, h)
 	}
 
-	return zones, labels
+	zones := FromLabels(labels, w, zoneID)
+	return zones, labels, nil
 }
 
 // ZoneColors holds the aggregated color for each zone.
@@ -194,9 +443,24 @@ type ZoneColors struct {
 	Colors []color.RGBA // indexed by zone ID
 }
 
+// colorSampleLimit is the maximum number of pixels read per zone when
+// computing its color. Larger zones are sampled with a fixed stride; the mean
+// of 65k pixels is indistinguishable from the full mean, while huge
+// background zones would otherwise dominate the stage's runtime.
+const colorSampleLimit = 1 << 16
+
 // ComputeZoneColors computes the weighted mean color for each zone by
-// reading pixel colors from the source image.
+// reading pixel colors from the source image. Zones larger than
+// colorSampleLimit are sampled deterministically (every k-th pixel).
 func ComputeZoneColors(zones []Zone, img image.Image) *ZoneColors {
+	zc, _ := ComputeZoneColorsContext(context.Background(), zones, img)
+	return zc
+}
+
+// ComputeZoneColorsContext is like ComputeZoneColors but reports progress
+// through ctx, skips the remaining zones and returns ctx.Err() once ctx is
+// cancelled.
+func ComputeZoneColorsContext(ctx context.Context, zones []Zone, img image.Image) (*ZoneColors, error) {
 	zc := &ZoneColors{
 		Colors: make([]color.RGBA, len(zones)),
 	}
@@ -223,20 +487,166 @@ func ComputeZoneColors(zones []Zone, img image.Image) *ZoneColors {
 	for w := 0; w < numWorkers; w++ {
 		go func() {
 			for i := range work {
+				if ctx.Err() != nil {
+					ch <- result{idx: i}
+					continue
+				}
 				z := &zones[i]
-				colors := make([]color.RGBA, len(z.Pixels))
-				for j, p := range z.Pixels {
-					colors[j] = color.FromStdColor(img.At(p.X, p.Y))
+				area := z.Area()
+				stride := (area + colorSampleLimit - 1) / colorSampleLimit
+				if stride < 1 {
+					stride = 1
 				}
+				colors := make([]color.RGBA, 0, (area+stride-1)/stride)
+				j := 0
+				z.Each(func(x, y int) {
+					if j%stride == 0 {
+						colors = append(colors, color.FromStdColor(img.At(x, y)))
+					}
+					j++
+				})
 				ch <- result{idx: i, c: color.WeightedMean(colors, nil)}
 			}
 		}()
 	}
 
-	for range zones {
+	for n := range zones {
 		r := <-ch
 		zc.Colors[r.idx] = r.c
+		progress.Report(ctx, progress.Colors, n+1, len(zones))
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	return zc
+	return zc, nil
+}
+
+// Outside is the label of pixels outside the drawing, such as an added
+// margin or a dropped background, as opposed to the -1 of delimiter pixels.
+// Like those, they belong to no zone, but ExpandLabels leaves them out.
+const Outside = -2
+
+// ExpandLabels returns a copy of labels in which every delimiter pixel (-1)
+// takes the label of the nearest zone, measured in 4-connected steps. Ties are
+// broken by BFS order, so the result is deterministic. Zones then tile the
+// whole image, apart from Outside pixels, which is what adjacency and
+// gap-free outlines need.
+func ExpandLabels(labels []int, w, h int) []int {
+	out := make([]int, len(labels))
+	copy(out, labels)
+
+	queue := make([]int, 0, len(labels))
+	for i, l := range labels {
+		if l >= 0 {
+			queue = append(queue, i)
+		}
+	}
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		x, y := i%w, i/w
+		for _, d := range [4]image.Point{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+			nx, ny := x+d.X, y+d.Y
+			if nx < 0 || nx >= w || ny < 0 || ny >= h {
+				continue
+			}
+			ni := ny*w + nx
+			if out[ni] == -1 {
+				out[ni] = out[i]
+				queue = append(queue, ni)
+			}
+		}
+	}
+	return out
+}
+
+// Adjacency returns, for each of the n zones, the sorted IDs of the zones
+// that share a border with it. Zones separated by a delimiter line are
+// neighbors when the line is the only thing between them.
+func Adjacency(labels []int, w, h, n int) [][]int {
+	expanded := ExpandLabels(labels, w, h)
+	seen := make([]map[int]struct{}, n)
+	link := func(a, b int) {
+		if a == b || a < 0 || b < 0 {
+			return
+		}
+		if seen[a] == nil {
+			seen[a] = make(map[int]struct{})
+		}
+		seen[a][b] = struct{}{}
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			l := expanded[y*w+x]
+			if x+1 < w {
+				r := expanded[y*w+x+1]
+				link(l, r)
+				link(r, l)
+			}
+			if y+1 < h {
+				b := expanded[(y+1)*w+x]
+				link(l, b)
+				link(b, l)
+			}
+		}
+	}
+
+	adj := make([][]int, n)
+	for i, s := range seen {
+		adj[i] = make([]int, 0, len(s))
+		for j := range s {
+			adj[i] = append(adj[i], j)
+		}
+		sort.Ints(adj[i])
+	}
+	return adj
+}
+
+// InscribedRadii returns, for each of the n zones, its depth: the largest
+// number of 4-connected steps from a zone pixel to the zone's edge, counting
+// the edge pixel itself. A zone t pixels thick has depth about t/2, so
+// depth measures how large a label the zone can hold.
+func InscribedRadii(labels []int, w, h, n int) []int {
+	dist := make([]int, len(labels))
+	queue := make([]int, 0, len(labels)/4)
+	dirs := [4]image.Point{{-1, 0}, {1, 0}, {0, -1}, {0, 1}}
+	for i, l := range labels {
+		if l < 0 {
+			continue
+		}
+		x, y := i%w, i/w
+		for _, d := range dirs {
+			nx, ny := x+d.X, y+d.Y
+			if nx < 0 || nx >= w || ny < 0 || ny >= h || labels[ny*w+nx] != l {
+				dist[i] = 1
+				queue = append(queue, i)
+				break
+			}
+		}
+	}
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		x, y := i%w, i/w
+		for _, d := range dirs {
+			nx, ny := x+d.X, y+d.Y
+			if nx < 0 || nx >= w || ny < 0 || ny >= h {
+				continue
+			}
+			ni := ny*w + nx
+			if dist[ni] == 0 && labels[ni] == labels[i] {
+				dist[ni] = dist[i] + 1
+				queue = append(queue, ni)
+			}
+		}
+	}
+
+	radii := make([]int, n)
+	for i, l := range labels {
+		if l >= 0 && dist[i] > radii[l] {
+			radii[l] = dist[i]
+		}
+	}
+	return radii
 }
@@ -1,8 +1,14 @@
 package zone
 
 import (
+	"context"
+	"errors"
 	"image"
 	"image/color"
+	"math"
+	"math/rand"
+	"reflect"
+	"sync/atomic"
 	"testing"
 
 	mcol "github.com/maax3v3/macoma/v2/internal/color"
@@ -38,7 +44,7 @@ func TestCentroid(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			z := &Zone{ID: 0, Pixels: tt.pixels}
+			z := pixelZone(tt.pixels)
 			got := z.Centroid()
 			if got != tt.want {
 				t.Errorf("got %v, want %v", got, tt.want)
@@ -47,6 +53,12 @@ func TestCentroid(t *testing.T) {
 	}
 }
 
+// pixelZone returns the zone of pixels.
+func pixelZone(pixels []image.Point) *Zone {
+	z := FromPixels(0, pixels)
+	return &z
+}
+
 func TestInteriorPoint_EmptyZone(t *testing.T) {
 	z := &Zone{ID: 0}
 	got := z.InteriorPoint()
@@ -63,7 +75,7 @@ func TestInteriorPoint_ConvexZone(t *testing.T) {
 			pixels = append(pixels, image.Point{X: x, Y: y})
 		}
 	}
-	z := &Zone{ID: 0, Pixels: pixels}
+	z := pixelZone(pixels)
 	pt := z.InteriorPoint()
 
 	// Must be a zone pixel
@@ -106,7 +118,7 @@ func TestInteriorPoint_ConcaveZone(t *testing.T) {
 		}
 	}
 
-	z := &Zone{ID: 0, Pixels: pixels}
+	z := pixelZone(pixels)
 	pt := z.InteriorPoint()
 
 	// The returned point must be inside the zone
@@ -121,7 +133,7 @@ func TestInteriorPoint_ThinZone(t *testing.T) {
 	for x := 0; x < 30; x++ {
 		pixels = append(pixels, image.Point{X: x, Y: 0})
 	}
-	z := &Zone{ID: 0, Pixels: pixels}
+	z := pixelZone(pixels)
 	pt := z.InteriorPoint()
 
 	members := make(map[image.Point]struct{}, len(pixels))
@@ -133,6 +145,83 @@ func TestInteriorPoint_ThinZone(t *testing.T) {
 	}
 }
 
+func TestSpreadPoints(t *testing.T) {
+	// A 400x100 band: four points should sit side by side along it
+	var band []image.Point
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 400; x++ {
+			band = append(band, image.Point{X: x, Y: y})
+		}
+	}
+	z := pixelZone(band)
+	got := z.SpreadPoints(4)
+	if len(got) != 4 {
+		t.Fatalf("got %d points, want 4", len(got))
+	}
+	quarters := make(map[int]bool)
+	for _, p := range got {
+		if p.Y < 15 || p.Y >= 85 {
+			t.Errorf("point %v is within the margin of the edge", p)
+		}
+		quarters[p.X/100] = true
+	}
+	if len(quarters) != 4 {
+		t.Errorf("points %v: want one in each quarter of the band", got)
+	}
+
+	if got := z.SpreadPoints(1); len(got) != 1 || got[0] != z.InteriorPoint() {
+		t.Errorf("SpreadPoints(1) = %v, want the interior point", got)
+	}
+
+	// A zone too small to spread in returns fewer points
+	var square []image.Point
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			square = append(square, image.Point{X: x, Y: y})
+		}
+	}
+	small := pixelZone(square)
+	if got := small.SpreadPoints(5); len(got) != 1 {
+		t.Errorf("small zone: got %d points, want 1", len(got))
+	}
+}
+
+func TestFromPixels(t *testing.T) {
+	// Unordered, with a duplicate and two touching spans on row 1
+	z := FromPixels(3, []image.Point{{4, 1}, {0, 0}, {1, 0}, {2, 1}, {3, 1}, {1, 0}, {6, 1}})
+	want := []Run{{Y: 0, X0: 0, X1: 2}, {Y: 1, X0: 2, X1: 5}, {Y: 1, X0: 6, X1: 7}}
+	if z.ID != 3 || !reflect.DeepEqual(z.Runs, want) {
+		t.Errorf("got %d %v, want 3 %v", z.ID, z.Runs, want)
+	}
+	if z.Area() != 6 {
+		t.Errorf("area: got %d, want 6", z.Area())
+	}
+	pts := z.Points()
+	if len(pts) != 6 || pts[0] != (image.Point{0, 0}) || pts[5] != (image.Point{6, 1}) {
+		t.Errorf("points: got %v", pts)
+	}
+	if moved := z.Translate(image.Pt(10, 20)); moved.BoundingBox() != image.Rect(10, 20, 17, 22) {
+		t.Errorf("translated bounds: got %v", moved.BoundingBox())
+	}
+}
+
+func TestFromLabels(t *testing.T) {
+	labels := []int{
+		0, 0, -1, 1,
+		1, -2, 1, 1,
+	}
+	zones := FromLabels(labels, 4, 2)
+	want := [][]Run{
+		{{Y: 0, X0: 0, X1: 2}},
+		{{Y: 0, X0: 3, X1: 4}, {Y: 1, X0: 0, X1: 1}, {Y: 1, X0: 2, X1: 4}},
+	}
+	for i, z := range zones {
+		if z.ID != i || !reflect.DeepEqual(z.Runs, want[i]) {
+			t.Errorf("zone %d: got %d %v, want %v", i, z.ID, z.Runs, want[i])
+		}
+	}
+}
+
 func TestFindZones_SingleZone(t *testing.T) {
 	// 5x5 grid with no delimiters → one zone with 25 pixels
 	dm := &detection.Map{
@@ -145,8 +234,8 @@ func TestFindZones_SingleZone(t *testing.T) {
 	if len(zones) != 1 {
 		t.Fatalf("expected 1 zone, got %d", len(zones))
 	}
-	if len(zones[0].Pixels) != 25 {
-		t.Errorf("expected 25 pixels in zone, got %d", len(zones[0].Pixels))
+	if zones[0].Area() != 25 {
+		t.Errorf("expected 25 pixels in zone, got %d", zones[0].Area())
 	}
 	// All labels should be 0
 	for i, l := range labels {
@@ -176,8 +265,8 @@ func TestFindZones_FourQuadrants(t *testing.T) {
 
 	// Each zone should have 4 pixels (2x2 corners)
 	for i, z := range zones {
-		if len(z.Pixels) != 4 {
-			t.Errorf("zone %d: expected 4 pixels, got %d", i, len(z.Pixels))
+		if z.Area() != 4 {
+			t.Errorf("zone %d: expected 4 pixels, got %d", i, z.Area())
 		}
 	}
 
@@ -231,6 +320,34 @@ func TestFindZones_DiagonalNotConnected(t *testing.T) {
 	}
 }
 
+func TestFindZonesContext_EightConnected(t *testing.T) {
+	// Filler on the diagonal of a 3x3 grid, plus a separate corner.
+	w, h := 3, 3
+	delim := []bool{
+		false, true, false,
+		true, false, true,
+		true, true, false,
+	}
+	dm := &detection.Map{Width: w, Height: h, IsDelimiter: delim}
+
+	zones, labels, err := FindZonesContext(context.Background(), dm, Connect8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zones) != 1 || zones[0].Area() != 4 {
+		t.Fatalf("expected one zone of 4 diagonal pixels, got %d zones", len(zones))
+	}
+	for _, i := range []int{0, 2, 4, 8} {
+		if labels[i] != 0 {
+			t.Errorf("pixel %d has label %d, want 0", i, labels[i])
+		}
+	}
+
+	if zones, _, _ := FindZonesContext(context.Background(), dm, Connect4); len(zones) != 4 {
+		t.Errorf("4-connected: got %d zones, want 4", len(zones))
+	}
+}
+
 // testImage implements image.Image for ComputeZoneColors testing.
 type testImage struct {
 	w, h int
@@ -249,8 +366,8 @@ func (ti *testImage) At(x, y int) color.Color {
 func TestComputeZoneColors(t *testing.T) {
 	// Two zones: zone 0 is all red, zone 1 is all blue
 	zones := []Zone{
-		{ID: 0, Pixels: []image.Point{{0, 0}, {1, 0}}},
-		{ID: 1, Pixels: []image.Point{{3, 0}, {4, 0}}},
+		FromPixels(0, []image.Point{{0, 0}, {1, 0}}),
+		FromPixels(1, []image.Point{{3, 0}, {4, 0}}),
 	}
 	img := &testImage{
 		w: 5, h: 1,
@@ -278,7 +395,7 @@ func TestComputeZoneColors(t *testing.T) {
 func TestComputeZoneColors_MixedPixels(t *testing.T) {
 	// Zone with black (0,0,0) and white (255,255,255) pixels → mean is (128,128,128)
 	zones := []Zone{
-		{ID: 0, Pixels: []image.Point{{0, 0}, {1, 0}}},
+		FromPixels(0, []image.Point{{0, 0}, {1, 0}}),
 	}
 	img := &testImage{
 		w: 2, h: 1,
@@ -294,3 +411,331 @@ func TestComputeZoneColors_MixedPixels(t *testing.T) {
 		t.Errorf("expected ~{128,128,128}, got %+v", c)
 	}
 }
+
+func TestBoundingBox(t *testing.T) {
+	tests := []struct {
+		name   string
+		pixels []image.Point
+		want   image.Rectangle
+	}{
+		{name: "empty zone", pixels: nil, want: image.Rectangle{}},
+		{name: "single pixel", pixels: []image.Point{{3, 4}}, want: image.Rect(3, 4, 4, 5)},
+		{
+			name:   "L shape",
+			pixels: []image.Point{{2, 1}, {2, 2}, {2, 3}, {3, 3}, {4, 3}},
+			want:   image.Rect(2, 1, 5, 4),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			z := pixelZone(tt.pixels)
+			if got := z.BoundingBox(); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrincipalAxis(t *testing.T) {
+	// A diagonal band from top-left to bottom-right, three pixels thick
+	var band []image.Point
+	for i := 0; i < 40; i++ {
+		for d := -1; d <= 1; d++ {
+			band = append(band, image.Point{X: i + d, Y: i})
+		}
+	}
+	var square []image.Point
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			square = append(square, image.Point{X: x, Y: y})
+		}
+	}
+
+	angle, elong := pixelZone(band).PrincipalAxis()
+	if math.Abs(angle-math.Pi/4) > 0.05 {
+		t.Errorf("band angle: got %.3f, want π/4", angle)
+	}
+	if elong < 10 {
+		t.Errorf("band elongation: got %.1f, want > 10", elong)
+	}
+	if _, elong := pixelZone(square).PrincipalAxis(); math.Abs(elong-1) > 1e-9 {
+		t.Errorf("square elongation: got %v, want 1", elong)
+	}
+}
+
+func TestInscribedRadii(t *testing.T) {
+	// A 7x3 zone 0 above a 7x1 zone 1, split by a delimiter row.
+	w, h := 7, 5
+	labels := make([]int, w*h)
+	for i := range labels {
+		switch y := i / w; {
+		case y < 3:
+			labels[i] = 0
+		case y == 3:
+			labels[i] = -1
+		default:
+			labels[i] = 1
+		}
+	}
+	got := InscribedRadii(labels, w, h, 2)
+	if got[0] != 2 || got[1] != 1 {
+		t.Errorf("got %v, want [2 1]", got)
+	}
+}
+
+func TestMask(t *testing.T) {
+	pixels := []image.Point{{2, 1}, {2, 2}, {3, 2}}
+	z := pixelZone(pixels)
+	mask := z.Mask()
+
+	if mask.Bounds() != image.Rect(2, 1, 4, 3) {
+		t.Fatalf("mask bounds: got %v", mask.Bounds())
+	}
+	members := map[image.Point]bool{}
+	for _, p := range pixels {
+		members[p] = true
+	}
+	for y := mask.Rect.Min.Y; y < mask.Rect.Max.Y; y++ {
+		for x := mask.Rect.Min.X; x < mask.Rect.Max.X; x++ {
+			want := uint8(0)
+			if members[image.Point{X: x, Y: y}] {
+				want = 0xff
+			}
+			if got := mask.AlphaAt(x, y).A; got != want {
+				t.Errorf("mask at (%d,%d): got %d, want %d", x, y, got, want)
+			}
+		}
+	}
+}
+
+// countingImage is a uniform image that counts how many pixels are read.
+type countingImage struct {
+	w, h  int
+	c     color.RGBA
+	reads atomic.Int64
+}
+
+func (ci *countingImage) ColorModel() color.Model { return color.RGBAModel }
+func (ci *countingImage) Bounds() image.Rectangle { return image.Rect(0, 0, ci.w, ci.h) }
+func (ci *countingImage) At(x, y int) color.Color {
+	ci.reads.Add(1)
+	return ci.c
+}
+
+func TestComputeZoneColors_SamplesHugeZones(t *testing.T) {
+	w, h := 600, 300 // 180k pixels, above colorSampleLimit
+	var pixels []image.Point
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			pixels = append(pixels, image.Point{X: x, Y: y})
+		}
+	}
+	img := &countingImage{w: w, h: h, c: color.RGBA{10, 20, 30, 255}}
+
+	zc := ComputeZoneColors([]Zone{FromPixels(0, pixels)}, img)
+
+	if got := zc.Colors[0]; got != (mcol.RGBA{R: 10, G: 20, B: 30, A: 255}) {
+		t.Errorf("sampled color: got %+v", got)
+	}
+	if reads := img.reads.Load(); reads > colorSampleLimit {
+		t.Errorf("expected at most %d reads, got %d", colorSampleLimit, reads)
+	}
+}
+
+func TestExpandLabels(t *testing.T) {
+	// Two zones separated by a vertical delimiter column.
+	labels := []int{
+		0, -1, 1,
+		0, -1, 1,
+	}
+	got := ExpandLabels(labels, 3, 2)
+	for i, l := range got {
+		if l < 0 {
+			t.Fatalf("pixel %d left unlabelled: %v", i, got)
+		}
+	}
+	if labels[1] != -1 {
+		t.Error("input labels were modified")
+	}
+}
+
+func TestAdjacency(t *testing.T) {
+	// Zones 0 and 1 share a delimiter line; zone 2 is walled off from zone 0
+	// by zone 1.
+	labels := []int{
+		0, 0, -1, 1, 1, -1, 2,
+		0, 0, -1, 1, 1, -1, 2,
+	}
+	got := Adjacency(labels, 7, 2, 3)
+	want := [][]int{{1}, {0, 2}, {1}}
+	for i := range want {
+		if len(got[i]) != len(want[i]) {
+			t.Fatalf("zone %d: got %v, want %v", i, got[i], want[i])
+		}
+		for j := range want[i] {
+			if got[i][j] != want[i][j] {
+				t.Errorf("zone %d: got %v, want %v", i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestSubdivide(t *testing.T) {
+	// One 20x10 zone and one small 2x10 zone, split by a delimiter column.
+	const w, h = 23, 10
+	dm := detection.NewMap(w, h)
+	for y := 0; y < h; y++ {
+		dm.IsDelimiter[y*w+20] = true
+	}
+	zones, labels := FindZones(dm)
+
+	subs, subLabels, parent := Subdivide(zones, labels, w, 60)
+	if len(subs) < 4 {
+		t.Fatalf("expected the 200px zone to split into >= 4 parts, got %d zones", len(subs))
+	}
+	total := 0
+	for i, s := range subs {
+		if parent[i] == 0 && s.Area() > 60 {
+			t.Errorf("sub-zone %d has %d pixels, want <= 60", i, s.Area())
+		}
+		if s.ID != i {
+			t.Errorf("sub-zone %d has ID %d", i, s.ID)
+		}
+		for _, p := range s.Points() {
+			if subLabels[p.Y*w+p.X] != i {
+				t.Fatalf("label map disagrees with sub-zone %d at %v", i, p)
+			}
+		}
+		total += s.Area()
+	}
+	if total != 220 {
+		t.Errorf("sub-zones cover %d pixels, want 220", total)
+	}
+	if last := len(subs) - 1; parent[last] != 1 || subs[last].Area() != 20 {
+		t.Errorf("small zone should be kept whole: parent %d, %d pixels", parent[last], subs[last].Area())
+	}
+}
+
+func TestSubdivide_Disabled(t *testing.T) {
+	dm := detection.NewMap(10, 10)
+	zones, labels := FindZones(dm)
+	subs, _, parent := Subdivide(zones, labels, 10, 0)
+	if len(subs) != 1 || parent[0] != 0 {
+		t.Errorf("got %d zones, want the original one", len(subs))
+	}
+}
+
+func TestMergeSmall(t *testing.T) {
+	// Two zones split by a delimiter column, with two 1-pixel specks walled
+	// off inside the left one and a 2-pixel speck inside the right one.
+	const w, h = 22, 10
+	dm := detection.NewMap(w, h)
+	for y := 0; y < h; y++ {
+		dm.IsDelimiter[y*w+10] = true
+	}
+	wall := func(x, y int) {
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				if dx != 0 || dy != 0 {
+					dm.IsDelimiter[(y+dy)*w+x+dx] = true
+				}
+			}
+		}
+	}
+	wall(3, 3)
+	wall(6, 6)
+	for y := 3; y <= 6; y++ {
+		for x := 15; x <= 18; x++ {
+			dm.IsDelimiter[y*w+x] = true
+		}
+	}
+	dm.IsDelimiter[4*w+16], dm.IsDelimiter[4*w+17] = false, false
+	zones, labels := FindZones(dm)
+	if len(zones) != 5 {
+		t.Fatalf("setup: got %d zones, want 5", len(zones))
+	}
+
+	merged, mergedLabels := MergeSmall(zones, labels, w, h, 3)
+	if len(merged) != 2 {
+		t.Fatalf("got %d zones, want 2", len(merged))
+	}
+	total := 0
+	for i, z := range merged {
+		if z.ID != i {
+			t.Errorf("zone %d has ID %d", i, z.ID)
+		}
+		for _, p := range z.Points() {
+			if mergedLabels[p.Y*w+p.X] != i {
+				t.Fatalf("label map disagrees with zone %d at %v", i, p)
+			}
+		}
+		total += z.Area()
+	}
+	if want := zones[0].Area() + zones[1].Area() + zones[2].Area() + zones[3].Area() + zones[4].Area(); total != want {
+		t.Errorf("zones cover %d pixels, want %d", total, want)
+	}
+	if l := mergedLabels[3*w+3]; l != mergedLabels[0] {
+		t.Errorf("speck at (3,3) has label %d, want the left zone's %d", l, mergedLabels[0])
+	}
+	if l := mergedLabels[4*w+16]; l != mergedLabels[w-1] {
+		t.Errorf("speck at (16,4) has label %d, want the right zone's %d", l, mergedLabels[w-1])
+	}
+}
+
+func TestMergeSmall_Disabled(t *testing.T) {
+	dm := detection.NewMap(5, 1)
+	dm.IsDelimiter[1] = true
+	zones, labels := FindZones(dm)
+	if merged, _ := MergeSmall(zones, labels, 5, 1, 0); len(merged) != 2 {
+		t.Errorf("got %d zones, want 2", len(merged))
+	}
+}
+
+func TestFindZonesContext_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	dm := detection.NewMap(300, 300)
+	if _, _, err := FindZonesContext(ctx, dm, Connect4); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+	if zones, _, err := FindZonesContext(context.Background(), dm, Connect4); err != nil || len(zones) != 1 {
+		t.Errorf("got %d zones, %v; want 1 zone", len(zones), err)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	// Three zones in a row, split by delimiter columns.
+	dm := detection.NewMap(5, 1)
+	dm.IsDelimiter[1], dm.IsDelimiter[3] = true, true
+	zones, labels := FindZones(dm)
+	kept, keptLabels := Remove(zones, labels, []bool{true, false, false})
+	if len(kept) != 2 || kept[0].ID != 0 || kept[1].ID != 1 {
+		t.Fatalf("got %+v, want the last two zones renumbered 0 and 1", kept)
+	}
+	if want := []int{Outside, -1, 0, -1, 1}; !reflect.DeepEqual(keptLabels, want) {
+		t.Errorf("labels: got %v, want %v", keptLabels, want)
+	}
+	if labels[0] != 0 {
+		t.Errorf("the input labels were modified: %v", labels)
+	}
+}
+
+func TestFindZonesTiled_MatchesWholeMap(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	dm := detection.NewMap(37, 41)
+	for i := range dm.IsDelimiter {
+		dm.IsDelimiter[i] = rng.Intn(3) == 0
+	}
+	for _, conn := range []Connectivity{Connect4, Connect8} {
+		wantZones, wantLabels, _ := FindZonesContext(context.Background(), dm, conn)
+		for _, tile := range []int{1, 2, 7, 40} {
+			zones, labels, err := FindZonesTiled(context.Background(), dm, conn, tile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(labels, wantLabels) || !reflect.DeepEqual(zones, wantZones) {
+				t.Errorf("connectivity %d, tile height %d: zones differ from the whole-map fill", conn, tile)
+			}
+		}
+	}
+}
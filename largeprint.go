@@ -0,0 +1,72 @@
+package macoma
+
+import (
+	"image"
+
+	"golang.org/x/image/draw"
+
+	"github.com/maax3v3/macoma/v2/internal/detection"
+	"github.com/maax3v3/macoma/v2/internal/renderer"
+	"github.com/maax3v3/macoma/v2/internal/zone"
+)
+
+// LargePrintLabelSize is the minimum zone number height, in output pixels,
+// used by Options.LargePrint.
+const LargePrintLabelSize = 24
+
+// maxUpscale and maxUpscalePixels bound the output of large print and the
+// legibility guard, together.
+const (
+	maxUpscale       = 4
+	maxUpscalePixels = 40_000_000
+)
+
+// largePrintScale returns the integer factor by which to upscale an analysis
+// so its natural number size reaches LargePrintLabelSize, within the output
+// size limits. Upscaling keeps zones large enough to hold the bigger numbers.
+func largePrintScale(a *analysis) int {
+	b := a.img.Bounds()
+	natural := renderer.LabelSize(b.Dx(), b.Dy(), len(a.zones))
+	k := (LargePrintLabelSize + natural - 1) / natural
+	for k > 1 && (k > maxUpscale || b.Dx()*b.Dy()*k*k > maxUpscalePixels) {
+		k--
+	}
+	return k
+}
+
+// applyLargePrint adjusts the render configuration for large-print output.
+func applyLargePrint(cfg *renderer.Config) {
+	cfg.MinLabelSize = LargePrintLabelSize
+	cfg.OutlineWidth = 1
+	cfg.HighContrast = true
+	cfg.LegendCircleSize = cfg.LegendCircleSize * 3 / 2
+	cfg.LegendSpacing = cfg.LegendSpacing * 3 / 2
+	cfg.LegendPadding = cfg.LegendPadding * 3 / 2
+}
+
+// upscale returns the analysis enlarged k times with nearest-neighbor
+// sampling: every pixel becomes a k×k block. Colors are unaffected.
+func (a *analysis) upscale(k int) *analysis {
+	if k <= 1 {
+		return a
+	}
+	b := a.img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	W, H := w*k, h*k
+
+	img := image.NewRGBA(image.Rect(0, 0, W, H))
+	draw.NearestNeighbor.Scale(img, img.Bounds(), a.img, b, draw.Src, nil)
+
+	dm := detection.NewMap(W, H)
+	labels := make([]int, W*H)
+	for y := 0; y < H; y++ {
+		for x := 0; x < W; x++ {
+			src := (y/k)*w + x/k
+			dm.IsDelimiter[y*W+x] = a.dm.IsDelimiter[src]
+			labels[y*W+x] = a.labels[src]
+		}
+	}
+	zones := zone.FromLabels(labels, W, len(a.zones))
+
+	return &analysis{img: img, dm: dm, zones: zones, labels: labels, cm: a.cm, confidence: a.confidence}
+}
@@ -0,0 +1,146 @@
+package macoma
+
+import (
+	"image"
+
+	"github.com/maax3v3/macoma/v2/internal/aggregation"
+	"github.com/maax3v3/macoma/v2/internal/color"
+	"github.com/maax3v3/macoma/v2/internal/renderer"
+)
+
+// LegendEntry is one legend item.
+type LegendEntry struct {
+	Number int
+	Color  Color
+
+	// Coverage is the fraction (0–1) of the painted area using this color.
+	// It is only drawn when LegendConfig.Coverage is set.
+	Coverage float64
+
+	// Zones is the number of zones painted with this color. It is only
+	// drawn when LegendConfig.ZoneCounts is set.
+	Zones int
+}
+
+// LegendConfig controls the layout of a standalone legend.
+type LegendConfig struct {
+	CircleSize int // diameter of the color circles
+	Spacing    int // horizontal gap between items and vertical gap between rows
+	Padding    int // space above and below the legend
+	Margin     int // left/right margin
+
+	Coverage     bool // annotate entries with "(12%)"
+	Hex          bool // annotate entries with "#C84B3A"
+	ZoneCounts   bool // annotate entries with "× 3", their zone count
+	PatternFill  bool // show hatch patterns instead of colors
+	HighContrast bool // numbers in black on a white center
+}
+
+// DefaultLegendConfig returns the legend layout Convert uses for an output
+// of the given width.
+func DefaultLegendConfig(width int) LegendConfig {
+	rcfg := renderer.DefaultConfig()
+	scaleLegendConfig(&rcfg, image.Rect(0, 0, width, 0))
+	return LegendConfig{
+		CircleSize: rcfg.LegendCircleSize,
+		Spacing:    rcfg.LegendSpacing,
+		Padding:    rcfg.LegendPadding,
+		Margin:     rcfg.LegendMargin,
+	}
+}
+
+// GenerateLegend renders only a legend, width pixels wide, independently of
+// any conversion; the height follows from how the entries wrap. Use it to
+// draw the legend of a Result at another size, e.g. for a booklet cover. If
+// font is nil, the built-in bitmap font is used. With a CircleSize of 0,
+// such as in a zero LegendConfig, the layout fields left at 0 take their
+// DefaultLegendConfig(width) values.
+func GenerateLegend(entries []LegendEntry, width int, cfg LegendConfig, font FontRenderer) *image.RGBA {
+	if cfg.CircleSize <= 0 {
+		def := DefaultLegendConfig(width)
+		cfg.CircleSize = def.CircleSize
+		if cfg.Spacing == 0 {
+			cfg.Spacing = def.Spacing
+		}
+		if cfg.Padding == 0 {
+			cfg.Padding = def.Padding
+		}
+		if cfg.Margin == 0 {
+			cfg.Margin = def.Margin
+		}
+	}
+	cm := &aggregation.ColorMap{Entries: make([]aggregation.ColorEntry, len(entries))}
+	coverage := make([]float64, len(entries))
+	counts := make([]int, len(entries))
+	for i, e := range entries {
+		cm.Entries[i] = aggregation.ColorEntry{
+			Number: e.Number,
+			Color:  color.RGBA{R: e.Color.R, G: e.Color.G, B: e.Color.B, A: e.Color.A},
+		}
+		coverage[i] = e.Coverage
+		counts[i] = e.Zones
+	}
+
+	rcfg := renderer.DefaultConfig()
+	rcfg.LegendCircleSize = cfg.CircleSize
+	rcfg.LegendSpacing = cfg.Spacing
+	rcfg.LegendPadding = cfg.Padding
+	rcfg.LegendMargin = cfg.Margin
+	rcfg.LegendCoverage = cfg.Coverage
+	rcfg.LegendHex = cfg.Hex
+	rcfg.LegendZoneCounts = cfg.ZoneCounts
+	rcfg.PatternFill = cfg.PatternFill
+	rcfg.HighContrast = cfg.HighContrast
+	return renderer.RenderLegend(cm, coverage, counts, resolveFont(font), rcfg, width)
+}
+
+// LegendImage renders the legend of the conversion on its own, as wide as
+// the coloring and laid out as below it, e.g. for Options.SeparateLegend.
+// With SeparateLegend, it carries the Options.Title.
+func (r *Result) LegendImage() *image.RGBA {
+	img := r.legendImage()
+	if r.opts.Title != nil && r.opts.SeparateLegend {
+		img = renderer.AddTitle(img, titleConfig(r.opts.Title, r.rcfg, r.Scale), resolveFont(r.font), r.rcfg.Background)
+	}
+	return img
+}
+
+func (r *Result) legendImage() *image.RGBA {
+	return GenerateLegend(r.Legend(), r.Image.Bounds().Dx(), LegendConfig{
+		CircleSize:   r.rcfg.LegendCircleSize,
+		Spacing:      r.rcfg.LegendSpacing,
+		Padding:      r.rcfg.LegendPadding,
+		Margin:       r.rcfg.LegendMargin,
+		Coverage:     r.rcfg.LegendCoverage,
+		Hex:          r.rcfg.LegendHex,
+		ZoneCounts:   r.rcfg.LegendZoneCounts,
+		PatternFill:  r.rcfg.PatternFill,
+		HighContrast: r.rcfg.HighContrast,
+	}, r.font)
+}
+
+// Legend returns the legend entries of the conversion, with their coverage
+// and zone counts.
+func (r *Result) Legend() []LegendEntry {
+	cm := r.a.cm
+	areas := make([]int, len(r.a.zones))
+	for i := range r.a.zones {
+		areas[i] = r.a.zones[i].Area()
+	}
+	coverage := cm.Coverage(areas)
+	counts := make([]int, len(cm.Entries))
+	for _, e := range cm.ZoneMap {
+		counts[e]++
+	}
+
+	entries := make([]LegendEntry, len(cm.Entries))
+	for i, e := range cm.Entries {
+		entries[i] = LegendEntry{
+			Number:   e.Number,
+			Color:    Color{R: e.Color.R, G: e.Color.G, B: e.Color.B, A: e.Color.A},
+			Coverage: coverage[i],
+			Zones:    counts[i],
+		}
+	}
+	return entries
+}
@@ -0,0 +1,20 @@
+package macoma
+
+import "testing"
+
+func TestGenerateLegend_ZeroConfig(t *testing.T) {
+	entries := []LegendEntry{
+		{Number: 1, Color: Color{R: 255, A: 255}},
+		{Number: 2, Color: Color{B: 255, A: 255}},
+	}
+	got := GenerateLegend(entries, 300, LegendConfig{}, nil)
+	want := GenerateLegend(entries, 300, DefaultLegendConfig(300), nil)
+	if got.Bounds() != want.Bounds() {
+		t.Fatalf("zero config: got %v, want the default layout %v", got.Bounds(), want.Bounds())
+	}
+	for i := range got.Pix {
+		if got.Pix[i] != want.Pix[i] {
+			t.Fatal("zero config renders differently from DefaultLegendConfig")
+		}
+	}
+}
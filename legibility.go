@@ -0,0 +1,39 @@
+package macoma
+
+import (
+	"sort"
+
+	"github.com/maax3v3/macoma/v2/internal/renderer"
+	"github.com/maax3v3/macoma/v2/internal/zone"
+)
+
+// legibleFraction is the share of zones that must be thick enough to hold
+// their number. The thinnest rest are slivers along lines, which no
+// reasonable upscaling would make legible.
+const legibleFraction = 0.9
+
+// legibilityScale returns the smallest integer factor, at most maxK and
+// within maxUpscalePixels, by which to upscale a so that legibleFraction of
+// its zones are at least as thick as the numbers drawn in them. minLabel is
+// the renderer's MinLabelSize. Numbers stay at their floor size while zones
+// grow, so upscaling makes room for them.
+func legibilityScale(a *analysis, minLabel, maxK int) int {
+	if len(a.zones) == 0 {
+		return 1
+	}
+	b := a.img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	radii := zone.InscribedRadii(a.labels, w, h, len(a.zones))
+	sort.Ints(radii)
+	depth := radii[int(float64(len(radii))*(1-legibleFraction))]
+
+	k := 1
+	for ; k < maxK && w*h*(k+1)*(k+1) <= maxUpscalePixels; k++ {
+		label := max(renderer.LabelSize(w*k, h*k, len(a.zones)), minLabel)
+		// A zone of depth d is about 2d pixels thick
+		if 2*depth*k >= label {
+			break
+		}
+	}
+	return k
+}
@@ -15,14 +15,27 @@
 package macoma
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"image"
 	stdcolor "image/color"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"strconv"
 
 	"github.com/maax3v3/macoma/v2/internal/aggregation"
 	"github.com/maax3v3/macoma/v2/internal/color"
 	"github.com/maax3v3/macoma/v2/internal/detection"
+	"github.com/maax3v3/macoma/v2/internal/export"
 	"github.com/maax3v3/macoma/v2/internal/imaging"
+	"github.com/maax3v3/macoma/v2/internal/pool"
+	"github.com/maax3v3/macoma/v2/internal/progress"
+	"github.com/maax3v3/macoma/v2/internal/qr"
+	"github.com/maax3v3/macoma/v2/internal/quality"
 	"github.com/maax3v3/macoma/v2/internal/renderer"
 	"github.com/maax3v3/macoma/v2/internal/zone"
 )
@@ -31,15 +44,86 @@ import (
 const (
 	StrategyBorder = "border" // Detect borders by matching a specific color.
 	StrategyColor  = "color"  // Detect borders by color differences between neighbors.
+	StrategyGrid   = "grid"   // Ignore the drawing and divide it into square cells.
+)
+
+// Grid strategy defaults.
+const (
+	DefaultGridCells = 20  // cells across when Options.GridCells is 0
+	MinGridCell      = 24  // smallest cell side, in pixels, before enlarging
+	MaxGridCells     = 500 // most cells across accepted by WithGridCells
+)
+
+// Combine mode constants for Options.Delimiters.
+const (
+	CombineUnion        = "union"        // A pixel found by any strategy is a delimiter.
+	CombineIntersection = "intersection" // Only pixels found by every strategy are.
+)
+
+// Color metric constants.
+const (
+	MetricEuclidean = "euclidean" // Straight-line CIELAB (CIE76) or RGB distance.
+	MetricCIEDE2000 = "ciede2000" // The perceptual CIEDE2000 color difference.
+)
+
+// Watermark position constants.
+const (
+	WatermarkTopLeft     = renderer.PositionTopLeft
+	WatermarkTopRight    = renderer.PositionTopRight
+	WatermarkBottomLeft  = renderer.PositionBottomLeft
+	WatermarkBottomRight = renderer.PositionBottomRight
+	WatermarkCenter      = renderer.PositionCenter
+)
+
+// Label sets for Options.LabelSet.
+var (
+	// LetterLabels labels palette entries A, B, C, ... Z.
+	LetterLabels = []string{
+		"A", "B", "C", "D", "E", "F", "G", "H", "I", "J", "K", "L", "M",
+		"N", "O", "P", "Q", "R", "S", "T", "U", "V", "W", "X", "Y", "Z",
+	}
+
+	// SymbolLabels labels palette entries with shapes, as on cross-stitch
+	// charts.
+	SymbolLabels = []string{"●", "■", "▲", "◆", "♥", "★", "○", "□", "△", "◇", "+"}
+)
+
+// Title alignment constants.
+const (
+	TitleLeft   = renderer.AlignLeft
+	TitleCenter = renderer.AlignCenter
+	TitleRight  = renderer.AlignRight
 )
 
 // Options configures the magic coloring conversion.
 type Options struct {
+	// Preset, if set, fills in the settings of a built-in preset, such as
+	// PresetKids: each field the preset decides (see ApplyPreset) takes the
+	// preset's value when left at its zero or DefaultOptions value, and
+	// keeps its own otherwise. Other fields are never changed. To set a
+	// preset field back to its default, use ApplyPreset and change it
+	// afterwards.
+	Preset Preset
+
 	// DelimiterStrategy selects how zones are delimited.
 	// "border" matches a specific border color; "color" uses neighbor color
-	// differences. Default: "color".
+	// differences; "grid" divides the image into GridCells square cells
+	// for pixel-art colorings. Default: "color". Strategies added with
+	// RegisterStrategy are selected by their name.
 	DelimiterStrategy string
 
+	// Delimiters, if set, replaces DelimiterStrategy with several
+	// strategies whose results are combined per DelimiterCombine, e.g. a
+	// border and a color strategy so explicit black outlines and strong
+	// color edges both delimit zones. The other border and color options
+	// (ExtraBorderColors, ColorMetric, ...) apply to every entry of their
+	// strategy.
+	Delimiters []DelimiterConfig
+
+	// DelimiterCombine is CombineUnion or CombineIntersection.
+	// Default: "union".
+	DelimiterCombine string
+
 	// BorderDelimiterColor is the color of the delimiter lines.
 	// Only used when DelimiterStrategy is "border".
 	// Default: black (#000000).
@@ -47,30 +131,386 @@ type Options struct {
 
 	// BorderDelimiterTolerance is the tolerance percentage (0–100) for
 	// matching the border color. Only used when DelimiterStrategy is "border".
-	// Default: 10.
+	// With ColorMetric "ciede2000" it is a ΔE00 value instead. 0 picks
+	// the tolerance per image automatically, by Otsu's method on the
+	// distances to the border color. Default: 10.
 	BorderDelimiterTolerance float64
 
+	// BorderDelimiterLAB matches the border color by CIELAB distance
+	// instead of RGB, so BorderDelimiterTolerance is a ΔE76 value (100 is
+	// black against white). Dark navy or brown fills then no longer pass
+	// for black outlines. No effect with ColorMetric "ciede2000", which
+	// already compares in CIELAB.
+	BorderDelimiterLAB bool
+
+	// ExtraBorderColors lists further outline colors for the border
+	// strategy, each with its own tolerance, such as dark brown strokes in
+	// a drawing outlined mostly in black. A pixel matching any border
+	// color is a delimiter.
+	ExtraBorderColors []BorderColor
+
+	// ColorMetric selects how color differences are measured when reducing
+	// and mapping the palette and when matching the border color:
+	// "euclidean" uses the straight-line distance (CIELAB for the palette,
+	// RGB for the border), "ciede2000" the perceptual CIEDE2000 difference,
+	// which keeps distinct blues apart and merges near-identical greens.
+	// Default: "euclidean".
+	ColorMetric string
+
 	// ColorDelimiterTolerance is the color difference threshold percentage
 	// (0–100) from which two neighboring pixels are considered different
 	// sections. Only used when DelimiterStrategy is "color".
 	// Default: 10.
 	ColorDelimiterTolerance float64
 
+	// ColorDelimiterRadius is the neighborhood radius of the color
+	// strategy: pixels are compared across a (2r+1)×(2r+1) window. Use a
+	// larger radius for high-resolution scans and 1 for small icons.
+	// 0 means the default of 2 (a 5×5 window).
+	ColorDelimiterRadius int
+
+	// GridCells is the number of cells across the image with the grid
+	// strategy; the rows follow from the aspect ratio. Every cell is a
+	// zone numbered with its average color, like color-by-number pixel
+	// art. Inputs too small for cells of MinGridCell pixels are enlarged
+	// first, within the output size limit. 0 means DefaultGridCells; at
+	// most MaxGridCells.
+	GridCells int
+
 	// MaxColors is the maximum number of distinct colors in the output.
 	// 0 means unlimited.
 	// Default: 10.
 	MaxColors int
 
+	// AutoCrop trims the blank margins of the input before anything else:
+	// the rows and columns along its edges that are all the color of the
+	// paper, give or take scan noise. Scans with centimeters of empty paper
+	// then keep the drawing large once the legend is appended. See
+	// OutputMargin to put a margin back.
+	AutoCrop bool
+
+	// OutputMargin, if positive, surrounds the drawing with a white margin
+	// this many pixels wide on output, above the legend. Like LineWidth, it
+	// scales with the output when it is upscaled.
+	OutputMargin int
+
+	// MaxDimension, if positive, shrinks inputs whose long edge exceeds it
+	// to that many pixels before detection, with high-quality resampling.
+	// Huge scans then convert in a fraction of the time and memory.
+	MaxDimension int
+
+	// Denoise, if positive, is the radius of a median filter run over the
+	// input before detection: each channel of each pixel becomes the median
+	// of its (2r+1)×(2r+1) neighborhood. 1 or 2 removes the JPEG artifacts
+	// and scanner noise that otherwise make the color strategy find
+	// thousands of single-pixel zones.
+	Denoise int
+
+	// BlurSigma, if positive, is the standard deviation in pixels of a
+	// Gaussian blur run over the input before detection, after Denoise.
+	// 1 to 2 suppresses paper grain and texture that the color strategy
+	// would otherwise outline.
+	BlurSigma float64
+
+	// PosterizeLevels, if 2 or more, quantizes each channel of the input to
+	// that many evenly spaced levels before detection. Gradients become flat
+	// bands, which steadies the color strategy on shaded art and lets fewer
+	// distinct colors reach color reduction.
+	PosterizeLevels int
+
+	// RestoreSize renders an input shrunk by MaxDimension back at its
+	// original size: the zones found on the smaller image are scaled up
+	// and drawn over the full-size original.
+	RestoreSize bool
+
+	// Quantizer names the color reduction used when no palette is imposed:
+	// QuantizerMerge or one added with RegisterQuantizer.
+	// Default: "merge".
+	Quantizer string
+
+	// PaletteFromImage, if non-nil, is a reference image (e.g. a photo of
+	// the colorer's pencil set) whose dominant colors become the palette.
+	// Up to MaxColors colors are extracted and every zone is mapped to the
+	// closest one, instead of merging the drawing's own colors.
+	PaletteFromImage image.Image
+
+	// ImportedPalette, if non-empty, is the numbered palette of an earlier
+	// conversion (see LoadPalette). Every zone is mapped to the closest
+	// entry and keeps that entry's number, so corrected pages of a book
+	// match its original legend. It takes precedence over Palette,
+	// PaletteFromImage and MaxColors.
+	ImportedPalette []PaletteEntry
+
+	// Palette, if non-empty, is a fixed set of colors, such as the crayons
+	// the colorers actually own (see LoadPaletteColors). Every zone is
+	// mapped to the closest one, and the legend shows those exact colors,
+	// numbered by their position in Palette so a color keeps its number
+	// across drawings. It takes precedence over PaletteFromImage and
+	// MaxColors.
+	Palette []Color
+
+	// MaxZoneArea, if > 0, splits zones larger than this many pixels into
+	// sub-zones with faint divider lines, each labelled with the zone's
+	// number. Huge backgrounds are easier to paint evenly in sections.
+	MaxZoneArea int
+
+	// Connectivity is how filler pixels join into zones: 4 (the default,
+	// also used for 0) joins pixels sharing an edge, 8 also joins pixels
+	// touching at a corner. Use 8 when artwork has diagonal runs of filler
+	// pixels that should be one zone; with 8, delimiter lines one pixel
+	// thick that run diagonally no longer separate zones. Game-data
+	// outlines only trace the first edge-connected part of a zone.
+	Connectivity int
+
+	// TileHeight, if > 0, runs detection and the zone flood fill on
+	// horizontal bands of this many rows, with enough overlap that the
+	// result is unchanged, so their scratch buffers and flood-fill queue
+	// are bounded by a band rather than the image. The image, delimiter
+	// map and label map stay whole. With an automatic border tolerance,
+	// each band picks its own.
+	// Custom strategies (RegisterStrategy) still see the whole image.
+	TileHeight int
+
+	// MinZoneSize, if set, merges zones smaller than this into their
+	// largest neighboring zone before numbering, so the specks of
+	// anti-aliased scans do not each get an unreadable number.
+	MinZoneSize ZoneSize
+
+	// SkipBackground leaves the paper around the drawing blank: zones
+	// touching the image edge whose mean color is near-white (or mostly
+	// transparent) get no number, and their color no legend entry, instead
+	// of a giant "color this white" zone.
+	SkipBackground bool
+
+	// LegendCoverage annotates each legend entry with the percentage of the
+	// painted area that uses its color, e.g. "(12%)".
+	LegendCoverage bool
+
+	// LegendHex prints each legend entry's hex code, e.g. "#C84B3A",
+	// beside its swatch, for recoloring in an editor with exact values.
+	LegendHex bool
+
+	// LegendZoneCounts prints after each legend entry how many zones use
+	// its color, e.g. "× 3", so colorists can tick off zones as they go.
+	LegendZoneCounts bool
+
+	// LegendQR, if set, is encoded as a QR code in the bottom right corner
+	// of the legend, e.g. a link to the solution online, so printed sheets
+	// lead back to it. Up to 213 bytes; the solution and the vector
+	// outputs leave it off.
+	LegendQR string
+
+	// SeparateLegend leaves the legend off the coloring, so it keeps the
+	// drawing's aspect ratio for framing or engraving. Result.LegendImage
+	// renders the legend on its own; the solution keeps its legend.
+	SeparateLegend bool
+
+	// BackgroundColor fills the paper of the coloring: its zones, margins
+	// and legend area. The zero Color keeps white.
+	BackgroundColor Color
+
+	// TransparentBackground leaves the paper fully transparent instead,
+	// so the coloring can be composited onto a themed worksheet. PNG, WebP
+	// and SVG keep it; JPEG, PDF and TIFF output flatten it onto white.
+	TransparentBackground bool
+
+	// LineColor draws the zone borders of the coloring and the solution,
+	// e.g. a light gray for faint workbook outlines. The zero Color keeps
+	// black.
+	LineColor Color
+
+	// PatternFill hatches every zone with a black-and-white pattern unique to
+	// its color, shown in the legend, for monochrome printing and
+	// pattern-matching exercises. Numbers are still drawn.
+	PatternFill bool
+
+	// HintOpacity, if > 0, fills every zone with a faint version of its
+	// color instead of plain paper, as a hint for young children: the
+	// color mixed into the paper at this opacity, 0–1 (e.g. 0.15).
+	HintOpacity float64
+
+	// LineWidth, if > 0, redraws every zone border exactly this many pixels
+	// wide, so scans with uneven stroke widths print with even outlines.
+	// Lines are centered on the source strokes; strokes that do not
+	// separate two zones are dropped. The width scales with the output
+	// when it is upscaled. Default: 0 (copy the source strokes).
+	LineWidth int
+
+	// LargePrint renders for low-vision colorers: numbers at least
+	// LargePrintLabelSize pixels tall in black on white, thicker outlines and
+	// an enlarged legend. The output is upscaled (up to 4×) when the drawing
+	// is too small for numbers that size.
+	LargePrint bool
+
+	// EnsureLegible upscales the output (up to 4× in total with LargePrint)
+	// when zones are too thin for the numbers drawn in them, so tiny source
+	// images still produce readable colorings. Result.Scale reports the
+	// factor applied.
+	EnsureLegible bool
+
+	// OutputScale, if > 1, renders the coloring at this many times its
+	// resolution: zones are enlarged with nearest-neighbor sampling, so
+	// their edges stay sharp, and numbers, lines and the legend are drawn
+	// at the larger size rather than enlarged afterwards. Use it to print
+	// small source images at 300 DPI. The factor is lowered when the
+	// output would exceed 40 megapixels; Result.Scale includes it.
+	OutputScale int
+
+	// RotateLabels lets the number of a long, thin zone (such as a diagonal
+	// stripe) be drawn turned along the zone when it cannot fit upright.
+	RotateLabels bool
+
+	// MultiLabelFraction, if > 0, repeats the number of zones covering more
+	// than this fraction of the image (0–1): once per that fraction of the
+	// image, up to 9 times, at well-spread points. Colorists then do not
+	// lose track of a single small number on a big sky or background.
+	MultiLabelFraction float64
+
+	// LabelSet, if set, labels the zones and legend entries of palette
+	// number n with LabelSet[n-1] instead of the number, e.g. LetterLabels
+	// for early readers or SymbolLabels for cross-stitch charts. Numbers
+	// past its end stay digits. The built-in font draws letters, digits and
+	// the symbols of SymbolLabels; set Font for others.
+	LabelSet []string
+
+	// Solution also renders Result.Solution in the same pass: the answer key
+	// with every zone filled with its color and numbered, plus the legend.
+	Solution bool
+
+	// MinConfidence, if > 0, makes conversions fail with ErrLowConfidence
+	// when the detection confidence score (0–1) is below it.
+	MinConfidence float64
+
+	// Watermark, if non-nil, is stamped onto the finished output.
+	Watermark *Watermark
+
+	// Title, if non-nil, is a caption printed in a band above the drawing
+	// and the solution, e.g. a worksheet header. With SeparateLegend it
+	// heads the legend instead, so the drawing keeps its size.
+	Title *Title
+
 	// Font is the font renderer used to draw numbers on the output image.
 	// If nil, a built-in bitmap font is used.
 	Font FontRenderer
+
+	// Progress, if non-nil, is called as the conversion advances with the
+	// current stage (one of the Stage* constants) and how much of it is
+	// done, 0–100. Calls are serialized, percentages only increase within a
+	// stage, and every stage that runs ends at 100.
+	Progress func(stage string, percent int)
+
+	// Metrics, if non-nil, receives the duration of every stage and the
+	// zone and palette counts of the conversion.
+	Metrics Metrics
+
+	// OnDetected, if non-nil, is called with the delimiter map before zones
+	// are found. It may edit IsDelimiter in place, e.g. to erase specks or
+	// close gaps, but must not resize the map.
+	OnDetected func(dm *DetectionMap)
+
+	// OnZonesFound, if non-nil, is called with the zones before their
+	// colors are computed and returns the zones to keep. It may drop zones
+	// (their pixels then render blank and unnumbered) or move pixels between
+	// zones; no pixel may be in two zones. IDs are renumbered afterwards.
+	OnZonesFound func(zones []Zone) []Zone
+
+	// OnPaletteReduced, if non-nil, is called with the palette and the
+	// zone-to-entry assignment before rendering, and may edit both in
+	// place: recolor or renumber entries, or move zones between them.
+	// Numbers must stay positive and unique.
+	OnPaletteReduced func(p *Palette)
 }
 
+// Progress stage names, in pipeline order.
+const (
+	StageDetection = progress.Detection // finding delimiter pixels
+	StageZones     = progress.Zones     // flood-filling zones
+	StageColors    = progress.Colors    // averaging zone colors
+	StageReduction = progress.Reduction // reducing or mapping the palette
+	StageRender    = progress.Render    // drawing the coloring
+)
+
 // Color represents an RGBA color with 8-bit components.
 type Color struct {
 	R, G, B, A uint8
 }
 
+// DelimiterConfig is one strategy of Options.Delimiters.
+type DelimiterConfig struct {
+	// Strategy is StrategyBorder, StrategyColor or a registered strategy.
+	Strategy string
+
+	// BorderColor is the color of the delimiter lines (border only).
+	BorderColor Color
+
+	// Tolerance has the meaning of BorderDelimiterTolerance or
+	// ColorDelimiterTolerance, depending on Strategy.
+	Tolerance float64
+
+	// Radius is the neighborhood radius, as ColorDelimiterRadius (color
+	// only).
+	Radius int
+}
+
+// BorderColor is an outline color of the border strategy. Tolerance has
+// the meaning of Options.BorderDelimiterTolerance, including 0 for an
+// automatic tolerance.
+type BorderColor struct {
+	Color     Color
+	Tolerance float64
+}
+
+// ZoneSize is a zone area, either in pixels or as a percentage of the
+// image area. Set one of the two; the zero value is no size.
+type ZoneSize struct {
+	Pixels  int
+	Percent float64 // 0–100
+}
+
+// pixels returns the size in pixels for an image of total pixels. If both
+// fields are set, the larger size wins.
+func (s ZoneSize) pixels(total int) int {
+	return max(s.Pixels, int(math.Ceil(s.Percent/100*float64(total))))
+}
+
+// String formats the size as "20" pixels or "0.5%".
+func (s ZoneSize) String() string {
+	if s.Percent > 0 {
+		return strconv.FormatFloat(s.Percent, 'g', -1, 64) + "%"
+	}
+	return strconv.Itoa(s.Pixels)
+}
+
+// Watermark is a text or small image stamp blended onto the output, e.g. an
+// attribution line. If Image is set it takes precedence over Text.
+type Watermark struct {
+	Text  string
+	Image image.Image
+
+	// Position is one of the Watermark* constants. Default: bottom-right.
+	Position string
+
+	// Opacity is the stamp opacity, 0–1. 0 means the default of 0.5.
+	Opacity float64
+
+	// Size is the text height in pixels. 0 picks one from the output size.
+	Size int
+}
+
+// Title is a caption printed above the output, e.g. "Unit 3 – The Farm".
+// The built-in font draws letters, digits and common punctuation; set
+// Options.Font for other characters.
+type Title struct {
+	Text string
+
+	// Size is the text height in pixels. 0 picks one from the output width.
+	// The text shrinks if it is too wide for the page.
+	Size int
+
+	// Align is one of the Title* constants. Default: centered.
+	Align string
+}
+
 // FontRenderer is the interface for drawing text onto images.
 // Implement this to provide a custom font (e.g., TTF rendering).
 type FontRenderer interface {
@@ -83,6 +523,19 @@ type FontRenderer interface {
 	MeasureString(text string, size int) (width, height int)
 }
 
+// NewTTFFont returns a FontRenderer that draws with a TrueType or OpenType
+// font, for Options.Font. Numbers and legend text are anti-aliased instead
+// of the blocky 5×7 bitmap glyphs, which shows on large images. The size a
+// FontRenderer is asked for is the digit height, so the layout is the same
+// as with the bitmap font.
+func NewTTFFont(data []byte) (FontRenderer, error) {
+	f, err := renderer.NewTTFFont(data)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
 // DefaultOptions returns Options with sensible defaults.
 func DefaultOptions() Options {
 	return Options{
@@ -91,6 +544,7 @@ func DefaultOptions() Options {
 		BorderDelimiterTolerance: 10,
 		ColorDelimiterTolerance:  10,
 		MaxColors:                10,
+		Quantizer:                QuantizerMerge,
 	}
 }
 
@@ -103,48 +557,489 @@ func ParseHexColor(hex string) (Color, error) {
 	return Color{R: c.R, G: c.G, B: c.B, A: c.A}, nil
 }
 
+// ClosestNamedColor returns the CSS/X11 color keyword closest to c (in CIELAB
+// space) and the distance to it. Useful for labelling palette entries.
+func ClosestNamedColor(c Color) (name string, dist float64) {
+	return color.ClosestNamed(color.RGBA{R: c.R, G: c.G, B: c.B, A: c.A})
+}
+
 // LoadImage reads an image from disk. Supports PNG, JPEG, and WEBP.
 func LoadImage(path string) (image.Image, error) {
 	return imaging.Load(path)
 }
 
+// LoadImageFS reads an image from fsys, such as an embed.FS, a zip.Reader
+// or a testing/fstest.MapFS, so bundled assets need no temporary files.
+func LoadImageFS(fsys fs.FS, path string) (image.Image, error) {
+	return imaging.LoadFS(fsys, path)
+}
+
 // SavePNG writes an image to disk as PNG.
 func SavePNG(path string, img image.Image) error {
 	return imaging.SavePNG(path, img)
 }
 
+// Decode reads a PNG, JPEG or WEBP image from r, detecting the format from
+// its contents. Use it instead of LoadImage for uploads and in-memory data.
+func Decode(r io.Reader) (image.Image, error) {
+	return imaging.Decode(r)
+}
+
+// EncodePNG writes img to w as PNG, the in-memory counterpart of SavePNG.
+func EncodePNG(w io.Writer, img image.Image) error {
+	return imaging.EncodePNG(w, img, PNGOptions{})
+}
+
+// EncodePNGWithOptions is like EncodePNG with control over compression
+// level and interlacing.
+func EncodePNGWithOptions(w io.Writer, img image.Image, opts PNGOptions) error {
+	return imaging.EncodePNG(w, img, opts)
+}
+
+// PNGOptions tunes PNG encoding: compression level and interlacing. The
+// zero value matches SavePNG.
+type PNGOptions = imaging.PNGOptions
+
+// SavePNGWithOptions writes an image to disk as PNG with the given encoder
+// options.
+func SavePNGWithOptions(path string, img image.Image, opts PNGOptions) error {
+	return imaging.SavePNGWithOptions(path, img, opts)
+}
+
+// DefaultJPEGQuality is the JPEG quality SaveJPEG and EncodeJPEG use for
+// quality 0.
+const DefaultJPEGQuality = imaging.DefaultJPEGQuality
+
+// SaveJPEG writes an image to disk as JPEG at quality 1–100 (0 means
+// DefaultJPEGQuality). Alpha is dropped.
+func SaveJPEG(path string, img image.Image, quality int) error {
+	return imaging.SaveJPEG(path, img, quality)
+}
+
+// EncodeJPEG writes img to w as JPEG, the in-memory counterpart of SaveJPEG.
+func EncodeJPEG(w io.Writer, img image.Image, quality int) error {
+	return imaging.EncodeJPEG(w, img, quality)
+}
+
+// SaveWebP writes an image to disk as lossless WebP, up to 16384 pixels on
+// a side.
+func SaveWebP(path string, img image.Image) error {
+	return imaging.SaveWebP(path, img)
+}
+
+// EncodeWebP writes img to w as lossless WebP, the in-memory counterpart of
+// SaveWebP.
+func EncodeWebP(w io.Writer, img image.Image) error {
+	return imaging.EncodeWebP(w, img)
+}
+
+// SaveGameData writes game data to path as JSON.
+func SaveGameData(path string, gd *GameData) error {
+	f, err := os.Create(imaging.ExpandPath(path))
+	if err != nil {
+		return fmt.Errorf("creating game data file: %w", err)
+	}
+	defer f.Close()
+	return gd.Encode(f)
+}
+
+// FeatureCollection is the zones of a coloring as GeoJSON polygons with
+// their palette numbers, from Result.GeoJSON. See TECH.md for the schema.
+type FeatureCollection = export.FeatureCollection
+
+// GeoJSON returns the zone outlines and holes of the conversion as a
+// GeoJSON feature collection, one Polygon feature per zone with its
+// palette number and color, for apps that draw colorings natively.
+func (r *Result) GeoJSON() *FeatureCollection {
+	return r.GameData().GeoJSON()
+}
+
+// SaveGeoJSON writes a feature collection to path as JSON.
+func SaveGeoJSON(path string, fc *FeatureCollection) error {
+	f, err := os.Create(imaging.ExpandPath(path))
+	if err != nil {
+		return fmt.Errorf("creating GeoJSON file: %w", err)
+	}
+	defer f.Close()
+	return fc.Encode(f)
+}
+
 // Convert takes an input image and produces a magic coloring image.
 // The returned image has the coloring zones with numbers and a legend
 // appended at the bottom.
-func Convert(img image.Image, opts Options) (*image.RGBA, error) {
+//
+// opts may be an Options struct, With* options, or both; see Option.
+// Without options, DefaultOptions is used.
+func Convert(img image.Image, opts ...Option) (*image.RGBA, error) {
+	return ConvertContext(context.Background(), img, opts...)
+}
+
+// ConvertContext is like Convert but can be cancelled: detection, flood
+// fill, color reduction and rendering check ctx as they go, and the
+// conversion returns ctx.Err() soon after ctx is done. Use it to abort
+// conversions of huge scans, e.g. when a web client disconnects.
+func ConvertContext(ctx context.Context, img image.Image, opts ...Option) (*image.RGBA, error) {
+	res, err := ConvertDetailedContext(ctx, img, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return res.Image, nil
+}
+
+// GameData is the JSON-serializable description of a coloring for
+// interactive tap-to-fill apps: palette, zone outlines, label points,
+// numbers and adjacency. See TECH.md for the schema.
+type GameData = export.GameData
+
+// ErrLowConfidence is returned (wrapped) when a conversion's detection
+// confidence is below Options.MinConfidence.
+var ErrLowConfidence = errors.New("detection confidence too low")
+
+// Confidence scores a border detection: Score combines the Density,
+// Continuity and Closure sub-scores, each in [0, 1] with higher being more
+// trustworthy. See TECH.md for how they are computed.
+type Confidence = quality.Report
+
+// DetectionConfidence runs only the detection and zoning stages and scores
+// them, which is much cheaper than a full conversion.
+func DetectionConfidence(img image.Image, opts Options) (Confidence, error) {
+	if img == nil {
+		return Confidence{}, fmt.Errorf("input image is nil")
+	}
+	opts, err := buildOptions([]Option{opts})
+	if err != nil {
+		return Confidence{}, err
+	}
+	_, img = preprocess(img, opts)
+	delim, err := delimiterFromOpts(opts)
+	if err != nil {
+		return Confidence{}, err
+	}
+	dm, err := detection.DetectTiled(context.Background(), delim, img, opts.TileHeight)
+	if err != nil {
+		return Confidence{}, err
+	}
+	zones, _, _ := zone.FindZonesTiled(context.Background(), dm, zone.Connectivity(opts.Connectivity), opts.TileHeight)
+	return quality.Assess(dm, zones), nil
+}
+
+// VisualizeDetection runs only the detection stage and returns the source
+// image with every delimiter pixel painted magenta, for tuning the strategy
+// and tolerances without running and inspecting a full conversion. The
+// source is shown as detection sees it, after AutoCrop, MaxDimension,
+// Denoise, BlurSigma and PosterizeLevels.
+func VisualizeDetection(img image.Image, opts Options) (*image.RGBA, error) {
 	if img == nil {
 		return nil, fmt.Errorf("input image is nil")
 	}
+	opts, err := buildOptions([]Option{opts})
+	if err != nil {
+		return nil, err
+	}
+	_, img = preprocess(img, opts)
+	delim, err := delimiterFromOpts(opts)
+	if err != nil {
+		return nil, err
+	}
+	dm, err := detection.DetectTiled(context.Background(), delim, img, opts.TileHeight)
+	if err != nil {
+		return nil, err
+	}
+	return renderer.HighlightDelimiters(img, dm, stdcolor.RGBA{R: 255, B: 255, A: 255}), nil
+}
 
-	// Build the appropriate delimiter strategy
-	delim := delimiterFromOpts(opts)
+// Result is a finished conversion: the rendered image plus the zone data it
+// was built from. Legend, Zones and Delimiters expose that data, and the
+// exporters (GameData, Metadata, WriteSVG, ...) build on it.
+type Result struct {
+	// Image is the rendered coloring, identical to what Convert returns.
+	Image *image.RGBA
 
-	// Detect delimiter pixels
-	dm := delim.Detect(img)
+	// Confidence scores how trustworthy the border detection looks.
+	Confidence Confidence
 
-	// Find zones via flood-fill
-	zones, labels := zone.FindZones(dm)
+	// Scale is the factor by which the drawing was upscaled for
+	// Options.LargePrint, Options.EnsureLegible and Options.OutputScale
+	// together; 1 when it was not.
+	Scale int
 
-	// Compute per-zone aggregated colors
-	zoneColors := zone.ComputeZoneColors(zones, img)
+	// Solution is the numbered answer key when Options.Solution is set,
+	// nil otherwise.
+	Solution *image.RGBA
 
-	// Reduce colors if necessary
-	cm := aggregation.ReduceColors(zoneColors.Colors, opts.MaxColors)
+	a    *analysis
+	opts Options
+	rcfg renderer.Config
+	font FontRenderer
+
+	titleH int        // height of the title band above the drawing
+	pool   *pool.Pool // of the Converter that made it, for Release
+}
+
+// GameData builds the tap-to-fill description of the conversion.
+func (r *Result) GameData() *GameData {
+	a := r.a
+	b := a.img.Bounds()
+	return export.BuildGameData(a.zones, a.labels, b.Dx(), b.Dy(), a.cm)
+}
+
+// ConvertDetailed is like Convert but also keeps the intermediate zone data
+// so the result can be exported in other formats.
+func ConvertDetailed(img image.Image, opts ...Option) (*Result, error) {
+	return ConvertDetailedContext(context.Background(), img, opts...)
+}
+
+// ConvertDetailedContext is like ConvertDetailed but can be cancelled
+// through ctx, as with ConvertContext.
+func ConvertDetailedContext(ctx context.Context, img image.Image, options ...Option) (*Result, error) {
+	if img == nil {
+		return nil, fmt.Errorf("input image is nil")
+	}
+	opts, err := buildOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	ctx = progress.WithFunc(ctx, opts.Progress)
+
+	src, img := detectionCacheFrom(ctx).preprocess(img, opts)
+
+	a, err := analyze(ctx, img, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.MinConfidence > 0 && a.confidence.Score < opts.MinConfidence {
+		return nil, fmt.Errorf("%w: %.2f is below the minimum of %.2f", ErrLowConfidence, a.confidence.Score, opts.MinConfidence)
+	}
+	if opts.RestoreSize && img != src {
+		a = a.resampleTo(src)
+	}
+
+	timer := newStageTimer(opts.Metrics)
 
 	// Resolve font
 	font := resolveFont(opts.Font)
 
 	// Render output image
 	rcfg := renderer.DefaultConfig()
-	scaleLegendConfig(&rcfg, img.Bounds())
-	output := renderer.Render(img, dm, zones, labels, cm, font, rcfg)
+	scale := 1
+	minLabel := 0
+	if opts.LargePrint {
+		scale = largePrintScale(a)
+		minLabel = LargePrintLabelSize
+		a = a.upscale(scale)
+	}
+	if opts.EnsureLegible {
+		k := legibilityScale(a, minLabel, maxUpscale/scale)
+		a = a.upscale(k)
+		scale *= k
+	}
+	a = a.pad(opts.OutputMargin * scale)
+	scaleLegendConfig(&rcfg, a.img.Bounds())
+	if opts.LargePrint {
+		applyLargePrint(&rcfg)
+	}
+	if k := outputScale(a, opts.OutputScale); k > 1 {
+		a = a.upscale(k)
+		scaleRenderConfig(&rcfg, a, k)
+		scale *= k
+	}
+	rcfg.LegendCoverage = opts.LegendCoverage
+	rcfg.LegendHex = opts.LegendHex
+	rcfg.LegendZoneCounts = opts.LegendZoneCounts
+	rcfg.NoLegend = opts.SeparateLegend
+	if opts.LegendQR != "" {
+		if rcfg.LegendQR, err = qr.Encode(opts.LegendQR); err != nil {
+			return nil, fmt.Errorf("legend QR code: %w", err)
+		}
+	}
+	if c := opts.BackgroundColor; c != (Color{}) {
+		rcfg.Background = stdcolor.RGBA{R: c.R, G: c.G, B: c.B, A: c.A}
+	}
+	if opts.TransparentBackground {
+		rcfg.Background = stdcolor.RGBA{}
+	}
+	if c := opts.LineColor; c != (Color{}) {
+		rcfg.LineColor = stdcolor.RGBA{R: c.R, G: c.G, B: c.B, A: c.A}
+	}
+	rcfg.PatternFill = opts.PatternFill
+	rcfg.HintOpacity = opts.HintOpacity
+	rcfg.RotateLabels = opts.RotateLabels
+	rcfg.LineWidth = opts.LineWidth * scale
+	rcfg.Labels = opts.LabelSet
+	if opts.MultiLabelFraction > 0 {
+		b := a.img.Bounds()
+		rcfg.MultiLabelArea = max(1, int(opts.MultiLabelFraction*float64(b.Dx()*b.Dy())))
+	}
+	output, err := renderer.RenderContext(ctx, a.img, a.dm, a.zones, a.labels, a.cm, font, rcfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var solution *image.RGBA
+	if opts.Solution {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		solution = renderer.RenderSolution(a.img, a.dm, a.zones, a.labels, a.cm, font, rcfg)
+	}
 
-	return output, nil
+	titleH := 0
+	if opts.Title != nil && !opts.SeparateLegend {
+		rt := titleConfig(opts.Title, rcfg, scale)
+		if titled := renderer.AddTitle(output, rt, font, rcfg.Background); titled != output {
+			titleH = titled.Bounds().Dy() - output.Bounds().Dy()
+			pool.From(ctx).PutBytes(output.Pix)
+			output = titled
+		}
+		if solution != nil {
+			solution = renderer.AddTitle(solution, rt, font, rcfg.Background)
+		}
+	}
+
+	if wm := opts.Watermark; wm != nil {
+		rwm := renderer.Watermark{
+			Text:     wm.Text,
+			Image:    wm.Image,
+			Position: wm.Position,
+			Opacity:  wm.Opacity,
+			Size:     wm.Size,
+		}
+		renderer.DrawWatermark(output, rwm, font)
+		if solution != nil {
+			renderer.DrawWatermark(solution, rwm, font)
+		}
+	}
+	timer.done(StageRender)
+	if m := opts.Metrics; m != nil {
+		m.Converted(len(a.zones), len(a.cm.Entries))
+	}
+
+	return &Result{Image: output, Confidence: a.confidence, Scale: scale, Solution: solution, a: a, opts: opts, rcfg: rcfg, font: opts.Font, titleH: titleH, pool: pool.From(ctx)}, nil
+}
+
+// analysis holds the output of the detection, zoning and color stages,
+// shared by the renderer and the exporters.
+type analysis struct {
+	img    image.Image
+	dm     *detection.Map
+	zones  []zone.Zone
+	labels []int
+	cm     *aggregation.ColorMap
+
+	confidence Confidence
+}
+
+// analyze runs every stage before rendering. It returns ctx.Err() if ctx is
+// cancelled before it finishes.
+func analyze(ctx context.Context, img image.Image, opts Options) (*analysis, error) {
+	timer := newStageTimer(opts.Metrics)
+
+	// Detect delimiter pixels, unless a Session already did
+	cache := detectionCacheFrom(ctx)
+	dm := cache.delimiters(opts)
+	if dm == nil {
+		delim, err := delimiterFromOpts(opts)
+		if err != nil {
+			return nil, err
+		}
+		if dm, err = detection.DetectTiled(ctx, delim, img, opts.TileHeight); err != nil {
+			return nil, err
+		}
+		if err := runDetectedHook(opts, dm); err != nil {
+			return nil, err
+		}
+		cache.keep(opts, dm)
+	}
+	timer.done(StageDetection)
+
+	// Find zones via flood-fill
+	zones, labels, err := zone.FindZonesTiled(ctx, dm, zone.Connectivity(opts.Connectivity), opts.TileHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	// Score the detection before zones are post-processed
+	confidence := quality.Assess(dm, zones)
+
+	// Merge specks into their neighbors
+	if minArea := opts.MinZoneSize.pixels(dm.Width * dm.Height); minArea > 1 {
+		zones, labels = zone.MergeSmall(zones, labels, dm.Width, dm.Height, minArea)
+	}
+
+	// Leave the paper around the drawing unnumbered
+	if opts.SkipBackground {
+		zones, labels = skipBackground(img, zones, labels, dm.Width, dm.Height)
+	}
+
+	zones, labels, err = runZonesHook(opts, dm, zones, labels)
+	if err != nil {
+		return nil, err
+	}
+	timer.done(StageZones)
+
+	// Compute per-zone aggregated colors
+	zoneColors, err := zone.ComputeZoneColorsContext(ctx, zones, img)
+	if err != nil {
+		return nil, err
+	}
+	timer.done(StageColors)
+
+	// Reduce colors, or map them onto the imported, fixed or reference
+	// palette
+	metric := colorMetric(opts)
+	var cm *aggregation.ColorMap
+	if len(opts.ImportedPalette) > 0 {
+		entries := make([]aggregation.ColorEntry, len(opts.ImportedPalette))
+		for i, p := range opts.ImportedPalette {
+			entries[i] = aggregation.ColorEntry{
+				Number: p.Number,
+				Color:  color.RGBA{R: p.Color.R, G: p.Color.G, B: p.Color.B, A: p.Color.A},
+			}
+		}
+		cm = aggregation.MapToEntries(zoneColors.Colors, entries, metric)
+	} else if len(opts.Palette) > 0 {
+		entries := make([]aggregation.ColorEntry, len(opts.Palette))
+		for i, c := range opts.Palette {
+			entries[i] = aggregation.ColorEntry{
+				Number: i + 1,
+				Color:  color.RGBA{R: c.R, G: c.G, B: c.B, A: c.A},
+			}
+		}
+		cm = aggregation.MapToEntries(zoneColors.Colors, entries, metric)
+	} else if opts.PaletteFromImage != nil {
+		palette := aggregation.ExtractPalette(opts.PaletteFromImage, opts.MaxColors, metric)
+		if len(palette) == 0 {
+			return nil, errNoPaletteColors
+		}
+		cm = aggregation.MapToPalette(zoneColors.Colors, palette, metric)
+	} else {
+		areas := make([]int, len(zones))
+		for i := range zones {
+			areas[i] = zones[i].Area()
+		}
+		cm, err = reduceColors(ctx, zoneColors.Colors, areas, opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+	progress.Report(ctx, progress.Reduction, 1, 1)
+	if cm, err = runPaletteHook(opts, cm); err != nil {
+		return nil, err
+	}
+
+	// Split oversized zones; sub-zones inherit their parent's color
+	if opts.MaxZoneArea > 0 {
+		var parent []int
+		zones, labels, parent = zone.Subdivide(zones, labels, dm.Width, opts.MaxZoneArea)
+		zoneMap := make([]int, len(zones))
+		for i, p := range parent {
+			zoneMap[i] = cm.ZoneMap[p]
+		}
+		cm.ZoneMap = zoneMap
+	}
+	timer.done(StageReduction)
+
+	return &analysis{img: img, dm: dm, zones: zones, labels: labels, cm: cm, confidence: confidence}, nil
 }
 
 // ConvertFile is a convenience that loads an image from inPath, converts it,
@@ -160,13 +1055,44 @@ func ConvertFile(inPath, outPath string, opts Options) error {
 		return fmt.Errorf("converting: %w", err)
 	}
 
-	if err := SavePNG(outPath, result); err != nil {
+	f, err := os.Create(imaging.ExpandPath(outPath))
+	if err != nil {
+		return fmt.Errorf("saving output: %w", err)
+	}
+	cw := &countingWriter{w: f}
+	err = EncodePNG(cw, result)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
 		return fmt.Errorf("saving output: %w", err)
 	}
+	reportOutput(opts.Metrics, "png", cw.n)
 
 	return nil
 }
 
+// ConvertBytes decodes an encoded image (PNG, JPEG or WebP), converts it and
+// returns the coloring as PNG bytes along with its legend. It touches neither
+// the filesystem nor any OS facility, so it is the entry point for builds
+// such as GOOS=js GOARCH=wasm where those are unavailable.
+func ConvertBytes(input []byte, opts ...Option) ([]byte, []LegendEntry, error) {
+	img, err := Decode(bytes.NewReader(input))
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := ConvertDetailed(img, opts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("converting: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := EncodePNG(&buf, result.Image); err != nil {
+		return nil, nil, fmt.Errorf("encoding output: %w", err)
+	}
+	reportOutput(result.opts.Metrics, "png", buf.Len())
+	return buf.Bytes(), result.Legend(), nil
+}
+
 // resolveFont returns a renderer.FontRenderer, using the built-in bitmap font
 // if the user did not provide one.
 func resolveFont(f FontRenderer) renderer.FontRenderer {
@@ -189,21 +1115,36 @@ func (a *fontAdapter) MeasureString(text string, size int) (int, int) {
 	return a.f.MeasureString(text, size)
 }
 
-// delimiterFromOpts builds the appropriate Delimiter from public Options.
-func delimiterFromOpts(opts Options) detection.Delimiter {
-	if opts.DelimiterStrategy == StrategyBorder {
-		return &detection.BorderDelimiter{
-			Color: color.RGBA{
-				R: opts.BorderDelimiterColor.R,
-				G: opts.BorderDelimiterColor.G,
-				B: opts.BorderDelimiterColor.B,
-				A: opts.BorderDelimiterColor.A,
-			},
-			TolerancePct: opts.BorderDelimiterTolerance,
-		}
+// extraBorderColors converts Options.ExtraBorderColors for the border
+// delimiter.
+func extraBorderColors(colors []BorderColor) []detection.BorderColor {
+	var out []detection.BorderColor
+	for _, c := range colors {
+		out = append(out, detection.BorderColor{
+			Color:        color.RGBA{R: c.Color.R, G: c.Color.G, B: c.Color.B, A: c.Color.A},
+			TolerancePct: c.Tolerance,
+			Auto:         c.Tolerance == 0,
+		})
+	}
+	return out
+}
+
+// colorMetric maps Options.ColorMetric to the internal metric.
+func colorMetric(opts Options) color.Metric {
+	if opts.ColorMetric == MetricCIEDE2000 {
+		return color.MetricCIEDE2000
 	}
-	return &detection.ColorDelimiter{
-		TolerancePct: opts.ColorDelimiterTolerance,
+	return color.MetricEuclidean
+}
+
+// titleConfig lays out t like the legend: aligned to the legend margins,
+// and scaled with the drawing.
+func titleConfig(t *Title, rcfg renderer.Config, scale int) renderer.Title {
+	return renderer.Title{
+		Text:   t.Text,
+		Size:   t.Size * scale,
+		Align:  t.Align,
+		Margin: rcfg.LegendMargin,
 	}
 }
 
@@ -0,0 +1,225 @@
+package macoma
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/maax3v3/macoma/v2/internal/color"
+	"github.com/maax3v3/macoma/v2/internal/detection"
+	"github.com/maax3v3/macoma/v2/internal/export"
+	"github.com/maax3v3/macoma/v2/internal/imaging"
+)
+
+// Metadata is the JSON sidecar of a rendered coloring: the palette (number,
+// hex and RGB color, zone count and coverage per color), the image
+// dimensions and the options used. See TECH.md for the schema.
+type Metadata = export.Metadata
+
+// metadataOptions records the settings of Options that shape the output.
+// Images and fonts are only noted as present.
+type metadataOptions struct {
+	DelimiterStrategy        string              `json:"delimiter_strategy"`
+	Delimiters               []metadataDelimiter `json:"delimiters"`
+	DelimiterCombine         string              `json:"delimiter_combine"`
+	BorderDelimiterColor     string              `json:"border_delimiter_color"`
+	BorderDelimiterTolerance float64             `json:"border_delimiter_tolerance"`
+	BorderDelimiterLAB       bool                `json:"border_delimiter_lab"`
+	ExtraBorderColors        []string            `json:"extra_border_colors"` // "#rrggbb:tolerance"
+	ColorDelimiterTolerance  float64             `json:"color_delimiter_tolerance"`
+	ColorDelimiterRadius     int                 `json:"color_delimiter_radius"`
+	GridCells                int                 `json:"grid_cells"`
+	ColorMetric              string              `json:"color_metric"`
+	MaxColors                int                 `json:"max_colors"`
+	Quantizer                string              `json:"quantizer"`
+	AutoCrop                 bool                `json:"auto_crop"`
+	OutputMargin             int                 `json:"output_margin"`
+	MaxDimension             int                 `json:"max_dimension"`
+	RestoreSize              bool                `json:"restore_size"`
+	Denoise                  int                 `json:"denoise"`
+	BlurSigma                float64             `json:"blur_sigma"`
+	PosterizeLevels          int                 `json:"posterize_levels"`
+	MaxZoneArea              int                 `json:"max_zone_area"`
+	MinZoneSize              string              `json:"min_zone_size"`
+	SkipBackground           bool                `json:"skip_background"`
+	Connectivity             int                 `json:"connectivity"`
+	TileHeight               int                 `json:"tile_height"`
+	PaletteFromImage         bool                `json:"palette_from_image"`
+	ImportedPalette          bool                `json:"imported_palette"`
+	FixedPalette             bool                `json:"fixed_palette"`
+	LegendCoverage           bool                `json:"legend_coverage"`
+	LegendHex                bool                `json:"legend_hex"`
+	LegendZoneCounts         bool                `json:"legend_zone_counts"`
+	LegendQR                 string              `json:"legend_qr,omitempty"`
+	SeparateLegend           bool                `json:"separate_legend"`
+	BackgroundColor          string              `json:"background_color"`
+	TransparentBackground    bool                `json:"transparent_background"`
+	LineColor                string              `json:"line_color"`
+	PatternFill              bool                `json:"pattern_fill"`
+	HintOpacity              float64             `json:"hint_opacity"`
+	LineWidth                int                 `json:"line_width"`
+	LargePrint               bool                `json:"large_print"`
+	EnsureLegible            bool                `json:"ensure_legible"`
+	OutputScale              int                 `json:"output_scale"`
+	RotateLabels             bool                `json:"rotate_labels"`
+	MultiLabelFraction       float64             `json:"multi_label_fraction"`
+	Solution                 bool                `json:"solution"`
+	MinConfidence            float64             `json:"min_confidence"`
+	Watermark                bool                `json:"watermark"`
+	Title                    string              `json:"title"`
+	CustomFont               bool                `json:"custom_font"`
+	Scale                    int                 `json:"scale"` // upscale factor actually applied
+}
+
+// metadataDelimiter records one entry of Options.Delimiters.
+type metadataDelimiter struct {
+	Strategy    string  `json:"strategy"`
+	BorderColor string  `json:"border_color,omitempty"`
+	Tolerance   float64 `json:"tolerance"`
+	Radius      int     `json:"radius,omitempty"`
+}
+
+// Metadata describes the rendered coloring for apps that read its legend
+// programmatically instead of parsing the legend strip.
+func (r *Result) Metadata() *Metadata {
+	o := r.opts
+	bc := color.RGBA{R: o.BorderDelimiterColor.R, G: o.BorderDelimiterColor.G, B: o.BorderDelimiterColor.B, A: o.BorderDelimiterColor.A}
+	b := r.Image.Bounds()
+	md := export.BuildMetadata(r.a.zones, r.a.cm, b.Dx(), b.Dy(), r.titleH+r.a.img.Bounds().Dy(), metadataOptions{
+		DelimiterStrategy:        o.DelimiterStrategy,
+		Delimiters:               metadataDelimiters(o.Delimiters),
+		DelimiterCombine:         combineName(o),
+		BorderDelimiterColor:     bc.Hex(),
+		BorderDelimiterTolerance: o.BorderDelimiterTolerance,
+		BorderDelimiterLAB:       o.BorderDelimiterLAB,
+		ExtraBorderColors:        extraBorderNames(o.ExtraBorderColors),
+		ColorDelimiterTolerance:  o.ColorDelimiterTolerance,
+		ColorDelimiterRadius:     colorRadius(o.ColorDelimiterRadius),
+		GridCells:                gridCells(o),
+		ColorMetric:              metricName(o.ColorMetric),
+		MaxColors:                o.MaxColors,
+		Quantizer:                quantizerName(o.Quantizer),
+		AutoCrop:                 o.AutoCrop,
+		OutputMargin:             o.OutputMargin,
+		MaxDimension:             o.MaxDimension,
+		RestoreSize:              o.RestoreSize,
+		Denoise:                  o.Denoise,
+		BlurSigma:                o.BlurSigma,
+		PosterizeLevels:          o.PosterizeLevels,
+		MaxZoneArea:              o.MaxZoneArea,
+		MinZoneSize:              o.MinZoneSize.String(),
+		SkipBackground:           o.SkipBackground,
+		Connectivity:             max(o.Connectivity, 4),
+		TileHeight:               o.TileHeight,
+		PaletteFromImage:         o.PaletteFromImage != nil,
+		ImportedPalette:          len(o.ImportedPalette) > 0,
+		FixedPalette:             len(o.Palette) > 0,
+		LegendCoverage:           o.LegendCoverage,
+		LegendHex:                o.LegendHex,
+		LegendZoneCounts:         o.LegendZoneCounts,
+		LegendQR:                 o.LegendQR,
+		SeparateLegend:           o.SeparateLegend,
+		BackgroundColor:          color.FromStdColor(r.rcfg.Background).Hex(),
+		TransparentBackground:    o.TransparentBackground,
+		LineColor:                color.FromStdColor(r.rcfg.LineColor).Hex(),
+		PatternFill:              o.PatternFill,
+		HintOpacity:              o.HintOpacity,
+		LineWidth:                o.LineWidth,
+		LargePrint:               o.LargePrint,
+		EnsureLegible:            o.EnsureLegible,
+		OutputScale:              o.OutputScale,
+		RotateLabels:             o.RotateLabels,
+		MultiLabelFraction:       o.MultiLabelFraction,
+		Solution:                 o.Solution,
+		MinConfidence:            o.MinConfidence,
+		Watermark:                o.Watermark != nil,
+		Title:                    titleText(o.Title),
+		CustomFont:               o.Font != nil,
+		Scale:                    r.Scale,
+	})
+	if len(o.LabelSet) > 0 {
+		for i := range md.Palette {
+			md.Palette[i].Label = r.rcfg.EntryLabel(md.Palette[i].Number)
+		}
+	}
+	return md
+}
+
+// titleText returns the text of t, or "" without a title.
+func titleText(t *Title) string {
+	if t == nil {
+		return ""
+	}
+	return t.Text
+}
+
+// quantizerName reports the quantizer in effect for Options.Quantizer.
+func quantizerName(name string) string {
+	if name == "" {
+		return QuantizerMerge
+	}
+	return name
+}
+
+// metadataDelimiters converts Options.Delimiters for the metadata.
+func metadataDelimiters(configs []DelimiterConfig) []metadataDelimiter {
+	var out []metadataDelimiter
+	for _, dc := range configs {
+		md := metadataDelimiter{Strategy: dc.Strategy, Tolerance: dc.Tolerance}
+		if dc.Strategy == StrategyBorder {
+			md.BorderColor = color.RGBA{R: dc.BorderColor.R, G: dc.BorderColor.G, B: dc.BorderColor.B, A: dc.BorderColor.A}.Hex()
+		} else {
+			md.Radius = colorRadius(dc.Radius)
+		}
+		out = append(out, md)
+	}
+	return out
+}
+
+// combineName returns the combine mode of a composite detection, or ""
+// when a single strategy is used.
+func combineName(o Options) string {
+	switch {
+	case len(o.Delimiters) == 0:
+		return ""
+	case o.DelimiterCombine == "":
+		return CombineUnion
+	}
+	return o.DelimiterCombine
+}
+
+// extraBorderNames formats extra border colors as "#rrggbb:tolerance".
+func extraBorderNames(colors []BorderColor) []string {
+	var out []string
+	for _, c := range colors {
+		hex := color.RGBA{R: c.Color.R, G: c.Color.G, B: c.Color.B, A: c.Color.A}.Hex()
+		out = append(out, fmt.Sprintf("%s:%g", hex, c.Tolerance))
+	}
+	return out
+}
+
+// colorRadius returns the color strategy radius, defaulting to
+// detection.DefaultColorRadius.
+func colorRadius(r int) int {
+	if r <= 0 {
+		return detection.DefaultColorRadius
+	}
+	return r
+}
+
+// metricName returns the color metric name, defaulting to MetricEuclidean.
+func metricName(m string) string {
+	if m == "" {
+		return MetricEuclidean
+	}
+	return m
+}
+
+// SaveMetadata writes metadata to path as indented JSON.
+func SaveMetadata(path string, md *Metadata) error {
+	f, err := os.Create(imaging.ExpandPath(path))
+	if err != nil {
+		return fmt.Errorf("creating metadata file: %w", err)
+	}
+	defer f.Close()
+	return md.Encode(f)
+}
@@ -0,0 +1,70 @@
+package macoma
+
+import (
+	"io"
+	"time"
+)
+
+// Metrics receives measurements of conversions through Options.Metrics,
+// for monitoring macoma as a service. One Metrics is typically shared by
+// concurrent conversions, so implementations must be safe for concurrent
+// use.
+type Metrics interface {
+	// StageDuration reports how long a stage (one of the Stage* constants)
+	// of one conversion took.
+	StageDuration(stage string, d time.Duration)
+
+	// Converted reports a finished conversion with its number of zones and
+	// palette entries.
+	Converted(zones, colors int)
+
+	// OutputBytes reports the size of an encoded output, with format such
+	// as "png" or "svg". ConvertBytes and ConvertFile report their output;
+	// code encoding a Result itself reports it as it sees fit.
+	OutputBytes(format string, n int)
+}
+
+// stageTimer reports the durations of consecutive stages to a Metrics. It
+// does nothing when the Metrics is nil.
+type stageTimer struct {
+	m     Metrics
+	start time.Time
+}
+
+func newStageTimer(m Metrics) *stageTimer {
+	t := &stageTimer{m: m}
+	if m != nil {
+		t.start = time.Now()
+	}
+	return t
+}
+
+// done reports the time since the previous stage ended, or since the timer
+// was created, as the duration of stage.
+func (t *stageTimer) done(stage string) {
+	if t.m == nil {
+		return
+	}
+	now := time.Now()
+	t.m.StageDuration(stage, now.Sub(t.start))
+	t.start = now
+}
+
+// reportOutput reports n encoded bytes of format to m, if any.
+func reportOutput(m Metrics, format string, n int) {
+	if m != nil {
+		m.OutputBytes(format, n)
+	}
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += n
+	return n, err
+}
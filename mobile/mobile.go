@@ -0,0 +1,141 @@
+// Package mobile is a gomobile-friendly wrapper around macoma for on-device
+// conversion on Android and iOS:
+//
+//	gomobile bind -target=android github.com/maax3v3/macoma/v2/mobile
+//	gomobile bind -target=ios github.com/maax3v3/macoma/v2/mobile
+//
+// Its surface is restricted to types gomobile can bind: images travel as
+// encoded bytes (PNG, JPEG or WebP in, PNG out) and options are a struct of
+// primitives, with colors as hex strings.
+package mobile
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+
+	"github.com/maax3v3/macoma/v2"
+)
+
+// Options mirrors macoma.Options using bindable field types. Create it with
+// NewOptions to start from the library defaults; in a zero Options, the
+// fields without a meaning of their own at zero take the defaults too.
+type Options struct {
+	// DelimiterStrategy is "color", "border" or a registered strategy;
+	// empty means the default.
+	DelimiterStrategy string
+	// BorderDelimiterColor is a hex color such as "#000" (border strategy);
+	// empty means the default.
+	BorderDelimiterColor string
+	// BorderDelimiterTolerance is a percentage, 0-100; 0 picks it per image.
+	BorderDelimiterTolerance float64
+	// ColorDelimiterTolerance is a percentage, 0-100; 0 means the default.
+	ColorDelimiterTolerance float64
+	// MaxColors caps the palette size; 0 means unlimited.
+	MaxColors      int
+	LegendCoverage bool
+}
+
+// NewOptions returns Options holding the library defaults.
+func NewOptions() *Options {
+	d := macoma.DefaultOptions()
+	return &Options{
+		DelimiterStrategy:        d.DelimiterStrategy,
+		BorderDelimiterColor:     "#000000",
+		BorderDelimiterTolerance: d.BorderDelimiterTolerance,
+		ColorDelimiterTolerance:  d.ColorDelimiterTolerance,
+		MaxColors:                d.MaxColors,
+		LegendCoverage:           d.LegendCoverage,
+	}
+}
+
+// Conversion is the output of ConvertWithGameData.
+type Conversion struct {
+	PNG      []byte // the rendered coloring
+	GameData []byte // tap-to-fill zone description as JSON
+}
+
+// Convert decodes an encoded image, converts it and returns the coloring as
+// PNG bytes. A nil opts uses the defaults.
+func Convert(input []byte, opts *Options) ([]byte, error) {
+	res, err := convert(input, opts)
+	if err != nil {
+		return nil, err
+	}
+	return encodePNG(res.Image)
+}
+
+// ConvertWithGameData is like Convert but also returns the game-data JSON
+// used by tap-to-fill apps.
+func ConvertWithGameData(input []byte, opts *Options) (*Conversion, error) {
+	res, err := convert(input, opts)
+	if err != nil {
+		return nil, err
+	}
+	pngData, err := encodePNG(res.Image)
+	if err != nil {
+		return nil, err
+	}
+	var gd bytes.Buffer
+	if err := res.GameData().Encode(&gd); err != nil {
+		return nil, err
+	}
+	return &Conversion{PNG: pngData, GameData: gd.Bytes()}, nil
+}
+
+func convert(input []byte, opts *Options) (*macoma.Result, error) {
+	if opts == nil {
+		opts = NewOptions()
+	}
+	o, err := opts.toLibrary()
+	if err != nil {
+		return nil, err
+	}
+	img, err := macoma.Decode(bytes.NewReader(input))
+	if err != nil {
+		return nil, err
+	}
+	return macoma.ConvertDetailed(img, o)
+}
+
+// toLibrary validates o and converts it to macoma.Options.
+func (o *Options) toLibrary() (macoma.Options, error) {
+	opts := macoma.DefaultOptions()
+	if o.DelimiterStrategy != "" {
+		if err := macoma.CheckStrategy(o.DelimiterStrategy); err != nil {
+			return opts, fmt.Errorf("DelimiterStrategy: %w", err)
+		}
+		opts.DelimiterStrategy = o.DelimiterStrategy
+	}
+	if o.BorderDelimiterTolerance < 0 || o.BorderDelimiterTolerance > 100 {
+		return opts, fmt.Errorf("BorderDelimiterTolerance must be between 0 and 100, got %g", o.BorderDelimiterTolerance)
+	}
+	if o.ColorDelimiterTolerance < 0 || o.ColorDelimiterTolerance > 100 {
+		return opts, fmt.Errorf("ColorDelimiterTolerance must be between 0 and 100, got %g", o.ColorDelimiterTolerance)
+	}
+	if o.MaxColors < 0 {
+		return opts, fmt.Errorf("MaxColors must be >= 0, got %d", o.MaxColors)
+	}
+	if o.BorderDelimiterColor != "" {
+		c, err := macoma.ParseHexColor(o.BorderDelimiterColor)
+		if err != nil {
+			return opts, fmt.Errorf("BorderDelimiterColor: %w", err)
+		}
+		opts.BorderDelimiterColor = c
+	}
+	opts.BorderDelimiterTolerance = o.BorderDelimiterTolerance
+	if o.ColorDelimiterTolerance != 0 {
+		opts.ColorDelimiterTolerance = o.ColorDelimiterTolerance
+	}
+	opts.MaxColors = o.MaxColors
+	opts.LegendCoverage = o.LegendCoverage
+	return opts, nil
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := macoma.EncodePNG(&buf, img); err != nil {
+		return nil, fmt.Errorf("encoding PNG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,146 @@
+package mobile
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/maax3v3/macoma/v2"
+)
+
+func samplePNG(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			c := color.RGBA{220, 30, 30, 255}
+			if x >= 20 {
+				c = color.RGBA{30, 30, 220, 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestConvert(t *testing.T) {
+	out, err := Convert(samplePNG(t), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("output is not a PNG: %v", err)
+	}
+	if img.Bounds().Dx() != 40 {
+		t.Errorf("width: got %d, want 40", img.Bounds().Dx())
+	}
+}
+
+func TestConvertWithGameData(t *testing.T) {
+	res, err := ConvertWithGameData(samplePNG(t), NewOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gd struct {
+		Zones []json.RawMessage `json:"zones"`
+	}
+	if err := json.Unmarshal(res.GameData, &gd); err != nil {
+		t.Fatal(err)
+	}
+	if len(gd.Zones) != 2 {
+		t.Errorf("got %d zones, want 2", len(gd.Zones))
+	}
+}
+
+func TestConvert_InvalidOptions(t *testing.T) {
+	opts := NewOptions()
+	opts.BorderDelimiterColor = "#12"
+	if _, err := Convert(samplePNG(t), opts); err == nil {
+		t.Error("expected error for bad color")
+	}
+	opts = NewOptions()
+	opts.DelimiterStrategy = "magic"
+	if _, err := Convert(samplePNG(t), opts); err == nil {
+		t.Error("expected error for bad strategy")
+	}
+	opts = NewOptions()
+	opts.ColorDelimiterTolerance = 150
+	if _, err := Convert(samplePNG(t), opts); err == nil {
+		t.Error("expected error for a tolerance above 100")
+	}
+	opts = NewOptions()
+	opts.BorderDelimiterTolerance = -1
+	if _, err := Convert(samplePNG(t), opts); err == nil {
+		t.Error("expected error for a negative tolerance")
+	}
+	if _, err := Convert([]byte("not an image"), nil); err == nil {
+		t.Error("expected error for undecodable input")
+	}
+}
+
+func TestOptions_Zero(t *testing.T) {
+	got, err := (&Options{}).toLibrary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := macoma.DefaultOptions()
+	if got.DelimiterStrategy != d.DelimiterStrategy || got.BorderDelimiterColor != d.BorderDelimiterColor || got.ColorDelimiterTolerance != d.ColorDelimiterTolerance {
+		t.Errorf("zero Options: got strategy %q, border color %v, color tolerance %g, want the defaults",
+			got.DelimiterStrategy, got.BorderDelimiterColor, got.ColorDelimiterTolerance)
+	}
+	if got.BorderDelimiterTolerance != 0 || got.MaxColors != 0 {
+		t.Errorf("zero Options: got border tolerance %g and max colors %d, want 0 (automatic, unlimited)",
+			got.BorderDelimiterTolerance, got.MaxColors)
+	}
+	if _, err := ConvertWithGameData(samplePNG(t), &Options{}); err != nil {
+		t.Fatalf("converting with zero Options: %v", err)
+	}
+}
+
+// columnDelimiter marks column X of the image as a delimiter.
+type columnDelimiter struct{ X int }
+
+func (d columnDelimiter) DetectContext(_ context.Context, img image.Image) (*macoma.DetectionMap, error) {
+	b := img.Bounds()
+	dm := &macoma.DetectionMap{Width: b.Dx(), Height: b.Dy(), IsDelimiter: make([]bool, b.Dx()*b.Dy())}
+	for y := 0; y < dm.Height; y++ {
+		dm.IsDelimiter[y*dm.Width+d.X] = true
+	}
+	return dm, nil
+}
+
+func TestConvert_RegisteredStrategy(t *testing.T) {
+	macoma.RegisterStrategy("mobile-test-column", func(macoma.Options) macoma.Delimiter {
+		return columnDelimiter{X: 10}
+	})
+	opts := NewOptions()
+	opts.DelimiterStrategy = "mobile-test-column"
+	res, err := ConvertWithGameData(samplePNG(t), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gd struct {
+		Zones []json.RawMessage `json:"zones"`
+	}
+	if err := json.Unmarshal(res.GameData, &gd); err != nil {
+		t.Fatal(err)
+	}
+	// The column splits the red half; the color change is not a delimiter.
+	if len(gd.Zones) != 2 {
+		t.Errorf("got %d zones, want 2", len(gd.Zones))
+	}
+
+	opts.DelimiterStrategy = "unregistered"
+	if _, err := Convert(samplePNG(t), opts); err == nil {
+		t.Error("expected an error for an unregistered strategy")
+	}
+}
@@ -0,0 +1,647 @@
+package macoma
+
+import (
+	"errors"
+	"fmt"
+	"image"
+
+	"github.com/maax3v3/macoma/v2/internal/aggregation"
+	"github.com/maax3v3/macoma/v2/internal/color"
+	"github.com/maax3v3/macoma/v2/internal/qr"
+)
+
+// Option configures a conversion:
+//
+//	macoma.Convert(img, macoma.WithMaxColors(8), macoma.WithStrategy(macoma.StrategyBorder))
+//
+// Options are applied in order on top of DefaultOptions, and each With*
+// option validates its value, so mistakes are reported by Convert instead
+// of being silently read as a default. An Options struct is itself an
+// Option that replaces the whole configuration, so Convert(img, opts)
+// works as before and can be followed by With* overrides.
+type Option interface {
+	apply(*Options) error
+}
+
+func (o Options) apply(dst *Options) error {
+	if o.Preset != "" {
+		resolved, err := o.withPreset()
+		if err != nil {
+			return err
+		}
+		o = resolved
+	}
+	*dst = o
+	return nil
+}
+
+type optionFunc func(*Options) error
+
+func (f optionFunc) apply(o *Options) error { return f(o) }
+
+// buildOptions applies opts in order to DefaultOptions.
+func buildOptions(opts []Option) (Options, error) {
+	o := DefaultOptions()
+	for _, opt := range opts {
+		if opt == nil {
+			continue
+		}
+		if err := opt.apply(&o); err != nil {
+			return Options{}, fmt.Errorf("invalid option: %w", err)
+		}
+	}
+	return o, nil
+}
+
+// WithPreset applies a built-in preset, keeping the options a preset does
+// not decide (see Options.ApplyPreset). Options after it override it.
+func WithPreset(p Preset) Option {
+	return optionFunc(func(o *Options) error { return o.ApplyPreset(p) })
+}
+
+// WithStrategy selects the delimiter strategy: StrategyColor,
+// StrategyBorder, StrategyGrid or one added with RegisterStrategy.
+func WithStrategy(strategy string) Option {
+	return optionFunc(func(o *Options) error {
+		if err := CheckStrategy(strategy); err != nil {
+			return err
+		}
+		o.DelimiterStrategy = strategy
+		return nil
+	})
+}
+
+// WithDelimiters detects delimiters with several strategies and combines
+// them with mode, CombineUnion or CombineIntersection.
+func WithDelimiters(mode string, configs ...DelimiterConfig) Option {
+	return optionFunc(func(o *Options) error {
+		if mode != CombineUnion && mode != CombineIntersection {
+			return fmt.Errorf("combine mode must be %q or %q, got %q", CombineUnion, CombineIntersection, mode)
+		}
+		for _, dc := range configs {
+			if err := CheckStrategy(dc.Strategy); err != nil {
+				return err
+			}
+			if dc.Tolerance < 0 || dc.Tolerance > 100 {
+				return fmt.Errorf("%s tolerance must be between 0 and 100, got %g", dc.Strategy, dc.Tolerance)
+			}
+			if dc.Radius < 0 {
+				return fmt.Errorf("color radius must be >= 0, got %d", dc.Radius)
+			}
+		}
+		o.Delimiters = configs
+		o.DelimiterCombine = mode
+		return nil
+	})
+}
+
+// WithBorderColor sets the color of the delimiter lines for the border
+// strategy.
+func WithBorderColor(c Color) Option {
+	return optionFunc(func(o *Options) error {
+		o.BorderDelimiterColor = c
+		return nil
+	})
+}
+
+// WithBorderTolerance sets the border color matching tolerance, 0–100, where
+// 0 picks it automatically for each image.
+func WithBorderTolerance(pct float64) Option {
+	return optionFunc(func(o *Options) error {
+		if pct < 0 || pct > 100 {
+			return fmt.Errorf("border tolerance must be between 0 and 100, got %g", pct)
+		}
+		o.BorderDelimiterTolerance = pct
+		return nil
+	})
+}
+
+// WithBorderLAB turns CIELAB border color matching on or off.
+func WithBorderLAB(on bool) Option {
+	return optionFunc(func(o *Options) error {
+		o.BorderDelimiterLAB = on
+		return nil
+	})
+}
+
+// WithExtraBorderColors adds outline colors for the border strategy, each
+// with its own tolerance, 0–100.
+func WithExtraBorderColors(colors ...BorderColor) Option {
+	return optionFunc(func(o *Options) error {
+		for _, c := range colors {
+			if c.Tolerance < 0 || c.Tolerance > 100 {
+				return fmt.Errorf("border tolerance must be between 0 and 100, got %g", c.Tolerance)
+			}
+		}
+		o.ExtraBorderColors = append(o.ExtraBorderColors, colors...)
+		return nil
+	})
+}
+
+// WithColorTolerance sets the neighbor color difference threshold of the
+// color strategy, 0–100.
+func WithColorTolerance(pct float64) Option {
+	return optionFunc(func(o *Options) error {
+		if pct < 0 || pct > 100 {
+			return fmt.Errorf("color tolerance must be between 0 and 100, got %g", pct)
+		}
+		o.ColorDelimiterTolerance = pct
+		return nil
+	})
+}
+
+// WithColorRadius sets the neighborhood radius of the color strategy; 0
+// restores the default of 2.
+func WithColorRadius(r int) Option {
+	return optionFunc(func(o *Options) error {
+		if r < 0 {
+			return fmt.Errorf("color radius must be >= 0, got %d", r)
+		}
+		o.ColorDelimiterRadius = r
+		return nil
+	})
+}
+
+// WithGridCells sets the number of cells across the image with
+// StrategyGrid, up to MaxGridCells; 0 restores DefaultGridCells.
+func WithGridCells(n int) Option {
+	return optionFunc(func(o *Options) error {
+		if n < 0 || n > MaxGridCells {
+			return fmt.Errorf("grid cells must be between 0 and %d, got %d", MaxGridCells, n)
+		}
+		o.GridCells = n
+		return nil
+	})
+}
+
+// WithColorMetric selects how color differences are measured:
+// MetricEuclidean or MetricCIEDE2000.
+func WithColorMetric(metric string) Option {
+	return optionFunc(func(o *Options) error {
+		if metric != MetricEuclidean && metric != MetricCIEDE2000 {
+			return fmt.Errorf("color metric must be %q or %q, got %q", MetricEuclidean, MetricCIEDE2000, metric)
+		}
+		o.ColorMetric = metric
+		return nil
+	})
+}
+
+// WithMaxColors limits the number of colors; 0 means unlimited.
+func WithMaxColors(n int) Option {
+	return optionFunc(func(o *Options) error {
+		if n < 0 {
+			return fmt.Errorf("max colors must be >= 0, got %d", n)
+		}
+		o.MaxColors = n
+		return nil
+	})
+}
+
+// WithAutoCrop trims the blank margins of the input before conversion (see
+// Options.AutoCrop).
+func WithAutoCrop(on bool) Option {
+	return optionFunc(func(o *Options) error {
+		o.AutoCrop = on
+		return nil
+	})
+}
+
+// WithOutputMargin surrounds the drawing with a white margin of n pixels on
+// output (see Options.OutputMargin).
+func WithOutputMargin(n int) Option {
+	return optionFunc(func(o *Options) error {
+		if n < 0 {
+			return fmt.Errorf("output margin must be >= 0, got %d", n)
+		}
+		o.OutputMargin = n
+		return nil
+	})
+}
+
+// WithMaxDimension shrinks inputs whose long edge exceeds n pixels before
+// detection (see Options.MaxDimension); 0 keeps every input at full size.
+func WithMaxDimension(n int) Option {
+	return optionFunc(func(o *Options) error {
+		if n < 0 {
+			return fmt.Errorf("max dimension must be >= 0, got %d", n)
+		}
+		o.MaxDimension = n
+		return nil
+	})
+}
+
+// WithDenoise runs a median filter of radius r over the input before
+// detection (see Options.Denoise); 0 turns it off.
+func WithDenoise(r int) Option {
+	return optionFunc(func(o *Options) error {
+		if r < 0 {
+			return fmt.Errorf("denoise radius must be >= 0, got %d", r)
+		}
+		o.Denoise = r
+		return nil
+	})
+}
+
+// WithBlur runs a Gaussian blur of standard deviation sigma pixels over
+// the input before detection (see Options.BlurSigma); 0 turns it off.
+func WithBlur(sigma float64) Option {
+	return optionFunc(func(o *Options) error {
+		if sigma < 0 || sigma > 50 {
+			return fmt.Errorf("blur sigma must be between 0 and 50, got %g", sigma)
+		}
+		o.BlurSigma = sigma
+		return nil
+	})
+}
+
+// WithPosterize quantizes each channel of the input to levels values
+// before detection (see Options.PosterizeLevels); 0 turns it off.
+func WithPosterize(levels int) Option {
+	return optionFunc(func(o *Options) error {
+		if levels != 0 && (levels < 2 || levels > 255) {
+			return fmt.Errorf("posterize levels must be 0 or between 2 and 255, got %d", levels)
+		}
+		o.PosterizeLevels = levels
+		return nil
+	})
+}
+
+// WithRestoreSize renders inputs shrunk by MaxDimension back at their
+// original size.
+func WithRestoreSize(on bool) Option {
+	return optionFunc(func(o *Options) error {
+		o.RestoreSize = on
+		return nil
+	})
+}
+
+// WithQuantizer selects the color reduction: QuantizerMerge or one added
+// with RegisterQuantizer.
+func WithQuantizer(name string) Option {
+	return optionFunc(func(o *Options) error {
+		if err := CheckQuantizer(name); err != nil {
+			return err
+		}
+		o.Quantizer = name
+		return nil
+	})
+}
+
+// WithMaxZoneArea splits zones larger than n pixels; 0 never splits.
+func WithMaxZoneArea(n int) Option {
+	return optionFunc(func(o *Options) error {
+		if n < 0 {
+			return fmt.Errorf("max zone area must be >= 0, got %d", n)
+		}
+		o.MaxZoneArea = n
+		return nil
+	})
+}
+
+// WithConnectivity sets the flood-fill connectivity, 4 or 8 (see
+// Options.Connectivity).
+func WithConnectivity(n int) Option {
+	return optionFunc(func(o *Options) error {
+		if n != 4 && n != 8 {
+			return fmt.Errorf("connectivity must be 4 or 8, got %d", n)
+		}
+		o.Connectivity = n
+		return nil
+	})
+}
+
+// WithTileHeight processes detection and zone finding in bands of n rows
+// (see Options.TileHeight); 0 processes the whole image at once.
+func WithTileHeight(n int) Option {
+	return optionFunc(func(o *Options) error {
+		if n < 0 {
+			return fmt.Errorf("tile height must be >= 0, got %d", n)
+		}
+		o.TileHeight = n
+		return nil
+	})
+}
+
+// WithMinZoneSize merges zones smaller than size into their largest
+// neighbor (see Options.MinZoneSize).
+func WithMinZoneSize(size ZoneSize) Option {
+	return optionFunc(func(o *Options) error {
+		if size.Pixels < 0 || size.Percent < 0 || size.Percent > 100 {
+			return fmt.Errorf("min zone size must be >= 0 pixels or 0–100%%, got %v", size)
+		}
+		if size.Pixels > 0 && size.Percent > 0 {
+			return fmt.Errorf("min zone size must be in pixels or percent, not both")
+		}
+		o.MinZoneSize = size
+		return nil
+	})
+}
+
+// WithSkipBackground leaves near-white zones touching the image edge
+// unnumbered (see Options.SkipBackground).
+func WithSkipBackground(on bool) Option {
+	return optionFunc(func(o *Options) error {
+		o.SkipBackground = on
+		return nil
+	})
+}
+
+// errNoPaletteColors reports a palette image that is empty or fully
+// transparent, which would leave the zones without a color to map to.
+var errNoPaletteColors = errors.New("palette image has no opaque colors")
+
+// WithPaletteFromImage takes the palette from a reference image.
+func WithPaletteFromImage(img image.Image) Option {
+	return optionFunc(func(o *Options) error {
+		if img == nil {
+			return fmt.Errorf("palette image is nil")
+		}
+		if len(aggregation.ExtractPalette(img, 0, color.MetricEuclidean)) == 0 {
+			return errNoPaletteColors
+		}
+		o.PaletteFromImage = img
+		return nil
+	})
+}
+
+// WithImportedPalette maps zones onto the numbered palette of an earlier
+// conversion (see LoadPalette).
+func WithImportedPalette(entries []PaletteEntry) Option {
+	return optionFunc(func(o *Options) error {
+		if len(entries) == 0 {
+			return fmt.Errorf("imported palette is empty")
+		}
+		seen := make(map[int]bool, len(entries))
+		for _, e := range entries {
+			if e.Number <= 0 || seen[e.Number] {
+				return fmt.Errorf("imported palette numbers must be positive and unique, got %d", e.Number)
+			}
+			seen[e.Number] = true
+		}
+		o.ImportedPalette = entries
+		return nil
+	})
+}
+
+// WithPalette maps zones onto a fixed set of colors (see Options.Palette).
+func WithPalette(colors ...Color) Option {
+	return optionFunc(func(o *Options) error {
+		if len(colors) == 0 {
+			return fmt.Errorf("palette is empty")
+		}
+		o.Palette = colors
+		return nil
+	})
+}
+
+// WithMinConfidence makes conversions fail with ErrLowConfidence below the
+// given detection confidence, 0–1; 0 never fails.
+func WithMinConfidence(score float64) Option {
+	return optionFunc(func(o *Options) error {
+		if score < 0 || score > 1 {
+			return fmt.Errorf("min confidence must be between 0 and 1, got %g", score)
+		}
+		o.MinConfidence = score
+		return nil
+	})
+}
+
+// WithWatermark stamps wm onto the output.
+func WithWatermark(wm Watermark) Option {
+	return optionFunc(func(o *Options) error {
+		if wm.Text == "" && wm.Image == nil {
+			return fmt.Errorf("watermark needs a text or an image")
+		}
+		switch wm.Position {
+		case "", WatermarkTopLeft, WatermarkTopRight, WatermarkBottomLeft, WatermarkBottomRight, WatermarkCenter:
+		default:
+			return fmt.Errorf("unknown watermark position %q", wm.Position)
+		}
+		if wm.Opacity < 0 || wm.Opacity > 1 {
+			return fmt.Errorf("watermark opacity must be between 0 and 1, got %g", wm.Opacity)
+		}
+		o.Watermark = &wm
+		return nil
+	})
+}
+
+// WithTitle prints t above the output.
+func WithTitle(t Title) Option {
+	return optionFunc(func(o *Options) error {
+		if t.Text == "" {
+			return fmt.Errorf("title needs a text")
+		}
+		switch t.Align {
+		case "", TitleLeft, TitleCenter, TitleRight:
+		default:
+			return fmt.Errorf("unknown title alignment %q", t.Align)
+		}
+		if t.Size < 0 {
+			return fmt.Errorf("title size must be >= 0, got %d", t.Size)
+		}
+		o.Title = &t
+		return nil
+	})
+}
+
+// WithFont draws numbers with f instead of the built-in bitmap font.
+func WithFont(f FontRenderer) Option {
+	return optionFunc(func(o *Options) error {
+		o.Font = f
+		return nil
+	})
+}
+
+// WithProgress reports conversion progress to fn (see Options.Progress).
+func WithProgress(fn func(stage string, percent int)) Option {
+	return optionFunc(func(o *Options) error {
+		o.Progress = fn
+		return nil
+	})
+}
+
+// WithMetrics reports stage durations and conversion sizes to m (see
+// Options.Metrics).
+func WithMetrics(m Metrics) Option {
+	return optionFunc(func(o *Options) error {
+		o.Metrics = m
+		return nil
+	})
+}
+
+// WithLegendCoverage turns the "(12%)" legend annotations on or off.
+func WithLegendCoverage(on bool) Option {
+	return optionFunc(func(o *Options) error {
+		o.LegendCoverage = on
+		return nil
+	})
+}
+
+// WithLegendHex turns the "#C84B3A" legend annotations on or off.
+func WithLegendHex(on bool) Option {
+	return optionFunc(func(o *Options) error {
+		o.LegendHex = on
+		return nil
+	})
+}
+
+// WithLegendZoneCounts turns the "× 3" zone count legend annotations on or
+// off.
+func WithLegendZoneCounts(on bool) Option {
+	return optionFunc(func(o *Options) error {
+		o.LegendZoneCounts = on
+		return nil
+	})
+}
+
+// WithLegendQR puts a QR code of url in the legend corner (see
+// Options.LegendQR).
+func WithLegendQR(url string) Option {
+	return optionFunc(func(o *Options) error {
+		if len(url) > qr.MaxBytes {
+			return fmt.Errorf("legend QR URL is %d bytes, at most %d fit", len(url), qr.MaxBytes)
+		}
+		o.LegendQR = url
+		return nil
+	})
+}
+
+// WithSeparateLegend leaves the legend off the coloring, for
+// Result.LegendImage to render on its own.
+func WithSeparateLegend(on bool) Option {
+	return optionFunc(func(o *Options) error {
+		o.SeparateLegend = on
+		return nil
+	})
+}
+
+// WithBackgroundColor sets the paper color of the coloring.
+func WithBackgroundColor(c Color) Option {
+	return optionFunc(func(o *Options) error {
+		o.BackgroundColor = c
+		return nil
+	})
+}
+
+// WithTransparentBackground turns the see-through paper on or off.
+func WithTransparentBackground(on bool) Option {
+	return optionFunc(func(o *Options) error {
+		o.TransparentBackground = on
+		return nil
+	})
+}
+
+// WithLineColor sets the color of the zone borders.
+func WithLineColor(c Color) Option {
+	return optionFunc(func(o *Options) error {
+		o.LineColor = c
+		return nil
+	})
+}
+
+// WithPatternFill turns black-and-white pattern fills on or off.
+func WithPatternFill(on bool) Option {
+	return optionFunc(func(o *Options) error {
+		o.PatternFill = on
+		return nil
+	})
+}
+
+// WithHintOpacity tints every zone with its color at this opacity (see
+// Options.HintOpacity); 0 turns it off.
+func WithHintOpacity(opacity float64) Option {
+	return optionFunc(func(o *Options) error {
+		if opacity < 0 || opacity > 1 {
+			return fmt.Errorf("hint opacity must be between 0 and 1, got %g", opacity)
+		}
+		o.HintOpacity = opacity
+		return nil
+	})
+}
+
+// WithLineWidth redraws every zone border at this width in pixels; 0
+// copies the source strokes.
+func WithLineWidth(px int) Option {
+	return optionFunc(func(o *Options) error {
+		if px < 0 {
+			return fmt.Errorf("line width must be >= 0, got %d", px)
+		}
+		o.LineWidth = px
+		return nil
+	})
+}
+
+// WithLargePrint turns the large-print accessibility mode on or off.
+func WithLargePrint(on bool) Option {
+	return optionFunc(func(o *Options) error {
+		o.LargePrint = on
+		return nil
+	})
+}
+
+// WithEnsureLegible turns the legibility upscaling on or off.
+func WithEnsureLegible(on bool) Option {
+	return optionFunc(func(o *Options) error {
+		o.EnsureLegible = on
+		return nil
+	})
+}
+
+// WithOutputScale renders the coloring at n times its resolution; 0 or 1
+// keeps the source size.
+func WithOutputScale(n int) Option {
+	return optionFunc(func(o *Options) error {
+		if n < 0 {
+			return fmt.Errorf("output scale must be >= 0, got %d", n)
+		}
+		o.OutputScale = n
+		return nil
+	})
+}
+
+// WithRotateLabels turns rotated numbers for thin zones on or off.
+func WithRotateLabels(on bool) Option {
+	return optionFunc(func(o *Options) error {
+		o.RotateLabels = on
+		return nil
+	})
+}
+
+// WithMultiLabelFraction repeats the number of zones covering more than
+// fraction of the image (see Options.MultiLabelFraction); 0 turns it off.
+func WithMultiLabelFraction(fraction float64) Option {
+	return optionFunc(func(o *Options) error {
+		if fraction < 0 || fraction > 1 {
+			return fmt.Errorf("multi-label fraction must be between 0 and 1, got %g", fraction)
+		}
+		o.MultiLabelFraction = fraction
+		return nil
+	})
+}
+
+// WithLabelSet draws labels[n-1] instead of palette number n (see
+// Options.LabelSet).
+func WithLabelSet(labels []string) Option {
+	return optionFunc(func(o *Options) error {
+		seen := make(map[string]bool, len(labels))
+		for i, l := range labels {
+			if l == "" {
+				return fmt.Errorf("label %d is empty", i+1)
+			}
+			if seen[l] {
+				return fmt.Errorf("label %q appears twice", l)
+			}
+			seen[l] = true
+		}
+		o.LabelSet = labels
+		return nil
+	})
+}
+
+// WithSolution turns the numbered answer key (Result.Solution) on or off.
+func WithSolution(on bool) Option {
+	return optionFunc(func(o *Options) error {
+		o.Solution = on
+		return nil
+	})
+}
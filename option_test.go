@@ -0,0 +1,75 @@
+package macoma
+
+import "testing"
+
+func TestBuildOptions(t *testing.T) {
+	got, err := buildOptions(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.DelimiterStrategy != StrategyColor || got.MaxColors != 10 {
+		t.Errorf("no options: got %+v, want DefaultOptions", got)
+	}
+
+	opts := DefaultOptions()
+	opts.MaxColors = 4
+	got, err = buildOptions([]Option{WithMaxColors(20), opts, nil, WithLegendHex(true)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.MaxColors != 4 || !got.LegendHex {
+		t.Errorf("an Options struct should replace earlier options and be overridden by later ones: %+v", got)
+	}
+}
+
+func TestWithValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  Option
+	}{
+		{"strategy", WithStrategy("magic")},
+		{"combine", WithDelimiters("xor")},
+		{"border tolerance", WithBorderTolerance(101)},
+		{"extra border tolerance", WithExtraBorderColors(BorderColor{Tolerance: -1})},
+		{"color tolerance", WithColorTolerance(-1)},
+		{"color radius", WithColorRadius(-1)},
+		{"grid cells", WithGridCells(-1)},
+		{"color metric", WithColorMetric("cie94")},
+		{"max colors", WithMaxColors(-1)},
+		{"output margin", WithOutputMargin(-1)},
+		{"max dimension", WithMaxDimension(-1)},
+		{"denoise", WithDenoise(-1)},
+		{"blur", WithBlur(-1)},
+		{"posterize", WithPosterize(1)},
+		{"quantizer", WithQuantizer("octree-x")},
+		{"max zone area", WithMaxZoneArea(-1)},
+		{"connectivity", WithConnectivity(6)},
+		{"tile height", WithTileHeight(-1)},
+		{"min zone size", WithMinZoneSize(ZoneSize{Percent: 101})},
+		{"min zone size both", WithMinZoneSize(ZoneSize{Pixels: 10, Percent: 1})},
+		{"palette image", WithPaletteFromImage(nil)},
+		{"min confidence", WithMinConfidence(2)},
+		{"hint opacity", WithHintOpacity(-0.5)},
+		{"line width", WithLineWidth(-1)},
+		{"output scale", WithOutputScale(-1)},
+		{"multi-label fraction", WithMultiLabelFraction(1.5)},
+		{"empty label", WithLabelSet([]string{"A", ""})},
+		{"duplicate label", WithLabelSet([]string{"A", "A"})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := buildOptions([]Option{tt.opt}); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+
+	valid := []Option{
+		WithStrategy(StrategyBorder), WithBorderTolerance(20), WithColorTolerance(0), WithGridCells(0),
+		WithColorMetric(MetricCIEDE2000), WithMaxColors(0), WithConnectivity(8),
+		WithMinZoneSize(ZoneSize{Percent: 0.5}), WithMinConfidence(0.6), WithLabelSet(SymbolLabels),
+	}
+	if _, err := buildOptions(valid); err != nil {
+		t.Errorf("valid options: %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package macoma
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/maax3v3/macoma/v2/internal/color"
+	"github.com/maax3v3/macoma/v2/internal/export"
+	"github.com/maax3v3/macoma/v2/internal/imaging"
+)
+
+// WriteEPS writes the zone borders and numbers as Encapsulated PostScript
+// line art for plotters and print workflows. Shared borders are drawn
+// once; there is no paper fill or legend.
+func (r *Result) WriteEPS(w io.Writer) error {
+	return export.WriteEPS(w, r.GameData(), r.outlineStyle())
+}
+
+// WriteDXF writes the zone borders and numbers as an R12 DXF drawing for
+// laser engravers and vinyl cutters: borders as lines on the OUTLINES
+// layer, numbers as text on the NUMBERS layer.
+func (r *Result) WriteDXF(w io.Writer) error {
+	return export.WriteDXF(w, r.GameData(), r.outlineStyle())
+}
+
+// SaveEPS writes the conversion to path as EPS.
+func SaveEPS(path string, r *Result) error {
+	return saveOutline(path, r.WriteEPS)
+}
+
+// SaveDXF writes the conversion to path as DXF.
+func SaveDXF(path string, r *Result) error {
+	return saveOutline(path, r.WriteDXF)
+}
+
+func saveOutline(path string, write func(io.Writer) error) error {
+	f, err := os.Create(imaging.ExpandPath(path))
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// outlineStyle sizes EPS and DXF output like the SVG.
+func (r *Result) outlineStyle() export.OutlineStyle {
+	s := r.svgStyle()
+	line := r.rcfg.LineColor
+	line.A = 255
+	return export.OutlineStyle{
+		LabelSize:   s.LabelSize,
+		StrokeWidth: s.StrokeWidth,
+		LineColor:   color.FromStdColor(line).Hex(),
+		Labels:      s.Labels,
+	}
+}
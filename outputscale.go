@@ -0,0 +1,31 @@
+package macoma
+
+import "github.com/maax3v3/macoma/v2/internal/renderer"
+
+// outputScale returns the factor, at most n, by which to upscale a for
+// Options.OutputScale, within maxUpscalePixels.
+func outputScale(a *analysis, n int) int {
+	b := a.img.Bounds()
+	k := n
+	for k > 1 && b.Dx()*b.Dy()*k*k > maxUpscalePixels {
+		k--
+	}
+	return max(k, 1)
+}
+
+// scaleRenderConfig multiplies the pixel sizes of cfg by k, after a was
+// upscaled k times for Options.OutputScale, so the output looks as it would
+// at its original size. Numbers keep their size relative to the drawing:
+// the label size of a before upscaling becomes the floor, since LabelSize
+// stops growing on large images.
+func scaleRenderConfig(cfg *renderer.Config, a *analysis, k int) {
+	b := a.img.Bounds()
+	natural := renderer.LabelSize(b.Dx()/k, b.Dy()/k, len(a.zones))
+	cfg.MinLabelSize = max(cfg.MinLabelSize, natural) * k
+	cfg.OutlineWidth *= k
+	cfg.LabelHalo *= k
+	cfg.LegendCircleSize *= k
+	cfg.LegendSpacing *= k
+	cfg.LegendPadding *= k
+	cfg.LegendMargin *= k
+}
@@ -0,0 +1,120 @@
+package macoma
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/maax3v3/macoma/v2/internal/aggregation"
+	"github.com/maax3v3/macoma/v2/internal/color"
+	"github.com/maax3v3/macoma/v2/internal/export"
+	"github.com/maax3v3/macoma/v2/internal/imaging"
+)
+
+// PaletteEntry is a legend color with the number it is printed as.
+type PaletteEntry struct {
+	Number int
+	Color  Color
+}
+
+// LoadPalette reads the palette of an earlier conversion for
+// Options.ImportedPalette from a JSON file with a top-level "palette" array
+// of {"number", "color"} objects, such as the file written by SaveGameData.
+func LoadPalette(path string) ([]PaletteEntry, error) {
+	f, err := os.Open(imaging.ExpandPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("opening palette: %w", err)
+	}
+	defer f.Close()
+
+	entries, err := export.DecodePalette(f)
+	if err != nil {
+		return nil, err
+	}
+	palette := make([]PaletteEntry, len(entries))
+	for i, e := range entries {
+		palette[i] = PaletteEntry{
+			Number: e.Number,
+			Color:  Color{R: e.Color.R, G: e.Color.G, B: e.Color.B, A: e.Color.A},
+		}
+	}
+	return palette, nil
+}
+
+// LoadPaletteColors reads a fixed palette for Options.Palette. A .json file
+// is read like LoadPalette, with colors taken in number order, and a .gpl
+// file as a GIMP palette, in row order; any other file is plain text with one hex color per line, optionally followed by a
+// name:
+//
+//	// Crayons
+//	#ed0a3f Red
+//	#ff8833 Orange
+func LoadPaletteColors(path string) ([]Color, error) {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		entries, err := LoadPalette(path)
+		if err != nil {
+			return nil, err
+		}
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].Number < entries[j].Number })
+		colors := make([]Color, len(entries))
+		for i, e := range entries {
+			colors[i] = e.Color
+		}
+		return colors, nil
+	}
+
+	f, err := os.Open(imaging.ExpandPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("opening palette: %w", err)
+	}
+	defer f.Close()
+
+	decode := export.DecodeColors
+	if strings.EqualFold(filepath.Ext(path), ".gpl") {
+		decode = export.DecodeGPL
+	}
+	decoded, err := decode(f)
+	if err != nil {
+		return nil, err
+	}
+	colors := make([]Color, len(decoded))
+	for i, c := range decoded {
+		colors[i] = Color{R: c.R, G: c.G, B: c.B, A: c.A}
+	}
+	return colors, nil
+}
+
+// SavePalette writes a numbered palette, such as the Legend of a Result, to
+// path. A .json file is written for LoadPalette; a .gpl file is a GIMP
+// palette, named after the file, for paint programs and LoadPaletteColors;
+// any other file is the plain text read by LoadPaletteColors. Colors are
+// in number order.
+func SavePalette(path string, palette []PaletteEntry) error {
+	entries := make([]aggregation.ColorEntry, len(palette))
+	for i, e := range palette {
+		entries[i] = aggregation.ColorEntry{
+			Number: e.Number,
+			Color:  color.RGBA{R: e.Color.R, G: e.Color.G, B: e.Color.B, A: e.Color.A},
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Number < entries[j].Number })
+
+	f, err := os.Create(imaging.ExpandPath(path))
+	if err != nil {
+		return fmt.Errorf("creating palette file: %w", err)
+	}
+	defer f.Close()
+	switch ext := filepath.Ext(path); {
+	case strings.EqualFold(ext, ".json"):
+		return export.EncodePalette(f, entries)
+	case strings.EqualFold(ext, ".gpl"):
+		return export.EncodeGPL(f, strings.TrimSuffix(filepath.Base(path), ext), entries)
+	}
+	colors := make([]color.RGBA, len(entries))
+	for i, e := range entries {
+		colors[i] = e.Color
+	}
+	return export.EncodeColors(f, colors)
+}
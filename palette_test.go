@@ -0,0 +1,33 @@
+package macoma
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestConvert_TransparentPaletteImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	img.SetRGBA(10, 5, color.RGBA{A: 255})
+
+	empty := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	opts := DefaultOptions()
+	opts.PaletteFromImage = empty
+	if _, err := Convert(img, opts); err == nil {
+		t.Error("Options.PaletteFromImage without opaque pixels: expected error")
+	}
+	if _, err := Convert(img, WithPaletteFromImage(empty)); err == nil {
+		t.Error("WithPaletteFromImage without opaque pixels: expected error")
+	}
+
+	opaque := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for i := range opaque.Pix {
+		opaque.Pix[i] = 255
+	}
+	if _, err := Convert(img, WithPaletteFromImage(opaque)); err != nil {
+		t.Errorf("opaque palette image: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package macoma
+
+import (
+	"image"
+	"io"
+
+	"github.com/maax3v3/macoma/v2/internal/imaging"
+)
+
+// PaperSize is a PDF page size in points (1/72 inch), portrait.
+type PaperSize = imaging.PaperSize
+
+// Paper sizes accepted by SavePDF and Result.PageImage.
+var (
+	PaperA4     = imaging.PaperA4
+	PaperA5     = imaging.PaperA5
+	PaperLetter = imaging.PaperLetter
+)
+
+// PaperByName returns the paper size called name: "a4", "a5" or "letter".
+func PaperByName(name string) (PaperSize, bool) {
+	return imaging.PaperByName(name)
+}
+
+// PDFOptions places the coloring on paper: page size, print resolution and
+// minimum margins (in points). Drawings too large for the page at the given
+// DPI are shrunk to fit inside the margins, legend included; with Fit,
+// smaller drawings are enlarged to them too.
+type PDFOptions = imaging.PDFOptions
+
+// DefaultPDFOptions returns A4 at 300 DPI with 10 mm margins.
+func DefaultPDFOptions() PDFOptions {
+	return PDFOptions{Paper: PaperA4, DPI: 300, Margin: MillimetersToPoints(10)}
+}
+
+// MillimetersToPoints converts a length in millimeters to PDF points.
+func MillimetersToPoints(mm float64) float64 {
+	return mm * 72 / 25.4
+}
+
+// SavePDF writes the coloring to path as a one-page, print-ready PDF.
+func SavePDF(path string, r *Result, opts PDFOptions) error {
+	return imaging.SavePDF(path, []image.Image{r.Image}, opts)
+}
+
+// WritePDF writes the PDF of SavePDF to w.
+func (r *Result) WritePDF(w io.Writer, opts PDFOptions) error {
+	return imaging.EncodePDF(w, []image.Image{r.Image}, opts)
+}
+
+// WriteLegendPDF writes Result.LegendImage to w as a one-page PDF, placed
+// like the coloring by WritePDF, e.g. to print the legend of
+// Options.SeparateLegend on its own page.
+func (r *Result) WriteLegendPDF(w io.Writer, opts PDFOptions) error {
+	return imaging.EncodePDF(w, []image.Image{r.LegendImage()}, opts)
+}
+
+// PageImage returns the coloring laid out on a page as WritePDF lays it out,
+// rendered at opts.DPI on the paper color, for printing from a PNG, JPEG or
+// WebP file at the exact page size.
+func (r *Result) PageImage(opts PDFOptions) *image.RGBA {
+	return r.LayoutPage(r.Image, opts)
+}
+
+// LayoutPage is PageImage for another image of the conversion, such as
+// SideBySide.
+func (r *Result) LayoutPage(img image.Image, opts PDFOptions) *image.RGBA {
+	return imaging.LayoutPage(img, opts, r.rcfg.Background)
+}
@@ -0,0 +1,136 @@
+package macoma
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Preset names a curated set of conversion settings.
+type Preset string
+
+// Built-in presets.
+const (
+	// PresetKids: few colors and coarse zones, for young children, with
+	// large numbers, a large legend and the paper around the drawing left
+	// unnumbered.
+	PresetKids Preset = "kids"
+	// PresetStandard: the default colors and tolerance, with specks merged
+	// away and the output enlarged where numbers would not fit.
+	PresetStandard Preset = "standard"
+	// PresetDetailed: many colors and fine zones, for experienced colorers,
+	// with numbers turned along thin zones.
+	PresetDetailed Preset = "detailed"
+	// PresetPoster: for large prints colored by several people at once,
+	// rendered at twice the resolution with large zones numbered more than
+	// once.
+	PresetPoster Preset = "poster"
+	// PresetCrossStitch: a hatch pattern per color and per-color coverage,
+	// like a cross-stitch chart with its thread list.
+	PresetCrossStitch Preset = "cross-stitch"
+	// PresetPhoto: a high delimiter tolerance so photographic texture does
+	// not turn into zone borders.
+	PresetPhoto Preset = "photo"
+)
+
+// Presets lists the built-in presets.
+func Presets() []Preset {
+	return []Preset{PresetKids, PresetStandard, PresetDetailed, PresetPoster, PresetCrossStitch, PresetPhoto}
+}
+
+// Options returns the fully configured Options for the preset, starting
+// from DefaultOptions. Unknown presets return an error.
+func (p Preset) Options() (Options, error) {
+	opts := DefaultOptions()
+	switch p {
+	case PresetKids:
+		opts.ColorDelimiterTolerance = 20
+		opts.BorderDelimiterTolerance = 20
+		opts.MaxColors = 6
+		opts.MinZoneSize = ZoneSize{Percent: 0.2}
+		opts.SkipBackground = true
+		opts.LargePrint = true
+	case PresetStandard:
+		opts.MinZoneSize = ZoneSize{Percent: 0.02}
+		opts.EnsureLegible = true
+	case PresetDetailed:
+		opts.ColorDelimiterTolerance = 6
+		opts.MaxColors = 24
+		opts.MinZoneSize = ZoneSize{Pixels: 8}
+		opts.EnsureLegible = true
+		opts.RotateLabels = true
+	case PresetPoster:
+		opts.ColorDelimiterTolerance = 12
+		opts.BorderDelimiterTolerance = 12
+		opts.MaxColors = 16
+		opts.MinZoneSize = ZoneSize{Percent: 0.05}
+		opts.OutputScale = 2
+		opts.MultiLabelFraction = 0.1
+	case PresetCrossStitch:
+		opts.ColorDelimiterTolerance = 5
+		opts.MaxColors = 16
+		opts.PatternFill = true
+		opts.LegendCoverage = true
+	case PresetPhoto:
+		opts.ColorDelimiterTolerance = 20
+		opts.MaxColors = 12
+		opts.MaxZoneArea = 250_000
+	default:
+		return Options{}, fmt.Errorf("unknown preset %q", p)
+	}
+	return opts, nil
+}
+
+// ApplyPreset sets the fields preset p decides to its values: the
+// delimiter tolerances, MaxColors, MinZoneSize, MaxZoneArea, SkipBackground,
+// PatternFill, LegendCoverage, LargePrint, EnsureLegible, OutputScale,
+// RotateLabels and MultiLabelFraction. Every other field is left unchanged.
+func (o *Options) ApplyPreset(p Preset) error {
+	po, err := p.Options()
+	if err != nil {
+		return err
+	}
+	dst, src := presetFields(o), presetFields(&po)
+	for i := range dst {
+		reflect.ValueOf(dst[i]).Elem().Set(reflect.ValueOf(src[i]).Elem())
+	}
+	return nil
+}
+
+// presetFields returns pointers to the fields of o that presets decide.
+func presetFields(o *Options) []any {
+	return []any{
+		&o.BorderDelimiterTolerance,
+		&o.ColorDelimiterTolerance,
+		&o.MaxColors,
+		&o.MinZoneSize,
+		&o.MaxZoneArea,
+		&o.SkipBackground,
+		&o.PatternFill,
+		&o.LegendCoverage,
+		&o.LargePrint,
+		&o.EnsureLegible,
+		&o.OutputScale,
+		&o.RotateLabels,
+		&o.MultiLabelFraction,
+	}
+}
+
+// withPreset returns o resolved against its Preset as documented on
+// Options.Preset, with Preset cleared so applying it again changes nothing.
+// Like ApplyPreset, only the fields presets decide can change.
+func (o Options) withPreset() (Options, error) {
+	po, err := o.Preset.Options()
+	if err != nil {
+		return Options{}, err
+	}
+	def := DefaultOptions()
+	dst, src, defaults := presetFields(&o), presetFields(&po), presetFields(&def)
+	for i := range dst {
+		f := reflect.ValueOf(dst[i]).Elem()
+		if f.IsZero() || reflect.DeepEqual(f.Interface(), reflect.ValueOf(defaults[i]).Elem().Interface()) {
+			f.Set(reflect.ValueOf(src[i]).Elem())
+		}
+	}
+	o.Preset = ""
+	return o, nil
+}
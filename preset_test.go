@@ -0,0 +1,139 @@
+package macoma
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyPreset_KeepsOtherFields(t *testing.T) {
+	opts := DefaultOptions()
+	opts.LineColor = Color{R: 128, G: 128, B: 128, A: 255}
+	opts.TransparentBackground = true
+	opts.LabelSet = LetterLabels
+	opts.ColorMetric = MetricCIEDE2000
+	opts.Connectivity = 8
+	opts.LegendQR = "https://example.com"
+	opts.GridCells = 32
+	opts.MaxColors = 3
+	want := opts
+
+	if err := opts.ApplyPreset(PresetPhoto); err != nil {
+		t.Fatal(err)
+	}
+	po, _ := PresetPhoto.Options()
+	want.ColorDelimiterTolerance = po.ColorDelimiterTolerance
+	want.MaxColors = po.MaxColors
+	want.MaxZoneArea = po.MaxZoneArea
+	if !reflect.DeepEqual(opts, want) {
+		t.Errorf("ApplyPreset(PresetPhoto):\ngot  %+v\nwant %+v", opts, want)
+	}
+}
+
+// TestPresetFields checks that presetFields lists every field a preset
+// changes, so ApplyPreset cannot silently drop one.
+func TestPresetFields(t *testing.T) {
+	def := DefaultOptions()
+	owned := make(map[uintptr]bool)
+	base := reflect.ValueOf(&def).Pointer()
+	for _, f := range presetFields(&def) {
+		owned[reflect.ValueOf(f).Pointer()-base] = true
+	}
+	dv := reflect.ValueOf(def)
+	typ := dv.Type()
+	for _, p := range Presets() {
+		po, err := p.Options()
+		if err != nil {
+			t.Fatalf("%s: %v", p, err)
+		}
+		pv := reflect.ValueOf(po)
+		for i := 0; i < typ.NumField(); i++ {
+			if reflect.DeepEqual(pv.Field(i).Interface(), dv.Field(i).Interface()) {
+				continue
+			}
+			if !owned[typ.Field(i).Offset] {
+				t.Errorf("%s sets %s, which presetFields does not list", p, typ.Field(i).Name)
+			}
+		}
+	}
+}
+
+func TestOptionsPreset_FillsUnsetFields(t *testing.T) {
+	for _, p := range Presets() {
+		t.Run(string(p), func(t *testing.T) {
+			po, err := p.Options()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			user := Options{
+				Preset:            p,
+				MaxColors:         7,                                  // a preset field set by the user
+				LineColor:         Color{R: 40, G: 40, B: 40, A: 255}, // fields presets do not decide
+				LegendHex:         true,
+				ColorMetric:       MetricCIEDE2000,
+				ExtraBorderColors: []BorderColor{{Color: Color{R: 255, A: 255}, Tolerance: 5}},
+			}
+			got, err := buildOptions([]Option{user})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := user
+			want.Preset = ""
+			want.BorderDelimiterTolerance = po.BorderDelimiterTolerance
+			want.ColorDelimiterTolerance = po.ColorDelimiterTolerance
+			want.MinZoneSize = po.MinZoneSize
+			want.MaxZoneArea = po.MaxZoneArea
+			want.SkipBackground = po.SkipBackground
+			want.PatternFill = po.PatternFill
+			want.LegendCoverage = po.LegendCoverage
+			want.LargePrint = po.LargePrint
+			want.EnsureLegible = po.EnsureLegible
+			want.OutputScale = po.OutputScale
+			want.RotateLabels = po.RotateLabels
+			want.MultiLabelFraction = po.MultiLabelFraction
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got  %+v\nwant %+v", got, want)
+			}
+
+			// A default value does not override the preset.
+			def := DefaultOptions()
+			def.Preset = p
+			got, err = buildOptions([]Option{def})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.MaxColors != po.MaxColors || got.ColorDelimiterTolerance != po.ColorDelimiterTolerance {
+				t.Errorf("defaults: got %d colors, tolerance %g; want the preset's %d, %g",
+					got.MaxColors, got.ColorDelimiterTolerance, po.MaxColors, po.ColorDelimiterTolerance)
+			}
+			again, err := buildOptions([]Option{got})
+			if err != nil || !reflect.DeepEqual(again, got) {
+				t.Errorf("resolved options should apply unchanged: %v", err)
+			}
+		})
+	}
+}
+
+func TestWithPreset_Order(t *testing.T) {
+	got, err := buildOptions([]Option{WithMaxColors(3), WithLegendHex(true), WithPreset(PresetKids), WithColorTolerance(30)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.MaxColors != 6 {
+		t.Errorf("preset should override earlier options it decides: max colors %d", got.MaxColors)
+	}
+	if !got.LegendHex {
+		t.Error("preset should keep earlier options it does not decide")
+	}
+	if got.ColorDelimiterTolerance != 30 {
+		t.Errorf("later options should override the preset: tolerance %g", got.ColorDelimiterTolerance)
+	}
+
+	if _, err := buildOptions([]Option{WithPreset("expert")}); err == nil {
+		t.Error("unknown preset: expected error")
+	}
+	if _, err := buildOptions([]Option{Options{Preset: "expert"}}); err == nil {
+		t.Error("unknown Options.Preset: expected error")
+	}
+}
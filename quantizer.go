@@ -0,0 +1,110 @@
+package macoma
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/maax3v3/macoma/v2/internal/aggregation"
+	"github.com/maax3v3/macoma/v2/internal/color"
+)
+
+// QuantizerMerge is the built-in quantizer: it repeatedly merges the two
+// closest colors, under Options.ColorMetric, until at most MaxColors remain.
+const QuantizerMerge = "merge"
+
+// ZoneColor is the input of a Quantizer for one zone: its mean color and
+// its area in pixels.
+type ZoneColor struct {
+	Color Color
+	Area  int
+}
+
+// Quantizer reduces the zone colors of a conversion to a numbered palette.
+// It is only used when no palette is imposed through ImportedPalette,
+// Palette or PaletteFromImage.
+type Quantizer interface {
+	// Quantize returns the palette of zones: Palette.ZoneMap[i] is the
+	// entry zones[i] is painted with. maxColors is Options.MaxColors, 0
+	// meaning unlimited. Entry numbers must be positive and unique.
+	Quantize(ctx context.Context, zones []ZoneColor, maxColors int) (*Palette, error)
+}
+
+var (
+	quantizersMu sync.RWMutex
+	quantizers   = map[string]Quantizer{QuantizerMerge: nil} // built-in, see reduceColors
+)
+
+// RegisterQuantizer makes q available under name, for Options.Quantizer
+// and the CLI's --quantizer. Call it from an init function; like
+// RegisterStrategy, it panics if name is empty or already registered, or
+// if q is nil.
+func RegisterQuantizer(name string, q Quantizer) {
+	quantizersMu.Lock()
+	defer quantizersMu.Unlock()
+	if name == "" {
+		panic("macoma: invalid quantizer name \"\"")
+	}
+	if q == nil {
+		panic("macoma: RegisterQuantizer quantizer is nil for " + name)
+	}
+	if _, dup := quantizers[name]; dup {
+		panic("macoma: RegisterQuantizer called twice for " + name)
+	}
+	quantizers[name] = q
+}
+
+// Quantizers returns the names of the registered quantizers in sorted
+// order, the built-in QuantizerMerge included.
+func Quantizers() []string {
+	quantizersMu.RLock()
+	defer quantizersMu.RUnlock()
+	names := make([]string, 0, len(quantizers))
+	for name := range quantizers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckQuantizer returns an error naming the registered quantizers unless
+// name is one of them.
+func CheckQuantizer(name string) error {
+	quantizersMu.RLock()
+	_, ok := quantizers[name]
+	quantizersMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("quantizer must be one of %s, got %q", strings.Join(Quantizers(), ", "), name)
+	}
+	return nil
+}
+
+// reduceColors reduces zoneColors, the mean colors of zones, with the
+// quantizer selected by opts. An empty name means QuantizerMerge.
+func reduceColors(ctx context.Context, zoneColors []color.RGBA, areas []int, opts Options) (*aggregation.ColorMap, error) {
+	name := opts.Quantizer
+	if name == "" || name == QuantizerMerge {
+		return aggregation.ReduceColorsContext(ctx, zoneColors, opts.MaxColors, colorMetric(opts))
+	}
+	quantizersMu.RLock()
+	q, ok := quantizers[name]
+	quantizersMu.RUnlock()
+	if !ok {
+		return nil, CheckQuantizer(name)
+	}
+
+	zones := make([]ZoneColor, len(zoneColors))
+	for i, c := range zoneColors {
+		zones[i] = ZoneColor{Color: Color{R: c.R, G: c.G, B: c.B, A: c.A}, Area: areas[i]}
+	}
+	p, err := q.Quantize(ctx, zones, opts.MaxColors)
+	if err != nil {
+		return nil, fmt.Errorf("quantizer %q: %w", name, err)
+	}
+	if p == nil {
+		return nil, fmt.Errorf("quantizer %q returned no palette", name)
+	}
+	return paletteToColorMap("quantizer "+name, p, len(zones))
+}
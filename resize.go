@@ -0,0 +1,110 @@
+package macoma
+
+import (
+	"image"
+
+	"golang.org/x/image/draw"
+
+	"github.com/maax3v3/macoma/v2/internal/detection"
+	"github.com/maax3v3/macoma/v2/internal/filter"
+	"github.com/maax3v3/macoma/v2/internal/imaging"
+	"github.com/maax3v3/macoma/v2/internal/zone"
+)
+
+// preprocess prepares the input for detection. It returns the input after
+// AutoCrop and the grid enlargement, the image Options.RestoreSize renders
+// at, and then prepared after MaxDimension, Denoise, BlurSigma and
+// PosterizeLevels, in that order. Both are img itself when there is
+// nothing to do.
+func preprocess(img image.Image, opts Options) (cropped, prepared image.Image) {
+	if opts.AutoCrop {
+		img = imaging.TrimMargins(img)
+	}
+	if usesGrid(opts) {
+		img = enlargeForGrid(img, gridCells(opts))
+	}
+	cropped = img
+	img = imaging.ScaleDown(img, opts.MaxDimension)
+	img = filter.Median(img, opts.Denoise)
+	img = filter.GaussianBlur(img, opts.BlurSigma)
+	return cropped, filter.Posterize(img, opts.PosterizeLevels)
+}
+
+// resampleTo returns the analysis of a downscaled image mapped back onto
+// src, the full-size original, with nearest-neighbor sampling of its
+// delimiters and labels, so Options.RestoreSize renders at the input size.
+// Zone colors and the palette are unaffected.
+func (a *analysis) resampleTo(src image.Image) *analysis {
+	b := a.img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	sb := src.Bounds()
+	W, H := sb.Dx(), sb.Dy()
+
+	dm := detection.NewMap(W, H)
+	labels := make([]int, W*H)
+	for y := 0; y < H; y++ {
+		sy := y * h / H
+		for x := 0; x < W; x++ {
+			i := sy*w + x*w/W
+			dm.IsDelimiter[y*W+x] = a.dm.IsDelimiter[i]
+			labels[y*W+x] = a.labels[i]
+		}
+	}
+	zones := zone.FromLabels(labels, W, len(a.zones))
+
+	return &analysis{img: src, dm: dm, zones: zones, labels: labels, cm: a.cm, confidence: a.confidence}
+}
+
+// pad returns the analysis with a white border m pixels wide around the
+// image, for Options.OutputMargin. The border is outside the drawing: it
+// has no delimiters, and zone outlines do not reach into it.
+func (a *analysis) pad(m int) *analysis {
+	if m <= 0 {
+		return a
+	}
+	b := a.img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	W, H := w+2*m, h+2*m
+
+	img := image.NewRGBA(image.Rect(0, 0, W, H))
+	draw.Draw(img, img.Rect, image.White, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(m, m, m+w, m+h), a.img, b.Min, draw.Src)
+
+	dm := detection.NewMap(W, H)
+	labels := make([]int, W*H)
+	for i := range labels {
+		labels[i] = zone.Outside
+	}
+	for y := 0; y < h; y++ {
+		copy(dm.IsDelimiter[(y+m)*W+m:][:w], a.dm.IsDelimiter[y*w:][:w])
+		copy(labels[(y+m)*W+m:][:w], a.labels[y*w:][:w])
+	}
+	zones := make([]zone.Zone, len(a.zones))
+	for i := range a.zones {
+		zones[i] = a.zones[i].Translate(image.Pt(m, m))
+	}
+
+	return &analysis{img: img, dm: dm, zones: zones, labels: labels, cm: a.cm, confidence: a.confidence}
+}
+
+// enlargeForGrid returns img enlarged with nearest-neighbor sampling so
+// that a grid cols cells across has cells of at least MinGridCell pixels,
+// keeping every source pixel a sharp block, as pixel art needs. Larger
+// images are returned as is. The enlarged image stays within
+// maxUpscalePixels, so cells may end up smaller than MinGridCell.
+func enlargeForGrid(img image.Image, cols int) image.Image {
+	b := img.Bounds()
+	if b.Dx() == 0 || b.Dy() == 0 || b.Dx() >= cols*MinGridCell {
+		return img
+	}
+	k := (cols*MinGridCell + b.Dx() - 1) / b.Dx()
+	for k > 1 && b.Dx()*b.Dy()*k*k > maxUpscalePixels {
+		k--
+	}
+	if k <= 1 {
+		return img
+	}
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx()*k, b.Dy()*k))
+	draw.NearestNeighbor.Scale(out, out.Rect, img, b, draw.Src, nil)
+	return out
+}
@@ -0,0 +1,28 @@
+package macoma
+
+import (
+	"image"
+	"testing"
+)
+
+func TestEnlargeForGrid(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 200, 150))
+
+	got := enlargeForGrid(img, 20).Bounds()
+	if got.Dx() != 600 || got.Dy() != 450 {
+		t.Errorf("20 cells: got %dx%d, want 600x450", got.Dx(), got.Dy())
+	}
+
+	// 1000 cells of MinGridCell pixels would need 24000x18000 pixels.
+	got = enlargeForGrid(img, 1000).Bounds()
+	if got.Dx()*got.Dy() > maxUpscalePixels {
+		t.Errorf("1000 cells: got %dx%d, over the %d pixel limit", got.Dx(), got.Dy(), maxUpscalePixels)
+	}
+	if got.Dx() < 1000 {
+		t.Errorf("1000 cells: got %d pixels across, want at least one per cell", got.Dx())
+	}
+
+	if _, err := buildOptions([]Option{WithGridCells(MaxGridCells + 1)}); err == nil {
+		t.Error("WithGridCells above MaxGridCells: expected error")
+	}
+}
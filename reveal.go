@@ -0,0 +1,77 @@
+package macoma
+
+import (
+	"image"
+	stdcolor "image/color"
+	"io"
+	"time"
+
+	"github.com/maax3v3/macoma/v2/internal/imaging"
+	"github.com/maax3v3/macoma/v2/internal/renderer"
+)
+
+// DefaultRevealDelay is how long each frame of a reveal animation shows
+// when no delay is given.
+const DefaultRevealDelay = time.Second
+
+// RevealFrames renders a progressive reveal of the solution: the coloring,
+// then one frame per legend color in legend order, each filling the zones
+// of one more color. The legend and numbers of zones not yet filled stay.
+func (r *Result) RevealFrames() []*image.RGBA {
+	return renderer.RenderReveal(r.Image, r.titleH, r.a.zones, r.a.cm)
+}
+
+// SaveRevealGIF writes the frames of Result.RevealFrames to path as a
+// looping animated GIF, for classroom projection or previews. Each frame
+// shows for delay (DefaultRevealDelay if 0), the finished picture three
+// times as long.
+func SaveRevealGIF(path string, r *Result, delay time.Duration) error {
+	frames, delays := r.revealAnimation(delay)
+	return imaging.SaveGIF(path, frames, delays, r.revealPalette())
+}
+
+// WriteRevealGIF writes the animated GIF of SaveRevealGIF to w.
+func (r *Result) WriteRevealGIF(w io.Writer, delay time.Duration) error {
+	frames, delays := r.revealAnimation(delay)
+	return imaging.EncodeGIF(w, frames, delays, r.revealPalette())
+}
+
+// revealAnimation returns the reveal frames and their delays in hundredths
+// of a second.
+func (r *Result) revealAnimation(delay time.Duration) ([]image.Image, []int) {
+	if delay <= 0 {
+		delay = DefaultRevealDelay
+	}
+	cs := max(1, int(delay/(10*time.Millisecond)))
+	rendered := r.RevealFrames()
+	frames := make([]image.Image, len(rendered))
+	delays := make([]int, len(rendered))
+	for i, f := range rendered {
+		frames[i], delays[i] = f, cs
+	}
+	delays[len(delays)-1] = 3 * cs
+	return frames, delays
+}
+
+// revealPalette returns the GIF palette of a reveal: the paper and line
+// colors and the legend colors exactly, then grays for the anti-aliased
+// numbers and legend.
+func (r *Result) revealPalette() stdcolor.Palette {
+	seen := make(map[stdcolor.RGBA]bool)
+	var pal stdcolor.Palette
+	add := func(c stdcolor.RGBA) {
+		if !seen[c] && len(pal) < 256 {
+			seen[c] = true
+			pal = append(pal, c)
+		}
+	}
+	add(r.rcfg.Background)
+	add(r.rcfg.LineColor)
+	for _, e := range r.a.cm.Entries {
+		add(e.Color.ToStdColor())
+	}
+	for g := 0; g <= 255; g += 17 {
+		add(stdcolor.RGBA{uint8(g), uint8(g), uint8(g), 255})
+	}
+	return pal
+}
@@ -0,0 +1,171 @@
+package macoma
+
+import (
+	"context"
+	"image"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/maax3v3/macoma/v2/internal/detection"
+)
+
+// Session converts one image again and again with changing options, as
+// an interactive editor does. It keeps the prepared image and its
+// delimiter map from one conversion to the next, so a change that only
+// affects later stages, such as MinZoneSize, MaxColors, MaxZoneArea or
+// any rendering option, skips preprocessing and delimiter detection and
+// gives the same result as Convert.
+//
+// Changing a detection or preprocessing option, such as a tolerance or
+// the strategy, runs both again. Conversions with Options.OnDetected or a
+// strategy added with RegisterStrategy, which may read any option, are
+// never cached. A Session is safe for concurrent use.
+type Session struct {
+	img   image.Image
+	cache detectionCache
+}
+
+// NewSession returns a Session for img.
+func NewSession(img image.Image) *Session {
+	return &Session{img: img}
+}
+
+// ConvertDetailed converts the image of the Session with opts, like the
+// package-level ConvertDetailed.
+func (s *Session) ConvertDetailed(opts ...Option) (*Result, error) {
+	return s.ConvertDetailedContext(context.Background(), opts...)
+}
+
+// ConvertDetailedContext is like ConvertDetailed but can be cancelled
+// through ctx.
+func (s *Session) ConvertDetailedContext(ctx context.Context, opts ...Option) (*Result, error) {
+	return ConvertDetailedContext(context.WithValue(ctx, detectionCacheKey{}, &s.cache), s.img, opts...)
+}
+
+// detectionCache holds the prepared images and delimiter map of the last
+// conversion of a Session, and the options that made them.
+type detectionCache struct {
+	mu       sync.Mutex
+	key      detectionOptions
+	src, img image.Image
+	dm       *detection.Map // nil until detection ran
+}
+
+type detectionCacheKey struct{}
+
+// detectionCacheFrom returns the cache of the Session converting in ctx,
+// or nil. Its methods work on a nil cache, which caches nothing.
+func detectionCacheFrom(ctx context.Context) *detectionCache {
+	c, _ := ctx.Value(detectionCacheKey{}).(*detectionCache)
+	return c
+}
+
+// detectionOptions are the options preprocessing and the built-in
+// strategies read.
+type detectionOptions struct {
+	Strategy        string
+	Delimiters      []DelimiterConfig
+	Combine         string
+	BorderColor     Color
+	BorderTolerance float64
+	BorderLAB       bool
+	ExtraBorders    []BorderColor
+	ColorMetric     string
+	ColorTolerance  float64
+	ColorRadius     int
+	GridCells       int
+	AutoCrop        bool
+	MaxDimension    int
+	Denoise         int
+	BlurSigma       float64
+	PosterizeLevels int
+	TileHeight      int
+}
+
+// cacheKey returns the detection options of opts, or false when their
+// delimiter map cannot be reused. The key copies the slices of opts, so
+// a caller changing them in place between conversions still invalidates
+// the cache.
+func cacheKey(opts Options) (detectionOptions, bool) {
+	if opts.OnDetected != nil {
+		return detectionOptions{}, false
+	}
+	builtin := func(s string) bool {
+		return s == "" || s == StrategyColor || s == StrategyBorder || s == StrategyGrid
+	}
+	if !builtin(opts.DelimiterStrategy) {
+		return detectionOptions{}, false
+	}
+	for _, dc := range opts.Delimiters {
+		if !builtin(dc.Strategy) {
+			return detectionOptions{}, false
+		}
+	}
+	return detectionOptions{
+		Strategy:        opts.DelimiterStrategy,
+		Delimiters:      slices.Clone(opts.Delimiters),
+		Combine:         opts.DelimiterCombine,
+		BorderColor:     opts.BorderDelimiterColor,
+		BorderTolerance: opts.BorderDelimiterTolerance,
+		BorderLAB:       opts.BorderDelimiterLAB,
+		ExtraBorders:    slices.Clone(opts.ExtraBorderColors),
+		ColorMetric:     opts.ColorMetric,
+		ColorTolerance:  opts.ColorDelimiterTolerance,
+		ColorRadius:     opts.ColorDelimiterRadius,
+		GridCells:       opts.GridCells,
+		AutoCrop:        opts.AutoCrop,
+		MaxDimension:    opts.MaxDimension,
+		Denoise:         opts.Denoise,
+		BlurSigma:       opts.BlurSigma,
+		PosterizeLevels: opts.PosterizeLevels,
+		TileHeight:      opts.TileHeight,
+	}, true
+}
+
+// preprocess is the package-level preprocess, returning the images of the
+// last conversion when opts prepare them the same way.
+func (c *detectionCache) preprocess(img image.Image, opts Options) (cropped, prepared image.Image) {
+	key, ok := cacheKey(opts)
+	if c == nil || !ok {
+		return preprocess(img, opts)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.img != nil && reflect.DeepEqual(c.key, key) {
+		return c.src, c.img
+	}
+	c.src, c.img = preprocess(img, opts)
+	c.key, c.dm = key, nil
+	return c.src, c.img
+}
+
+// delimiters returns a copy of the cached delimiter map for opts, or nil.
+// Conversions get their own copy, as later stages and Result.Release may
+// take it over.
+func (c *detectionCache) delimiters(opts Options) *detection.Map {
+	key, ok := cacheKey(opts)
+	if c == nil || !ok {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.dm == nil || !reflect.DeepEqual(c.key, key) {
+		return nil
+	}
+	return c.dm.Clone()
+}
+
+// keep caches a copy of dm, detected with opts, unless a conversion with
+// other options has replaced the prepared image since.
+func (c *detectionCache) keep(opts Options, dm *detection.Map) {
+	key, ok := cacheKey(opts)
+	if c == nil || !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if reflect.DeepEqual(c.key, key) {
+		c.dm = dm.Clone()
+	}
+}
@@ -0,0 +1,23 @@
+package macoma
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCacheKey_CopiesSlices(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Delimiters = []DelimiterConfig{{Strategy: StrategyColor, Tolerance: 10}}
+	opts.ExtraBorderColors = []BorderColor{{Color: Color{R: 255, A: 255}, Tolerance: 5}}
+	key, ok := cacheKey(opts)
+	if !ok {
+		t.Fatal("built-in strategies must be cacheable")
+	}
+
+	opts.Delimiters[0].Tolerance = 40
+	opts.ExtraBorderColors[0].Tolerance = 20
+	changed, _ := cacheKey(opts)
+	if reflect.DeepEqual(key, changed) {
+		t.Fatal("changing the option slices in place kept the cache key")
+	}
+}
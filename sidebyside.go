@@ -0,0 +1,29 @@
+package macoma
+
+import (
+	"image"
+
+	"github.com/maax3v3/macoma/v2/internal/renderer"
+)
+
+// Reference images for Result.SideBySide.
+const (
+	// ReferenceOriginal is the drawing as it was converted.
+	ReferenceOriginal = "original"
+	// ReferencePreview is the drawing recolored with the reduced palette,
+	// as Result.PalettePreview renders it.
+	ReferencePreview = "preview"
+)
+
+// SideBySide renders the coloring with a colored reference to its left, on
+// one canvas with a divider between them, for "color by reference"
+// exercises. reference is ReferenceOriginal or ReferencePreview; any other
+// value uses the original. The reference is level with the drawing, below
+// the title if there is one.
+func (r *Result) SideBySide(reference string) *image.RGBA {
+	var ref image.Image = r.a.img
+	if reference == ReferencePreview {
+		ref = r.PalettePreview()
+	}
+	return renderer.SideBySide(ref, r.Image, r.titleH, r.rcfg)
+}
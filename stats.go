@@ -0,0 +1,46 @@
+package macoma
+
+import (
+	"context"
+	"fmt"
+	"image"
+
+	"github.com/maax3v3/macoma/v2/internal/progress"
+	"github.com/maax3v3/macoma/v2/internal/stats"
+)
+
+// Statistics summarizes a conversion in numbers: the drawing size, the
+// zone count, the delimiter pixels and their share of the drawing, and a
+// ColorStatistics per palette entry. It marshals to JSON.
+type Statistics = stats.Report
+
+// ColorStatistics is the use of one palette entry: its number and
+// "#rrggbb" color, how many zones are painted with it, their area in
+// pixels and their share of the total zone area.
+type ColorStatistics = stats.Color
+
+// Stats runs the detection, zoning and palette stages on img, without
+// rendering, and returns the statistics of the coloring a conversion with
+// the same options would produce. Use it to tune MaxColors or the
+// detection before printing anything.
+func Stats(img image.Image, opts ...Option) (*Statistics, error) {
+	if img == nil {
+		return nil, fmt.Errorf("input image is nil")
+	}
+	o, err := buildOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	ctx := progress.WithFunc(context.Background(), o.Progress)
+	_, img = preprocess(img, o)
+	a, err := analyze(ctx, img, o)
+	if err != nil {
+		return nil, err
+	}
+	return stats.Compute(a.dm, a.zones, a.cm), nil
+}
+
+// Stats returns the statistics of the conversion, in output pixels.
+func (r *Result) Stats() *Statistics {
+	return stats.Compute(r.a.dm, r.a.zones, r.a.cm)
+}
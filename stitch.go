@@ -0,0 +1,174 @@
+package macoma
+
+import (
+	"fmt"
+	"image"
+	"sort"
+	"strconv"
+
+	"github.com/maax3v3/macoma/v2/internal/aggregation"
+	"github.com/maax3v3/macoma/v2/internal/color"
+	"github.com/maax3v3/macoma/v2/internal/dmc"
+	"github.com/maax3v3/macoma/v2/internal/grid"
+	"github.com/maax3v3/macoma/v2/internal/renderer"
+)
+
+// StitchOptions configures ConvertStitch.
+type StitchOptions struct {
+	// Stitches is the width of the chart in stitches, between 1 and the
+	// image width. The number of rows follows from the aspect ratio.
+	Stitches int
+
+	// MaxThreads caps the number of floss colors; 0 keeps every thread the
+	// image maps to.
+	MaxThreads int
+
+	// CellSize is the side of a chart cell in pixels, at least 8 so the
+	// symbols stay readable.
+	CellSize int
+
+	// Font draws the symbols and the key. If nil, the built-in bitmap font
+	// is used, which has every symbol ConvertStitch assigns.
+	Font FontRenderer
+}
+
+// DefaultStitchOptions returns a chart 80 stitches wide in at most 20
+// threads, 16 pixels per stitch.
+func DefaultStitchOptions() StitchOptions {
+	return StitchOptions{Stitches: 80, MaxThreads: 20, CellSize: 16}
+}
+
+// Thread is a DMC floss color used by a cross-stitch chart.
+type Thread struct {
+	Code     string // DMC number, e.g. "321"
+	Name     string
+	Color    Color
+	Symbol   string // the mark of its cells on the chart
+	Stitches int    // cells stitched in this thread
+}
+
+// StitchChart is the result of ConvertStitch.
+type StitchChart struct {
+	// Image is the chart: the grid of symbols over the thread colors,
+	// with the thread key below.
+	Image *image.RGBA
+
+	Cols, Rows int
+
+	// Cells holds, row by row, the index in Threads of each stitch, or -1
+	// for a cell left bare because the image is transparent there.
+	Cells []int
+
+	// Threads lists the floss colors, most used first.
+	Threads []Thread
+}
+
+// ConvertStitch turns img into a cross-stitch chart: it averages the image
+// over a grid of square stitches, matches each stitch to the nearest DMC
+// floss, merges the closest threads down to opts.MaxThreads as Convert
+// merges palette colors, and marks each thread with its own symbol. Zone
+// detection plays no part, so photos work as well as drawings.
+func ConvertStitch(img image.Image, opts StitchOptions) (*StitchChart, error) {
+	if img == nil {
+		return nil, fmt.Errorf("input image is nil")
+	}
+	w := img.Bounds().Dx()
+	if opts.Stitches < 1 || opts.Stitches > w {
+		return nil, fmt.Errorf("stitches must be between 1 and the image width (%d), got %d", w, opts.Stitches)
+	}
+	if opts.MaxThreads < 0 {
+		return nil, fmt.Errorf("max threads must be non-negative, got %d", opts.MaxThreads)
+	}
+	if opts.CellSize < 8 {
+		return nil, fmt.Errorf("cell size must be at least 8, got %d", opts.CellSize)
+	}
+
+	g := grid.Sample(img, opts.Stitches)
+	var filled []int
+	var snapped []color.RGBA
+	for i, c := range g.Colors {
+		if !g.Empty[i] {
+			filled = append(filled, i)
+			snapped = append(snapped, dmc.Threads[dmc.Nearest(c)].Color)
+		}
+	}
+
+	// Merged groups get a mean color between threads, so snap them back;
+	// two groups may land on the same thread.
+	cm := aggregation.ReduceColors(snapped, opts.MaxThreads)
+	entryThread := make([]int, len(cm.Entries))
+	var used []int // indexes into dmc.Threads
+	seen := make(map[int]int)
+	for i, e := range cm.Entries {
+		t := dmc.Nearest(e.Color)
+		if _, ok := seen[t]; !ok {
+			seen[t] = len(used)
+			used = append(used, t)
+		}
+		entryThread[i] = seen[t]
+	}
+
+	counts := make([]int, len(used))
+	for _, e := range cm.ZoneMap {
+		counts[entryThread[e]]++
+	}
+	order := make([]int, len(used))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return counts[order[a]] > counts[order[b]] })
+	rank := make([]int, len(used))
+	for r, i := range order {
+		rank[i] = r
+	}
+
+	chart := &StitchChart{
+		Cols:    g.Cols,
+		Rows:    g.Rows,
+		Cells:   make([]int, len(g.Colors)),
+		Threads: make([]Thread, len(used)),
+	}
+	for i := range chart.Cells {
+		chart.Cells[i] = -1
+	}
+	for k, cell := range filled {
+		chart.Cells[cell] = rank[entryThread[cm.ZoneMap[k]]]
+	}
+
+	keys := make([]renderer.StitchThread, len(used))
+	for r, i := range order {
+		t := dmc.Threads[used[i]]
+		chart.Threads[r] = Thread{
+			Code:     t.Code,
+			Name:     t.Name,
+			Color:    Color{R: t.Color.R, G: t.Color.G, B: t.Color.B, A: t.Color.A},
+			Symbol:   stitchSymbol(r),
+			Stitches: counts[i],
+		}
+		label := t.Code + " " + t.Name
+		if t.Name == t.Code {
+			label = t.Code
+		}
+		keys[r] = renderer.StitchThread{
+			Symbol: chart.Threads[r].Symbol,
+			Color:  t.Color.ToStdColor(),
+			Label:  fmt.Sprintf("%s (%d)", label, counts[i]),
+		}
+	}
+
+	chart.Image = renderer.RenderStitchChart(chart.Cells, chart.Cols, chart.Rows, keys, opts.CellSize, resolveFont(opts.Font))
+	return chart, nil
+}
+
+// stitchSymbol returns the chart symbol of the i-th thread: the shapes of
+// SymbolLabels, then the letters, then numbers.
+func stitchSymbol(i int) string {
+	if i < len(SymbolLabels) {
+		return SymbolLabels[i]
+	}
+	i -= len(SymbolLabels)
+	if i < len(LetterLabels) {
+		return LetterLabels[i]
+	}
+	return strconv.Itoa(i - len(LetterLabels) + 1)
+}
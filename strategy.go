@@ -0,0 +1,198 @@
+package macoma
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/maax3v3/macoma/v2/internal/color"
+	"github.com/maax3v3/macoma/v2/internal/detection"
+)
+
+// Delimiter finds the delimiter pixels of an image: the outlines that
+// separate its zones. It must return a map the size of img.Bounds() and
+// should return ctx.Err() soon after ctx is done.
+type Delimiter interface {
+	DetectContext(ctx context.Context, img image.Image) (*DetectionMap, error)
+}
+
+// StrategyFactory builds the Delimiter of a strategy for one conversion,
+// reading whichever Options it needs, such as ColorDelimiterTolerance.
+type StrategyFactory func(opts Options) Delimiter
+
+var (
+	strategiesMu sync.RWMutex
+	strategies   = map[string]StrategyFactory{
+		StrategyBorder: func(opts Options) Delimiter { return borderDelimiter(opts) },
+		StrategyColor:  func(opts Options) Delimiter { return colorDelimiter(opts) },
+		StrategyGrid:   func(opts Options) Delimiter { return gridDelimiter(opts) },
+	}
+)
+
+// RegisterStrategy makes a delimiter strategy available under name, for
+// Options.DelimiterStrategy, DelimiterConfig.Strategy and the CLI's
+// --delimiter-strategy. Call it from an init function; like
+// database/sql.Register, it panics if name is empty or already registered,
+// or if factory is nil.
+func RegisterStrategy(name string, factory StrategyFactory) {
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+	if name == "" || strings.Contains(name, ",") {
+		panic(fmt.Sprintf("macoma: invalid strategy name %q", name))
+	}
+	if factory == nil {
+		panic("macoma: RegisterStrategy factory is nil for " + name)
+	}
+	if _, dup := strategies[name]; dup {
+		panic("macoma: RegisterStrategy called twice for " + name)
+	}
+	strategies[name] = factory
+}
+
+// Strategies returns the names of the registered delimiter strategies in
+// sorted order, the built-in StrategyBorder, StrategyColor and
+// StrategyGrid included.
+func Strategies() []string {
+	strategiesMu.RLock()
+	defer strategiesMu.RUnlock()
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckStrategy returns an error naming the registered strategies unless
+// name is one of them.
+func CheckStrategy(name string) error {
+	strategiesMu.RLock()
+	_, ok := strategies[name]
+	strategiesMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("strategy must be one of %s, got %q", strings.Join(Strategies(), ", "), name)
+	}
+	return nil
+}
+
+// strategyDelimiter adapts a registered Delimiter to the internal
+// interface, checking the maps it returns.
+type strategyDelimiter struct {
+	name string
+	d    Delimiter
+}
+
+func (s strategyDelimiter) Detect(img image.Image) *detection.Map {
+	dm, _ := s.DetectContext(context.Background(), img)
+	return dm
+}
+
+func (s strategyDelimiter) DetectContext(ctx context.Context, img image.Image) (*detection.Map, error) {
+	dm, err := s.d.DetectContext(ctx, img)
+	if err != nil {
+		return nil, fmt.Errorf("strategy %q: %w", s.name, err)
+	}
+	b := img.Bounds()
+	if dm == nil || dm.Width != b.Dx() || dm.Height != b.Dy() || len(dm.IsDelimiter) != dm.Width*dm.Height {
+		return nil, fmt.Errorf("strategy %q: the map must be %dx%d", s.name, b.Dx(), b.Dy())
+	}
+	return dm, nil
+}
+
+// delimiterFromOpts builds the Delimiter of opts.DelimiterStrategy, or of
+// every entry of opts.Delimiters combined. An empty strategy name means
+// StrategyColor.
+func delimiterFromOpts(opts Options) (detection.Delimiter, error) {
+	if len(opts.Delimiters) > 0 {
+		composite := &detection.CompositeDelimiter{}
+		if opts.DelimiterCombine == CombineIntersection {
+			composite.Mode = detection.CombineIntersection
+		}
+		for _, dc := range opts.Delimiters {
+			o := opts
+			o.Delimiters = nil
+			o.DelimiterStrategy = dc.Strategy
+			o.BorderDelimiterColor = dc.BorderColor
+			o.BorderDelimiterTolerance = dc.Tolerance
+			o.ColorDelimiterTolerance = dc.Tolerance
+			o.ColorDelimiterRadius = dc.Radius
+			d, err := delimiterFromOpts(o)
+			if err != nil {
+				return nil, err
+			}
+			composite.Delimiters = append(composite.Delimiters, d)
+		}
+		return composite, nil
+	}
+
+	switch opts.DelimiterStrategy {
+	case StrategyColor, "":
+		return colorDelimiter(opts), nil
+	case StrategyBorder:
+		return borderDelimiter(opts), nil
+	case StrategyGrid:
+		return gridDelimiter(opts), nil
+	}
+	strategiesMu.RLock()
+	factory, ok := strategies[opts.DelimiterStrategy]
+	strategiesMu.RUnlock()
+	if !ok {
+		return nil, CheckStrategy(opts.DelimiterStrategy)
+	}
+	return strategyDelimiter{name: opts.DelimiterStrategy, d: factory(opts)}, nil
+}
+
+// borderDelimiter builds the StrategyBorder delimiter.
+func borderDelimiter(opts Options) *detection.BorderDelimiter {
+	return &detection.BorderDelimiter{
+		Color: color.RGBA{
+			R: opts.BorderDelimiterColor.R,
+			G: opts.BorderDelimiterColor.G,
+			B: opts.BorderDelimiterColor.B,
+			A: opts.BorderDelimiterColor.A,
+		},
+		TolerancePct: opts.BorderDelimiterTolerance,
+		Auto:         opts.BorderDelimiterTolerance == 0,
+		Extra:        extraBorderColors(opts.ExtraBorderColors),
+		Metric:       colorMetric(opts),
+		LAB:          opts.BorderDelimiterLAB,
+	}
+}
+
+// colorDelimiter builds the StrategyColor delimiter.
+func colorDelimiter(opts Options) *detection.ColorDelimiter {
+	return &detection.ColorDelimiter{
+		TolerancePct: opts.ColorDelimiterTolerance,
+		Radius:       opts.ColorDelimiterRadius,
+	}
+}
+
+// gridDelimiter builds the StrategyGrid delimiter.
+func gridDelimiter(opts Options) *detection.GridDelimiter {
+	return &detection.GridDelimiter{Cols: gridCells(opts)}
+}
+
+// gridCells returns Options.GridCells, defaulting to DefaultGridCells.
+func gridCells(opts Options) int {
+	if opts.GridCells <= 0 {
+		return DefaultGridCells
+	}
+	return opts.GridCells
+}
+
+// usesGrid reports whether opts delimits zones with StrategyGrid, alone or
+// among Delimiters.
+func usesGrid(opts Options) bool {
+	if len(opts.Delimiters) == 0 {
+		return opts.DelimiterStrategy == StrategyGrid
+	}
+	for _, dc := range opts.Delimiters {
+		if dc.Strategy == StrategyGrid {
+			return true
+		}
+	}
+	return false
+}
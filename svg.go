@@ -0,0 +1,90 @@
+package macoma
+
+import (
+	"compress/gzip"
+	"fmt"
+	stdcolor "image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/maax3v3/macoma/v2/internal/color"
+	"github.com/maax3v3/macoma/v2/internal/export"
+	"github.com/maax3v3/macoma/v2/internal/imaging"
+	"github.com/maax3v3/macoma/v2/internal/renderer"
+)
+
+// WriteSVG writes the conversion as a vector coloring: traced zone
+// outlines, numbers and the legend. Pattern fills, watermarks, titles and
+// the legend QR code only apply to the raster output.
+func (r *Result) WriteSVG(w io.Writer) error {
+	return export.WriteSVG(w, r.GameData(), r.svgStyle())
+}
+
+// WriteSVGZ writes the SVG gzip-compressed, as stored in .svgz files.
+// Outlines of detailed drawings compress by an order of magnitude.
+func (r *Result) WriteSVGZ(w io.Writer) error {
+	zw, err := gzip.NewWriterLevel(w, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	if err := r.WriteSVG(zw); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("compressing SVG: %w", err)
+	}
+	return nil
+}
+
+// SaveSVG writes the conversion to path as SVG, gzip-compressed when path
+// ends in ".svgz".
+func SaveSVG(path string, r *Result) error {
+	path = imaging.ExpandPath(path)
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".svgz") {
+		return r.WriteSVGZ(f)
+	}
+	return r.WriteSVG(f)
+}
+
+// svgStyle sizes the SVG like the PNG output of the same conversion.
+func (r *Result) svgStyle() export.SVGStyle {
+	def := renderer.DefaultConfig()
+	b := r.a.img.Bounds()
+	stroke := max(min(b.Dx(), b.Dy())/500, 1)
+	if r.rcfg.LineWidth > 0 {
+		stroke = r.rcfg.LineWidth
+	}
+	return export.SVGStyle{
+		LabelSize:        max(renderer.LabelSize(b.Dx(), b.Dy(), len(r.a.zones)), r.rcfg.MinLabelSize),
+		StrokeWidth:      float64(stroke + 2*r.rcfg.OutlineWidth),
+		LegendPadding:    r.rcfg.LegendPadding,
+		LegendCircleSize: r.rcfg.LegendCircleSize,
+		LegendSpacing:    r.rcfg.LegendSpacing,
+		LegendMargin:     r.rcfg.LegendMargin,
+		NoLegend:         r.rcfg.NoLegend,
+		Background:       svgColor(r.rcfg.Background, def.Background),
+		LineColor:        svgColor(r.rcfg.LineColor, def.LineColor),
+		Labels:           r.rcfg.Labels,
+	}
+}
+
+// svgColor returns c as an SVG color, or "" for the default def, which
+// WriteSVG spells out itself. Transparent is "transparent" rather than
+// "none", so zones still catch clicks.
+func svgColor(c, def stdcolor.RGBA) string {
+	if c == def {
+		return ""
+	}
+	if c.A == 0 {
+		return "transparent"
+	}
+	return color.FromStdColor(c).Hex()
+}
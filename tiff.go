@@ -0,0 +1,39 @@
+package macoma
+
+import (
+	"image"
+	"io"
+
+	"github.com/maax3v3/macoma/v2/internal/imaging"
+	"github.com/maax3v3/macoma/v2/internal/renderer"
+)
+
+// AnswerKey renders the solution of the conversion: every zone filled with
+// its legend color, delimiters in black, without numbers or legend.
+func (r *Result) AnswerKey() *image.RGBA {
+	return renderer.RenderAnswerKey(r.a.dm, r.a.zones, r.a.cm, r.rcfg)
+}
+
+// PalettePreview renders the drawing recolored with the reduced palette:
+// every zone in its legend color, the lines as in the source, and no
+// numbers or legend. Compare it with the input to judge whether MaxColors
+// merged colors that should have stayed apart.
+func (r *Result) PalettePreview() *image.RGBA {
+	return renderer.RenderPalettePreview(r.a.img, r.a.zones, r.a.cm)
+}
+
+// SaveTIFF writes the conversion to path as a three-page TIFF for prepress
+// workflows: the coloring page, the answer key, and the legend on its own.
+func SaveTIFF(path string, r *Result) error {
+	return imaging.SaveTIFF(path, r.tiffPages())
+}
+
+// WriteTIFF writes the three-page TIFF of SaveTIFF to w.
+func (r *Result) WriteTIFF(w io.Writer) error {
+	return imaging.EncodeTIFF(w, r.tiffPages())
+}
+
+// tiffPages returns the coloring page, the answer key and the legend.
+func (r *Result) tiffPages() []image.Image {
+	return []image.Image{r.Image, r.AnswerKey(), r.LegendImage()}
+}
@@ -0,0 +1,16 @@
+package macoma
+
+import (
+	"image"
+
+	"github.com/maax3v3/macoma/v2/internal/renderer"
+)
+
+// Worksheets renders one "find all the 3s" page per legend color, in legend
+// order: the zones of that color shaded and numbered, the rest of the
+// drawing faint, and that color alone in the legend. Therapists and
+// teachers use them as search exercises before coloring.
+func (r *Result) Worksheets() []*image.RGBA {
+	a := r.a
+	return renderer.RenderWorksheets(a.img, a.dm, a.zones, a.labels, a.cm, resolveFont(r.font), r.rcfg)
+}
@@ -0,0 +1,67 @@
+package macoma
+
+import (
+	"image"
+
+	"github.com/maax3v3/macoma/v2/internal/detection"
+)
+
+// ZoneInfo describes one numbered zone of a conversion. Coordinates are
+// output pixels: those of the source drawing multiplied by Result.Scale.
+type ZoneInfo struct {
+	ID     int
+	Number int // legend number the zone is painted with
+	Area   int // pixels, excluding delimiter lines
+
+	Label  image.Point     // where the number is drawn
+	Bounds image.Rectangle // bounding box of the zone pixels
+}
+
+// DelimiterStats summarizes the delimiter lines found by detection, in
+// output pixels.
+type DelimiterStats struct {
+	Pixels   int     // delimiter pixels
+	Fraction float64 // share of the drawing's pixels that are delimiters
+
+	// Strokes is the number of connected delimiter lines (8-connected).
+	Strokes         int
+	TotalLength     float64 // summed centerline length of the strokes
+	MeanThickness   float64 // mean stroke width
+	MedianThickness float64 // median stroke width
+}
+
+// Zones returns every zone of the conversion in zone ID order, with its
+// number, area and label position.
+func (r *Result) Zones() []ZoneInfo {
+	a := r.a
+	out := make([]ZoneInfo, len(a.zones))
+	for i := range a.zones {
+		z := &a.zones[i]
+		out[i] = ZoneInfo{
+			ID:     i,
+			Number: a.cm.Entries[a.cm.ZoneMap[i]].Number,
+			Area:   z.Area(),
+			Label:  z.InteriorPoint(),
+			Bounds: z.BoundingBox(),
+		}
+	}
+	return out
+}
+
+// Delimiters measures the delimiter lines of the conversion: how much of
+// the drawing they cover and how many strokes of what width they form.
+func (r *Result) Delimiters() DelimiterStats {
+	dm := r.a.dm
+	stats := detection.AnalyzeStrokes(dm)
+	ds := DelimiterStats{
+		Pixels:          dm.Count(),
+		Strokes:         len(stats.Strokes),
+		TotalLength:     stats.TotalLength,
+		MeanThickness:   stats.MeanThickness,
+		MedianThickness: stats.MedianThickness,
+	}
+	if n := dm.Width * dm.Height; n > 0 {
+		ds.Fraction = float64(ds.Pixels) / float64(n)
+	}
+	return ds
+}